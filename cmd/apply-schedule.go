@@ -0,0 +1,322 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/byteowlz/ppr/pkg/battery"
+	"github.com/byteowlz/ppr/pkg/config"
+	"github.com/byteowlz/ppr/pkg/headless"
+	"github.com/byteowlz/ppr/pkg/schedule"
+	"github.com/byteowlz/ppr/pkg/state"
+	"github.com/byteowlz/ppr/pkg/weather"
+	"github.com/spf13/cobra"
+)
+
+var applyScheduleCmd = &cobra.Command{
+	Use:   "apply-schedule",
+	Short: "Set the wallpaper the schedule selects for the current time",
+	Long: `Apply the first [[schedule]] rule in config.toml matching the current
+time. A rule may set a theme, a template or both; whatever it leaves out
+keeps the current choice. Instead of a template a rule may name one of the
+[template_groups]: the current template is kept while it is in the group,
+otherwise the group's template shown longest ago with the theme is used. Rules can be limited to weekdays (mon-sun,
+weekdays, weekend), months (jan-dec), meteorological seasons (spring,
+summer, autumn, winter) and weather (clear, cloudy, fog, rain, snow,
+storm), and names may use {weekday}, {month}, {season} and {weather}.
+
+A rule's warmth (kelvin, 1000-6500) bakes a night-mode color temperature
+into the palette. Consecutive evening rules with falling warmth act as
+checkpoints, so the wallpaper warms gradually as the night goes on; list
+them before broader rules, since the first match wins.
+
+Weather comes from the [weather] provider and is fetched at most once per
+refresh interval (default 1h); the last known condition is used offline.
+
+battery_below (percent) and power ("battery" or "ac") follow the battery,
+e.g. a red-accented theme when it runs low. They never match on machines
+without a battery.
+
+Nothing is regenerated when the selection is already applied, so the
+command is cheap to run from cron or a systemd timer every few minutes.
+
+Example config:
+  [[schedule]]
+  from = "09:00"
+  to = "17:00"
+  template = "minimal.svg"
+
+  [[schedule]]
+  from = "20:00"
+  to = "21:30"
+  warmth = 4500
+
+  [[schedule]]
+  from = "21:30"
+  to = "06:00"
+  warmth = 3400
+
+  [[schedule]]
+  from = "17:00"
+  to = "09:00"
+  theme = "gruvbox-dark"
+  template = "waves.svg"
+
+  [[schedule]]
+  weekdays = ["fri"]
+  template = "friday.svg"
+
+  [template_groups]
+  minimal = ["waves", "geo-simple"]
+
+  [[schedule]]
+  weekdays = ["weekend"]
+  group = "minimal"
+
+  [[schedule]]
+  seasons = ["autumn"]
+  theme = "gruvbox-dark"
+  template = "{season}.svg"
+
+  [weather]
+  provider = "open-meteo"
+  latitude = 52.52
+  longitude = 13.41
+
+  [[schedule]]
+  weather = ["rain", "storm"]
+  theme = "nord"
+
+  [[schedule]]
+  battery_below = 15
+  theme = "nord-red"
+
+  [[schedule]]
+  power = "battery"
+  theme = "nord-dim"`,
+	Args: cobra.NoArgs,
+	RunE: runApplySchedule,
+}
+
+var (
+	applyScheduleDryRun bool
+	applyScheduleForce  bool
+	applyScheduleAt     string
+)
+
+func init() {
+	applyScheduleCmd.Flags().BoolVarP(&applyScheduleDryRun, "dry-run", "n", false, "Only print the selection")
+	applyScheduleCmd.Flags().BoolVar(&applyScheduleForce, "force", false, "Regenerate even if the selection is already applied")
+	applyScheduleCmd.Flags().StringVar(&applyScheduleAt, "at", "", "Evaluate the schedule at this local time (YYYY-MM-DDTHH:MM)")
+}
+
+// scheduleRules converts the config rules for evaluation
+func scheduleRules(cfg *config.Config) []schedule.Rule {
+	rules := make([]schedule.Rule, 0, len(cfg.Schedule))
+	for _, r := range cfg.Schedule {
+		rules = append(rules, schedule.Rule{
+			From:         r.From,
+			To:           r.To,
+			Weekdays:     r.Weekdays,
+			Months:       r.Months,
+			Seasons:      r.Seasons,
+			Weather:      r.Weather,
+			BatteryBelow: r.BatteryBelow,
+			Power:        r.Power,
+			Theme:        r.Theme,
+			Template:     r.Template,
+			Group:        r.Group,
+			Warmth:       r.Warmth,
+		})
+	}
+	return rules
+}
+
+// weatherProvider builds the provider configured under [weather]
+func weatherProvider(cfg *config.Config) (weather.Provider, error) {
+	switch cfg.Weather.Provider {
+	case "open-meteo":
+		if cfg.Weather.Latitude == 0 && cfg.Weather.Longitude == 0 {
+			return nil, fmt.Errorf("the open-meteo weather provider needs latitude and longitude")
+		}
+		return weather.NewOpenMeteo(cfg.Weather.Latitude, cfg.Weather.Longitude), nil
+	case "command":
+		if cfg.Weather.Command == "" {
+			return nil, fmt.Errorf("the command weather provider needs a command")
+		}
+		return &weather.Command{Command: cfg.Weather.Command}, nil
+	case "":
+		return nil, fmt.Errorf("weather rules need a [weather] provider (open-meteo or command)")
+	default:
+		return nil, fmt.Errorf("unknown weather provider: %s (expected open-meteo or command)", cfg.Weather.Provider)
+	}
+}
+
+// currentWeather returns the cached or freshly fetched condition, or an
+// empty one when it is unknown so weather rules are skipped
+func currentWeather(cfg *config.Config) string {
+	provider, err := weatherProvider(cfg)
+	if err != nil {
+		fmt.Printf("Warning: %v\n", err)
+		return ""
+	}
+
+	refresh := time.Hour
+	if cfg.Weather.Refresh != "" {
+		refresh, err = time.ParseDuration(cfg.Weather.Refresh)
+		if err != nil {
+			fmt.Printf("Warning: invalid weather refresh %q, using 1h\n", cfg.Weather.Refresh)
+			refresh = time.Hour
+		}
+	}
+
+	condition, err := weather.Cached(provider, weather.CachePath(), refresh)
+	if err != nil {
+		if condition == "" {
+			fmt.Printf("Warning: failed to get weather: %v\n", err)
+		} else {
+			fmt.Printf("Warning: failed to get weather, using last known %s: %v\n", condition, err)
+		}
+	}
+	return condition
+}
+
+// currentBattery returns the battery status, or nil when there is no
+// battery or it cannot be read so battery rules are skipped
+func currentBattery() *battery.Status {
+	status, err := battery.Read()
+	if err != nil {
+		if !errors.Is(err, battery.ErrNoBattery) {
+			fmt.Printf("Warning: failed to read battery: %v\n", err)
+		}
+		return nil
+	}
+	return status
+}
+
+func runApplySchedule(cmd *cobra.Command, args []string) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	if len(cfg.Schedule) == 0 {
+		return fmt.Errorf("no [[schedule]] rules in %s", config.GetConfigPath())
+	}
+
+	now := headless.Now()
+	if applyScheduleAt != "" {
+		now, err = time.ParseInLocation("2006-01-02T15:04", applyScheduleAt, time.Local)
+		if err != nil {
+			return fmt.Errorf("invalid --at time %q (expected YYYY-MM-DDTHH:MM)", applyScheduleAt)
+		}
+	}
+
+	rules := scheduleRules(cfg)
+	var conditions schedule.Conditions
+	if schedule.UsesWeather(rules) {
+		conditions.Weather = currentWeather(cfg)
+	}
+	if schedule.UsesBattery(rules) {
+		conditions.Battery = currentBattery()
+	}
+	condition := conditions.Weather
+
+	rule, err := schedule.Active(rules, now, conditions)
+	if err != nil {
+		return err
+	}
+	if rule == nil {
+		fmt.Printf("No schedule rule matches %s\n", now.Format("Mon 2006-01-02 15:04"))
+		return nil
+	}
+
+	themeToUse := schedule.Expand(rule.Theme, now, condition)
+	if themeToUse == "" {
+		themeToUse = cfg.CurrentTheme
+	}
+	if themeToUse == "" {
+		themeToUse = cfg.DefaultTheme
+	}
+
+	templateToUse := schedule.Expand(rule.Template, now, condition)
+	if rule.Group != "" {
+		templateToUse, err = groupTemplate(cfg, schedule.Expand(rule.Group, now, condition), themeToUse)
+		if err != nil {
+			return err
+		}
+	}
+	if templateToUse == "" {
+		templateToUse = cfg.CurrentTemplate
+	}
+	if templateToUse == "" {
+		templateToUse = cfg.DefaultTemplate
+		ensureDefaultTemplate(cfg)
+	}
+	if filepath.Ext(templateToUse) == "" {
+		templateToUse += ".svg"
+	}
+
+	span := "all day"
+	if rule.From != "" {
+		span = rule.From + "-" + rule.To
+	}
+	for _, names := range [][]string{rule.Weekdays, rule.Months, rule.Seasons, rule.Weather} {
+		if len(names) > 0 {
+			span += " " + strings.Join(names, ",")
+		}
+	}
+	if rule.BatteryBelow != 0 {
+		span += fmt.Sprintf(" battery<%d%%", rule.BatteryBelow)
+	}
+	if rule.Power != "" {
+		span += " on " + rule.Power
+	}
+	if rule.Warmth != 0 {
+		fmt.Printf("Schedule rule %s: theme %s, template %s, warmth %dK\n", span, themeToUse, templateToUse, rule.Warmth)
+	} else {
+		fmt.Printf("Schedule rule %s: theme %s, template %s\n", span, themeToUse, templateToUse)
+	}
+
+	if applyScheduleDryRun {
+		return nil
+	}
+	if pin := wallpaperPin(); pin != "" {
+		fmt.Printf("Wallpaper %s, not applying the schedule\n", pin)
+		return nil
+	}
+
+	if !applyScheduleForce && cfg.CurrentTheme == themeToUse && cfg.CurrentTemplate == filepath.Base(templateToUse) && cfg.CurrentWarmth == rule.Warmth {
+		fmt.Println("Already applied")
+		return nil
+	}
+
+	themeName = themeToUse
+	templatePath = templateToUse
+	warmth = rule.Warmth
+	setWallpaper = true
+	return runGenerate(generateCmd, nil)
+}
+
+// groupTemplate keeps the current template while it belongs to the group and
+// otherwise picks the group's template shown longest ago with the theme
+func groupTemplate(cfg *config.Config, group, themeName string) (string, error) {
+	templates, err := templateGroup(cfg, group)
+	if err != nil {
+		return "", err
+	}
+	for _, template := range templates {
+		if filepath.Base(template) == cfg.CurrentTemplate {
+			return template, nil
+		}
+	}
+
+	st, err := state.Load(config.GetStatePath())
+	if err != nil {
+		return "", err
+	}
+	return leastRecentTemplate(templates, templates[0], themeName, st), nil
+}
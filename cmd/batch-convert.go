@@ -7,7 +7,6 @@ import (
 	"strings"
 
 	"github.com/byteowlz/ppr/pkg/config"
-	"github.com/byteowlz/ppr/pkg/theme"
 	"github.com/spf13/cobra"
 )
 
@@ -56,7 +55,7 @@ func runBatchConvert(cmd *cobra.Command, args []string) error {
 	}
 
 	// Load theme manager and get the specified theme
-	themeManager := theme.NewThemeManager(cfg.ThemesPath)
+	themeManager := newThemeManager(cfg)
 	if err := themeManager.LoadThemes(); err != nil {
 		return fmt.Errorf("failed to load themes: %w", err)
 	}
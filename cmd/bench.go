@@ -0,0 +1,194 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/byteowlz/ppr/pkg/config"
+	"github.com/byteowlz/ppr/pkg/image"
+	"github.com/byteowlz/ppr/pkg/resolution"
+	"github.com/spf13/cobra"
+)
+
+var benchCmd = &cobra.Command{
+	Use:   "bench",
+	Short: "Benchmark theme loading, template processing and rasterization",
+	Long: `Time the stages of wallpaper generation and print a comparison table.
+Theme loading and template processing are measured once per iteration,
+rasterization is measured for every requested resolution and for every
+rasterizer backend found on the system.
+
+Examples:
+  ppr bench
+  ppr bench --theme nord --template shapes --iterations 5
+  ppr bench --resolutions 1920x1080,3840x2160,7680x4320`,
+	RunE: runBench,
+}
+
+var (
+	benchThemeName   string
+	benchTemplate    string
+	benchResolutions []string
+	benchIterations  int
+)
+
+func init() {
+	benchCmd.Flags().StringVarP(&benchThemeName, "theme", "t", "", "Theme to benchmark with (defaults to current or default theme)")
+	benchCmd.Flags().StringVarP(&benchTemplate, "template", "s", "", "Template to benchmark with (defaults to default template)")
+	benchCmd.Flags().StringSliceVar(&benchResolutions, "resolutions", []string{"1280x720", "1920x1080", "2560x1440", "3840x2160"}, "Comma-separated list of resolutions to rasterize")
+	benchCmd.Flags().IntVarP(&benchIterations, "iterations", "n", 3, "Number of iterations per measurement")
+}
+
+// benchTiming accumulates durations for one measured stage
+type benchTiming struct {
+	stage   string
+	backend string
+	runs    []time.Duration
+}
+
+func (b *benchTiming) add(d time.Duration) {
+	b.runs = append(b.runs, d)
+}
+
+func (b *benchTiming) stats() (min, avg, max time.Duration) {
+	if len(b.runs) == 0 {
+		return 0, 0, 0
+	}
+	min = b.runs[0]
+	var total time.Duration
+	for _, d := range b.runs {
+		total += d
+		if d < min {
+			min = d
+		}
+		if d > max {
+			max = d
+		}
+	}
+	return min, total / time.Duration(len(b.runs)), max
+}
+
+func runBench(cmd *cobra.Command, args []string) error {
+	if benchIterations < 1 {
+		return fmt.Errorf("iterations must be at least 1")
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	themeToUse := benchThemeName
+	if themeToUse == "" {
+		themeToUse = cfg.CurrentTheme
+	}
+	if themeToUse == "" {
+		themeToUse = cfg.DefaultTheme
+	}
+
+	templatePath := benchTemplate
+	if templatePath == "" {
+		templatePath = cfg.DefaultTemplate
+		ensureDefaultTemplate(cfg)
+	}
+	if !filepath.IsAbs(templatePath) {
+		templatePath = filepath.Join(cfg.TemplatesPath, templatePath)
+	}
+	if filepath.Ext(templatePath) == "" {
+		templatePath += ".svg"
+	}
+
+	var resolutions []*resolution.Resolution
+	for _, resStr := range benchResolutions {
+		res, err := resolution.ParseResolution(strings.TrimSpace(resStr))
+		if err != nil {
+			return fmt.Errorf("failed to parse resolution: %w", err)
+		}
+		resolutions = append(resolutions, res)
+	}
+
+	tempDir, err := os.MkdirTemp("", "ppr-bench-")
+	if err != nil {
+		return fmt.Errorf("failed to create temp directory: %w", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	fmt.Printf("Benchmarking theme '%s' with template '%s' (%d iterations)\n\n", themeToUse, filepath.Base(templatePath), benchIterations)
+
+	loadTiming := &benchTiming{stage: "theme loading", backend: "-"}
+	processTiming := &benchTiming{stage: "template processing", backend: "-"}
+	backends := image.AvailableBackends()
+	var rasterTimings []*benchTiming
+	for _, backend := range backends {
+		for _, res := range resolutions {
+			rasterTimings = append(rasterTimings, &benchTiming{stage: "rasterize " + res.String(), backend: backend})
+		}
+	}
+
+	var themeCount int
+	for i := 0; i < benchIterations; i++ {
+		start := time.Now()
+		themeManager := newThemeManager(cfg)
+		if err := themeManager.LoadThemes(); err != nil {
+			return fmt.Errorf("failed to load themes: %w", err)
+		}
+		loadTiming.add(time.Since(start))
+		themeCount = len(themeManager.ListThemes())
+
+		selectedTheme, err := themeManager.GetTheme(themeToUse)
+		if err != nil {
+			return fmt.Errorf("failed to get theme: %w", err)
+		}
+
+		start = time.Now()
+		processor := newProcessor(cfg)
+		svgContent, err := processor.ProcessTemplate(templatePath, selectedTheme)
+		if err != nil {
+			return fmt.Errorf("failed to process template: %w", err)
+		}
+		processTiming.add(time.Since(start))
+
+		for b, backend := range backends {
+			generator := image.NewGenerator()
+			if err := generator.SetBackend(backend); err != nil {
+				return err
+			}
+
+			for j, res := range resolutions {
+				outPath := filepath.Join(tempDir, fmt.Sprintf("bench_%s_%s.png", backend, res.String()))
+				start = time.Now()
+				if err := generator.GenerateWallpaper(svgContent, res.Width, res.Height, outPath); err != nil {
+					return fmt.Errorf("failed to rasterize at %s with %s: %w", res.String(), backend, err)
+				}
+				rasterTimings[b*len(resolutions)+j].add(time.Since(start))
+			}
+		}
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "STAGE\tBACKEND\tMIN\tAVG\tMAX")
+	timings := append([]*benchTiming{loadTiming, processTiming}, rasterTimings...)
+	for _, t := range timings {
+		min, avg, max := t.stats()
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", t.stage, t.backend, formatBenchDuration(min), formatBenchDuration(avg), formatBenchDuration(max))
+	}
+	w.Flush()
+
+	fmt.Printf("\nThemes loaded: %d\n", themeCount)
+	return nil
+}
+
+func formatBenchDuration(d time.Duration) string {
+	switch {
+	case d >= time.Second:
+		return fmt.Sprintf("%.2fs", d.Seconds())
+	case d >= time.Millisecond:
+		return fmt.Sprintf("%.1fms", float64(d)/float64(time.Millisecond))
+	default:
+		return fmt.Sprintf("%.0fµs", float64(d)/float64(time.Microsecond))
+	}
+}
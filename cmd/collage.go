@@ -0,0 +1,194 @@
+package cmd
+
+import (
+	"fmt"
+	stdimage "image"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/byteowlz/ppr/pkg/config"
+	"github.com/byteowlz/ppr/pkg/image"
+	"github.com/byteowlz/ppr/pkg/resolution"
+	"github.com/byteowlz/ppr/pkg/theme"
+	"github.com/spf13/cobra"
+)
+
+var collageCmd = &cobra.Command{
+	Use:   "collage",
+	Short: "Render one template in several themes as a grid",
+	Long: `Render a template once per theme and tile the results into a single
+wallpaper, row by row. Without --grid the grid is as square as possible.
+When the grid has more cells than themes, the themes repeat.
+
+Examples:
+  ppr collage --themes nord,gruvbox-dark,dracula,catppuccin-mocha --template waves --grid 2x2
+  ppr collage --themes nord,dracula,rose-pine --grid 3x1 -r 5120x1440 -w`,
+	Args: cobra.NoArgs,
+	RunE: runCollage,
+}
+
+var (
+	collageThemes        []string
+	collageTemplate      string
+	collageGrid          string
+	collageOutputPath    string
+	collageResolutionStr string
+	collageSetWallpaper  bool
+)
+
+func init() {
+	collageCmd.Flags().StringSliceVar(&collageThemes, "themes", nil, "Comma-separated themes, one per cell")
+	collageCmd.Flags().StringVarP(&collageTemplate, "template", "s", "", "Template to render (uses default template if not specified)")
+	collageCmd.Flags().StringVarP(&collageGrid, "grid", "g", "", "Grid as COLSxROWS (e.g., 2x2)")
+	collageCmd.Flags().StringVarP(&collageOutputPath, "output", "o", "", "Output image path (defaults to the output directory)")
+	collageCmd.Flags().StringVarP(&collageResolutionStr, "resolution", "r", "", "Output resolution (e.g., 3440x1440)")
+	collageCmd.Flags().BoolVarP(&collageSetWallpaper, "set-wallpaper", "w", false, "Set collage as wallpaper")
+	collageCmd.MarkFlagRequired("themes")
+	addRenderFlags(collageCmd)
+}
+
+// parseGrid reads COLSxROWS, or picks a near-square grid for n cells
+func parseGrid(value string, n int) (int, int, error) {
+	if value == "" {
+		cols := int(math.Ceil(math.Sqrt(float64(n))))
+		rows := (n + cols - 1) / cols
+		return cols, rows, nil
+	}
+
+	var cols, rows int
+	if _, err := fmt.Sscanf(strings.ToLower(value), "%dx%d", &cols, &rows); err != nil || cols < 1 || rows < 1 {
+		return 0, 0, fmt.Errorf("invalid grid %q (expected COLSxROWS, e.g. 2x2)", value)
+	}
+	return cols, rows, nil
+}
+
+func runCollage(cmd *cobra.Command, args []string) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	if err := cfg.EnsureDirectories(); err != nil {
+		return fmt.Errorf("failed to ensure directories: %w", err)
+	}
+
+	cols, rows, err := parseGrid(collageGrid, len(collageThemes))
+	if err != nil {
+		return err
+	}
+	if len(collageThemes) > cols*rows {
+		return fmt.Errorf("%d themes do not fit a %dx%d grid", len(collageThemes), cols, rows)
+	}
+
+	themeManager := newThemeManager(cfg)
+	if err := themeManager.LoadThemes(); err != nil {
+		return fmt.Errorf("failed to load themes: %w", err)
+	}
+
+	themes := make([]*theme.Theme, 0, len(collageThemes))
+	for _, name := range collageThemes {
+		t, err := themeManager.GetTheme(strings.TrimSpace(name))
+		if err != nil {
+			return fmt.Errorf("failed to get theme: %w", err)
+		}
+		themes = append(themes, t)
+	}
+
+	templateToUse := collageTemplate
+	if templateToUse == "" {
+		templateToUse = cfg.DefaultTemplate
+		ensureDefaultTemplate(cfg)
+		fmt.Printf("Using default template: %s\n", templateToUse)
+	}
+	templateFile := templateToUse
+	if _, err := os.Stat(templateFile); err != nil && !filepath.IsAbs(templateFile) {
+		templateFile = filepath.Join(cfg.TemplatesPath, templateFile)
+	}
+	if filepath.Ext(templateFile) == "" {
+		templateFile += ".svg"
+	}
+
+	var res *resolution.Resolution
+	if collageResolutionStr != "" {
+		res, err = resolution.ParseResolution(collageResolutionStr)
+		if err != nil {
+			return fmt.Errorf("failed to parse resolution: %w", err)
+		}
+	} else {
+		res, err = resolution.NewDetector().GetPrimaryDisplayResolution()
+		if err != nil {
+			fmt.Printf("Warning: failed to detect resolution, using default: %v\n", err)
+			res = &resolution.Resolution{Width: cfg.DefaultWidth, Height: cfg.DefaultHeight}
+		}
+	}
+
+	// Themes repeat when the grid has more cells, so each is parsed once
+	type parsedTheme struct {
+		generator *image.Generator
+		parsed    *image.Parsed
+	}
+	parsedThemes := make([]*parsedTheme, len(themes))
+
+	processor := newProcessor(cfg)
+	collage, err := image.Collage(res.Width, res.Height, cols, rows, func(cell, w, h int) (*stdimage.RGBA, error) {
+		t := themes[cell%len(themes)]
+		pt := parsedThemes[cell%len(themes)]
+		if pt == nil {
+			svgContent, err := processor.ProcessTemplate(templateFile, t)
+			if err != nil {
+				return nil, fmt.Errorf("failed to process template for %s: %w", t.Name, err)
+			}
+			renderContent, generator, err := prepareRender(cfg, svgContent)
+			if err != nil {
+				return nil, fmt.Errorf("failed to prepare render for %s: %w", t.Name, err)
+			}
+			parsed, err := generator.Parse(renderContent)
+			if err != nil {
+				return nil, fmt.Errorf("failed to render %s: %w", t.Name, err)
+			}
+			pt = &parsedTheme{generator: generator, parsed: parsed}
+			parsedThemes[cell%len(themes)] = pt
+		}
+		img, err := renderParsedWithTimeout(cmd, cfg, pt.generator, pt.parsed, w, h)
+		if err != nil {
+			return nil, fmt.Errorf("failed to render %s: %w", t.Name, err)
+		}
+		return img, nil
+	})
+	if err != nil {
+		return err
+	}
+
+	outPath := collageOutputPath
+	if outPath == "" {
+		collageDir := filepath.Join(cfg.OutputPath, "ppr", "collage")
+		if err := os.MkdirAll(collageDir, 0755); err != nil {
+			return fmt.Errorf("failed to create collage directory: %w", err)
+		}
+		base := strings.TrimSuffix(filepath.Base(templateFile), filepath.Ext(templateFile))
+		outPath = filepath.Join(collageDir, fmt.Sprintf("%s-%dx%d.png", base, cols, rows))
+	}
+
+	if err := image.WriteImage(collage, outPath); err != nil {
+		return fmt.Errorf("failed to write collage: %w", err)
+	}
+	fmt.Printf("Generated %dx%d collage of %d themes: %s (%s)\n", cols, rows, len(themes), outPath, res.String())
+
+	if collageSetWallpaper {
+		absPath, err := filepath.Abs(outPath)
+		if err != nil {
+			return fmt.Errorf("failed to resolve output path: %w", err)
+		}
+
+		setter := newWallpaperSetter(cfg)
+		if err := setter.SetWallpaper(absPath); err != nil {
+			fmt.Printf("Warning: failed to set wallpaper: %v\n", err)
+		} else {
+			fmt.Println("Wallpaper set successfully!")
+		}
+	}
+
+	return nil
+}
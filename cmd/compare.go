@@ -0,0 +1,183 @@
+package cmd
+
+import (
+	"encoding/xml"
+	"fmt"
+	stdimage "image"
+	"image/draw"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/byteowlz/ppr/pkg/config"
+	"github.com/byteowlz/ppr/pkg/image"
+	"github.com/byteowlz/ppr/pkg/theme"
+	"github.com/spf13/cobra"
+)
+
+var compareCmd = &cobra.Command{
+	Use:   "compare",
+	Short: "Render templates side by side to compare them",
+	Long: `Render two or more templates with the same theme next to each other
+into one image, each labeled with its file name, to compare iterations of
+a design. Templates are looked up in the working directory first, then in
+the templates folder. The theme defaults to the current one.
+
+Examples:
+  ppr compare --templates a.svg,b.svg --theme nord
+  ppr compare --templates waves,waves-v2,waves-v3 -r 3840x1080 -o compare.png`,
+	Args: cobra.NoArgs,
+	RunE: runCompare,
+}
+
+var (
+	compareTemplates     []string
+	compareTheme         string
+	compareOutputPath    string
+	compareResolutionStr string
+	compareSetWallpaper  bool
+)
+
+func init() {
+	compareCmd.Flags().StringSliceVar(&compareTemplates, "templates", nil, "Comma-separated templates to compare")
+	compareCmd.Flags().StringVarP(&compareTheme, "theme", "t", "", "Theme to render with (defaults to the current theme)")
+	compareCmd.Flags().StringVarP(&compareOutputPath, "output", "o", "", "Output image path (defaults to the output directory)")
+	compareCmd.Flags().StringVarP(&compareResolutionStr, "resolution", "r", "", "Width and wallpaper aspect of the comparison (e.g., 3840x1080)")
+	compareCmd.Flags().BoolVarP(&compareSetWallpaper, "set-wallpaper", "w", false, "Set the comparison as wallpaper")
+	compareCmd.MarkFlagRequired("templates")
+	addRenderFlags(compareCmd)
+}
+
+func runCompare(cmd *cobra.Command, args []string) error {
+	if len(compareTemplates) < 2 {
+		return fmt.Errorf("compare needs at least two templates")
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	if err := cfg.EnsureDirectories(); err != nil {
+		return fmt.Errorf("failed to ensure directories: %w", err)
+	}
+
+	// The current theme is compared as it is rendered, named ones as they are
+	themeToUse, kelvin := cfg.CurrentTheme, cfg.CurrentWarmth
+	if themeToUse == "" {
+		themeToUse = cfg.DefaultTheme
+	}
+	if compareTheme != "" {
+		themeToUse, kelvin = compareTheme, 0
+	}
+	selectedTheme, themeToUse, err := loadRenderTheme(cfg, themeToUse, nil, kelvin)
+	if err != nil {
+		return err
+	}
+
+	files := make([]string, 0, len(compareTemplates))
+	for _, name := range compareTemplates {
+		name = strings.TrimSpace(name)
+		file := name
+		if _, err := os.Stat(file); err != nil {
+			file = templateFile(cfg, name)
+		}
+		if _, err := os.Stat(file); err != nil {
+			return fmt.Errorf("template %s not found", name)
+		}
+		files = append(files, file)
+	}
+
+	res, err := targetResolution(cfg, compareResolutionStr)
+	if err != nil {
+		return err
+	}
+
+	// Every template keeps the wallpaper aspect, with its label below
+	cellWidth := res.Width / len(files)
+	imageHeight := cellWidth * res.Height / res.Width
+	labelHeight := imageHeight / 12
+	if labelHeight < 24 {
+		labelHeight = 24
+	}
+
+	processor := newProcessor(cfg)
+	comparison, err := image.Collage(res.Width, imageHeight+labelHeight, len(files), 1, func(cell, w, h int) (*stdimage.RGBA, error) {
+		file := files[cell]
+		svgContent, err := processor.ProcessTemplate(file, selectedTheme)
+		if err != nil {
+			return nil, fmt.Errorf("failed to process %s: %w", file, err)
+		}
+
+		out := stdimage.NewRGBA(stdimage.Rect(0, 0, w, h))
+		parts := []struct {
+			content string
+			rect    stdimage.Rectangle
+		}{
+			{svgContent, stdimage.Rect(0, 0, w, h-labelHeight)},
+			{compareLabel(filepath.Base(file), selectedTheme, w, labelHeight), stdimage.Rect(0, h-labelHeight, w, h)},
+		}
+		for _, part := range parts {
+			renderContent, generator, err := prepareRender(cfg, part.content)
+			if err != nil {
+				return nil, fmt.Errorf("failed to prepare render for %s: %w", file, err)
+			}
+			img, err := renderWithTimeout(cmd, cfg, generator, renderContent, part.rect.Dx(), part.rect.Dy())
+			if err != nil {
+				return nil, fmt.Errorf("failed to render %s: %w", file, err)
+			}
+			draw.Draw(out, part.rect, img, img.Bounds().Min, draw.Src)
+		}
+		return out, nil
+	})
+	if err != nil {
+		return err
+	}
+
+	outPath := compareOutputPath
+	if outPath == "" {
+		compareDir := filepath.Join(cfg.OutputPath, "ppr", "compare")
+		if err := os.MkdirAll(compareDir, 0755); err != nil {
+			return fmt.Errorf("failed to create compare directory: %w", err)
+		}
+		var names []string
+		for _, file := range files {
+			names = append(names, strings.TrimSuffix(filepath.Base(file), filepath.Ext(file)))
+		}
+		outPath = filepath.Join(compareDir, fmt.Sprintf("%s-%s.png", strings.Join(names, "-vs-"), themeToUse))
+	}
+
+	if err := image.WriteImage(comparison, outPath); err != nil {
+		return fmt.Errorf("failed to write comparison: %w", err)
+	}
+	fmt.Printf("Compared %d templates with theme %s: %s (%dx%d)\n", len(files), themeToUse, outPath, res.Width, imageHeight+labelHeight)
+
+	if compareSetWallpaper {
+		absPath, err := filepath.Abs(outPath)
+		if err != nil {
+			return fmt.Errorf("failed to resolve output path: %w", err)
+		}
+
+		setter := newWallpaperSetter(cfg)
+		if err := setter.SetWallpaper(absPath); err != nil {
+			fmt.Printf("Warning: failed to set wallpaper: %v\n", err)
+		} else {
+			fmt.Println("Wallpaper set successfully!")
+		}
+	}
+
+	return nil
+}
+
+// compareLabel is an SVG label strip of width x height showing text in the
+// colors of t
+func compareLabel(text string, t *theme.Theme, width, height int) string {
+	var escaped strings.Builder
+	xml.EscapeText(&escaped, []byte(text))
+	return fmt.Sprintf(`<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 %d %d">`+
+		`<rect width="%d" height="%d" fill="%s"/>`+
+		`<text x="%d" y="%d" font-family="sans-serif" font-size="%d" text-anchor="middle" fill="%s">%s</text>`+
+		`</svg>`,
+		width, height, width, height, t.Palette["base01"],
+		width/2, height*2/3, height/2, t.Palette["base05"], escaped.String())
+}
@@ -0,0 +1,179 @@
+package cmd
+
+import (
+	"fmt"
+	"image/color"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/byteowlz/ppr/pkg/config"
+	"github.com/byteowlz/ppr/pkg/palette"
+	"github.com/byteowlz/ppr/pkg/svg"
+	"github.com/byteowlz/ppr/pkg/theme"
+	"github.com/spf13/cobra"
+)
+
+// WCAG 2.1 minimum contrast ratios for normal text (1.4.3) and for
+// graphical objects (1.4.11)
+const (
+	textContrast    = 4.5
+	graphicContrast = 3.0
+)
+
+var compatCmd = &cobra.Command{
+	Use:   "compat",
+	Short: "Check which themes have enough contrast for a template",
+	Long: `Evaluate every theme against the palette slots a template draws with and
+print the WCAG contrast ratio of each foreground slot on the background.
+
+A theme passes when every foreground reaches 4.5:1 (text), warns when the
+weakest only reaches 3:1 (graphics) and fails below that.
+
+Templates declare their slots with comments:
+  <!-- ppr:background base00 -->
+  <!-- ppr:foreground base05 base0D -->
+Without them the background is base00 and all other placeholders are
+foregrounds.`,
+	Example: `  ppr compat --template waves
+  ppr compat --template shapes --variant dark --failing`,
+	Args: cobra.NoArgs,
+	RunE: runCompat,
+}
+
+var (
+	compatTemplate string
+	compatVariant  string
+	compatFailing  bool
+)
+
+func init() {
+	compatCmd.Flags().StringVarP(&compatTemplate, "template", "s", "", "Template to check (defaults to the current template)")
+	compatCmd.Flags().StringVarP(&compatVariant, "variant", "v", "", "Only check themes of this variant (dark/light)")
+	compatCmd.Flags().BoolVar(&compatFailing, "failing", false, "Only list themes that warn or fail")
+}
+
+// compatRow is the contrast of one theme's foregrounds on its background
+type compatRow struct {
+	theme  string
+	ratios []float64
+	min    float64
+}
+
+func (r compatRow) status() string {
+	switch {
+	case r.min >= textContrast:
+		return "pass"
+	case r.min >= graphicContrast:
+		return "warn"
+	default:
+		return "fail"
+	}
+}
+
+func runCompat(cmd *cobra.Command, args []string) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	name := compatTemplate
+	if name == "" {
+		name = cfg.CurrentTemplate
+	}
+	if name == "" {
+		name = cfg.DefaultTemplate
+		ensureDefaultTemplate(cfg)
+	}
+	templatePath := templateFile(cfg, name)
+	content, err := os.ReadFile(templatePath)
+	if err != nil {
+		return fmt.Errorf("failed to read template: %w", err)
+	}
+
+	usage := svg.ParseSlotUsage(string(content))
+	if len(usage.Foregrounds) == 0 {
+		return fmt.Errorf("template %s uses no foreground colors", name)
+	}
+
+	themeManager := newThemeManager(cfg)
+	if err := themeManager.LoadThemes(); err != nil {
+		return fmt.Errorf("failed to load themes: %w", err)
+	}
+	themeNames := themeManager.ListThemes()
+	sort.Strings(themeNames)
+
+	var rows []compatRow
+	for _, themeName := range themeNames {
+		t, err := themeManager.GetTheme(themeName)
+		if err != nil {
+			continue
+		}
+		if compatVariant != "" && t.Variant != compatVariant {
+			continue
+		}
+		row, err := compatContrast(themeName, t, usage)
+		if err != nil {
+			fmt.Printf("Warning: skipping theme %s: %v\n", themeName, err)
+			continue
+		}
+		if compatFailing && row.status() == "pass" {
+			continue
+		}
+		rows = append(rows, row)
+	}
+
+	source := "inferred from placeholders"
+	if usage.Declared {
+		source = "declared"
+	}
+	fmt.Printf("Template %s (%s): %s on %s\n\n", filepath.Base(templatePath), source, strings.Join(usage.Foregrounds, ", "), usage.Background)
+
+	counts := make(map[string]int)
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "THEME\t%s\tRESULT\n", strings.Join(usage.Foregrounds, "\t"))
+	for _, row := range rows {
+		cells := make([]string, len(row.ratios))
+		for i, ratio := range row.ratios {
+			cells[i] = fmt.Sprintf("%.1f", ratio)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\n", row.theme, strings.Join(cells, "\t"), row.status())
+		counts[row.status()]++
+	}
+	w.Flush()
+
+	fmt.Printf("\n%d pass, %d warn, %d fail\n", counts["pass"], counts["warn"], counts["fail"])
+	return nil
+}
+
+// compatContrast computes the contrast of each foreground slot of usage on
+// the background slot in t
+func compatContrast(name string, t *theme.Theme, usage svg.SlotUsage) (compatRow, error) {
+	row := compatRow{theme: name, min: 21}
+	background, err := themeSlot(t, usage.Background)
+	if err != nil {
+		return row, err
+	}
+	for _, slot := range usage.Foregrounds {
+		foreground, err := themeSlot(t, slot)
+		if err != nil {
+			return row, err
+		}
+		ratio := palette.ContrastRatio(foreground, background)
+		row.ratios = append(row.ratios, ratio)
+		if ratio < row.min {
+			row.min = ratio
+		}
+	}
+	return row, nil
+}
+
+func themeSlot(t *theme.Theme, slot string) (color.RGBA, error) {
+	value, ok := t.Palette[slot]
+	if !ok {
+		return color.RGBA{}, fmt.Errorf("no %s color", slot)
+	}
+	return palette.ParseHex(value)
+}
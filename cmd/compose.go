@@ -0,0 +1,215 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/byteowlz/ppr/pkg/config"
+	"github.com/byteowlz/ppr/pkg/image"
+	"github.com/byteowlz/ppr/pkg/resolution"
+	"github.com/spf13/cobra"
+)
+
+var composeCmd = &cobra.Command{
+	Use:   "compose",
+	Short: "Compose several themed templates into one wallpaper",
+	Long: `Render each --layer template with the theme and stack the results into
+a single wallpaper, the first layer at the bottom.
+
+A layer is a template path or name followed by optional settings:
+  template.svg:opacity=0.6,blend=multiply
+
+Blend modes: normal, multiply, screen, overlay, darken, lighten.
+Uses the current theme if no theme is specified.
+
+Examples:
+  ppr compose --layer bg.svg --layer overlay.svg:opacity=0.6 --theme nord
+  ppr compose --layer gradient --layer grain:blend=overlay,opacity=0.3 -w`,
+	Args: cobra.NoArgs,
+	RunE: runCompose,
+}
+
+var (
+	composeLayers        []string
+	composeThemeName     string
+	composeOutputPath    string
+	composeResolutionStr string
+	composeSetWallpaper  bool
+)
+
+func init() {
+	composeCmd.Flags().StringArrayVarP(&composeLayers, "layer", "l", nil, "Template layer with optional :opacity=N,blend=MODE (repeatable, bottom first)")
+	composeCmd.Flags().StringVarP(&composeThemeName, "theme", "t", "", "Theme to apply (defaults to current theme)")
+	composeCmd.Flags().StringVarP(&composeOutputPath, "output", "o", "", "Output image path (defaults to the theme output directory)")
+	composeCmd.Flags().StringVarP(&composeResolutionStr, "resolution", "r", "", "Output resolution (e.g., 1920x1080)")
+	composeCmd.Flags().BoolVarP(&composeSetWallpaper, "set-wallpaper", "w", false, "Set composed image as wallpaper")
+	composeCmd.MarkFlagRequired("layer")
+	addRenderFlags(composeCmd)
+}
+
+// composeLayerSpec is a parsed --layer value
+type composeLayerSpec struct {
+	template string
+	opacity  float64
+	blend    string
+}
+
+// parseLayerSpec splits "path:opacity=0.6,blend=multiply". Settings follow
+// the last colon only when it is followed by key=value pairs, so Windows
+// drive letters stay part of the path.
+func parseLayerSpec(spec string) (composeLayerSpec, error) {
+	layer := composeLayerSpec{template: spec, opacity: 1, blend: image.BlendNormal}
+
+	i := strings.LastIndex(spec, ":")
+	if i < 0 || !strings.Contains(spec[i+1:], "=") {
+		return layer, nil
+	}
+	layer.template = spec[:i]
+
+	for _, pair := range strings.Split(spec[i+1:], ",") {
+		key, value, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if !ok {
+			return layer, fmt.Errorf("invalid layer setting %q in %s", pair, spec)
+		}
+		switch key {
+		case "opacity":
+			opacity, err := strconv.ParseFloat(value, 64)
+			if err != nil || opacity < 0 || opacity > 1 {
+				return layer, fmt.Errorf("invalid opacity %q in %s (expected 0-1)", value, spec)
+			}
+			layer.opacity = opacity
+		case "blend":
+			for _, mode := range image.BlendModes() {
+				if value == mode {
+					layer.blend = value
+				}
+			}
+			if layer.blend != value {
+				return layer, fmt.Errorf("unknown blend mode %q in %s (expected one of %s)", value, spec, strings.Join(image.BlendModes(), ", "))
+			}
+		default:
+			return layer, fmt.Errorf("unknown layer setting %q in %s (expected opacity or blend)", key, spec)
+		}
+	}
+	return layer, nil
+}
+
+func runCompose(cmd *cobra.Command, args []string) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	if err := cfg.EnsureDirectories(); err != nil {
+		return fmt.Errorf("failed to ensure directories: %w", err)
+	}
+
+	specs := make([]composeLayerSpec, 0, len(composeLayers))
+	for _, value := range composeLayers {
+		spec, err := parseLayerSpec(value)
+		if err != nil {
+			return err
+		}
+		specs = append(specs, spec)
+	}
+
+	themeToUse := composeThemeName
+	if themeToUse == "" {
+		themeToUse = cfg.CurrentTheme
+	}
+	if themeToUse == "" {
+		themeToUse = cfg.DefaultTheme
+	}
+
+	themeManager := newThemeManager(cfg)
+	if err := themeManager.LoadThemes(); err != nil {
+		return fmt.Errorf("failed to load themes: %w", err)
+	}
+
+	selectedTheme, err := themeManager.GetTheme(themeToUse)
+	if err != nil {
+		return fmt.Errorf("failed to get theme: %w", err)
+	}
+
+	var res *resolution.Resolution
+	if composeResolutionStr != "" {
+		res, err = resolution.ParseResolution(composeResolutionStr)
+		if err != nil {
+			return fmt.Errorf("failed to parse resolution: %w", err)
+		}
+	} else {
+		res, err = resolution.NewDetector().GetPrimaryDisplayResolution()
+		if err != nil {
+			fmt.Printf("Warning: failed to detect resolution, using default: %v\n", err)
+			res = &resolution.Resolution{Width: cfg.DefaultWidth, Height: cfg.DefaultHeight}
+		}
+	}
+
+	processor := newProcessor(cfg)
+	layers := make([]image.Layer, 0, len(specs))
+	names := make([]string, 0, len(specs))
+	for _, spec := range specs {
+		templatePath := spec.template
+		if _, err := os.Stat(templatePath); err != nil && !filepath.IsAbs(templatePath) {
+			templatePath = filepath.Join(cfg.TemplatesPath, templatePath)
+		}
+		if filepath.Ext(templatePath) == "" {
+			templatePath += ".svg"
+		}
+
+		svgContent, err := processor.ProcessTemplate(templatePath, selectedTheme)
+		if err != nil {
+			return fmt.Errorf("failed to process layer %s: %w", spec.template, err)
+		}
+
+		renderContent, generator, err := prepareRender(cfg, svgContent)
+		if err != nil {
+			return fmt.Errorf("failed to prepare layer %s: %w", spec.template, err)
+		}
+		rendered, err := renderWithTimeout(cmd, cfg, generator, renderContent, res.Width, res.Height)
+		if err != nil {
+			return fmt.Errorf("failed to render layer %s: %w", spec.template, err)
+		}
+
+		layers = append(layers, image.Layer{Image: rendered, Opacity: spec.opacity, Blend: spec.blend})
+		names = append(names, strings.TrimSuffix(filepath.Base(templatePath), filepath.Ext(templatePath)))
+	}
+
+	composed, err := image.Compose(layers)
+	if err != nil {
+		return fmt.Errorf("failed to compose layers: %w", err)
+	}
+
+	outPath := composeOutputPath
+	if outPath == "" {
+		themeSubDir := filepath.Join(cfg.OutputPath, "ppr", themeToUse)
+		if err := os.MkdirAll(themeSubDir, 0755); err != nil {
+			return fmt.Errorf("failed to create theme subdirectory: %w", err)
+		}
+		outPath = filepath.Join(themeSubDir, "compose-"+strings.Join(names, "+")+".png")
+	}
+
+	if err := image.WriteImage(composed, outPath); err != nil {
+		return fmt.Errorf("failed to write composed image: %w", err)
+	}
+	fmt.Printf("Composed %d layers with theme '%s': %s (%s)\n", len(layers), themeToUse, outPath, res.String())
+
+	if composeSetWallpaper {
+		absPath, err := filepath.Abs(outPath)
+		if err != nil {
+			return fmt.Errorf("failed to resolve output path: %w", err)
+		}
+
+		setter := newWallpaperSetter(cfg)
+		if err := setter.SetWallpaper(absPath); err != nil {
+			fmt.Printf("Warning: failed to set wallpaper: %v\n", err)
+		} else {
+			fmt.Println("Wallpaper set successfully!")
+		}
+	}
+
+	return nil
+}
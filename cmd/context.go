@@ -0,0 +1,239 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/byteowlz/ppr/pkg/config"
+	"github.com/byteowlz/ppr/pkg/state"
+	"github.com/spf13/cobra"
+)
+
+// terminalReset restores the terminal's own palette, foreground,
+// background and cursor colors
+const terminalReset = "\033]104\033\\\033]110\033\\\033]111\033\\\033]112\033\\"
+
+var contextCmd = &cobra.Command{
+	Use:   "context",
+	Short: "Temporarily switch the theme for a context like an SSH session",
+	Long: `Switch the wallpaper and terminal colors to a context-specific theme and
+back, e.g. to make sessions on production hosts stand out. Call the
+subcommands from a shell hook:
+
+  ssh() {
+    ppr context ssh "$@"
+    command ssh "$@"
+    ppr context restore
+  }
+
+Host themes are set in config.toml, patterns are tried in sorted order:
+
+  [ssh_themes]
+  "prod-*" = "gruvbox-dark"
+  "*.staging.example.com" = "nord"`,
+}
+
+var contextSSHCmd = &cobra.Command{
+	Use:   "ssh <host> [ssh-args...]",
+	Short: "Switch to the theme configured for an SSH host",
+	Long: `Switch the wallpaper and terminal colors to the theme of the first
+[ssh_themes] pattern matching host. The setup it replaces is remembered
+for 'ppr context restore'. Hosts without a theme are left alone, so the
+hook can run for every connection. The arguments are those of ssh: the
+host is found among its options and a user@ prefix is ignored.`,
+	Args: cobra.MinimumNArgs(1),
+	// ssh options are passed through, they are no ppr flags
+	DisableFlagParsing: true,
+	RunE:               runContextSSH,
+}
+
+var contextRestoreCmd = &cobra.Command{
+	Use:   "restore",
+	Short: "Return to the theme in use before 'ppr context ssh'",
+	Args:  cobra.NoArgs,
+	RunE:  runContextRestore,
+}
+
+var contextNoTerminal bool
+
+func init() {
+	contextCmd.PersistentFlags().BoolVar(&contextNoTerminal, "no-terminal", false, "Leave the terminal colors alone")
+
+	contextCmd.AddCommand(contextSSHCmd)
+	contextCmd.AddCommand(contextRestoreCmd)
+}
+
+func runContextSSH(cmd *cobra.Command, args []string) error {
+	var sshArgs []string
+	for _, arg := range args {
+		if arg == "--no-terminal" {
+			contextNoTerminal = true
+			continue
+		}
+		sshArgs = append(sshArgs, arg)
+	}
+	host := sshHost(sshArgs)
+	if host == "" {
+		return nil
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	hostTheme, err := sshTheme(cfg.SSHThemes, host)
+	if err != nil || hostTheme == "" {
+		return err
+	}
+
+	st, err := state.Load(config.GetStatePath())
+	if err != nil {
+		return err
+	}
+	// Nested sessions keep the setup from before the outermost one
+	if st.Context == nil {
+		st.Context = &state.Context{
+			Host:     host,
+			Theme:    cfg.CurrentTheme,
+			Template: cfg.CurrentTemplate,
+			Warmth:   cfg.CurrentWarmth,
+		}
+		if err := st.Save(); err != nil {
+			return err
+		}
+	}
+
+	fmt.Printf("Switching to theme %s for %s\n", hostTheme, host)
+	if err := switchContext(hostTheme, cfg.CurrentTemplate, 0); err != nil {
+		return err
+	}
+	return recolorTerminal(cfg, hostTheme)
+}
+
+func runContextRestore(cmd *cobra.Command, args []string) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	st, err := state.Load(config.GetStatePath())
+	if err != nil {
+		return err
+	}
+	saved := st.Context
+	if saved == nil {
+		return nil
+	}
+	st.Context = nil
+	if err := st.Save(); err != nil {
+		return err
+	}
+
+	if saved.Theme == "" {
+		saved.Theme = cfg.DefaultTheme
+	}
+	fmt.Printf("Restoring theme %s after %s\n", saved.Theme, saved.Host)
+	if err := switchContext(saved.Theme, saved.Template, saved.Warmth); err != nil {
+		return err
+	}
+	if isTerminal(os.Stdout) && !contextNoTerminal {
+		fmt.Print(terminalReset)
+	}
+	return nil
+}
+
+// sshOptionsWithValue are the ssh options that take the next argument
+const sshOptionsWithValue = "BbcDEeFIiJLlmOoPpQRSWw"
+
+// sshHost returns the destination of ssh arguments, the first argument
+// that is neither an option nor its value, without a user@ prefix
+func sshHost(args []string) string {
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			if i+1 < len(args) {
+				return stripSSHUser(args[i+1])
+			}
+			return ""
+		}
+		if !strings.HasPrefix(arg, "-") || arg == "-" {
+			return stripSSHUser(arg)
+		}
+		// -p 22, unlike -p22 or bundled flags like -tA, consumes the next argument
+		if len(arg) == 2 && strings.ContainsRune(sshOptionsWithValue, rune(arg[1])) {
+			i++
+		}
+	}
+	return ""
+}
+
+func stripSSHUser(destination string) string {
+	if uri, ok := strings.CutPrefix(destination, "ssh://"); ok {
+		destination, _, _ = strings.Cut(uri, "/")
+		if i := strings.LastIndex(destination, ":"); i >= 0 {
+			destination = destination[:i]
+		}
+	}
+	if i := strings.LastIndex(destination, "@"); i >= 0 {
+		destination = destination[i+1:]
+	}
+	return destination
+}
+
+// sshTheme returns the theme of the first pattern matching host. Patterns
+// are tried in sorted order, as TOML tables keep none.
+func sshTheme(themes map[string]string, host string) (string, error) {
+	patterns := make([]string, 0, len(themes))
+	for pattern := range themes {
+		patterns = append(patterns, pattern)
+	}
+	sort.Strings(patterns)
+
+	for _, pattern := range patterns {
+		matched, err := path.Match(pattern, host)
+		if err != nil {
+			return "", fmt.Errorf("invalid ssh_themes pattern %q: %w", pattern, err)
+		}
+		if matched {
+			return themes[pattern], nil
+		}
+	}
+	return "", nil
+}
+
+// switchContext generates and sets the wallpaper for a context
+func switchContext(contextTheme, template string, kelvin int) error {
+	themeName = contextTheme
+	templatePath = template
+	warmth = kelvin
+	setWallpaper = true
+	return runGenerate(generateCmd, nil)
+}
+
+// recolorTerminal sends the escape sequences of the theme to the terminal
+// ppr runs in
+func recolorTerminal(cfg *config.Config, name string) error {
+	if contextNoTerminal || !isTerminal(os.Stdout) {
+		return nil
+	}
+	t, _, err := loadRenderTheme(cfg, name, nil, 0)
+	if err != nil {
+		return err
+	}
+	sequences, err := t.Export("osc")
+	if err != nil {
+		return err
+	}
+	fmt.Print(sequences)
+	return nil
+}
+
+// isTerminal reports whether f is a terminal rather than a pipe or file
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
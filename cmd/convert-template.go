@@ -8,7 +8,6 @@ import (
 	"strings"
 
 	"github.com/byteowlz/ppr/pkg/config"
-	"github.com/byteowlz/ppr/pkg/theme"
 	"github.com/spf13/cobra"
 )
 
@@ -222,7 +221,7 @@ func createInteractiveMapping(colors []string) (map[string]string, error) {
 
 func createThemeMapping(cfg *config.Config, colors []string, themeName string) (map[string]string, error) {
 	// Load theme manager and get the specified theme
-	themeManager := theme.NewThemeManager(cfg.ThemesPath)
+	themeManager := newThemeManager(cfg)
 	if err := themeManager.LoadThemes(); err != nil {
 		return nil, fmt.Errorf("failed to load themes: %w", err)
 	}
@@ -2,18 +2,17 @@ package cmd
 
 import (
 	"fmt"
-	"io"
-	"os"
 	"path/filepath"
 	"sort"
+	"strings"
 	"time"
 
 	"github.com/byteowlz/ppr/pkg/config"
+	"github.com/byteowlz/ppr/pkg/headless"
+	"github.com/byteowlz/ppr/pkg/idle"
 	"github.com/byteowlz/ppr/pkg/image"
-	"github.com/byteowlz/ppr/pkg/resolution"
-	"github.com/byteowlz/ppr/pkg/svg"
-	"github.com/byteowlz/ppr/pkg/theme"
-	"github.com/byteowlz/ppr/pkg/wallpaper"
+	"github.com/byteowlz/ppr/pkg/pipeline"
+	"github.com/byteowlz/ppr/pkg/state"
 	"github.com/spf13/cobra"
 )
 
@@ -24,7 +23,13 @@ var cycleCmd = &cobra.Command{
 If preferred_templates contains "all", it will cycle through all available templates.
 Otherwise, it cycles through the specified list of preferred templates.
 Uses the current theme if no theme is specified.
-The wallpaper is set automatically by default.`,
+The wallpaper is set automatically by default.
+
+With --min-idle the wallpaper only changes once the session has been idle
+that long, so a timer running 'ppr cycle --min-idle 10m' never switches it
+mid-work. Idle time comes from HIDIdleTime on macOS, GetLastInputInfo on
+Windows, Mutter on GNOME, xprintidle on X11 and otherwise the logind idle
+hint, which needs an idle daemon like swayidle or hypridle.`,
 	Args: cobra.MaximumNArgs(1),
 	RunE: runCycle,
 }
@@ -35,6 +40,11 @@ var (
 	cycleOutputFilename string
 	cycleResolutionStr  string
 	cycleOutputSVG      bool
+	cyclePreset         string
+	cycleLeastRecent    bool
+	cycleGroup          string
+	cycleMinIdle        time.Duration
+	cycleOverrides      []string
 )
 
 func init() {
@@ -43,9 +53,44 @@ func init() {
 	cycleCmd.Flags().StringVarP(&cycleOutputFilename, "filename", "f", "", "Output filename (optional)")
 	cycleCmd.Flags().StringVarP(&cycleResolutionStr, "resolution", "r", "", "Output resolution (e.g., 1920x1080)")
 	cycleCmd.Flags().BoolVar(&cycleOutputSVG, "svg", false, "Output SVG file instead of PNG")
+	cycleCmd.Flags().StringVar(&cyclePreset, "preset", "", "Render preset from [presets] in config.toml")
+	cycleCmd.Flags().StringVar(&cycleGroup, "group", "", "Cycle through a [template_groups] entry instead of preferred_templates")
+	cycleCmd.Flags().DurationVar(&cycleMinIdle, "min-idle", 0, "Only cycle after the session has been idle this long (e.g. 10m)")
+	cycleCmd.Flags().BoolVar(&cycleLeastRecent, "least-recent", false, "Pick the template least recently shown with this theme instead of the next one")
+	cycleCmd.Flags().StringArrayVar(&cycleOverrides, "override", nil, "Set a palette slot for this render only, e.g. base0D=#FF5500 (repeatable)")
+	addPaletteLimitFlags(cycleCmd)
+	addSummaryFlag(cycleCmd)
+	addRenderFlags(cycleCmd)
 }
 
 func runCycle(cmd *cobra.Command, args []string) error {
+	summaryOut, restoreStdout, err := startSummary()
+	if err != nil {
+		return err
+	}
+	defer restoreStdout()
+
+	if cycleMinIdle > 0 {
+		idleFor, err := idle.Duration()
+		if err != nil {
+			return fmt.Errorf("not cycling: %w", err)
+		}
+		if idleFor < cycleMinIdle {
+			fmt.Printf("Session idle for %s, waiting for %s\n", idleFor.Round(time.Second), cycleMinIdle)
+			if summaryOut != nil {
+				return writeSummary(summaryOut, &runSummary{Command: "cycle", Skipped: fmt.Sprintf("session idle for %s, waiting for %s", idleFor.Round(time.Second), cycleMinIdle)})
+			}
+			return nil
+		}
+	}
+	if pin := wallpaperPin(); pin != "" {
+		fmt.Printf("Wallpaper %s, not cycling\n", pin)
+		if summaryOut != nil {
+			return writeSummary(summaryOut, &runSummary{Command: "cycle", Skipped: "wallpaper " + pin})
+		}
+		return nil
+	}
+
 	cfg, err := config.Load()
 	if err != nil {
 		return fmt.Errorf("failed to load config: %w", err)
@@ -55,6 +100,31 @@ func runCycle(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to ensure directories: %w", err)
 	}
 
+	preset, err := lookupPreset(cfg, cyclePreset)
+	if err != nil {
+		return err
+	}
+	resolutionToUse := cycleResolutionStr
+	format := image.FormatPNG
+	presetWarmth := 0
+	if preset != nil {
+		if resolutionToUse == "" {
+			resolutionToUse = preset.Resolution
+		}
+		if preset.Format != "" {
+			format, err = image.ParseFormat(preset.Format)
+			if err != nil {
+				return err
+			}
+		}
+		presetWarmth = preset.Warmth
+	}
+	if cycleOutputFilename != "" {
+		if format, err = filenameFormat(cycleOutputFilename, format, preset != nil && preset.Format != ""); err != nil {
+			return err
+		}
+	}
+
 	// Determine theme to use
 	themeToUse := cfg.CurrentTheme
 	if len(args) > 0 {
@@ -65,18 +135,17 @@ func runCycle(cmd *cobra.Command, args []string) error {
 		fmt.Printf("No current or specified theme, using default: %s\n", themeToUse)
 	}
 
-	themeManager := theme.NewThemeManager(cfg.ThemesPath)
-	if err := themeManager.LoadThemes(); err != nil {
-		return fmt.Errorf("failed to load themes: %w", err)
+	selectedTheme, themeToUse, err := loadRenderTheme(cfg, themeToUse, preset, presetWarmth)
+	if err != nil {
+		return err
 	}
-
-	selectedTheme, err := themeManager.GetTheme(themeToUse)
+	selectedTheme, overrideSuffix, err := applyOverrides(selectedTheme, cycleOverrides)
 	if err != nil {
-		return fmt.Errorf("failed to get theme: %w", err)
+		return err
 	}
 
 	// Get templates to cycle through
-	templates, err := getTemplatesToCycle(cfg)
+	templates, err := getTemplatesToCycle(cfg, cycleGroup)
 	if err != nil {
 		return fmt.Errorf("failed to get templates: %w", err)
 	}
@@ -87,180 +156,82 @@ func runCycle(cmd *cobra.Command, args []string) error {
 
 	// Find next template to use
 	nextTemplate := getNextTemplate(templates, cfg.CurrentTemplate)
-	fmt.Printf("Cycling to template: %s\n", nextTemplate)
-
-	// Build full template path
-	templatePath := nextTemplate
-	if !filepath.IsAbs(templatePath) {
-		templatePath = filepath.Join(cfg.TemplatesPath, templatePath)
-	}
-
-	// Add .svg extension if not present
-	if filepath.Ext(templatePath) == "" {
-		templatePath += ".svg"
+	if cycleLeastRecent {
+		st, err := state.Load(config.GetStatePath())
+		if err != nil {
+			return err
+		}
+		nextTemplate = leastRecentTemplate(templates, nextTemplate, themeToUse, st)
 	}
+	fmt.Printf("Cycling to template: %s\n", nextTemplate)
+	templatePath := templateFile(cfg, nextTemplate)
 
-	processor := svg.NewProcessor()
-	svgContent, err := processor.ProcessTemplate(templatePath, selectedTheme)
+	res, err := targetResolution(cfg, resolutionToUse)
 	if err != nil {
-		return fmt.Errorf("failed to process template: %w", err)
+		return err
 	}
 
-	var res *resolution.Resolution
-	if cycleResolutionStr != "" {
-		res, err = resolution.ParseResolution(cycleResolutionStr)
-		if err != nil {
-			return fmt.Errorf("failed to parse resolution: %w", err)
-		}
-	} else {
-		detector := resolution.NewDetector()
-		res, err = detector.GetPrimaryDisplayResolution()
-		if err != nil {
-			fmt.Printf("Warning: failed to detect resolution, using default: %v\n", err)
-			res = &resolution.Resolution{Width: cfg.DefaultWidth, Height: cfg.DefaultHeight}
-		}
-	}
-
-	// Determine output directories and paths
 	baseOutputDir := cfg.OutputPath
 	if cycleOutputPath != "" {
 		baseOutputDir = cycleOutputPath
 	}
 
-	// Create theme subdirectory under ppr for named variants
-	themeSubDir := filepath.Join(baseOutputDir, "ppr", themeToUse)
-	if err := os.MkdirAll(themeSubDir, 0755); err != nil {
-		return fmt.Errorf("failed to create theme subdirectory: %w", err)
-	}
-
-	// Generate simplified filename for named variant (no timestamp)
-	namedFilename := cycleOutputFilename
-	if namedFilename == "" {
-		templateName := filepath.Base(nextTemplate)
-		// Remove .svg extension if present
-		if filepath.Ext(templateName) == ".svg" {
-			templateName = templateName[:len(templateName)-4]
-		}
-		if cycleOutputSVG {
-			namedFilename = fmt.Sprintf("%s.svg", templateName)
-		} else {
-			namedFilename = fmt.Sprintf("%s.png", templateName)
-		}
-	}
-
-	// Paths for both files
-	namedVariantPath := filepath.Join(themeSubDir, namedFilename)
-	currentWallpaperPath := filepath.Join(baseOutputDir, "current.png")
-
-	if cycleOutputSVG {
-		// For SVG, only write the named variant (current.png doesn't make sense for SVG)
-		// Check if named variant already exists
-		if _, err := os.Stat(namedVariantPath); err == nil {
-			fmt.Printf("Reusing existing SVG: %s\n", namedVariantPath)
-		} else {
-			if err := processor.WriteSVG(svgContent, namedVariantPath); err != nil {
-				return fmt.Errorf("failed to write SVG: %w", err)
-			}
-			fmt.Printf("Generated SVG: %s\n", namedVariantPath)
-		}
-	} else {
-		generator := image.NewGenerator()
-		var namedVariantExists bool
-
-		// Check if named variant already exists, generate if not
-		if _, err := os.Stat(namedVariantPath); err == nil {
-			fmt.Printf("Reusing existing wallpaper: %s (%s)\n", namedVariantPath, res.String())
-			namedVariantExists = true
-		} else {
-			if err := generator.GenerateWallpaper(svgContent, res.Width, res.Height, namedVariantPath); err != nil {
-				return fmt.Errorf("failed to generate named wallpaper: %w", err)
-			}
-			fmt.Printf("Generated wallpaper: %s (%s)\n", namedVariantPath, res.String())
-			namedVariantExists = true
-		}
-
-		// Copy named variant to current.png (more efficient than regenerating)
-		if namedVariantExists {
-			// Inline file copy to avoid function duplication
-			sourceFile, err := os.Open(namedVariantPath)
-			if err != nil {
-				return fmt.Errorf("failed to open source file: %w", err)
-			}
-			defer sourceFile.Close()
-
-			destFile, err := os.Create(currentWallpaperPath)
-			if err != nil {
-				return fmt.Errorf("failed to create dest file: %w", err)
-			}
-			defer destFile.Close()
-
-			if _, err := io.Copy(destFile, sourceFile); err != nil {
-				return fmt.Errorf("failed to copy file: %w", err)
-			}
-
-			fmt.Printf("Cycled to template '%s' with theme '%s': %s\n", nextTemplate, themeToUse, currentWallpaperPath)
-		}
-	}
-
-	// Use current.png for wallpaper setting (or named variant for SVG)
-	wallpaperPath := currentWallpaperPath
-	if cycleOutputSVG {
-		wallpaperPath = namedVariantPath
+	font := ""
+	if preset != nil {
+		font = preset.Font
 	}
 
 	// Always set wallpaper by default for cycle command, unless explicitly disabled
-	if cycleSetWallpaper {
-		// For macOS wallpaper caching issue, create a temporary file with unique name
-		// This ensures the system recognizes it as a new wallpaper file
-		timestamp := time.Now().Format("20060102-150405")
-		tempWallpaperPath := filepath.Join(baseOutputDir, fmt.Sprintf("current_temp_%s.png", timestamp))
-
-		// Copy current wallpaper to temp file for setting
-		if !cycleOutputSVG {
-			// Inline file copy for temp wallpaper
-			sourceFile, err := os.Open(wallpaperPath)
-			if err != nil {
-				fmt.Printf("Warning: failed to open wallpaper file: %v\n", err)
-			} else {
-				defer sourceFile.Close()
-
-				destFile, err := os.Create(tempWallpaperPath)
-				if err != nil {
-					fmt.Printf("Warning: failed to create temp wallpaper file: %v\n", err)
-				} else {
-					defer destFile.Close()
-
-					if _, err := io.Copy(destFile, sourceFile); err != nil {
-						fmt.Printf("Warning: failed to copy to temp wallpaper: %v\n", err)
-					} else {
-						wallpaperPath = tempWallpaperPath
-						// Inline cleanup to avoid function duplication
-						inlineCleanupOldTempFiles(baseOutputDir)
-					}
-				}
-			}
-		}
-
-		setter := wallpaper.NewSetter()
-		if err := setter.SetWallpaper(wallpaperPath); err != nil {
-			fmt.Printf("Warning: failed to set wallpaper: %v\n", err)
-		} else {
-			fmt.Println("Wallpaper set successfully!")
-		}
+	result, err := pipeline.Run(commandContext(cmd), pipeline.Options{
+		Theme:         selectedTheme,
+		ThemeName:     themeToUse,
+		TemplatePath:  templatePath,
+		Font:          font,
+		AllowUnsafe:   allowUnsafe,
+		Locale:        renderLocale(cfg),
+		OutputDir:     baseOutputDir,
+		Name:          variantBaseName(nextTemplate, cyclePreset, presetWarmth) + overrideSuffix,
+		Filename:      cycleOutputFilename,
+		Format:        format,
+		SVG:           cycleOutputSVG,
+		Optimize:      cfg.OptimizeOutput,
+		Resolution:    res,
+		Rasterizer:    cfg.Rasterizer,
+		FontsPath:     cfg.FontsPath,
+		PaletteLimit:  renderPaletteLimit(),
+		AspectRatio:   aspectRatio,
+		StrokeScale:   strokeScale,
+		SafeArea:      renderSafeArea(cmd, cfg),
+		Overlays:      activeOverlays(cfg, headless.Now()),
+		Regenerate:    aspectRatio != "" || cmd.Flags().Changed("safe-area"),
+		RenderTimeout: configTimeout("render_timeout", cfg.RenderTimeout, defaultRenderTimeout),
+		Limits:        renderLimits(cfg),
+		SetWallpaper:  cycleSetWallpaper,
+		Setter:        newWallpaperSetter(cfg),
+		CacheDir:      cfg.CacheDir,
+		Manifest:      newManifest(),
+		TemplatesDir:  cfg.TemplatesPath,
+		SaveState:     saveCurrentState(cfg, selectedTheme, themeToUse, nextTemplate, presetWarmth),
+	})
+	if err != nil {
+		return err
 	}
 
-	// Update current state in config
-	cfg.CurrentTheme = themeToUse
-	cfg.CurrentTemplate = filepath.Base(nextTemplate)
-	cfg.LastOutputPath = wallpaperPath
-	if err := cfg.Save(); err != nil {
-		fmt.Printf("Warning: failed to save current state: %v\n", err)
+	if result.VariantPath != "" {
+		fmt.Printf("Cycled to template '%s' with theme '%s': %s\n", nextTemplate, themeToUse, result.CurrentPath)
+	}
+	if summaryOut != nil {
+		return writeSummary(summaryOut, newRunSummary("cycle", themeToUse, templatePath, res, result))
 	}
-
 	return nil
 }
 
-func getTemplatesToCycle(cfg *config.Config) ([]string, error) {
+// getTemplatesToCycle returns the templates of group, or the preferred
+// templates when group is empty
+func getTemplatesToCycle(cfg *config.Config, group string) ([]string, error) {
+	if group != "" {
+		return templateGroup(cfg, group)
+	}
 	if len(cfg.PreferredTemplates) == 0 {
 		return nil, fmt.Errorf("no preferred templates configured")
 	}
@@ -309,25 +280,55 @@ func getNextTemplate(templates []string, currentTemplate string) string {
 	return templates[currentIndex+1]
 }
 
-// inlineCleanupOldTempFiles removes old temporary wallpaper files to prevent clutter
-func inlineCleanupOldTempFiles(baseDir string) {
-	entries, err := os.ReadDir(baseDir)
-	if err != nil {
-		return
-	}
-
-	for _, entry := range entries {
-		if !entry.IsDir() && filepath.Ext(entry.Name()) == ".png" {
-			// Check if it's an old temp file (current_temp_*.png)
-			if len(entry.Name()) > 12 && entry.Name()[:12] == "current_temp" {
-				// Get file info to check age
-				if info, err := entry.Info(); err == nil {
-					// Remove temp files older than 1 hour
-					if time.Since(info.ModTime()) > time.Hour {
-						os.Remove(filepath.Join(baseDir, entry.Name()))
-					}
-				}
-			}
+// leastRecentTemplate returns the template shown longest ago with the theme,
+// never shown ones first. Ties go to the first candidate from next onwards
+// in cycle order, so unused templates are still visited in sequence.
+func leastRecentTemplate(templates []string, next, themeName string, st *state.State) string {
+	start := 0
+	for i, template := range templates {
+		if template == next {
+			start = i
+			break
+		}
+	}
+
+	best := next
+	var bestUsed time.Time
+	for i := range templates {
+		template := templates[(start+i)%len(templates)]
+		used := st.Combination(themeName, filepath.Base(template)).LastUsed
+		if i == 0 || used.Before(bestUsed) {
+			best, bestUsed = template, used
+		}
+	}
+	return best
+}
+
+// templateGroup returns the templates of a [template_groups] entry, with the
+// .svg extension added where it is missing
+func templateGroup(cfg *config.Config, name string) ([]string, error) {
+	members, exists := cfg.TemplateGroups[name]
+	if !exists {
+		available := make([]string, 0, len(cfg.TemplateGroups))
+		for group := range cfg.TemplateGroups {
+			available = append(available, group)
+		}
+		sort.Strings(available)
+		if len(available) == 0 {
+			return nil, fmt.Errorf("unknown template group %q: no [template_groups] configured", name)
+		}
+		return nil, fmt.Errorf("unknown template group %q (available: %s)", name, strings.Join(available, ", "))
+	}
+	if len(members) == 0 {
+		return nil, fmt.Errorf("template group %q is empty", name)
+	}
+
+	templates := make([]string, 0, len(members))
+	for _, member := range members {
+		if filepath.Ext(member) == "" {
+			member += ".svg"
 		}
+		templates = append(templates, member)
 	}
+	return templates, nil
 }
@@ -0,0 +1,176 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"runtime"
+	"strings"
+	"syscall"
+
+	"github.com/byteowlz/ppr/pkg/config"
+	"github.com/byteowlz/ppr/pkg/dbusservice"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+var dbusServiceCmd = &cobra.Command{
+	Use:   "dbus-service",
+	Short: "Serve ppr on the D-Bus session bus",
+	Long: `Own dev.byteowlz.ppr on the session bus so desktop extensions, widgets
+and scripts can control ppr. The object /dev/byteowlz/ppr implements:
+
+  Cycle()              move to the next preferred template
+  SetTheme(s name)     switch the current template to a theme
+  SetTemplate(s name)  render a template with the current theme
+
+and publishes CurrentTheme, CurrentTemplate and Wallpaper properties,
+emitting PropertiesChanged when they change. Each call sets the wallpaper.
+
+With --install a D-Bus activation file is written instead, so the bus
+starts the service on the first call. With --watchdog the service also
+restarts a crashed swww, hyprpaper or swaybg, see 'ppr watchdog'. Linux
+and BSD only.
+
+Examples:
+  ppr dbus-service --install
+  busctl --user call dev.byteowlz.ppr /dev/byteowlz/ppr dev.byteowlz.ppr Cycle
+  busctl --user call dev.byteowlz.ppr /dev/byteowlz/ppr dev.byteowlz.ppr SetTheme s nord`,
+	Args: cobra.NoArgs,
+	RunE: runDBusService,
+}
+
+var (
+	dbusServiceInstall  bool
+	dbusServiceWatchdog bool
+)
+
+func init() {
+	dbusServiceCmd.Flags().BoolVar(&dbusServiceInstall, "install", false, "Install a D-Bus activation file and exit")
+	dbusServiceCmd.Flags().BoolVar(&dbusServiceWatchdog, "watchdog", false, "Restart the wallpaper daemon when it crashes")
+}
+
+func runDBusService(cmd *cobra.Command, args []string) error {
+	if runtime.GOOS == "windows" || runtime.GOOS == "darwin" {
+		return fmt.Errorf("the D-Bus service is only supported on Linux and BSD")
+	}
+
+	if dbusServiceInstall {
+		exe, err := os.Executable()
+		if err != nil {
+			return fmt.Errorf("failed to locate executable: %w", err)
+		}
+		path, err := dbusservice.InstallActivationFile(exe, "dbus-service")
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Installed D-Bus activation file: %s\n", path)
+		return nil
+	}
+
+	service, err := dbusservice.New(dbusHandler{})
+	if err != nil {
+		return err
+	}
+	defer service.Close()
+	fmt.Printf("Serving %s on the session bus\n", dbusservice.BusName)
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+
+	done := make(chan struct{})
+	go func() {
+		service.Wait()
+		close(done)
+	}()
+
+	if dbusServiceWatchdog {
+		stop := make(chan struct{})
+		defer close(stop)
+		go func() {
+			if err := superviseSetter(defaultWatchdogInterval, stop); err != nil {
+				fmt.Printf("Warning: watchdog stopped: %v\n", err)
+			}
+		}()
+	}
+
+	select {
+	case <-signals:
+	case <-done:
+		return fmt.Errorf("lost connection to the session bus")
+	}
+	return nil
+}
+
+// dbusHandler runs D-Bus calls through the cycle, switch-current and
+// generate commands, always setting the wallpaper. The flags of the command
+// are reset first, so values set by one call do not leak into the next.
+type dbusHandler struct{}
+
+func (dbusHandler) Cycle() error {
+	if err := resetFlags(cycleCmd); err != nil {
+		return err
+	}
+	cycleSetWallpaper = true
+	return runCycle(cycleCmd, nil)
+}
+
+func (dbusHandler) SetTheme(name string) error {
+	if err := resetFlags(switchCurrentCmd); err != nil {
+		return err
+	}
+	switchSetWallpaper = true
+	return runSwitchCurrent(switchCurrentCmd, []string{name})
+}
+
+func (dbusHandler) SetTemplate(name string) error {
+	if err := resetFlags(generateCmd); err != nil {
+		return err
+	}
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	themeName = cfg.CurrentTheme
+	if themeName == "" {
+		themeName = cfg.DefaultTheme
+	}
+	templatePath = name
+	setWallpaper = true
+	return runGenerate(generateCmd, nil)
+}
+
+func (dbusHandler) State() dbusservice.State {
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Printf("Warning: failed to load config: %v\n", err)
+		return dbusservice.State{}
+	}
+	return dbusservice.State{
+		CurrentTheme:    cfg.CurrentTheme,
+		CurrentTemplate: cfg.CurrentTemplate,
+		Wallpaper:       cfg.LastOutputPath,
+	}
+}
+
+// resetFlags sets the flags of cmd back to their defaults, as if it had
+// been run without any
+func resetFlags(cmd *cobra.Command) error {
+	var err error
+	cmd.Flags().VisitAll(func(f *pflag.Flag) {
+		if sv, ok := f.Value.(pflag.SliceValue); ok {
+			var values []string
+			if def := strings.Trim(f.DefValue, "[]"); def != "" {
+				values = strings.Split(def, ",")
+			}
+			if replaceErr := sv.Replace(values); replaceErr != nil && err == nil {
+				err = fmt.Errorf("failed to reset --%s: %w", f.Name, replaceErr)
+			}
+		} else if setErr := f.Value.Set(f.DefValue); setErr != nil && err == nil {
+			err = fmt.Errorf("failed to reset --%s: %w", f.Name, setErr)
+		}
+		f.Changed = false
+	})
+	return err
+}
@@ -0,0 +1,66 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/byteowlz/ppr/pkg/image"
+	"github.com/spf13/cobra"
+)
+
+var diffCmd = &cobra.Command{
+	Use:   "diff <a.png> <b.png>",
+	Short: "Compare two PNG images and report their similarity",
+	Long: `Compare two PNG images of the same size and print a perceptual similarity score.
+Optionally write an image highlighting every differing pixel in red.
+
+Examples:
+  ppr diff before.png after.png
+  ppr diff before.png after.png --output diff.png`,
+	Args: cobra.ExactArgs(2),
+	RunE: runDiff,
+}
+
+var (
+	diffOutputPath string
+	diffTolerance  float64
+)
+
+func init() {
+	diffCmd.Flags().StringVarP(&diffOutputPath, "output", "o", "", "Write a highlighted difference image to this path")
+	diffCmd.Flags().Float64Var(&diffTolerance, "tolerance", image.DefaultPixelTolerance, "Per-pixel perceptual delta (0-1) still considered identical")
+}
+
+func runDiff(cmd *cobra.Command, args []string) error {
+	a, err := image.LoadPNG(args[0])
+	if err != nil {
+		return err
+	}
+
+	b, err := image.LoadPNG(args[1])
+	if err != nil {
+		return err
+	}
+
+	result, err := image.Compare(a, b, diffTolerance)
+	if err != nil {
+		return fmt.Errorf("failed to compare images: %w", err)
+	}
+
+	fmt.Printf("Similarity:       %.4f\n", result.Similarity)
+	fmt.Printf("Mean delta:       %.4f\n", result.MeanDelta)
+	fmt.Printf("Max delta:        %.4f\n", result.MaxDelta)
+	fmt.Printf("Differing pixels: %d of %d (%.2f%%)\n", result.DiffPixels, result.TotalPixels, result.DiffRatio()*100)
+
+	if diffOutputPath != "" {
+		diffImage, err := image.DiffImage(a, b, diffTolerance)
+		if err != nil {
+			return fmt.Errorf("failed to create difference image: %w", err)
+		}
+		if err := image.WritePNG(diffImage, diffOutputPath); err != nil {
+			return fmt.Errorf("failed to write difference image: %w", err)
+		}
+		fmt.Printf("Difference image: %s\n", diffOutputPath)
+	}
+
+	return nil
+}
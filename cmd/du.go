@@ -0,0 +1,313 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/byteowlz/ppr/pkg/cache"
+	"github.com/byteowlz/ppr/pkg/config"
+	"github.com/byteowlz/ppr/pkg/image"
+	"github.com/spf13/cobra"
+)
+
+var duCmd = &cobra.Command{
+	Use:   "du",
+	Short: "Report disk usage of generated wallpapers",
+	Long: `Walk the output directory and report disk usage per theme and per template,
+the size of the rendered variant cache, and the cache of copies handed to the
+desktop.
+
+Use --prune-over to clean up desktop cache entries that are no longer in use,
+then delete the oldest rendered variants until both fit under the given size.
+
+Examples:
+  ppr du
+  ppr du --by-template
+  ppr du --prune-over 2GB`,
+	RunE: runDu,
+}
+
+var (
+	duOutputPath string
+	duByTemplate bool
+	duPruneOver  string
+	duDryRun     bool
+)
+
+func init() {
+	duCmd.Flags().StringVarP(&duOutputPath, "output", "o", "", "Output directory to inspect (defaults to config output path)")
+	duCmd.Flags().BoolVar(&duByTemplate, "by-template", false, "Also break down usage per template")
+	duCmd.Flags().StringVar(&duPruneOver, "prune-over", "", "Prune oldest variants until total size is below this limit (e.g., 2GB, 500MB)")
+	duCmd.Flags().BoolVar(&duDryRun, "dry-run", false, "Show what --prune-over would delete without deleting")
+}
+
+// outputFile describes a single file found in the output tree
+type outputFile struct {
+	path     string
+	theme    string
+	template string
+	size     int64
+	modTime  int64
+}
+
+func runDu(cmd *cobra.Command, args []string) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	baseOutputDir := cfg.OutputPath
+	if duOutputPath != "" {
+		baseOutputDir = duOutputPath
+	}
+
+	files, err := scanOutputTree(baseOutputDir)
+	if err != nil {
+		return fmt.Errorf("failed to scan output directory: %w", err)
+	}
+
+	desktopCache := cache.New(cfg.CacheDir)
+	desktopSize, desktopCount, err := dirSize(desktopCache.Dir)
+	if err != nil {
+		return fmt.Errorf("failed to scan cache directory: %w", err)
+	}
+
+	var total, cacheSize, otherSize int64
+	themeSizes := make(map[string]int64)
+	themeCounts := make(map[string]int)
+	templateSizes := make(map[string]int64)
+
+	for _, f := range files {
+		total += f.size
+		if f.theme != "" {
+			cacheSize += f.size
+			themeSizes[f.theme] += f.size
+			themeCounts[f.theme]++
+			templateSizes[f.template] += f.size
+		} else {
+			otherSize += f.size
+		}
+	}
+
+	fmt.Printf("Output directory: %s\n\n", baseOutputDir)
+
+	if len(themeSizes) > 0 {
+		fmt.Println("Per theme:")
+		for _, name := range sortedBySize(themeSizes) {
+			fmt.Printf("  %-32s %10s  (%d files)\n", name, image.FormatBytes(themeSizes[name]), themeCounts[name])
+		}
+		fmt.Println()
+	}
+
+	if duByTemplate && len(templateSizes) > 0 {
+		fmt.Println("Per template:")
+		for _, name := range sortedBySize(templateSizes) {
+			fmt.Printf("  %-32s %10s\n", name, image.FormatBytes(templateSizes[name]))
+		}
+		fmt.Println()
+	}
+
+	fmt.Printf("Variant cache:   %10s\n", image.FormatBytes(cacheSize))
+	fmt.Printf("Other files:     %10s\n", image.FormatBytes(otherSize))
+	fmt.Printf("Desktop cache:   %10s  (%d files, %d expired, in %s)\n", image.FormatBytes(desktopSize), desktopCount, len(desktopCache.Expired()), desktopCache.Dir)
+	fmt.Printf("Total:           %10s\n", image.FormatBytes(total+desktopSize))
+
+	if duPruneOver == "" {
+		return nil
+	}
+
+	limit, err := parseByteSize(duPruneOver)
+	if err != nil {
+		return fmt.Errorf("invalid --prune-over value: %w", err)
+	}
+
+	return pruneOutputTree(files, desktopCache, total+desktopSize, limit, duDryRun)
+}
+
+// scanOutputTree collects all files below the output directory, classifying
+// rendered variants (ppr/<theme>/<template>.<ext>)
+func scanOutputTree(baseDir string) ([]outputFile, error) {
+	var files []outputFile
+
+	if _, err := os.Stat(baseDir); os.IsNotExist(err) {
+		return files, nil
+	}
+
+	err := filepath.Walk(baseDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if info.IsDir() {
+			return nil
+		}
+
+		f := outputFile{
+			path:    path,
+			size:    info.Size(),
+			modTime: info.ModTime().UnixNano(),
+		}
+
+		relPath, err := filepath.Rel(baseDir, path)
+		if err != nil {
+			return err
+		}
+
+		parts := strings.Split(filepath.ToSlash(relPath), "/")
+		if len(parts) == 3 && parts[0] == "ppr" {
+			f.theme = parts[1]
+			// Manifests belong to the variant they describe
+			name := strings.TrimSuffix(parts[2], ".manifest.json")
+			f.template = strings.TrimSuffix(name, filepath.Ext(name))
+		}
+
+		files = append(files, f)
+		return nil
+	})
+
+	return files, err
+}
+
+// dirSize sums the sizes of the files directly in dir
+func dirSize(dir string) (int64, int, error) {
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return 0, 0, nil
+	} else if err != nil {
+		return 0, 0, err
+	}
+
+	var size int64
+	var count int
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		size += info.Size()
+		count++
+	}
+	return size, count, nil
+}
+
+// pruneOutputTree cleans up the desktop cache first, which keeps entries
+// still referenced by a desktop or monitor, then deletes the least recently
+// modified variants until the total size drops below limit
+func pruneOutputTree(files []outputFile, desktopCache *cache.Cache, total, limit int64, dryRun bool) error {
+	if total <= limit {
+		fmt.Printf("\nOutput tree and desktop cache are within the %s limit, nothing to prune\n", image.FormatBytes(limit))
+		return nil
+	}
+
+	fmt.Println()
+	var freed int64
+	var removed int
+	if dryRun {
+		for _, path := range desktopCache.Expired() {
+			if info, err := os.Stat(path); err == nil {
+				fmt.Printf("Would remove: %s (%s)\n", path, image.FormatBytes(info.Size()))
+				freed += info.Size()
+				removed++
+			}
+		}
+	} else {
+		before, _, _ := dirSize(desktopCache.Dir)
+		removed += desktopCache.Cleanup()
+		after, _, _ := dirSize(desktopCache.Dir)
+		if before > after {
+			freed += before - after
+			fmt.Printf("Removed unused desktop cache entries (%s)\n", image.FormatBytes(before-after))
+		}
+	}
+
+	var variants []outputFile
+	for _, f := range files {
+		if f.theme != "" {
+			variants = append(variants, f)
+		}
+	}
+
+	sort.Slice(variants, func(i, j int) bool {
+		return variants[i].modTime < variants[j].modTime
+	})
+
+	for _, f := range variants {
+		if total-freed <= limit {
+			break
+		}
+
+		if dryRun {
+			fmt.Printf("Would remove: %s (%s)\n", f.path, image.FormatBytes(f.size))
+		} else {
+			if err := os.Remove(f.path); err != nil {
+				fmt.Printf("Warning: failed to remove %s: %v\n", f.path, err)
+				continue
+			}
+			fmt.Printf("Removed: %s (%s)\n", f.path, image.FormatBytes(f.size))
+		}
+		freed += f.size
+		removed++
+	}
+
+	if total-freed > limit {
+		fmt.Printf("Warning: could not get below %s without touching non-variant files\n", image.FormatBytes(limit))
+	}
+
+	verb := "Freed"
+	if dryRun {
+		verb = "Would free"
+	}
+	fmt.Printf("%s %s across %d files\n", verb, image.FormatBytes(freed), removed)
+
+	return nil
+}
+
+func sortedBySize(sizes map[string]int64) []string {
+	var names []string
+	for name := range sizes {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if sizes[names[i]] == sizes[names[j]] {
+			return names[i] < names[j]
+		}
+		return sizes[names[i]] > sizes[names[j]]
+	})
+	return names
+}
+
+// parseByteSize parses human readable sizes such as "2GB", "500M" or "1024"
+func parseByteSize(s string) (int64, error) {
+	s = strings.ToUpper(strings.TrimSpace(s))
+	units := []struct {
+		suffix string
+		factor int64
+	}{
+		{"TB", 1 << 40}, {"GB", 1 << 30}, {"MB", 1 << 20}, {"KB", 1 << 10},
+		{"T", 1 << 40}, {"G", 1 << 30}, {"M", 1 << 20}, {"K", 1 << 10},
+		{"B", 1},
+	}
+
+	factor := int64(1)
+	for _, unit := range units {
+		if strings.HasSuffix(s, unit.suffix) {
+			factor = unit.factor
+			s = strings.TrimSpace(strings.TrimSuffix(s, unit.suffix))
+			break
+		}
+	}
+
+	value, err := strconv.ParseFloat(s, 64)
+	if err != nil || value < 0 {
+		return 0, fmt.Errorf("invalid size: %s", s)
+	}
+
+	return int64(value * float64(factor)), nil
+}
@@ -16,12 +16,22 @@ var extractColorsCmd = &cobra.Command{
 	Short: "Extract color scheme from SVG file and create a new theme",
 	Long: `Extract base16 color scheme from an SVG file that contains 16 color swatches.
 The SVG should contain exactly 16 unique fill colors, which will be mapped to base00-base0F in order of appearance.
-This creates a new theme file that can be used with other templates.`,
+The new theme is staged for review, see 'ppr theme review', unless
+--skip-review is given.`,
 	Args: cobra.ExactArgs(2),
 	RunE: runExtractColors,
 }
 
+var (
+	extractSkipReview bool
+	extractLicense    string
+	extractSourceURL  string
+)
+
 func init() {
+	extractColorsCmd.Flags().BoolVar(&extractSkipReview, "skip-review", false, "Save the theme to the active themes instead of staging it")
+	extractColorsCmd.Flags().StringVar(&extractLicense, "license", "", "License of the colors, e.g. CC0-1.0")
+	extractColorsCmd.Flags().StringVar(&extractSourceURL, "source-url", "", "Where the colors come from")
 	rootCmd.AddCommand(extractColorsCmd)
 }
 
@@ -56,21 +66,27 @@ func runExtractColors(cmd *cobra.Command, args []string) error {
 
 	// Create theme
 	newTheme := &theme.Theme{
-		System:  "base16",
-		Name:    themeName,
-		Author:  "extracted",
-		Variant: "dark",
-		Palette: colors,
+		System:    "base16",
+		Name:      themeName,
+		Author:    "extracted",
+		Variant:   "dark",
+		License:   extractLicense,
+		SourceURL: extractSourceURL,
+		Palette:   colors,
 	}
 
 	// Save theme
-	themeManager := theme.NewThemeManager(cfg.ThemesPath)
-	if err := themeManager.SaveTheme(newTheme); err != nil {
-		return fmt.Errorf("failed to save theme: %w", err)
-	}
+	if extractSkipReview {
+		themeManager := newThemeManager(cfg)
+		if err := themeManager.SaveTheme(newTheme); err != nil {
+			return fmt.Errorf("failed to save theme: %w", err)
+		}
 
-	fmt.Printf("Successfully extracted colors and created theme '%s'\n", themeName)
-	fmt.Printf("Theme saved to: %s/base16/%s.yaml\n", cfg.ThemesPath, themeName)
+		fmt.Printf("Successfully extracted colors and created theme '%s'\n", themeName)
+		fmt.Printf("Theme saved to: %s/base16/%s.yaml\n", cfg.ThemesPath, themeName)
+	} else if err := stageTheme(cfg, newTheme); err != nil {
+		return err
+	}
 
 	// Print extracted colors for verification
 	fmt.Println("\nExtracted colors:")
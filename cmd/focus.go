@@ -0,0 +1,160 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/byteowlz/ppr/pkg/config"
+	"github.com/byteowlz/ppr/pkg/focus"
+	"github.com/byteowlz/ppr/pkg/state"
+	"github.com/spf13/cobra"
+)
+
+const defaultFocusInterval = 30 * time.Second
+
+var focusCmd = &cobra.Command{
+	Use:   "focus",
+	Short: "Switch to a calmer wallpaper while Focus or Do Not Disturb is on",
+	Long: `Switch to the [focus] theme and/or template while a macOS Focus mode or
+GNOME Do Not Disturb is on, and back to the previous setup when it ends.
+
+  [focus]
+  theme = "nord"
+  template = "minimal.svg"
+  interval = "30s"
+
+'ppr focus apply' checks once, e.g. from a timer; 'ppr focus watch' keeps
+checking every interval.`,
+}
+
+var focusApplyCmd = &cobra.Command{
+	Use:   "apply",
+	Short: "Switch wallpapers if Focus or Do Not Disturb changed",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.Load()
+		if err != nil {
+			return fmt.Errorf("failed to load config: %w", err)
+		}
+		return applyFocus(cfg)
+	},
+}
+
+var focusWatchCmd = &cobra.Command{
+	Use:   "watch",
+	Short: "Follow Focus and Do Not Disturb until interrupted",
+	Args:  cobra.NoArgs,
+	RunE:  runFocusWatch,
+}
+
+var focusInterval time.Duration
+
+func init() {
+	focusWatchCmd.Flags().DurationVar(&focusInterval, "interval", 0, "Time between checks (defaults to [focus] interval or 30s)")
+
+	focusCmd.AddCommand(focusApplyCmd)
+	focusCmd.AddCommand(focusWatchCmd)
+}
+
+func runFocusWatch(cmd *cobra.Command, args []string) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	interval := focusInterval
+	if interval == 0 {
+		interval = configTimeout("[focus] interval", cfg.Focus.Interval, defaultFocusInterval)
+	}
+	if interval <= 0 {
+		interval = defaultFocusInterval
+	}
+
+	// An unsupported desktop never changes, so fail once instead of every tick
+	if _, err := focus.Active(); err != nil {
+		return err
+	}
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	fmt.Printf("Watching Focus and Do Not Disturb every %s\n", interval)
+	for {
+		cfg, err := config.Load()
+		if err == nil {
+			err = applyFocus(cfg)
+		}
+		if err != nil {
+			fmt.Printf("Warning: %v\n", err)
+		}
+
+		select {
+		case <-signals:
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// applyFocus switches to the [focus] setup when Focus or Do Not Disturb
+// turned on and restores the saved setup when it turned off
+func applyFocus(cfg *config.Config) error {
+	if cfg.Focus.Theme == "" && cfg.Focus.Template == "" {
+		return fmt.Errorf("no [focus] theme or template configured")
+	}
+
+	active, err := focus.Active()
+	if err != nil {
+		return err
+	}
+	// Switching is left for after the pin, which then finds the same change
+	if pin := wallpaperPin(); pin != "" {
+		return nil
+	}
+
+	st, err := state.Load(config.GetStatePath())
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case active && st.Focus == nil:
+		st.Focus = &state.Context{
+			Theme:    cfg.CurrentTheme,
+			Template: cfg.CurrentTemplate,
+			Warmth:   cfg.CurrentWarmth,
+		}
+		if err := st.Save(); err != nil {
+			return err
+		}
+
+		focusTheme, focusTemplate := cfg.Focus.Theme, cfg.Focus.Template
+		if focusTheme == "" {
+			focusTheme = cfg.CurrentTheme
+		}
+		if focusTemplate == "" {
+			focusTemplate = cfg.CurrentTemplate
+		}
+		fmt.Println("Focus is on, switching to the focus wallpaper")
+		return switchContext(focusTheme, focusTemplate, 0)
+
+	case !active && st.Focus != nil:
+		saved := st.Focus
+		st.Focus = nil
+		if err := st.Save(); err != nil {
+			return err
+		}
+
+		if saved.Theme == "" {
+			saved.Theme = cfg.DefaultTheme
+		}
+		fmt.Println("Focus is off, restoring the previous wallpaper")
+		return switchContext(saved.Theme, saved.Template, saved.Warmth)
+	}
+	return nil
+}
@@ -2,17 +2,14 @@ package cmd
 
 import (
 	"fmt"
-	"io"
-	"os"
 	"path/filepath"
-	"time"
+	"strconv"
 
 	"github.com/byteowlz/ppr/pkg/config"
+	"github.com/byteowlz/ppr/pkg/headless"
 	"github.com/byteowlz/ppr/pkg/image"
+	"github.com/byteowlz/ppr/pkg/pipeline"
 	"github.com/byteowlz/ppr/pkg/resolution"
-	"github.com/byteowlz/ppr/pkg/svg"
-	"github.com/byteowlz/ppr/pkg/theme"
-	"github.com/byteowlz/ppr/pkg/wallpaper"
 	"github.com/spf13/cobra"
 )
 
@@ -33,6 +30,13 @@ var (
 	setWallpaper   bool
 	outputFilename string
 	outputSVG      bool
+	fontOverride   string
+	resolutionList []string
+	allDisplays    bool
+	outputFormat   string
+	warmth         int
+	presetName     string
+	overrides      []string
 )
 
 func init() {
@@ -43,211 +47,207 @@ func init() {
 	generateCmd.Flags().BoolVarP(&setWallpaper, "set-wallpaper", "w", false, "Set generated image as wallpaper")
 	generateCmd.Flags().StringVarP(&outputFilename, "filename", "f", "", "Output filename (optional)")
 	generateCmd.Flags().BoolVar(&outputSVG, "svg", false, "Output SVG file instead of PNG (for Illustrator compatibility)")
+	generateCmd.Flags().StringVar(&fontOverride, "font", "", "Font family to use for all template text")
+	generateCmd.Flags().StringSliceVar(&resolutionList, "resolutions", nil, "Comma-separated list of resolutions to render in one run (e.g., 1920x1080,3840x2160)")
+	generateCmd.Flags().BoolVar(&allDisplays, "all-displays", false, "Render one wallpaper per connected display resolution")
+	generateCmd.Flags().StringVar(&outputFormat, "format", image.FormatPNG, "Raster output format: png, bmp or tiff")
+	generateCmd.Flags().StringVar(&presetName, "preset", "", "Render preset from [presets] in config.toml")
+	generateCmd.Flags().IntVar(&warmth, "warmth", 0, "Warm the palette to this color temperature in kelvin (1000-6500, e.g. 3400)")
+	generateCmd.Flags().StringArrayVar(&overrides, "override", nil, "Set a palette slot for this render only, e.g. base0D=#FF5500 (repeatable)")
+	addPaletteLimitFlags(generateCmd)
+
+	addSummaryFlag(generateCmd)
 
 	generateCmd.MarkFlagRequired("theme")
+	addRenderFlags(generateCmd)
 }
 
 func runGenerate(cmd *cobra.Command, args []string) error {
+	summaryOut, restoreStdout, err := startSummary()
+	if err != nil {
+		return err
+	}
+	defer restoreStdout()
+
 	cfg, err := config.Load()
 	if err != nil {
 		return fmt.Errorf("failed to load config: %w", err)
 	}
 
+	preset, err := lookupPreset(cfg, presetName)
+	if err != nil {
+		return err
+	}
+	if preset != nil {
+		// Explicit flags win over the preset
+		flags := cmd.Flags()
+		if preset.Resolution != "" && !flags.Changed("resolution") && !flags.Changed("resolutions") {
+			resolutionStr = preset.Resolution
+		}
+		if preset.Format != "" && !flags.Changed("format") {
+			outputFormat = preset.Format
+		}
+		if preset.Font != "" && !flags.Changed("font") {
+			fontOverride = preset.Font
+		}
+		if preset.Warmth != 0 && !flags.Changed("warmth") {
+			warmth = preset.Warmth
+		}
+	}
+
+	format, err := image.ParseFormat(outputFormat)
+	if err != nil {
+		return err
+	}
+	if outputFilename != "" && !outputSVG {
+		explicit := cmd.Flags().Changed("format") || (preset != nil && preset.Format != "")
+		if format, err = filenameFormat(outputFilename, format, explicit); err != nil {
+			return err
+		}
+	}
+
 	if err := cfg.EnsureDirectories(); err != nil {
 		return fmt.Errorf("failed to ensure directories: %w", err)
 	}
 
-	themeManager := theme.NewThemeManager(cfg.ThemesPath)
-	if err := themeManager.LoadThemes(); err != nil {
-		return fmt.Errorf("failed to load themes: %w", err)
+	selectedTheme, selectedName, err := loadRenderTheme(cfg, themeName, preset, warmth)
+	if err != nil {
+		return err
 	}
-
-	selectedTheme, err := themeManager.GetTheme(themeName)
+	selectedTheme, overrideSuffix, err := applyOverrides(selectedTheme, overrides)
 	if err != nil {
-		return fmt.Errorf("failed to get theme: %w", err)
+		return err
 	}
 
 	// Use default template if none specified
 	if templatePath == "" {
 		templatePath = cfg.DefaultTemplate
+		ensureDefaultTemplate(cfg)
 		fmt.Printf("Using default template: %s\n", templatePath)
 	}
+	templatePath = templateFile(cfg, templatePath)
 
-	if !filepath.IsAbs(templatePath) {
-		templatePath = filepath.Join(cfg.TemplatesPath, templatePath)
+	if resolutionStr != "" && len(resolutionList) > 0 {
+		return fmt.Errorf("use either --resolution or --resolutions, not both")
 	}
 
-	// Add .svg extension if not present
-	if filepath.Ext(templatePath) == "" {
-		templatePath += ".svg"
+	// Extra sizes rendered from the same processed SVG, the first one doubles
+	// as the current wallpaper
+	var sizes []*resolution.Resolution
+	if len(resolutionList) > 0 {
+		sizes, err = resolution.ParseResolutions(resolutionList)
+		if err != nil {
+			return fmt.Errorf("failed to parse resolutions: %w", err)
+		}
 	}
-
-	processor := svg.NewProcessor()
-	svgContent, err := processor.ProcessTemplate(templatePath, selectedTheme)
-	if err != nil {
-		return fmt.Errorf("failed to process template: %w", err)
+	if allDisplays {
+		displays, err := resolution.NewDetector().GetAllDisplayResolutions()
+		if err != nil {
+			return fmt.Errorf("failed to detect displays: %w", err)
+		}
+		sizes = resolution.Unique(append(sizes, displays...))
 	}
 
 	var res *resolution.Resolution
-	if resolutionStr != "" {
-		res, err = resolution.ParseResolution(resolutionStr)
-		if err != nil {
-			return fmt.Errorf("failed to parse resolution: %w", err)
-		}
+	if len(sizes) > 0 {
+		res = sizes[0]
 	} else {
-		detector := resolution.NewDetector()
-		res, err = detector.GetPrimaryDisplayResolution()
+		res, err = targetResolution(cfg, resolutionStr)
 		if err != nil {
-			fmt.Printf("Warning: failed to detect resolution, using default: %v\n", err)
-			res = &resolution.Resolution{Width: cfg.DefaultWidth, Height: cfg.DefaultHeight}
+			return err
 		}
 	}
 
-	// Determine output directories and paths
 	baseOutputDir := cfg.OutputPath
 	if outputPath != "" {
 		baseOutputDir = outputPath
 	}
 
-	// Create theme subdirectory under ppr for named variants
-	themeSubDir := filepath.Join(baseOutputDir, "ppr", themeName)
-	if err := os.MkdirAll(themeSubDir, 0755); err != nil {
-		return fmt.Errorf("failed to create theme subdirectory: %w", err)
-	}
-
-	// Generate simplified filename for named variant (no timestamp)
-	namedFilename := outputFilename
-	if namedFilename == "" {
-		templateName := filepath.Base(templatePath)
-		// Remove .svg extension if present
-		if filepath.Ext(templateName) == ".svg" {
-			templateName = templateName[:len(templateName)-4]
-		}
-		if outputSVG {
-			namedFilename = fmt.Sprintf("%s.svg", templateName)
-		} else {
-			namedFilename = fmt.Sprintf("%s.png", templateName)
-		}
+	result, err := pipeline.Run(commandContext(cmd), pipeline.Options{
+		Theme:        selectedTheme,
+		ThemeName:    selectedName,
+		TemplatePath: templatePath,
+		Font:         fontOverride,
+		AllowUnsafe:  allowUnsafe,
+		Locale:       renderLocale(cfg),
+		OutputDir:    baseOutputDir,
+		Name:         variantBaseName(templatePath, presetName, warmth) + overrideSuffix,
+		Filename:     outputFilename,
+		Format:       format,
+		SVG:          outputSVG,
+		Optimize:     cfg.OptimizeOutput,
+		Sizes:        sizes,
+		Resolution:   res,
+		Rasterizer:   cfg.Rasterizer,
+		FontsPath:    cfg.FontsPath,
+		PaletteLimit: renderPaletteLimit(),
+		AspectRatio:  aspectRatio,
+		StrokeScale:  strokeScale,
+		SafeArea:     renderSafeArea(cmd, cfg),
+		Overlays:     activeOverlays(cfg, headless.Now()),
+		// Text may change with the font and framing with the aspect ratio or
+		// safe area, so do not reuse variants
+		Regenerate:    fontOverride != "" || aspectRatio != "" || cmd.Flags().Changed("safe-area"),
+		RenderTimeout: configTimeout("render_timeout", cfg.RenderTimeout, defaultRenderTimeout),
+		Limits:        renderLimits(cfg),
+		SetWallpaper:  setWallpaper || cfg.AutoSetWallpaper,
+		Setter:        newWallpaperSetter(cfg),
+		CacheDir:      cfg.CacheDir,
+		Manifest:      newManifest(),
+		TemplatesDir:  cfg.TemplatesPath,
+		SaveState:     saveCurrentState(cfg, selectedTheme, selectedName, templatePath, warmth),
+	})
+	if err != nil {
+		return err
 	}
 
-	// Paths for both files
-	namedVariantPath := filepath.Join(themeSubDir, namedFilename)
-	currentWallpaperPath := filepath.Join(baseOutputDir, "current.png")
-
-	generator := image.NewGenerator()
-	var pngGenerated bool
-
-	if outputSVG {
-		// Generate SVG version
-		if _, err := os.Stat(namedVariantPath); err == nil {
-			fmt.Printf("Reusing existing SVG: %s\n", namedVariantPath)
-		} else {
-			if err := processor.WriteSVG(svgContent, namedVariantPath); err != nil {
-				return fmt.Errorf("failed to write SVG: %w", err)
-			}
-			fmt.Printf("Generated SVG: %s\n", namedVariantPath)
-		}
+	if summaryOut != nil {
+		return writeSummary(summaryOut, newRunSummary("generate", selectedName, templatePath, res, result))
 	}
+	return nil
+}
 
-	// Always generate PNG if not outputSVG, or if outputSVG but wallpaper setting is requested
-	if !outputSVG || setWallpaper || cfg.AutoSetWallpaper {
-		// For PNG filename when outputSVG is true but we need PNG for wallpaper
-		pngFilename := outputFilename
-		if outputSVG && pngFilename != "" {
-			// Replace .svg extension with .png for the PNG version
-			if filepath.Ext(pngFilename) == ".svg" {
-				pngFilename = pngFilename[:len(pngFilename)-4] + ".png"
-			}
-		} else if outputSVG {
-			// Generate PNG filename from template name
-			templateName := filepath.Base(templatePath)
-			if filepath.Ext(templateName) == ".svg" {
-				templateName = templateName[:len(templateName)-4]
-			}
-			pngFilename = fmt.Sprintf("%s.png", templateName)
-		}
-
-		pngPath := namedVariantPath
-		if outputSVG {
-			pngPath = filepath.Join(themeSubDir, pngFilename)
-		}
-
-		// Check if PNG variant already exists, generate if not
-		if _, err := os.Stat(pngPath); err == nil {
-			fmt.Printf("Reusing existing wallpaper: %s (%s)\n", pngPath, res.String())
-			pngGenerated = true
-		} else {
-			if err := generator.GenerateWallpaper(svgContent, res.Width, res.Height, pngPath); err != nil {
-				return fmt.Errorf("failed to generate wallpaper: %w", err)
-			}
-			fmt.Printf("Generated wallpaper: %s (%s)\n", pngPath, res.String())
-			pngGenerated = true
-		}
-
-		// Copy PNG variant to current.png (more efficient than regenerating)
-		if pngGenerated {
-			if err := copyFile(pngPath, currentWallpaperPath); err != nil {
-				return fmt.Errorf("failed to copy to current wallpaper: %w", err)
-			}
-			fmt.Printf("Current wallpaper saved as: %s\n", currentWallpaperPath)
+// filenameFormat checks the extension of --filename against the raster
+// format before anything is rendered. Unless the format is explicit, from
+// --format or a preset, the extension picks it.
+func filenameFormat(filename, format string, explicit bool) (string, error) {
+	ext := filepath.Ext(filename)
+	if ext == "" {
+		if explicit && format != image.FormatPNG {
+			return "", fmt.Errorf("--filename %s has no extension, name it %s.%s for the %s output format", filename, filename, format, format)
 		}
+		return image.FormatPNG, nil
 	}
-
-	// Use current.png for wallpaper setting (always PNG, even when SVG was also generated)
-	wallpaperPath := currentWallpaperPath
-
-	if setWallpaper || cfg.AutoSetWallpaper {
-		if !pngGenerated {
-			fmt.Printf("Warning: Cannot set wallpaper without PNG file\n")
-		} else {
-			// For macOS wallpaper caching issue, create a temporary file with unique name
-			// This ensures the system recognizes it as a new wallpaper file
-			timestamp := time.Now().Format("20060102-150405")
-			tempWallpaperPath := filepath.Join(baseOutputDir, fmt.Sprintf("current_temp_%s.png", timestamp))
-
-			// Copy current wallpaper to temp file for setting
-			if err := copyFile(wallpaperPath, tempWallpaperPath); err != nil {
-				fmt.Printf("Warning: failed to create temp wallpaper file: %v\n", err)
-			} else {
-				wallpaperPath = tempWallpaperPath
-				// Clean up old temp files (keep only the current one)
-				inlineCleanupOldTempFiles(baseOutputDir)
-			}
-
-			setter := wallpaper.NewSetter()
-			if err := setter.SetWallpaper(wallpaperPath); err != nil {
-				fmt.Printf("Warning: failed to set wallpaper: %v\n", err)
-			} else {
-				fmt.Println("Wallpaper set successfully!")
-			}
-		}
+	implied, err := image.ParseFormat(ext)
+	if err != nil {
+		return "", fmt.Errorf("invalid --filename %s: %w", filename, err)
 	}
-
-	// Update current state in config
-	cfg.CurrentTheme = themeName
-	cfg.CurrentTemplate = filepath.Base(templatePath)
-	cfg.LastOutputPath = wallpaperPath
-	if err := cfg.Save(); err != nil {
-		fmt.Printf("Warning: failed to save current state: %v\n", err)
+	if explicit && implied != format {
+		return "", fmt.Errorf("--filename %s conflicts with the %s output format", filename, format)
 	}
-
-	return nil
+	return implied, nil
 }
 
-// copyFile copies a file from src to dst
-func copyFile(src, dst string) error {
-	sourceFile, err := os.Open(src)
-	if err != nil {
-		return err
+// variantBaseName names a variant after its template without the .svg
+// extension. The preset, warmth, --high-contrast, --stroke-scale and the
+// palette limit are appended, e.g. shapes-oled-3400k-hc-strokes2, so
+// adjusted variants never replace the plain render.
+func variantBaseName(templatePath, preset string, kelvin int) string {
+	name := filepath.Base(templatePath)
+	if filepath.Ext(name) == ".svg" {
+		name = name[:len(name)-4]
 	}
-	defer sourceFile.Close()
-
-	destFile, err := os.Create(dst)
-	if err != nil {
-		return err
+	if preset != "" {
+		name += "-" + preset
 	}
-	defer destFile.Close()
-
-	_, err = io.Copy(destFile, sourceFile)
-	return err
+	if kelvin != 0 {
+		name += fmt.Sprintf("-%dk", kelvin)
+	}
+	if highContrast {
+		name += "-hc"
+	}
+	if strokeScale != 1 && strokeScale > 0 {
+		name += "-strokes" + strconv.FormatFloat(strokeScale, 'f', -1, 64)
+	}
+	return name + paletteLimitSuffix()
 }
@@ -0,0 +1,130 @@
+package cmd
+
+import (
+	"fmt"
+	stdimage "image"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/byteowlz/ppr/pkg/config"
+	"github.com/byteowlz/ppr/pkg/image"
+	"github.com/spf13/cobra"
+)
+
+var iconCmd = &cobra.Command{
+	Use:   "icon <template>",
+	Short: "Render a themed template as a multi-size ICO or ICNS icon",
+	Long: `Render an SVG template with a theme at the standard icon sizes and pack
+them into a Windows .ico (16-256px) or macOS .icns (16-1024px) file, so
+folder and app icons can match the wallpaper palette.
+
+Non-square templates are scaled to cover the icon and center-cropped.
+Uses the current theme if no theme is specified.
+
+Examples:
+  ppr icon folder.svg --theme nord --format icns
+  ppr icon logo --theme gruvbox-dark --format ico -o logo.ico`,
+	Args: cobra.ExactArgs(1),
+	RunE: runIcon,
+}
+
+var (
+	iconThemeName  string
+	iconFormat     string
+	iconOutputPath string
+)
+
+func init() {
+	defaultFormat := image.IconFormatICO
+	if runtime.GOOS == "darwin" {
+		defaultFormat = image.IconFormatICNS
+	}
+
+	iconCmd.Flags().StringVarP(&iconThemeName, "theme", "t", "", "Theme to apply (defaults to current theme)")
+	iconCmd.Flags().StringVarP(&iconFormat, "format", "f", defaultFormat, "Icon format: ico or icns")
+	iconCmd.Flags().StringVarP(&iconOutputPath, "output", "o", "", "Output file (defaults to the theme output directory)")
+	addRenderFlags(iconCmd)
+}
+
+func runIcon(cmd *cobra.Command, args []string) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	format := strings.ToLower(iconFormat)
+	sizes, err := image.IconSizes(format)
+	if err != nil {
+		return err
+	}
+
+	themeToUse := iconThemeName
+	if themeToUse == "" {
+		themeToUse = cfg.CurrentTheme
+	}
+	if themeToUse == "" {
+		themeToUse = cfg.DefaultTheme
+	}
+
+	themeManager := newThemeManager(cfg)
+	if err := themeManager.LoadThemes(); err != nil {
+		return fmt.Errorf("failed to load themes: %w", err)
+	}
+
+	selectedTheme, err := themeManager.GetTheme(themeToUse)
+	if err != nil {
+		return fmt.Errorf("failed to get theme: %w", err)
+	}
+
+	templatePath := args[0]
+	if _, err := os.Stat(templatePath); err != nil && !filepath.IsAbs(templatePath) {
+		templatePath = filepath.Join(cfg.TemplatesPath, templatePath)
+	}
+	if filepath.Ext(templatePath) == "" {
+		templatePath += ".svg"
+	}
+
+	processor := newProcessor(cfg)
+	svgContent, err := processor.ProcessTemplate(templatePath, selectedTheme)
+	if err != nil {
+		return fmt.Errorf("failed to process template: %w", err)
+	}
+
+	renderContent, generator, err := prepareRender(cfg, svgContent)
+	if err != nil {
+		return fmt.Errorf("failed to prepare render: %w", err)
+	}
+
+	parsed, err := generator.Parse(renderContent)
+	if err != nil {
+		return err
+	}
+
+	images := make(map[int]stdimage.Image)
+	for _, size := range sizes {
+		img, err := renderParsedWithTimeout(cmd, cfg, generator, parsed, size, size)
+		if err != nil {
+			return fmt.Errorf("failed to render %dx%d: %w", size, size, err)
+		}
+		images[size] = img
+	}
+
+	outPath := iconOutputPath
+	if outPath == "" {
+		templateName := strings.TrimSuffix(filepath.Base(templatePath), filepath.Ext(templatePath))
+		themeDir := filepath.Join(cfg.OutputPath, "ppr", themeToUse)
+		if err := os.MkdirAll(themeDir, 0755); err != nil {
+			return fmt.Errorf("failed to create theme subdirectory: %w", err)
+		}
+		outPath = filepath.Join(themeDir, templateName+"."+format)
+	}
+
+	if err := image.WriteIcon(format, images, outPath); err != nil {
+		return err
+	}
+
+	fmt.Printf("Generated %s icon: %s (%d sizes)\n", strings.ToUpper(format), outPath, len(sizes))
+	return nil
+}
@@ -0,0 +1,215 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/byteowlz/ppr/pkg/config"
+	"github.com/byteowlz/ppr/pkg/image"
+	"github.com/byteowlz/ppr/pkg/palette"
+	"github.com/byteowlz/ppr/pkg/theme"
+	"github.com/byteowlz/ppr/pkg/wallpaper"
+)
+
+// Thumbnail size in terminal cells, each cell shows two pixel rows
+const (
+	thumbnailWidth = 32
+	thumbnailRows  = 9
+)
+
+// runInitWizard asks for the main settings, starting from cfg, and saves
+// the result. Pressing enter keeps the value shown in brackets.
+func runInitWizard(cfg *config.Config, in io.Reader) error {
+	reader := bufio.NewReader(in)
+	color := os.Getenv("NO_COLOR") == ""
+
+	fmt.Println()
+	fmt.Printf("Detected desktop: %s\n", wallpaper.DetectDesktop())
+	fmt.Println()
+
+	themeManager := newThemeManager(cfg)
+	if err := themeManager.LoadThemes(); err != nil {
+		fmt.Printf("Warning: failed to load themes: %v\n", err)
+	}
+	themeNames := themeManager.ListThemes()
+	sort.Strings(themeNames)
+
+	if len(themeNames) == 0 {
+		fmt.Printf("No themes found in %s, keeping default theme '%s'\n", cfg.ThemesPath, cfg.DefaultTheme)
+	} else {
+		fmt.Println("Themes:")
+		for i, name := range themeNames {
+			fmt.Printf("  %3d) %-28s", i+1, name)
+			if t, err := themeManager.GetTheme(name); err == nil && color {
+				fmt.Print(" " + swatch(t))
+			}
+			fmt.Println()
+		}
+		name, err := promptChoice(reader, "Default theme", themeNames, cfg.DefaultTheme)
+		if err != nil {
+			return err
+		}
+		cfg.DefaultTheme = name
+	}
+	fmt.Println()
+
+	templateNames, err := findTemplates(cfg.TemplatesPath)
+	if err != nil {
+		return fmt.Errorf("failed to find templates: %w", err)
+	}
+	sort.Strings(templateNames)
+
+	if len(templateNames) == 0 {
+		fmt.Printf("No templates found in %s, keeping default template '%s'\n", cfg.TemplatesPath, cfg.DefaultTemplate)
+	} else {
+		previewTheme, _ := themeManager.GetTheme(cfg.DefaultTheme)
+		fmt.Println("Templates:")
+		for i, name := range templateNames {
+			fmt.Printf("  %3d) %s\n", i+1, name)
+			if color && previewTheme != nil {
+				fmt.Print(thumbnail(cfg, filepath.Join(cfg.TemplatesPath, name), previewTheme))
+			}
+		}
+		name, err := promptChoice(reader, "Default template", templateNames, cfg.DefaultTemplate)
+		if err != nil {
+			return err
+		}
+		cfg.DefaultTemplate = name
+	}
+	fmt.Println()
+
+	autoSet, err := promptYesNo(reader, "Set generated wallpapers automatically", cfg.AutoSetWallpaper)
+	if err != nil {
+		return err
+	}
+	cfg.AutoSetWallpaper = autoSet
+
+	outputPath, err := prompt(reader, "Output directory", cfg.OutputPath)
+	if err != nil {
+		return err
+	}
+	cfg.OutputPath = config.ExpandPath(outputPath)
+
+	if err := cfg.EnsureDirectories(); err != nil {
+		return fmt.Errorf("failed to create directories: %w", err)
+	}
+	if err := cfg.Save(); err != nil {
+		return fmt.Errorf("failed to save config: %w", err)
+	}
+
+	fmt.Println()
+	fmt.Printf("Saved: theme %s, template %s, output %s\n", cfg.DefaultTheme, cfg.DefaultTemplate, cfg.OutputPath)
+	return nil
+}
+
+// prompt reads one line, returning def for an empty answer
+func prompt(reader *bufio.Reader, question, def string) (string, error) {
+	fmt.Printf("%s [%s]: ", question, def)
+	line, err := reader.ReadString('\n')
+	if err != nil && err != io.EOF {
+		return "", fmt.Errorf("failed to read answer: %w", err)
+	}
+	if err == io.EOF && line == "" {
+		// Input ended, keep the defaults for the remaining questions
+		fmt.Println()
+		return def, nil
+	}
+	if answer := strings.TrimSpace(line); answer != "" {
+		return answer, nil
+	}
+	return def, nil
+}
+
+// promptChoice accepts a list number or a name from choices
+func promptChoice(reader *bufio.Reader, question string, choices []string, def string) (string, error) {
+	for {
+		answer, err := prompt(reader, question, def)
+		if err != nil {
+			return "", err
+		}
+		if n, err := strconv.Atoi(answer); err == nil && n >= 1 && n <= len(choices) {
+			return choices[n-1], nil
+		}
+		for _, choice := range choices {
+			if answer == choice || answer+".svg" == choice {
+				return choice, nil
+			}
+		}
+		if answer == def {
+			return def, nil
+		}
+		fmt.Printf("Unknown choice %q, enter a number from 1 to %d or a name\n", answer, len(choices))
+	}
+}
+
+func promptYesNo(reader *bufio.Reader, question string, def bool) (bool, error) {
+	defAnswer := "y/N"
+	if def {
+		defAnswer = "Y/n"
+	}
+	for {
+		answer, err := prompt(reader, question, defAnswer)
+		if err != nil {
+			return false, err
+		}
+		if answer == defAnswer {
+			return def, nil
+		}
+		switch strings.ToLower(answer) {
+		case "y", "yes":
+			return true, nil
+		case "n", "no":
+			return false, nil
+		}
+		fmt.Println("Please answer y or n")
+	}
+}
+
+// swatch draws the first 16 palette colors as truecolor blocks
+func swatch(t *theme.Theme) string {
+	var b strings.Builder
+	for i, key := range t.PaletteKeys() {
+		if i == 16 {
+			break
+		}
+		c, err := palette.ParseHex(t.Palette[key])
+		if err != nil {
+			b.WriteString("  ")
+			continue
+		}
+		fmt.Fprintf(&b, "\x1b[48;2;%d;%d;%dm  ", c.R, c.G, c.B)
+	}
+	b.WriteString("\x1b[0m")
+	return b.String()
+}
+
+// thumbnail renders a template with t as truecolor half blocks. Templates
+// that fail to render get no preview.
+func thumbnail(cfg *config.Config, templatePath string, t *theme.Theme) string {
+	svgContent, err := newProcessor(cfg).ProcessTemplate(templatePath, t)
+	if err != nil {
+		return ""
+	}
+	img, err := image.NewGenerator().Render(svgContent, thumbnailWidth, thumbnailRows*2)
+	if err != nil {
+		return ""
+	}
+
+	var b strings.Builder
+	for row := 0; row < thumbnailRows; row++ {
+		b.WriteString("       ")
+		for x := 0; x < thumbnailWidth; x++ {
+			top := img.RGBAAt(x, row*2)
+			bottom := img.RGBAAt(x, row*2+1)
+			fmt.Fprintf(&b, "\x1b[38;2;%d;%d;%dm\x1b[48;2;%d;%d;%dm▀", top.R, top.G, top.B, bottom.R, bottom.G, bottom.B)
+		}
+		b.WriteString("\x1b[0m\n")
+	}
+	return b.String()
+}
@@ -3,10 +3,10 @@ package cmd
 import (
 	"fmt"
 	"os"
-	"path/filepath"
 
 	"github.com/byteowlz/ppr/pkg/config"
 	"github.com/byteowlz/ppr/pkg/templates"
+	"github.com/byteowlz/ppr/pkg/theme"
 	"github.com/spf13/cobra"
 )
 
@@ -14,16 +14,26 @@ var initConfigCmd = &cobra.Command{
 	Use:   "init",
 	Short: "Initialize PPR configuration",
 	Long: `Initialize PPR configuration by creating the config file and directory structure.
-This will create ~/.config/ppr/ with default settings and required directories.`,
+This will create ~/.config/ppr/ with default settings and required directories,
+and copy the example templates and a set of starter themes into it.
+--download-themes additionally fetches the full tinted-theming scheme collection.
+
+With --interactive, a short setup then shows the detected desktop and asks for
+the default theme (with color swatches), the default template (with
+previews), automatic wallpaper setting and the output directory.`,
 	RunE: runInitConfig,
 }
 
 var (
-	force bool
+	force              bool
+	initInteractive    bool
+	initDownloadThemes bool
 )
 
 func init() {
 	initConfigCmd.Flags().BoolVarP(&force, "force", "f", false, "Overwrite existing configuration")
+	initConfigCmd.Flags().BoolVarP(&initInteractive, "interactive", "i", false, "Ask for the main settings")
+	initConfigCmd.Flags().BoolVar(&initDownloadThemes, "download-themes", false, "Download the tinted-theming base16/base24 schemes")
 }
 
 func runInitConfig(cmd *cobra.Command, args []string) error {
@@ -57,34 +67,31 @@ func runInitConfig(cmd *cobra.Command, args []string) error {
 		fmt.Println("Example templates copied to templates directory")
 	}
 
-	if err := createExampleConfig(cfg.ThemesPath); err != nil {
-		fmt.Printf("Warning: failed to create example theme symlink: %v\n", err)
-	} else {
-		fmt.Println("Symlink created to existing themes directory")
+	if count, err := theme.CopyStarterThemes(cfg.ThemesPath); err != nil {
+		fmt.Printf("Warning: failed to copy starter themes: %v\n", err)
+	} else if count > 0 {
+		fmt.Printf("%d starter themes copied to themes directory\n", count)
 	}
 
-	fmt.Println()
-	fmt.Println("PPR is ready to use! Try:")
-	fmt.Println("   ppr list-themes")
-	fmt.Println("   ppr generate --theme nord --template geometric-simple.svg")
-
-	return nil
-}
-
-func createExampleConfig(themesDir string) error {
-	cwd, err := os.Getwd()
-	if err != nil {
-		return err
+	if initDownloadThemes {
+		fmt.Printf("Downloading schemes from %s...\n", theme.SchemesURL)
+		if count, err := theme.DownloadSchemes(theme.SchemesURL, cfg.ThemesPath); err != nil {
+			fmt.Printf("Warning: %v\n", err)
+		} else {
+			fmt.Printf("%d themes downloaded to themes directory\n", count)
+		}
 	}
 
-	sourceThemesDir := filepath.Join(cwd, "themes")
-	if _, err := os.Stat(sourceThemesDir); os.IsNotExist(err) {
-		return fmt.Errorf("source themes directory not found: %s", sourceThemesDir)
+	if initInteractive {
+		if err := runInitWizard(cfg, os.Stdin); err != nil {
+			return err
+		}
 	}
 
-	if _, err := os.Stat(themesDir); os.IsNotExist(err) {
-		return os.Symlink(sourceThemesDir, themesDir)
-	}
+	fmt.Println()
+	fmt.Println("PPR is ready to use! Try:")
+	fmt.Println("   ppr list-themes")
+	fmt.Println("   ppr generate --theme nord --template shapes")
 
 	return nil
 }
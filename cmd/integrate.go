@@ -0,0 +1,75 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/byteowlz/ppr/pkg/integrate"
+	"github.com/spf13/cobra"
+)
+
+var integrateCmd = &cobra.Command{
+	Use:   "integrate",
+	Short: "Add ppr to the file manager's context menu",
+}
+
+var integrateInstallCmd = &cobra.Command{
+	Use:   "install",
+	Short: "Add a \"" + integrate.ActionName + "\" action",
+	Long: `Add a "` + integrate.ActionName + `" action to the context menu of
+images, which recolors the image to the current theme and sets it as
+wallpaper, like 'ppr recolor <image> --set-wallpaper':
+
+  Linux, BSD  a Nautilus script in ~/.local/share/nautilus/scripts
+  macOS       a Finder Quick Action in ~/Library/Services
+  Windows     an Explorer entry under HKEY_CURRENT_USER
+
+The action runs this ppr executable; install again after moving it.
+'ppr integrate remove' takes the action away.`,
+	Args: cobra.NoArgs,
+	RunE: runIntegrateInstall,
+}
+
+var integrateRemoveCmd = &cobra.Command{
+	Use:   "remove",
+	Short: "Remove the action 'ppr integrate install' added",
+	Args:  cobra.NoArgs,
+	RunE:  runIntegrateRemove,
+}
+
+func init() {
+	integrateCmd.AddCommand(integrateInstallCmd)
+	integrateCmd.AddCommand(integrateRemoveCmd)
+}
+
+func runIntegrateInstall(cmd *cobra.Command, args []string) error {
+	exe, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("failed to locate executable: %w", err)
+	}
+	if resolved, err := filepath.EvalSymlinks(exe); err == nil {
+		exe = resolved
+	}
+
+	integration, err := integrate.Install(exe)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Installed %s action: %s\n", integration.FileManager, integration.Location)
+	return nil
+}
+
+func runIntegrateRemove(cmd *cobra.Command, args []string) error {
+	integration, err := integrate.Remove()
+	if errors.Is(err, integrate.ErrNotInstalled) {
+		fmt.Println("Nothing to remove, the action is not installed")
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Removed %s action: %s\n", integration.FileManager, integration.Location)
+	return nil
+}
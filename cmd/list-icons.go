@@ -0,0 +1,26 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/byteowlz/ppr/pkg/svg"
+	"github.com/spf13/cobra"
+)
+
+var listIconsCmd = &cobra.Command{
+	Use:   "list-icons",
+	Short: "List the icons available to templates",
+	Long: `List the bundled icons that templates can place with the icon directive:
+
+  {{icon "arch" size=120 x=900 y=480 fill=base0D}}`,
+	RunE: runListIcons,
+}
+
+func runListIcons(cmd *cobra.Command, args []string) error {
+	names := svg.IconNames()
+	fmt.Printf("Available icons (%d):\n", len(names))
+	for _, name := range names {
+		fmt.Printf("  %s\n", name)
+	}
+	return nil
+}
@@ -5,7 +5,7 @@ import (
 	"sort"
 
 	"github.com/byteowlz/ppr/pkg/config"
-	"github.com/byteowlz/ppr/pkg/theme"
+	"github.com/byteowlz/ppr/pkg/state"
 	"github.com/spf13/cobra"
 )
 
@@ -19,11 +19,13 @@ var listThemesCmd = &cobra.Command{
 var (
 	showDetails   bool
 	filterVariant string
+	themeSort     string
 )
 
 func init() {
 	listThemesCmd.Flags().BoolVarP(&showDetails, "details", "d", false, "Show theme details")
 	listThemesCmd.Flags().StringVarP(&filterVariant, "variant", "v", "", "Filter by variant (dark/light)")
+	listThemesCmd.Flags().StringVar(&themeSort, "sort", "name", "Sort by name, recent (last used first) or used (most used first)")
 }
 
 func runListThemes(cmd *cobra.Command, args []string) error {
@@ -32,7 +34,7 @@ func runListThemes(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to load config: %w", err)
 	}
 
-	themeManager := theme.NewThemeManager(cfg.ThemesPath)
+	themeManager := newThemeManager(cfg)
 	if err := themeManager.LoadThemes(); err != nil {
 		return fmt.Errorf("failed to load themes: %w", err)
 	}
@@ -46,6 +48,18 @@ func runListThemes(cmd *cobra.Command, args []string) error {
 
 	sort.Strings(themeNames)
 
+	var usage map[string]state.Usage
+	if themeSort != "name" {
+		st, err := state.Load(config.GetStatePath())
+		if err != nil {
+			return err
+		}
+		usage = st.Themes
+		if err := sortThemesByUsage(themeNames, usage, themeSort); err != nil {
+			return err
+		}
+	}
+
 	if showDetails {
 		fmt.Printf("Found %d themes:\n\n", len(themeNames))
 		for _, name := range themeNames {
@@ -65,6 +79,10 @@ func runListThemes(cmd *cobra.Command, args []string) error {
 			fmt.Printf("   System: %s\n", themeInfo.System)
 			fmt.Printf("   Variant: %s\n", themeInfo.Variant)
 			fmt.Printf("   Colors: %d\n", len(themeInfo.Palette))
+			fmt.Printf("   ID: %s\n", themeInfo.ID())
+			if usage != nil {
+				fmt.Printf("   Used: %s\n", formatUsage(usage[name]))
+			}
 			fmt.Println()
 		}
 	} else {
@@ -76,9 +94,38 @@ func runListThemes(cmd *cobra.Command, args []string) error {
 					continue
 				}
 			}
-			fmt.Printf("  • %s\n", name)
+			if usage != nil {
+				fmt.Printf("  • %s (%s)\n", name, formatUsage(usage[name]))
+			} else {
+				fmt.Printf("  • %s\n", name)
+			}
 		}
 	}
 
 	return nil
 }
+
+// sortThemesByUsage orders names, already sorted by name, by last use or use
+// count. Unused themes keep their name order at the end.
+func sortThemesByUsage(names []string, usage map[string]state.Usage, by string) error {
+	var less func(a, b state.Usage) bool
+	switch by {
+	case "recent":
+		less = func(a, b state.Usage) bool { return a.LastUsed.After(b.LastUsed) }
+	case "used":
+		less = func(a, b state.Usage) bool { return a.Count > b.Count }
+	default:
+		return fmt.Errorf("invalid sort: %s (expected name, recent or used)", by)
+	}
+	sort.SliceStable(names, func(i, j int) bool {
+		return less(usage[names[i]], usage[names[j]])
+	})
+	return nil
+}
+
+func formatUsage(u state.Usage) string {
+	if u.Count == 0 {
+		return "never used"
+	}
+	return fmt.Sprintf("used %dx, last %s", u.Count, u.LastUsed.Local().Format("2006-01-02 15:04"))
+}
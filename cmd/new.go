@@ -0,0 +1,150 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/byteowlz/ppr/pkg/config"
+	"github.com/byteowlz/ppr/pkg/templates"
+	"github.com/byteowlz/ppr/pkg/theme"
+	"github.com/spf13/cobra"
+)
+
+var newTemplateCmd = &cobra.Command{
+	Use:   "new-template <name>",
+	Short: "Create a commented starter template",
+	Long: `Create a new template in the templates folder from a commented starter
+that explains the palette placeholders, ready to edit.
+
+Bases: ` + strings.Join(templates.StarterNames(), ", ") + `
+
+Examples:
+  ppr new-template waves
+  ppr new-template hello --base text`,
+	Args: cobra.ExactArgs(1),
+	RunE: runNewTemplate,
+}
+
+var newThemeCmd = &cobra.Command{
+	Use:   "new-theme <name>",
+	Short: "Create a commented theme from an existing one",
+	Long: `Create a new theme in the themes folder with the palette of another
+theme, by default the current one, every slot commented with its role.
+Edit the colors and render it like any other theme.
+
+Examples:
+  ppr new-theme my-nord --from nord
+  ppr new-theme midnight --from gruvbox-dark --author "Jane Doe"`,
+	Args: cobra.ExactArgs(1),
+	RunE: runNewTheme,
+}
+
+var (
+	newTemplateBase string
+	newThemeFrom    string
+	newThemeAuthor  string
+	newForce        bool
+)
+
+func init() {
+	newTemplateCmd.Flags().StringVarP(&newTemplateBase, "base", "b", templates.DefaultStarter, "Starter to begin from: "+strings.Join(templates.StarterNames(), ", "))
+	newTemplateCmd.Flags().BoolVarP(&newForce, "force", "f", false, "Replace an existing template with the same name")
+	newThemeCmd.Flags().StringVar(&newThemeFrom, "from", "", "Theme to copy the palette from (defaults to the current theme)")
+	newThemeCmd.Flags().StringVar(&newThemeAuthor, "author", "", "Author of the new theme")
+	newThemeCmd.Flags().BoolVarP(&newForce, "force", "f", false, "Replace an existing theme with the same name")
+}
+
+// scaffoldName checks that name, without ext, can be used as a file name
+func scaffoldName(name, ext string) (string, error) {
+	name = strings.TrimSuffix(name, ext)
+	if name == "" || name != filepath.Base(name) || strings.HasPrefix(name, ".") {
+		return "", fmt.Errorf("invalid name %q", name)
+	}
+	return name, nil
+}
+
+func runNewTemplate(cmd *cobra.Command, args []string) error {
+	name, err := scaffoldName(args[0], ".svg")
+	if err != nil {
+		return err
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	content, err := templates.Starter(newTemplateBase, name)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(cfg.TemplatesPath, 0755); err != nil {
+		return fmt.Errorf("failed to create templates directory: %w", err)
+	}
+	path := filepath.Join(cfg.TemplatesPath, name+".svg")
+	if _, err := os.Stat(path); err == nil && !newForce {
+		return fmt.Errorf("template %s already exists (use --force to replace it)", path)
+	}
+	if err := os.WriteFile(path, content, 0644); err != nil {
+		return fmt.Errorf("failed to write template: %w", err)
+	}
+
+	fmt.Printf("Created template '%s' from the %s starter: %s\n", name, newTemplateBase, path)
+	fmt.Printf("Edit it, then render it with 'ppr generate -t <theme> -s %s'\n", name)
+	return nil
+}
+
+func runNewTheme(cmd *cobra.Command, args []string) error {
+	name, err := scaffoldName(args[0], ".yaml")
+	if err != nil {
+		return err
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	from := newThemeFrom
+	if from == "" {
+		from = cfg.CurrentTheme
+	}
+	if from == "" {
+		from = cfg.DefaultTheme
+	}
+
+	themeManager := newThemeManager(cfg)
+	if err := themeManager.LoadThemes(); err != nil {
+		return fmt.Errorf("failed to load themes: %w", err)
+	}
+	source, err := themeManager.GetTheme(from)
+	if err != nil {
+		return fmt.Errorf("failed to get theme: %w", err)
+	}
+	if existing, err := themeManager.ThemeFile(name); err == nil && !newForce {
+		return fmt.Errorf("theme %s already exists (use --force to replace it)", existing)
+	}
+
+	newTheme := *source
+	newTheme.Name = name
+	newTheme.Author = newThemeAuthor
+	if newTheme.System != "base24" {
+		newTheme.System = "base16"
+	}
+
+	themeDir := filepath.Join(cfg.ThemesPath, newTheme.System)
+	if err := os.MkdirAll(themeDir, 0755); err != nil {
+		return fmt.Errorf("failed to create theme directory: %w", err)
+	}
+	path := filepath.Join(themeDir, name+".yaml")
+	if err := os.WriteFile(path, theme.Scaffold(&newTheme), 0644); err != nil {
+		return fmt.Errorf("failed to write theme: %w", err)
+	}
+
+	fmt.Printf("Created theme '%s' from %s: %s\n", name, from, path)
+	fmt.Printf("Edit the colors, then render it with 'ppr generate -t %s'\n", name)
+	return nil
+}
@@ -0,0 +1,183 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/byteowlz/ppr/pkg/config"
+	"github.com/byteowlz/ppr/pkg/image"
+	"github.com/byteowlz/ppr/pkg/pipeline"
+	"github.com/byteowlz/ppr/pkg/schedule"
+	"github.com/spf13/cobra"
+)
+
+var overlayCmd = &cobra.Command{
+	Use:   "overlay",
+	Short: "List and toggle seasonal overlays",
+	Long: `Overlays are templates composited onto every wallpaper rendered within
+a yearly date range, e.g. snowflakes in December or pride accents in June.
+They are themed like the wallpaper and configured in config.toml:
+
+  [[overlays]]
+  name = "snow"
+  template = "snowflakes"
+  from = "12-01"
+  to = "01-06"
+  opacity = 0.8
+  blend = "screen"
+
+Dates are MM-DD and both included, a range ending before it starts wraps
+past new year. Decorated renders are saved as <template>+<name>.png next to
+the plain ones. --no-overlays skips them for one render.
+
+Examples:
+  ppr overlay list
+  ppr overlay disable snow`,
+}
+
+var overlayListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the overlays and whether they are active today",
+	Args:  cobra.NoArgs,
+	RunE:  runOverlayList,
+}
+
+var overlayEnableCmd = &cobra.Command{
+	Use:   "enable <name>",
+	Short: "Composite an overlay again during its dates",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return setOverlayDisabled(args[0], false)
+	},
+}
+
+var overlayDisableCmd = &cobra.Command{
+	Use:   "disable <name>",
+	Short: "Stop compositing an overlay without removing it",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return setOverlayDisabled(args[0], true)
+	},
+}
+
+func init() {
+	overlayCmd.AddCommand(overlayListCmd)
+	overlayCmd.AddCommand(overlayEnableCmd)
+	overlayCmd.AddCommand(overlayDisableCmd)
+}
+
+// overlayName is the configured name, or the template name without one
+func overlayName(o config.Overlay) string {
+	if o.Name != "" {
+		return o.Name
+	}
+	return strings.TrimSuffix(filepath.Base(o.Template), filepath.Ext(o.Template))
+}
+
+// checkOverlay reports an overlay that cannot be composited
+func checkOverlay(o config.Overlay) error {
+	if o.Template == "" {
+		return fmt.Errorf("no template")
+	}
+	if o.Opacity < 0 || o.Opacity > 1 {
+		return fmt.Errorf("invalid opacity %g (expected 0-1)", o.Opacity)
+	}
+	if o.Blend != "" && !slices.Contains(image.BlendModes(), o.Blend) {
+		return fmt.Errorf("unknown blend mode %q (expected one of %s)", o.Blend, strings.Join(image.BlendModes(), ", "))
+	}
+	_, err := schedule.InDateRange(o.From, o.To, time.Now())
+	return err
+}
+
+// activeOverlays are the enabled [[overlays]] whose dates include now,
+// none with --no-overlays. Invalid overlays are skipped with a warning.
+func activeOverlays(cfg *config.Config, now time.Time) []pipeline.Overlay {
+	if noOverlays {
+		return nil
+	}
+	var active []pipeline.Overlay
+	for _, o := range cfg.Overlays {
+		if o.Disabled {
+			continue
+		}
+		if err := checkOverlay(o); err != nil {
+			fmt.Printf("Warning: overlay %s: %v, skipping it\n", overlayName(o), err)
+			continue
+		}
+		if in, _ := schedule.InDateRange(o.From, o.To, now); !in {
+			continue
+		}
+
+		overlay := pipeline.Overlay{
+			Name:         overlayName(o),
+			TemplatePath: templateFile(cfg, o.Template),
+			Opacity:      o.Opacity,
+			Blend:        o.Blend,
+		}
+		if overlay.Opacity == 0 {
+			overlay.Opacity = 1
+		}
+		if overlay.Blend == "" {
+			overlay.Blend = image.BlendNormal
+		}
+		active = append(active, overlay)
+	}
+	return active
+}
+
+func runOverlayList(cmd *cobra.Command, args []string) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	if len(cfg.Overlays) == 0 {
+		fmt.Printf("No overlays configured, add [[overlays]] to %s\n", config.GetConfigPath())
+		return nil
+	}
+
+	now := time.Now()
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tTEMPLATE\tDATES\tSTATUS")
+	for _, o := range cfg.Overlays {
+		status := "inactive"
+		if err := checkOverlay(o); err != nil {
+			status = "invalid: " + err.Error()
+		} else if o.Disabled {
+			status = "disabled"
+		} else if in, _ := schedule.InDateRange(o.From, o.To, now); in {
+			status = "active"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s to %s\t%s\n", overlayName(o), o.Template, o.From, o.To, status)
+	}
+	return w.Flush()
+}
+
+// setOverlayDisabled toggles the overlay called name in config.toml
+func setOverlayDisabled(name string, disabled bool) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	for i, o := range cfg.Overlays {
+		if overlayName(o) != name {
+			continue
+		}
+		cfg.Overlays[i].Disabled = disabled
+		if err := cfg.Save(); err != nil {
+			return fmt.Errorf("failed to save config: %w", err)
+		}
+		if disabled {
+			fmt.Printf("Disabled overlay %s\n", name)
+		} else {
+			fmt.Printf("Enabled overlay %s\n", name)
+		}
+		return nil
+	}
+	return fmt.Errorf("no overlay named %s in %s", name, config.GetConfigPath())
+}
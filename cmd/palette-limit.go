@@ -0,0 +1,52 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/byteowlz/ppr/pkg/image"
+	"github.com/spf13/cobra"
+)
+
+// Output quantization shared by generate, cycle and switch-current
+var (
+	paletteLimit     int
+	paletteGrayscale bool
+	paletteDither    bool
+)
+
+// addPaletteLimitFlags adds --palette-limit, --grayscale and --dither to cmd
+func addPaletteLimitFlags(cmd *cobra.Command) {
+	cmd.Flags().IntVar(&paletteLimit, "palette-limit", 0, fmt.Sprintf("Quantize the output to this many colors (%d-%d), for e-ink and low-color displays", image.MinPaletteColors, image.MaxPaletteColors))
+	cmd.Flags().BoolVar(&paletteGrayscale, "grayscale", false, fmt.Sprintf("Quantize the output to gray levels, %d unless --palette-limit is given", image.GrayLevels))
+	cmd.Flags().BoolVar(&paletteDither, "dither", false, "Apply Floyd-Steinberg dithering with --palette-limit or --grayscale")
+}
+
+// renderPaletteLimit returns the quantization the flags ask for, nil to keep
+// all colors
+func renderPaletteLimit() *image.PaletteLimit {
+	if paletteLimit == 0 && !paletteGrayscale {
+		return nil
+	}
+	return &image.PaletteLimit{Colors: paletteLimit, Grayscale: paletteGrayscale, Dither: paletteDither}
+}
+
+// paletteLimitSuffix names quantized variants, e.g. -16c, -gray16 or
+// -8c-dither, so they never replace the full-color render
+func paletteLimitSuffix() string {
+	limit := renderPaletteLimit()
+	if limit == nil {
+		return ""
+	}
+	suffix := fmt.Sprintf("-%dc", limit.Colors)
+	if limit.Grayscale {
+		levels := limit.Colors
+		if levels == 0 {
+			levels = image.GrayLevels
+		}
+		suffix = fmt.Sprintf("-gray%d", levels)
+	}
+	if limit.Dither {
+		suffix += "-dither"
+	}
+	return suffix
+}
@@ -0,0 +1,122 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/byteowlz/ppr/pkg/config"
+	"github.com/byteowlz/ppr/pkg/state"
+	"github.com/spf13/cobra"
+)
+
+var pinCmd = &cobra.Command{
+	Use:   "pin [duration]",
+	Short: "Keep the current wallpaper, suspending automatic changes",
+	Long: `Pin the current wallpaper for presentations and screen sharing. While
+it is pinned, cycle (also over D-Bus), apply-schedule, focus and playlist
+leave the wallpaper alone, so cron jobs, timers and watchers can keep
+running. Explicit commands like generate still change it.
+
+Without a duration the pin holds until 'ppr unpin'.
+
+Examples:
+  ppr pin 2h
+  ppr pin
+  ppr pin --status`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runPin,
+}
+
+var unpinCmd = &cobra.Command{
+	Use:   "unpin",
+	Short: "Resume automatic wallpaper changes",
+	Args:  cobra.NoArgs,
+	RunE:  runUnpin,
+}
+
+var pinStatusOnly bool
+
+func init() {
+	pinCmd.Flags().BoolVar(&pinStatusOnly, "status", false, "Only show whether the wallpaper is pinned")
+}
+
+func runPin(cmd *cobra.Command, args []string) error {
+	st, err := state.Load(config.GetStatePath())
+	if err != nil {
+		return err
+	}
+
+	if pinStatusOnly {
+		if pin := pinDescription(st, time.Now()); pin != "" {
+			fmt.Printf("Wallpaper %s\n", pin)
+		} else {
+			fmt.Println("Wallpaper not pinned")
+		}
+		return nil
+	}
+
+	now := time.Now()
+	st.Pin = &state.Pin{Since: now}
+	if len(args) > 0 {
+		duration, err := time.ParseDuration(args[0])
+		if err != nil || duration <= 0 {
+			return fmt.Errorf("invalid duration %q (expected e.g. 90m or 2h)", args[0])
+		}
+		until := now.Add(duration)
+		st.Pin.Until = &until
+	}
+	if err := st.Save(); err != nil {
+		return err
+	}
+	fmt.Printf("Wallpaper %s\n", pinDescription(st, now))
+	return nil
+}
+
+func runUnpin(cmd *cobra.Command, args []string) error {
+	st, err := state.Load(config.GetStatePath())
+	if err != nil {
+		return err
+	}
+	pinned := st.Pinned(time.Now())
+	if st.Pin != nil {
+		st.Pin = nil
+		if err := st.Save(); err != nil {
+			return err
+		}
+	}
+	if !pinned {
+		fmt.Println("Wallpaper not pinned")
+		return nil
+	}
+	fmt.Println("Wallpaper unpinned, automatic changes resume")
+	return nil
+}
+
+// pinDescription says how long the wallpaper stays pinned, empty when it
+// is not pinned at t
+func pinDescription(st *state.State, t time.Time) string {
+	if !st.Pinned(t) {
+		return ""
+	}
+	if st.Pin.Until == nil {
+		return "pinned until 'ppr unpin'"
+	}
+	until := *st.Pin.Until
+	layout := "15:04"
+	if until.YearDay() != t.YearDay() || until.Year() != t.Year() {
+		layout = "Mon 2006-01-02 15:04"
+	}
+	return fmt.Sprintf("pinned until %s (%s left)", until.Format(layout), until.Sub(t).Round(time.Minute))
+}
+
+// wallpaperPin describes the pin holding the wallpaper now for commands
+// changing it automatically, empty when they may go ahead. A state file
+// that cannot be read does not hold them up.
+func wallpaperPin() string {
+	st, err := state.Load(config.GetStatePath())
+	if err != nil {
+		fmt.Printf("Warning: failed to check the wallpaper pin: %v\n", err)
+		return ""
+	}
+	return pinDescription(st, time.Now())
+}
@@ -0,0 +1,171 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/byteowlz/ppr/pkg/playlist"
+	"github.com/spf13/cobra"
+)
+
+var playlistCmd = &cobra.Command{
+	Use:   "playlist",
+	Short: "Play wallpapers in a fixed order, e.g. for digital signage",
+	Long: `Show a sequence of themes and templates in a fixed order and for fixed
+durations, for kiosks and signage where the order matters. A playlist is a
+TOML file:
+
+  loop = true
+
+  [[entry]]
+  theme = "nord"
+  template = "welcome"
+  duration = "30s"
+
+  [[entry]]
+  theme = "gruvbox-dark"
+  template = "sale"
+  duration = "1m"
+  from = "2026-11-20"
+  until = "2026-11-30"
+
+Entries outside their from/until dates, both included, are skipped. An
+entry can set warmth in kelvin like generate --warmth.
+
+Examples:
+  ppr playlist check lobby.toml
+  ppr playlist run lobby.toml`,
+}
+
+var playlistRunCmd = &cobra.Command{
+	Use:   "run <file>",
+	Short: "Play a playlist until it ends or is interrupted",
+	Long: `Set the wallpaper of each active entry in turn and hold it for its
+duration. The file is read again before every pass, so edits take effect
+without a restart. Without loop = true the playlist stops after one pass.
+When no entry is active the playlist waits for the next from date.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runPlaylistRun,
+}
+
+var playlistCheckCmd = &cobra.Command{
+	Use:   "check <file>",
+	Short: "Validate a playlist and list the entries active today",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runPlaylistCheck,
+}
+
+func init() {
+	playlistCmd.AddCommand(playlistRunCmd)
+	playlistCmd.AddCommand(playlistCheckCmd)
+}
+
+func runPlaylistRun(cmd *cobra.Command, args []string) error {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(signals)
+
+	for {
+		p, err := playlist.Load(args[0])
+		if err != nil {
+			return err
+		}
+
+		played, stopped := playPass(p, signals)
+		if stopped {
+			fmt.Println("Playlist stopped")
+			return nil
+		}
+		if played > 0 && !p.Loop {
+			fmt.Println("Playlist done")
+			return nil
+		}
+		if played > 0 {
+			continue
+		}
+
+		next, ok := p.NextStart(time.Now())
+		if !ok {
+			return fmt.Errorf("no entry of %s is active now or later", args[0])
+		}
+		fmt.Printf("No entry active, waiting until %s\n", next.Format(playlist.DateLayout))
+		select {
+		case <-signals:
+			fmt.Println("Playlist stopped")
+			return nil
+		case <-time.After(time.Until(next)):
+		}
+	}
+}
+
+// playPass shows every entry of p active when it comes up and returns how
+// many were shown, and whether a signal stopped the pass
+func playPass(p *playlist.Playlist, signals <-chan os.Signal) (int, bool) {
+	played := 0
+	for i := range p.Entries {
+		e := &p.Entries[i]
+		start := time.Now()
+		if !e.Active(start) {
+			continue
+		}
+		played++
+
+		fmt.Printf("[%d/%d] %s with %s for %s\n", i+1, len(p.Entries), e.Template, e.Theme, e.Length())
+		if pin := wallpaperPin(); pin != "" {
+			fmt.Printf("Wallpaper %s, holding the slot\n", pin)
+		} else if err := switchContext(e.Theme, e.Template, e.Warmth); err != nil {
+			// Hold the slot anyway so the timing of the rest stays the same
+			fmt.Printf("Warning: failed to show entry %d: %v\n", i+1, err)
+		}
+
+		// Measure from the start of the slot, rendering counts against it
+		select {
+		case <-signals:
+			return played, true
+		case <-time.After(time.Until(start.Add(e.Length()))):
+		}
+	}
+	return played, false
+}
+
+func runPlaylistCheck(cmd *cobra.Command, args []string) error {
+	p, err := playlist.Load(args[0])
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	var total time.Duration
+	for i := range p.Entries {
+		e := &p.Entries[i]
+		status := "inactive"
+		if e.Active(now) {
+			status = "active"
+			total += e.Length()
+		}
+
+		dates := ""
+		if e.From != "" || e.Until != "" {
+			dates = fmt.Sprintf(", %s to %s", orOpen(e.From), orOpen(e.Until))
+		}
+		fmt.Printf("  %d. %s with %s for %s (%s%s)\n", i+1, e.Template, e.Theme, e.Length(), status, dates)
+	}
+
+	mode := "once"
+	if p.Loop {
+		mode = "looped"
+	}
+	fmt.Printf("%d entries, one pass today takes %s, played %s\n", len(p.Entries), total, mode)
+	return nil
+}
+
+// orOpen shows an unset date of a range
+func orOpen(date string) string {
+	if date == "" {
+		return "open"
+	}
+	return date
+}
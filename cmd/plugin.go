@@ -0,0 +1,161 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+
+	"github.com/byteowlz/ppr/pkg/config"
+	"github.com/spf13/cobra"
+)
+
+// pluginPrefix names external commands, 'ppr foo' runs ppr-foo from PATH
+const pluginPrefix = "ppr-"
+
+var pluginsCmd = &cobra.Command{
+	Use:   "plugins",
+	Short: "List external ppr-<name> commands found on PATH",
+	Long: `List plugins: executables named ppr-<name> on PATH. Running
+'ppr <name> [args]' for a name that is not a built-in command runs the
+plugin with the remaining arguments.
+
+Plugins receive the ppr setup in the environment:
+  PPR_CONFIG          path of config.toml
+  PPR_THEME           current theme, or the default theme
+  PPR_TEMPLATE        current template, or the default template
+  PPR_WALLPAPER       last generated wallpaper
+  PPR_THEMES_PATH     themes directory
+  PPR_TEMPLATES_PATH  templates directory
+  PPR_OUTPUT_PATH     output directory
+  PPR_VERSION         ppr version`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		plugins := findPlugins()
+		if len(plugins) == 0 {
+			fmt.Println("No plugins found on PATH")
+			return nil
+		}
+		names := make([]string, 0, len(plugins))
+		for name := range plugins {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		fmt.Println("Plugins:")
+		for _, name := range names {
+			fmt.Printf("  %-16s %s\n", name, plugins[name])
+		}
+		return nil
+	},
+}
+
+// runPlugin runs args as a plugin when args[0] is not a built-in command
+// and a matching ppr-<name> is on PATH. It reports whether a plugin ran
+// and the exit code to leave with.
+func runPlugin(args []string) (bool, int) {
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+		return false, 0
+	}
+	name := args[0]
+	// help and completion are only added to rootCmd once it executes
+	if name == "help" || name == "completion" || strings.HasPrefix(name, "__") {
+		return false, 0
+	}
+	if cmd, _, err := rootCmd.Find(args); err == nil && cmd != rootCmd {
+		return false, 0
+	}
+
+	path, err := exec.LookPath(pluginPrefix + name)
+	if err != nil {
+		return false, 0
+	}
+
+	plugin := exec.Command(path, args[1:]...)
+	plugin.Stdin = os.Stdin
+	plugin.Stdout = os.Stdout
+	plugin.Stderr = os.Stderr
+	plugin.Env = append(os.Environ(), pluginEnv()...)
+
+	// The plugin shares the terminal and handles Ctrl-C itself
+	signal.Ignore(os.Interrupt)
+	defer signal.Reset(os.Interrupt)
+
+	if err := plugin.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return true, exitErr.ExitCode()
+		}
+		fmt.Printf("failed to run plugin %s: %v\n", path, err)
+		return true, 1
+	}
+	return true, 0
+}
+
+// pluginEnv describes the ppr setup for plugins
+func pluginEnv() []string {
+	env := []string{
+		"PPR_CONFIG=" + config.GetConfigPath(),
+		"PPR_VERSION=" + versionInfo.version,
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Printf("Warning: failed to load config for plugin: %v\n", err)
+		return env
+	}
+
+	themeName := cfg.CurrentTheme
+	if themeName == "" {
+		themeName = cfg.DefaultTheme
+	}
+	templateName := cfg.CurrentTemplate
+	if templateName == "" {
+		templateName = cfg.DefaultTemplate
+	}
+
+	return append(env,
+		"PPR_THEME="+themeName,
+		"PPR_TEMPLATE="+templateName,
+		"PPR_WALLPAPER="+cfg.LastOutputPath,
+		"PPR_THEMES_PATH="+cfg.ThemesPath,
+		"PPR_TEMPLATES_PATH="+cfg.TemplatesPath,
+		"PPR_OUTPUT_PATH="+cfg.OutputPath,
+	)
+}
+
+// findPlugins maps plugin names to their paths. Earlier PATH entries win,
+// as they do when the plugin runs.
+func findPlugins() map[string]string {
+	plugins := make(map[string]string)
+	for _, dir := range filepath.SplitList(os.Getenv("PATH")) {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			fileName := entry.Name()
+			if !strings.HasPrefix(fileName, pluginPrefix) || entry.IsDir() {
+				continue
+			}
+			name := strings.TrimPrefix(fileName, pluginPrefix)
+			if runtime.GOOS == "windows" {
+				ext := filepath.Ext(name)
+				if !strings.EqualFold(ext, ".exe") && !strings.EqualFold(ext, ".bat") && !strings.EqualFold(ext, ".cmd") {
+					continue
+				}
+				name = strings.TrimSuffix(name, ext)
+			} else if info, err := entry.Info(); err != nil || info.Mode().Perm()&0111 == 0 {
+				continue
+			}
+			if _, exists := plugins[name]; !exists && name != "" {
+				plugins[name] = filepath.Join(dir, fileName)
+			}
+		}
+	}
+	return plugins
+}
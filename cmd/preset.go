@@ -0,0 +1,145 @@
+package cmd
+
+import (
+	"fmt"
+	"image/color"
+	"sort"
+	"strings"
+
+	"github.com/byteowlz/ppr/pkg/config"
+	"github.com/byteowlz/ppr/pkg/palette"
+	"github.com/byteowlz/ppr/pkg/theme"
+)
+
+// lookupPreset returns the [presets] entry called name, or nil for an empty name
+func lookupPreset(cfg *config.Config, name string) (*config.Preset, error) {
+	if name == "" {
+		return nil, nil
+	}
+	preset, ok := cfg.Presets[name]
+	if !ok {
+		names := make([]string, 0, len(cfg.Presets))
+		for n := range cfg.Presets {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		if len(names) == 0 {
+			return nil, fmt.Errorf("unknown preset %s (no [presets] in %s)", name, config.GetConfigPath())
+		}
+		return nil, fmt.Errorf("unknown preset %s (available: %s)", name, strings.Join(names, ", "))
+	}
+	if preset.Brightness < -100 || preset.Brightness > 100 {
+		return nil, fmt.Errorf("preset %s: invalid brightness %d (expected -100..100)", name, preset.Brightness)
+	}
+	return &preset, nil
+}
+
+// applyPresetPalette applies the preset's brightness and background to a
+// copy of t
+func applyPresetPalette(t *theme.Theme, preset *config.Preset) (*theme.Theme, error) {
+	if preset == nil || (preset.Brightness == 0 && preset.Background == "") {
+		return t, nil
+	}
+
+	adjusted, err := t.MapPalette(func(c color.RGBA) color.RGBA {
+		return palette.Brighten(c, preset.Brightness)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if preset.Background != "" {
+		// A palette slot refers to the original theme, before brightness
+		value, ok := t.Palette[preset.Background]
+		if !ok {
+			value = preset.Background
+		}
+		background, err := palette.ParseHex(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid preset background %q (expected a palette slot or hex color)", preset.Background)
+		}
+		adjusted.Palette["base00"] = palette.ToHex(background)
+	}
+	return adjusted, nil
+}
+
+// toSRGB returns a copy of t converted from the color space its hex values
+// are authored in, or t itself for sRGB
+func toSRGB(t *theme.Theme, space string) (*theme.Theme, error) {
+	if err := palette.CheckColorSpace(space); err != nil {
+		return nil, err
+	}
+	if space != palette.ColorSpaceDisplayP3 {
+		return t, nil
+	}
+	converted, err := t.MapPalette(palette.DisplayP3ToSRGB)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert theme to sRGB: %w", err)
+	}
+	return converted, nil
+}
+
+// warmTheme returns a copy of t warmed to kelvin, or t itself for 0
+func warmTheme(t *theme.Theme, kelvin int) (*theme.Theme, error) {
+	if kelvin == 0 {
+		return t, nil
+	}
+	if kelvin < palette.MinKelvin || kelvin > palette.NeutralKelvin {
+		return nil, fmt.Errorf("invalid warmth %d (expected %d-%d kelvin)", kelvin, palette.MinKelvin, palette.NeutralKelvin)
+	}
+	warmed, err := t.MapPalette(func(c color.RGBA) color.RGBA {
+		return palette.Warm(c, kelvin)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to warm theme: %w", err)
+	}
+	return warmed, nil
+}
+
+// applyOverrides sets the slot=color pairs of --override on a copy of t, a
+// color being hex or another slot of t. The overrides are not warmed or
+// converted, so they render exactly as given. It also returns the variant
+// name suffix for them, e.g. -base0D-FF5500, so overridden renders never
+// replace the plain ones.
+func applyOverrides(t *theme.Theme, overrides []string) (*theme.Theme, string, error) {
+	if len(overrides) == 0 {
+		return t, "", nil
+	}
+
+	slots := make(map[string]bool)
+	for _, key := range t.PaletteKeys() {
+		slots[key] = true
+	}
+
+	adjusted := *t
+	adjusted.Palette = make(map[string]string, len(t.Palette))
+	for key, value := range t.Palette {
+		adjusted.Palette[key] = value
+	}
+
+	changed := make(map[string]bool)
+	for _, override := range overrides {
+		slot, value, ok := strings.Cut(override, "=")
+		slot, value = strings.TrimSpace(slot), strings.TrimSpace(value)
+		if !ok || !slots[slot] {
+			return nil, "", fmt.Errorf("invalid override %q (expected a %s slot and color, e.g. base0D=#FF5500)", override, t.System)
+		}
+		if ref, ok := t.Palette[value]; ok {
+			value = ref
+		}
+		c, err := palette.ParseHex(value)
+		if err != nil {
+			return nil, "", fmt.Errorf("invalid override %q (expected a hex color or palette slot)", override)
+		}
+		adjusted.Palette[slot] = palette.ToHex(c)
+		changed[slot] = true
+	}
+
+	var suffix strings.Builder
+	for _, key := range t.PaletteKeys() {
+		if changed[key] {
+			fmt.Fprintf(&suffix, "-%s-%s", key, strings.TrimPrefix(adjusted.Palette[key], "#"))
+		}
+	}
+	return &adjusted, suffix.String(), nil
+}
@@ -0,0 +1,96 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"runtime/pprof"
+	"runtime/trace"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	cpuProfilePath string
+	memProfilePath string
+	tracePath      string
+)
+
+// profiling holds the open profile outputs between start and stop
+var profiling struct {
+	cpuFile   *os.File
+	traceFile *os.File
+}
+
+func init() {
+	rootCmd.PersistentFlags().StringVar(&cpuProfilePath, "cpuprofile", "", "Write a CPU profile to this file")
+	rootCmd.PersistentFlags().StringVar(&memProfilePath, "memprofile", "", "Write a heap profile to this file on exit")
+	rootCmd.PersistentFlags().StringVar(&tracePath, "trace", "", "Write an execution trace to this file")
+
+	rootCmd.PersistentFlags().MarkHidden("cpuprofile")
+	rootCmd.PersistentFlags().MarkHidden("memprofile")
+	rootCmd.PersistentFlags().MarkHidden("trace")
+
+	rootCmd.PersistentPreRunE = func(cmd *cobra.Command, args []string) error {
+		return startProfiling()
+	}
+}
+
+// startProfiling starts the CPU profile and execution trace if requested
+func startProfiling() error {
+	if cpuProfilePath != "" {
+		f, err := os.Create(cpuProfilePath)
+		if err != nil {
+			return fmt.Errorf("failed to create CPU profile: %w", err)
+		}
+		if err := pprof.StartCPUProfile(f); err != nil {
+			f.Close()
+			return fmt.Errorf("failed to start CPU profile: %w", err)
+		}
+		profiling.cpuFile = f
+	}
+
+	if tracePath != "" {
+		f, err := os.Create(tracePath)
+		if err != nil {
+			return fmt.Errorf("failed to create trace file: %w", err)
+		}
+		if err := trace.Start(f); err != nil {
+			f.Close()
+			return fmt.Errorf("failed to start trace: %w", err)
+		}
+		profiling.traceFile = f
+	}
+
+	return nil
+}
+
+// stopProfiling flushes all running profiles and writes the heap profile.
+// It is called once after command execution, including on failure.
+func stopProfiling() {
+	if profiling.cpuFile != nil {
+		pprof.StopCPUProfile()
+		profiling.cpuFile.Close()
+		profiling.cpuFile = nil
+	}
+
+	if profiling.traceFile != nil {
+		trace.Stop()
+		profiling.traceFile.Close()
+		profiling.traceFile = nil
+	}
+
+	if memProfilePath != "" {
+		f, err := os.Create(memProfilePath)
+		if err != nil {
+			fmt.Printf("Warning: failed to create memory profile: %v\n", err)
+			return
+		}
+		defer f.Close()
+
+		runtime.GC()
+		if err := pprof.WriteHeapProfile(f); err != nil {
+			fmt.Printf("Warning: failed to write memory profile: %v\n", err)
+		}
+	}
+}
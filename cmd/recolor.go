@@ -0,0 +1,149 @@
+package cmd
+
+import (
+	"fmt"
+	"image/color"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/byteowlz/ppr/pkg/config"
+	"github.com/byteowlz/ppr/pkg/image"
+	"github.com/byteowlz/ppr/pkg/palette"
+	"github.com/byteowlz/ppr/pkg/theme"
+	"github.com/spf13/cobra"
+)
+
+var recolorCmd = &cobra.Command{
+	Use:   "recolor <image>",
+	Short: "Recolor an existing raster image to a theme palette",
+	Long: `Map the colors of an existing PNG, JPEG or GIF image onto a theme palette,
+so photographic wallpapers match your base16/base24 scheme.
+
+Modes:
+  luminance  Map each pixel's lightness onto a gradient through the palette
+             (preserves the tonal structure of photos, default)
+  nearest    Replace each pixel with the nearest palette color, use --dither
+             for smoother results
+
+Uses the current theme if no theme is specified.
+
+Examples:
+  ppr recolor photo.jpg --theme nord
+  ppr recolor photo.jpg --theme gruvbox-dark --mode nearest --dither
+  ppr recolor photo.jpg -t nord -o nord-photo.png --set-wallpaper`,
+	Args: cobra.ExactArgs(1),
+	RunE: runRecolor,
+}
+
+var (
+	recolorThemeName    string
+	recolorOutputPath   string
+	recolorMode         string
+	recolorDither       bool
+	recolorSetWallpaper bool
+)
+
+func init() {
+	recolorCmd.Flags().StringVarP(&recolorThemeName, "theme", "t", "", "Theme whose palette to map onto (defaults to current theme)")
+	recolorCmd.Flags().StringVarP(&recolorOutputPath, "output", "o", "", "Output PNG path (defaults to the theme output directory)")
+	recolorCmd.Flags().StringVar(&recolorMode, "mode", image.RecolorLuminance, "Mapping mode: luminance or nearest")
+	recolorCmd.Flags().BoolVar(&recolorDither, "dither", false, "Apply Floyd-Steinberg dithering (nearest mode)")
+	recolorCmd.Flags().BoolVarP(&recolorSetWallpaper, "set-wallpaper", "w", false, "Set recolored image as wallpaper")
+}
+
+func runRecolor(cmd *cobra.Command, args []string) error {
+	inputPath := args[0]
+
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	if err := cfg.EnsureDirectories(); err != nil {
+		return fmt.Errorf("failed to ensure directories: %w", err)
+	}
+
+	themeToUse := recolorThemeName
+	if themeToUse == "" {
+		themeToUse = cfg.CurrentTheme
+	}
+	if themeToUse == "" {
+		themeToUse = cfg.DefaultTheme
+	}
+
+	themeManager := newThemeManager(cfg)
+	if err := themeManager.LoadThemes(); err != nil {
+		return fmt.Errorf("failed to load themes: %w", err)
+	}
+
+	selectedTheme, err := themeManager.GetTheme(themeToUse)
+	if err != nil {
+		return fmt.Errorf("failed to get theme: %w", err)
+	}
+
+	colors, err := themeColors(selectedTheme)
+	if err != nil {
+		return err
+	}
+
+	src, err := image.LoadImage(inputPath)
+	if err != nil {
+		return err
+	}
+
+	recolored, err := image.Recolor(src, colors, image.RecolorOptions{Mode: recolorMode, Dither: recolorDither})
+	if err != nil {
+		return fmt.Errorf("failed to recolor image: %w", err)
+	}
+
+	outPath := recolorOutputPath
+	if outPath == "" {
+		themeSubDir := filepath.Join(cfg.OutputPath, "ppr", themeToUse)
+		if err := os.MkdirAll(themeSubDir, 0755); err != nil {
+			return fmt.Errorf("failed to create theme subdirectory: %w", err)
+		}
+		base := filepath.Base(inputPath)
+		outPath = filepath.Join(themeSubDir, "recolor-"+strings.TrimSuffix(base, filepath.Ext(base))+".png")
+	}
+
+	if err := image.WritePNG(recolored, outPath); err != nil {
+		return fmt.Errorf("failed to write recolored image: %w", err)
+	}
+
+	bounds := recolored.Bounds()
+	fmt.Printf("Recolored image with theme '%s': %s (%dx%d)\n", themeToUse, outPath, bounds.Dx(), bounds.Dy())
+
+	if recolorSetWallpaper {
+		absPath, err := filepath.Abs(outPath)
+		if err != nil {
+			return fmt.Errorf("failed to resolve output path: %w", err)
+		}
+
+		setter := newWallpaperSetter(cfg)
+		if err := setter.SetWallpaper(absPath); err != nil {
+			fmt.Printf("Warning: failed to set wallpaper: %v\n", err)
+		} else {
+			fmt.Println("Wallpaper set successfully!")
+		}
+	}
+
+	return nil
+}
+
+// themeColors returns the theme palette as colors in slot order
+func themeColors(t *theme.Theme) ([]color.RGBA, error) {
+	var colors []color.RGBA
+	for _, key := range t.PaletteKeys() {
+		value, exists := t.Palette[key]
+		if !exists {
+			continue
+		}
+		c, err := palette.ParseHex(value)
+		if err != nil {
+			return nil, fmt.Errorf("theme color %s: %w", key, err)
+		}
+		colors = append(colors, c)
+	}
+	return colors, nil
+}
@@ -0,0 +1,233 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/byteowlz/ppr/pkg/config"
+	"github.com/byteowlz/ppr/pkg/session"
+	"github.com/byteowlz/ppr/pkg/state"
+	"github.com/spf13/cobra"
+)
+
+var recordCmd = &cobra.Command{
+	Use:   "record",
+	Short: "Record wallpaper changes for 'ppr replay'",
+	Long: `Record every theme and template change with its timing, whatever
+command makes it, until 'ppr record stop'. Replay the session later with
+'ppr replay', e.g. while screen recording a demo of a theme pack.
+
+Examples:
+  ppr record start nord-demo
+  ppr cycle nord; sleep 5; ppr cycle nord
+  ppr record stop
+  ppr replay nord-demo`,
+}
+
+var recordStartCmd = &cobra.Command{
+	Use:   "start [name]",
+	Short: "Start recording a session, named after the time by default",
+	Args:  cobra.MaximumNArgs(1),
+	RunE:  runRecordStart,
+}
+
+var recordStopCmd = &cobra.Command{
+	Use:   "stop",
+	Short: "Stop the running recording",
+	Args:  cobra.NoArgs,
+	RunE:  runRecordStop,
+}
+
+var recordListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List recorded sessions",
+	Args:  cobra.NoArgs,
+	RunE:  runRecordList,
+}
+
+var replayCmd = &cobra.Command{
+	Use:   "replay <session>",
+	Short: "Apply the changes of a recorded session with their original timing",
+	Long: `Apply every change of a session recorded with 'ppr record' at the
+same offset from the start it was recorded at. Render time counts against
+the gap to the next change, so the timing does not drift.
+
+Examples:
+  ppr replay nord-demo
+  ppr replay nord-demo --speed 2`,
+	Args: cobra.ExactArgs(1),
+	RunE: runReplay,
+}
+
+var (
+	recordForce bool
+	replaySpeed float64
+)
+
+func init() {
+	recordStartCmd.Flags().BoolVarP(&recordForce, "force", "f", false, "Replace an existing session with the same name")
+	replayCmd.Flags().Float64Var(&replaySpeed, "speed", 1, "Playback speed, 2 replays twice as fast")
+
+	recordCmd.AddCommand(recordStartCmd)
+	recordCmd.AddCommand(recordStopCmd)
+	recordCmd.AddCommand(recordListCmd)
+}
+
+func runRecordStart(cmd *cobra.Command, args []string) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	st, err := state.Load(config.GetStatePath())
+	if err != nil {
+		return err
+	}
+	if st.Recording != "" {
+		return fmt.Errorf("already recording session %s (run 'ppr record stop' first)", st.Recording)
+	}
+
+	now := time.Now()
+	name := now.Format("20060102-150405")
+	if len(args) > 0 {
+		if name, err = scaffoldName(args[0], ".json"); err != nil {
+			return err
+		}
+	}
+	dir := session.Dir(config.GetConfigDir())
+	if _, err := os.Stat(session.Path(dir, name)); err == nil && !recordForce {
+		return fmt.Errorf("session %s already exists (use --force to replace it)", name)
+	}
+
+	// Start from the wallpaper shown now, so a replay begins the same way
+	s := session.New(dir, name, now)
+	if cfg.CurrentTemplate != "" {
+		currentTheme := cfg.CurrentTheme
+		if currentTheme == "" {
+			currentTheme = cfg.DefaultTheme
+		}
+		s.Add(now, currentTheme, cfg.CurrentTemplate, cfg.CurrentWarmth)
+	}
+	if err := s.Save(); err != nil {
+		return err
+	}
+
+	st.Recording = name
+	if err := st.Save(); err != nil {
+		return err
+	}
+	fmt.Printf("Recording session '%s', stop with 'ppr record stop'\n", name)
+	return nil
+}
+
+func runRecordStop(cmd *cobra.Command, args []string) error {
+	st, err := state.Load(config.GetStatePath())
+	if err != nil {
+		return err
+	}
+	if st.Recording == "" {
+		return fmt.Errorf("no recording running")
+	}
+
+	s, err := session.Load(session.Path(session.Dir(config.GetConfigDir()), st.Recording))
+	if err != nil {
+		return err
+	}
+	s.Stopped = time.Now()
+	if err := s.Save(); err != nil {
+		return err
+	}
+
+	st.Recording = ""
+	if err := st.Save(); err != nil {
+		return err
+	}
+	fmt.Printf("Recorded session '%s': %d changes in %s\n", s.Name, len(s.Events), s.Length())
+	fmt.Printf("Replay it with 'ppr replay %s'\n", s.Name)
+	return nil
+}
+
+func runRecordList(cmd *cobra.Command, args []string) error {
+	dir := session.Dir(config.GetConfigDir())
+	names, err := session.List(dir)
+	if err != nil {
+		return err
+	}
+	if len(names) == 0 {
+		fmt.Println("No recorded sessions")
+		return nil
+	}
+
+	st, err := state.Load(config.GetStatePath())
+	if err != nil {
+		return err
+	}
+	for _, name := range names {
+		s, err := session.Load(session.Path(dir, name))
+		if err != nil {
+			fmt.Printf("Warning: %v\n", err)
+			continue
+		}
+		status := ""
+		if name == st.Recording {
+			status = ", recording"
+		}
+		fmt.Printf("  %s (%d changes, %s, %s%s)\n", name, len(s.Events), s.Length(), s.Started.Local().Format("2006-01-02 15:04"), status)
+	}
+	return nil
+}
+
+// recordSession adds a wallpaper change to the running recording, if any
+func recordSession(themeName, templateName string, kelvin int) error {
+	st, err := state.Load(config.GetStatePath())
+	if err != nil || st.Recording == "" {
+		return err
+	}
+	s, err := session.Load(session.Path(session.Dir(config.GetConfigDir()), st.Recording))
+	if err != nil {
+		return err
+	}
+	s.Add(time.Now(), themeName, templateName, kelvin)
+	return s.Save()
+}
+
+func runReplay(cmd *cobra.Command, args []string) error {
+	if replaySpeed <= 0 {
+		return fmt.Errorf("speed must be positive")
+	}
+	s, err := session.Load(session.Path(session.Dir(config.GetConfigDir()), args[0]))
+	if err != nil {
+		return err
+	}
+	if len(s.Events) == 0 {
+		return fmt.Errorf("session %s has no changes", s.Name)
+	}
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(signals)
+
+	scaled := func(d time.Duration) time.Duration {
+		return time.Duration(float64(d) / replaySpeed)
+	}
+
+	fmt.Printf("Replaying session '%s': %d changes in %s\n", s.Name, len(s.Events), scaled(s.Length()).Round(time.Millisecond))
+	start := time.Now()
+	for i, e := range s.Events {
+		select {
+		case <-signals:
+			fmt.Println("Replay stopped")
+			return nil
+		case <-time.After(time.Until(start.Add(scaled(e.Offset())))):
+		}
+
+		fmt.Printf("[%d/%d] %s with %s\n", i+1, len(s.Events), e.Template, e.Theme)
+		if err := switchContext(e.Theme, e.Template, e.Warmth); err != nil {
+			fmt.Printf("Warning: failed to apply change %d: %v\n", i+1, err)
+		}
+	}
+	fmt.Println("Replay done")
+	return nil
+}
@@ -0,0 +1,392 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	stdimage "image"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/byteowlz/ppr/pkg/config"
+	"github.com/byteowlz/ppr/pkg/headless"
+	"github.com/byteowlz/ppr/pkg/hooks"
+	"github.com/byteowlz/ppr/pkg/image"
+	"github.com/byteowlz/ppr/pkg/locale"
+	"github.com/byteowlz/ppr/pkg/manifest"
+	"github.com/byteowlz/ppr/pkg/pipeline"
+	"github.com/byteowlz/ppr/pkg/resolution"
+	"github.com/byteowlz/ppr/pkg/state"
+	"github.com/byteowlz/ppr/pkg/svg"
+	"github.com/byteowlz/ppr/pkg/templates"
+	"github.com/byteowlz/ppr/pkg/theme"
+	"github.com/spf13/cobra"
+)
+
+// allowUnsafe skips template sanitization, see svg.Sanitize
+var allowUnsafe bool
+
+// aspectRatio replaces the preserveAspectRatio of templates when set
+var aspectRatio string
+
+// useSafeArea keeps renders clear of the [safe_area] edges, see
+// renderSafeArea
+var useSafeArea bool
+
+// highContrast remaps render palettes with theme.Theme.HighContrast and
+// strokeScale multiplies template stroke widths, see svg.ScaleStrokes
+var (
+	highContrast bool
+	strokeScale  float64
+)
+
+// noOverlays skips the seasonal overlays, see activeOverlays
+var noOverlays bool
+
+// addRenderFlags adds the flags shared by the commands that render
+// templates to cmd
+func addRenderFlags(cmd *cobra.Command) {
+	cmd.Flags().BoolVar(&allowUnsafe, "allow-unsafe", false, "Render templates with entities, remote references or scripts without sanitizing them")
+	cmd.Flags().StringVar(&aspectRatio, "aspect-ratio", "", "Override the preserveAspectRatio of templates (e.g. \"xMidYMin slice\", \"xMidYMid meet\", none)")
+	cmd.Flags().BoolVar(&useSafeArea, "safe-area", false, "Keep wallpaper content clear of the menu bar and dock configured in [safe_area]")
+	cmd.Flags().BoolVar(&highContrast, "high-contrast", false, "Remap the palette for maximum foreground/background contrast")
+	cmd.Flags().Float64Var(&strokeScale, "stroke-scale", 1, "Multiply template stroke widths, e.g. 2 with --high-contrast")
+	cmd.Flags().BoolVar(&noOverlays, "no-overlays", false, "Render without the seasonal [[overlays]] from config.toml")
+}
+
+// renderSafeArea maps [safe_area] onto the insets of the wallpaper. It is
+// nil unless --safe-area is given or always is set, and --safe-area=false
+// turns it off.
+func renderSafeArea(cmd *cobra.Command, cfg *config.Config) *image.SafeArea {
+	sa := cfg.SafeArea
+	enabled := sa.Always
+	if cmd.Flags().Changed("safe-area") {
+		enabled = useSafeArea
+	}
+	if !enabled {
+		return nil
+	}
+
+	area := &image.SafeArea{Mode: sa.Mode, Top: max(sa.MenuBar, 0)}
+	if area.Mode == "" {
+		area.Mode = image.SafeAreaShift
+	}
+	switch sa.Dock {
+	case "", "bottom":
+		area.Bottom = max(sa.DockSize, 0)
+	case "left":
+		area.Left = max(sa.DockSize, 0)
+	case "right":
+		area.Right = max(sa.DockSize, 0)
+	default:
+		fmt.Printf("Warning: invalid safe_area dock %q (expected bottom, left or right), ignoring the dock\n", sa.Dock)
+	}
+	if err := area.Validate(); err != nil {
+		fmt.Printf("Warning: %v, using %s\n", err, image.SafeAreaShift)
+		area.Mode = image.SafeAreaShift
+	}
+	if area.Empty() {
+		fmt.Printf("Warning: no safe area configured, set menu_bar or dock_size under [safe_area] in %s\n", config.GetConfigPath())
+		return nil
+	}
+	return area
+}
+
+// newProcessor returns a template processor honoring --allow-unsafe
+func newProcessor(cfg *config.Config) *svg.Processor {
+	return &svg.Processor{AllowUnsafe: allowUnsafe, Locale: renderLocale(cfg)}
+}
+
+// renderLocale is the locale setting, or the locale of the environment
+// when it is empty
+func renderLocale(cfg *config.Config) *locale.Locale {
+	if cfg.Locale == "" {
+		return locale.FromEnv()
+	}
+	l, err := locale.Parse(cfg.Locale)
+	if err != nil {
+		fmt.Printf("Warning: %v, using English\n", err)
+		return locale.English
+	}
+	return l
+}
+
+// newManifest is the base of the manifests written next to rendered
+// variants, see 'ppr reproduce'
+func newManifest() *manifest.Manifest {
+	return &manifest.Manifest{Version: versionInfo.version}
+}
+
+// Used when render_timeout or setter_timeout cannot be parsed
+const (
+	defaultRenderTimeout = 5 * time.Minute
+	defaultSetterTimeout = time.Minute
+)
+
+// prepareRender picks the configured rasterizer for svgContent, see
+// pipeline.PrepareRender, and applies --aspect-ratio
+func prepareRender(cfg *config.Config, svgContent string) (string, *image.Generator, error) {
+	renderContent, generator, err := pipeline.PrepareRender(svgContent, cfg.Rasterizer, cfg.FontsPath)
+	if err != nil {
+		return "", nil, err
+	}
+	generator.SetLimits(*renderLimits(cfg))
+	if err := generator.SetAspectRatio(aspectRatio); err != nil {
+		return "", nil, err
+	}
+	return renderContent, generator, nil
+}
+
+// renderLimits maps [limits] onto image.DefaultLimits, warning about values
+// that cannot be parsed
+func renderLimits(cfg *config.Config) *image.Limits {
+	limits := image.DefaultLimits
+	if value := cfg.Limits.MaxSVGSize; value != "" {
+		if size, err := parseByteSize(value); err != nil {
+			fmt.Printf("Warning: invalid max_svg_size %q, using the default\n", value)
+		} else {
+			limits.MaxSVGBytes = size
+		}
+	}
+	if value := cfg.Limits.MaxMemory; value != "" {
+		if size, err := parseByteSize(value); err != nil {
+			fmt.Printf("Warning: invalid max_memory %q, using the default\n", value)
+		} else {
+			limits.MaxMemory = size
+		}
+	}
+	if value := cfg.Limits.MaxResolution; value == "0" {
+		limits.MaxWidth, limits.MaxHeight = 0, 0
+	} else if value != "" {
+		if res, err := resolution.ParseResolution(value); err != nil {
+			fmt.Printf("Warning: invalid max_resolution %q, using the default\n", value)
+		} else {
+			limits.MaxWidth, limits.MaxHeight = res.Width, res.Height
+		}
+	}
+	return &limits
+}
+
+// configTimeout parses a timeout setting such as "90s", "0" disables it
+func configTimeout(name, value string, fallback time.Duration) time.Duration {
+	if value == "" {
+		return fallback
+	}
+	timeout, err := time.ParseDuration(value)
+	if err != nil || timeout < 0 {
+		fmt.Printf("Warning: invalid %s %q, using %s\n", name, value, fallback)
+		return fallback
+	}
+	return timeout
+}
+
+// renderWithTimeout rasterizes one image within render_timeout
+func renderWithTimeout(cmd *cobra.Command, cfg *config.Config, generator *image.Generator, svgContent string, width, height int) (*stdimage.RGBA, error) {
+	ctx, cancel := renderContext(cmd, cfg)
+	defer cancel()
+	return generator.RenderContext(ctx, svgContent, width, height)
+}
+
+// renderParsedWithTimeout is renderWithTimeout for an SVG parsed once and
+// rendered at several sizes
+func renderParsedWithTimeout(cmd *cobra.Command, cfg *config.Config, generator *image.Generator, parsed *image.Parsed, width, height int) (*stdimage.RGBA, error) {
+	ctx, cancel := renderContext(cmd, cfg)
+	defer cancel()
+	return generator.RenderParsedContext(ctx, parsed, width, height)
+}
+
+// renderContext is the command context limited to render_timeout
+func renderContext(cmd *cobra.Command, cfg *config.Config) (context.Context, context.CancelFunc) {
+	ctx := commandContext(cmd)
+	if timeout := configTimeout("render_timeout", cfg.RenderTimeout, defaultRenderTimeout); timeout > 0 {
+		return context.WithTimeout(ctx, timeout)
+	}
+	return context.WithCancel(ctx)
+}
+
+// commandContext is the context cmd was executed with. Commands run from
+// other commands, like generate from apply-schedule, have none.
+func commandContext(cmd *cobra.Command) context.Context {
+	if cmd != nil && cmd.Context() != nil {
+		return cmd.Context()
+	}
+	return context.Background()
+}
+
+// newThemeManager manages the configured themes directory, filling partial
+// themes from fallback_theme
+func newThemeManager(cfg *config.Config) *theme.ThemeManager {
+	tm := theme.NewThemeManager(cfg.ThemesPath)
+	tm.SetFallback(cfg.FallbackTheme)
+	return tm
+}
+
+// newStagingManager is newThemeManager for the staged themes
+func newStagingManager(cfg *config.Config) *theme.ThemeManager {
+	tm := theme.NewStagingManager(cfg.ThemesPath)
+	tm.SetFallback(cfg.FallbackTheme)
+	return tm
+}
+
+// loadRenderTheme loads a theme, converts it from the configured color
+// space and applies the preset palette adjustments and warmth to it. The
+// name the theme is stored under is returned with it, see ResolveName.
+func loadRenderTheme(cfg *config.Config, name string, preset *config.Preset, kelvin int) (*theme.Theme, string, error) {
+	themeManager := newThemeManager(cfg)
+	if err := themeManager.LoadThemes(); err != nil {
+		return nil, "", fmt.Errorf("failed to load themes: %w", err)
+	}
+
+	selectedTheme, err := themeManager.GetTheme(name)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to get theme: %w", err)
+	}
+	name = themeManager.ResolveName(name)
+
+	selectedTheme, err = toSRGB(selectedTheme, cfg.ColorSpace)
+	if err != nil {
+		return nil, "", err
+	}
+
+	selectedTheme, err = applyPresetPalette(selectedTheme, preset)
+	if err != nil {
+		return nil, "", err
+	}
+	selectedTheme, err = warmTheme(selectedTheme, kelvin)
+	if err != nil {
+		return nil, "", err
+	}
+	if highContrast {
+		if selectedTheme, err = selectedTheme.HighContrast(); err != nil {
+			return nil, "", fmt.Errorf("failed to raise theme contrast: %w", err)
+		}
+	}
+	return selectedTheme, name, nil
+}
+
+// templateFile resolves a template name against the templates directory,
+// adding the .svg extension when missing
+func templateFile(cfg *config.Config, name string) string {
+	if !filepath.IsAbs(name) {
+		name = filepath.Join(cfg.TemplatesPath, name)
+	}
+	if filepath.Ext(name) == "" {
+		name += ".svg"
+	}
+	return name
+}
+
+// ensureDefaultTemplate writes the embedded copy of the default template
+// into the templates directory when it is missing there, so the default
+// renders before 'ppr init' installed the templates
+func ensureDefaultTemplate(cfg *config.Config) {
+	path := templateFile(cfg, cfg.DefaultTemplate)
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		return
+	}
+	data, err := templates.Read(filepath.Base(path))
+	if err != nil {
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		fmt.Printf("Warning: failed to install the built-in %s: %v\n", filepath.Base(path), err)
+		return
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		fmt.Printf("Warning: failed to install the built-in %s: %v\n", filepath.Base(path), err)
+		return
+	}
+	fmt.Printf("Installed the built-in template %s\n", filepath.Base(path))
+}
+
+// targetResolution parses value, or detects the primary display and falls
+// back to the configured default size, which headless mode always uses
+func targetResolution(cfg *config.Config, value string) (*resolution.Resolution, error) {
+	if value != "" {
+		res, err := resolution.ParseResolution(value)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse resolution: %w", err)
+		}
+		return res, nil
+	}
+	if headless.Enabled() {
+		return &resolution.Resolution{Width: cfg.DefaultWidth, Height: cfg.DefaultHeight}, nil
+	}
+
+	res, err := resolution.NewDetector().GetPrimaryDisplayResolution()
+	if err != nil {
+		fmt.Printf("Warning: failed to detect resolution, using default: %v\n", err)
+		res = &resolution.Resolution{Width: cfg.DefaultWidth, Height: cfg.DefaultHeight}
+	}
+	return res, nil
+}
+
+// saveCurrentState returns a pipeline hook recording the theme, template
+// and warmth as current in the config. Wallpapers that were set also count
+// as used in the state file and run the [hooks] presets with selected.
+func saveCurrentState(cfg *config.Config, selected *theme.Theme, themeName, templatePath string, kelvin int) func(*pipeline.Result) error {
+	return func(result *pipeline.Result) error {
+		cfg.CurrentTheme = themeName
+		cfg.CurrentTemplate = filepath.Base(templatePath)
+		cfg.CurrentWarmth = kelvin
+		cfg.LastOutputPath = result.WallpaperPath
+		if cfg.RenderStats && len(result.Variants) > 0 {
+			if err := recordRender(cfg.CurrentTemplate, result); err != nil {
+				fmt.Printf("Warning: failed to record render stats: %v\n", err)
+			}
+		}
+		if result.WallpaperSet {
+			if err := recordUsage(themeName, cfg.CurrentTemplate); err != nil {
+				fmt.Printf("Warning: failed to record usage: %v\n", err)
+			}
+			if err := recordSession(themeName, cfg.CurrentTemplate, kelvin); err != nil {
+				fmt.Printf("Warning: failed to record session: %v\n", err)
+			}
+			applyHooks(cfg, selected)
+		}
+		return cfg.Save()
+	}
+}
+
+// applyHooks writes selected to the toolkit config files of the [hooks]
+// presets. Failures are warnings, the wallpaper is already set.
+func applyHooks(cfg *config.Config, selected *theme.Theme) {
+	if len(cfg.Hooks.Apply) == 0 {
+		return
+	}
+	written, err := hooks.Apply(cfg.Hooks.Apply, selected)
+	for _, path := range written {
+		fmt.Printf("Updated %s\n", path)
+	}
+	if err != nil {
+		fmt.Printf("Warning: %v\n", err)
+	}
+}
+
+// recordRender adds the render metrics of result to the state file
+func recordRender(templateName string, result *pipeline.Result) error {
+	st, err := state.Load(config.GetStatePath())
+	if err != nil {
+		return err
+	}
+	var rendered, reused int
+	for _, v := range result.Variants {
+		if v.Reused {
+			reused++
+		} else {
+			rendered++
+		}
+	}
+	st.RecordRender(templateName, rendered, reused, result.RenderTime)
+	return st.Save()
+}
+
+// recordUsage counts one use of the theme and template in the state file
+func recordUsage(themeName, templateName string) error {
+	st, err := state.Load(config.GetStatePath())
+	if err != nil {
+		return err
+	}
+	st.Record(themeName, templateName, time.Now())
+	return st.Save()
+}
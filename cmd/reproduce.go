@@ -0,0 +1,202 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/byteowlz/ppr/pkg/config"
+	"github.com/byteowlz/ppr/pkg/image"
+	"github.com/byteowlz/ppr/pkg/manifest"
+	"github.com/byteowlz/ppr/pkg/palette"
+	"github.com/byteowlz/ppr/pkg/pipeline"
+	"github.com/byteowlz/ppr/pkg/svg"
+	"github.com/spf13/cobra"
+)
+
+var reproduceCmd = &cobra.Command{
+	Use:   "reproduce <manifest>",
+	Short: "Render a wallpaper again from its manifest",
+	Long: `Every rendered wallpaper gets a manifest next to it, e.g.
+shapes.png.manifest.json, recording the ppr version, the palette as
+rendered, the template with its hash, the size, the rasterizer and any
+overlays.
+'ppr reproduce' renders the wallpaper again from it and checks that the
+result is identical, so exact artworks can be shared as template plus
+manifest.
+
+The template is read from the recorded path, or from the templates folder
+by file name. A template that changed since is refused unless --force is
+given. Other ppr versions or rasterizer builds may not render the same
+pixels, the hash check tells.
+
+Examples:
+  ppr reproduce ~/Pictures/ppr/ppr/nord/shapes.png.manifest.json
+  ppr reproduce shapes.png.manifest.json -o shared.png`,
+	Args: cobra.ExactArgs(1),
+	RunE: runReproduce,
+}
+
+var (
+	reproduceOutputPath string
+	reproduceForce      bool
+)
+
+func init() {
+	reproduceCmd.Flags().StringVarP(&reproduceOutputPath, "output", "o", "", "Output image path (defaults to <name>-reproduced.<ext> next to the manifest)")
+	reproduceCmd.Flags().BoolVarP(&reproduceForce, "force", "f", false, "Render even if the template changed since the manifest was written")
+	addRenderFlags(reproduceCmd)
+}
+
+func runReproduce(cmd *cobra.Command, args []string) error {
+	m, err := manifest.Load(args[0])
+	if err != nil {
+		return err
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	if m.Version != versionInfo.version {
+		fmt.Printf("Warning: manifest was written by ppr %s, this is %s\n", m.Version, versionInfo.version)
+	}
+
+	template := recordedTemplate(cfg, m.Template)
+	hash, err := manifest.HashFile(template)
+	if err != nil {
+		return fmt.Errorf("template %s not found: %w", filepath.Base(m.Template), err)
+	}
+	if hash != m.TemplateSHA256 {
+		if !reproduceForce {
+			return fmt.Errorf("template %s changed since the manifest was written (use --force to render it anyway)", template)
+		}
+		fmt.Printf("Warning: template %s changed since the manifest was written\n", template)
+	}
+
+	content, err := os.ReadFile(template)
+	if err != nil {
+		return fmt.Errorf("failed to read template file: %w", err)
+	}
+	processor := &svg.Processor{AllowUnsafe: allowUnsafe, Values: m.Values, Locale: renderLocale(cfg)}
+	svgContent, err := processor.ProcessContent(string(content), m.Palette)
+	if err != nil {
+		return fmt.Errorf("failed to process template: %w", err)
+	}
+	if m.Font != "" {
+		svgContent = svg.SetFontFamily(svgContent, m.Font)
+	}
+	if m.StrokeScale != 0 {
+		svgContent = svg.ScaleStrokes(svgContent, m.StrokeScale)
+	}
+
+	outPath := reproduceOutputPath
+	if outPath == "" {
+		ext := filepath.Ext(m.Output)
+		outPath = filepath.Join(filepath.Dir(args[0]), strings.TrimSuffix(m.Output, ext)+"-reproduced"+ext)
+	}
+
+	// The recorded backend, not the configured one, renders the same pixels
+	renderCfg := *cfg
+	renderCfg.Rasterizer = m.Rasterizer
+	renderContent, generator, err := prepareRender(&renderCfg, svgContent)
+	if err != nil {
+		return fmt.Errorf("failed to prepare render: %w", err)
+	}
+	if m.SafeArea != nil {
+		area := *m.SafeArea
+		if fill, err := palette.ParseHex(m.Palette["base00"]); err == nil {
+			area.Fill = fill
+		}
+		if err := generator.SetSafeArea(&area); err != nil {
+			return err
+		}
+	}
+	img, err := renderWithTimeout(cmd, cfg, generator, renderContent, m.Width, m.Height)
+	if err != nil {
+		return fmt.Errorf("failed to render: %w", err)
+	}
+	// With overlays, ComposeOverlays limits the palette after compositing
+	if m.PaletteLimit != nil && len(m.Overlays) == 0 {
+		if err := m.PaletteLimit.Validate(); err != nil {
+			return err
+		}
+		img = image.LimitPalette(img, m.PaletteLimit)
+	}
+	if len(m.Overlays) > 0 {
+		overlays, err := reproduceOverlays(cfg, m.Overlays)
+		if err != nil {
+			return err
+		}
+		ctx, cancel := renderContext(cmd, cfg)
+		defer cancel()
+		img, err = pipeline.ComposeOverlays(ctx, img, overlays, m.Palette, pipeline.Options{
+			AllowUnsafe:  allowUnsafe,
+			Locale:       renderLocale(cfg),
+			Rasterizer:   m.Rasterizer,
+			FontsPath:    cfg.FontsPath,
+			Limits:       renderLimits(cfg),
+			PaletteLimit: m.PaletteLimit,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	if err := image.WriteImage(img, outPath); err != nil {
+		return fmt.Errorf("failed to write %s: %w", outPath, err)
+	}
+	if m.Optimizer != "" {
+		if _, err := image.OptimizePNGWith(outPath, m.Optimizer); err != nil {
+			fmt.Printf("Warning: failed to optimize like the original: %v\n", err)
+		}
+	}
+	fmt.Printf("Reproduced %s with theme %s: %s (%dx%d)\n", filepath.Base(template), m.Theme, outPath, m.Width, m.Height)
+
+	outputHash, err := manifest.HashFile(outPath)
+	if err != nil {
+		return err
+	}
+	if outputHash == m.OutputSHA256 {
+		fmt.Println("Identical to the original")
+	} else {
+		fmt.Printf("Warning: the output differs from the original (rendered by ppr %s with %s)\n", m.Version, m.Rasterizer)
+	}
+	return nil
+}
+
+// reproduceOverlays finds the recorded overlay templates like the main
+// template, refusing changed ones unless --force is given
+func reproduceOverlays(cfg *config.Config, recorded []manifest.Overlay) ([]pipeline.Overlay, error) {
+	overlays := make([]pipeline.Overlay, 0, len(recorded))
+	for _, o := range recorded {
+		template := recordedTemplate(cfg, o.Template)
+		hash, err := manifest.HashFile(template)
+		if err != nil {
+			return nil, fmt.Errorf("overlay template %s not found: %w", filepath.Base(o.Template), err)
+		}
+		if hash != o.TemplateSHA256 {
+			if !reproduceForce {
+				return nil, fmt.Errorf("overlay template %s changed since the manifest was written (use --force to render it anyway)", template)
+			}
+			fmt.Printf("Warning: overlay template %s changed since the manifest was written\n", template)
+		}
+		overlays = append(overlays, pipeline.Overlay{Name: o.Name, TemplatePath: template, Opacity: o.Opacity, Blend: o.Blend})
+	}
+	return overlays, nil
+}
+
+// recordedTemplate finds a template recorded in a manifest at its path,
+// which headless manifests give relative to the templates directory, or
+// else by file name in the templates directory
+func recordedTemplate(cfg *config.Config, recorded string) string {
+	template := recorded
+	if !filepath.IsAbs(template) {
+		template = templateFile(cfg, template)
+	}
+	if _, err := os.Stat(template); err != nil {
+		template = templateFile(cfg, filepath.Base(recorded))
+	}
+	return template
+}
@@ -40,7 +40,16 @@ func Execute(version, commit, date string) {
 
 	rootCmd.Version = version
 
-	if err := rootCmd.Execute(); err != nil {
+	if ran, code := runPlugin(os.Args[1:]); ran {
+		// os.Exit skips deferred work, so flush the profiles first
+		stopProfiling()
+		os.Exit(code)
+	}
+
+	err := rootCmd.Execute()
+	stopProfiling()
+
+	if err != nil {
 		fmt.Println(err)
 		os.Exit(1)
 	}
@@ -50,11 +59,45 @@ func init() {
 	rootCmd.AddCommand(generateCmd)
 	rootCmd.AddCommand(listThemesCmd)
 	rootCmd.AddCommand(listTemplatesCmd)
+	rootCmd.AddCommand(listIconsCmd)
 	rootCmd.AddCommand(initConfigCmd)
 	rootCmd.AddCommand(setWallpaperCmd)
 	rootCmd.AddCommand(convertTemplateCmd)
 	rootCmd.AddCommand(batchConvertCmd)
 	rootCmd.AddCommand(switchCurrentCmd)
 	rootCmd.AddCommand(cycleCmd)
+	rootCmd.AddCommand(contextCmd)
+	rootCmd.AddCommand(focusCmd)
+	rootCmd.AddCommand(timerCmd)
+	rootCmd.AddCommand(playlistCmd)
+	rootCmd.AddCommand(recordCmd)
+	rootCmd.AddCommand(replayCmd)
+	rootCmd.AddCommand(applyScheduleCmd)
+	rootCmd.AddCommand(duCmd)
+	rootCmd.AddCommand(statsCmd)
+	rootCmd.AddCommand(benchCmd)
+	rootCmd.AddCommand(verifyCmd)
+	rootCmd.AddCommand(compatCmd)
+	rootCmd.AddCommand(diffCmd)
+	rootCmd.AddCommand(recolorCmd)
+	rootCmd.AddCommand(composeCmd)
+	rootCmd.AddCommand(collageCmd)
+	rootCmd.AddCommand(compareCmd)
+	rootCmd.AddCommand(reproduceCmd)
+	rootCmd.AddCommand(iconCmd)
+	rootCmd.AddCommand(spacesCmd)
+	rootCmd.AddCommand(themeCmd)
+	rootCmd.AddCommand(newTemplateCmd)
+	rootCmd.AddCommand(newThemeCmd)
+	rootCmd.AddCommand(slideshowCmd)
+	rootCmd.AddCommand(dbusServiceCmd)
+	rootCmd.AddCommand(watchdogCmd)
+	rootCmd.AddCommand(overlayCmd)
+	rootCmd.AddCommand(pinCmd)
+	rootCmd.AddCommand(unpinCmd)
+	rootCmd.AddCommand(templateCmd)
+	rootCmd.AddCommand(integrateCmd)
+	rootCmd.AddCommand(pluginsCmd)
+	rootCmd.AddCommand(upgradeCmd)
 	rootCmd.AddCommand(versionCmd)
 }
@@ -0,0 +1,70 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/byteowlz/ppr/pkg/config"
+	"github.com/spf13/cobra"
+)
+
+var slideshowCmd = &cobra.Command{
+	Use:   "slideshow",
+	Short: "Point the Windows desktop slideshow at generated wallpapers",
+	Long: `Configure the built-in Windows desktop slideshow to cycle through the
+wallpapers ppr has generated. By default every theme variant under the
+output folder is included; --theme limits it to one theme.
+
+Windows keeps advancing the slideshow on its own, no ppr process stays
+running. Setting a single wallpaper afterwards turns the slideshow off.
+Windows only.
+
+Examples:
+  ppr slideshow --interval 30m --shuffle
+  ppr slideshow --theme nord --interval 1h`,
+	Args: cobra.NoArgs,
+	RunE: runSlideshow,
+}
+
+var (
+	slideshowTheme    string
+	slideshowFolder   string
+	slideshowInterval time.Duration
+	slideshowShuffle  bool
+)
+
+func init() {
+	slideshowCmd.Flags().StringVarP(&slideshowTheme, "theme", "t", "", "Only include variants of this theme")
+	slideshowCmd.Flags().StringVar(&slideshowFolder, "folder", "", "Folder to show (defaults to the ppr output folder)")
+	slideshowCmd.Flags().DurationVarP(&slideshowInterval, "interval", "i", 30*time.Minute, "Time between pictures")
+	slideshowCmd.Flags().BoolVarP(&slideshowShuffle, "shuffle", "s", false, "Show pictures in random order")
+}
+
+func runSlideshow(cmd *cobra.Command, args []string) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	folder := slideshowFolder
+	if folder == "" {
+		// current.png and the temp copies live one level up and are left out
+		folder = filepath.Join(cfg.OutputPath, "ppr")
+		if slideshowTheme != "" {
+			folder = filepath.Join(folder, slideshowTheme)
+		}
+	}
+
+	setter := newWallpaperSetter(cfg)
+	if err := setter.SetWindowsSlideshow(folder, slideshowInterval, slideshowShuffle); err != nil {
+		return err
+	}
+
+	order := "in order"
+	if slideshowShuffle {
+		order = "shuffled"
+	}
+	fmt.Printf("Slideshow set: %s, every %s, %s\n", folder, slideshowInterval, order)
+	return nil
+}
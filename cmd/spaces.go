@@ -0,0 +1,156 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/byteowlz/ppr/pkg/config"
+	"github.com/byteowlz/ppr/pkg/wallpaper"
+	"github.com/spf13/cobra"
+)
+
+var spacesCmd = &cobra.Command{
+	Use:   "spaces",
+	Short: "List macOS Mission Control Spaces",
+	Long: `List the desktop Spaces of every display so each one can be given its
+own wallpaper with 'ppr spaces set'. macOS only.`,
+	Args: cobra.NoArgs,
+	RunE: runSpaces,
+}
+
+var spacesSetCmd = &cobra.Command{
+	Use:   "set <space> [image-path]",
+	Short: "Set the wallpaper of one Space",
+	Long: `Set the wallpaper of a single Mission Control Space, given by its index
+from 'ppr spaces' or "current". Instead of an image path, --theme picks the
+generated variant of a template, so each Space can carry its own theme.
+
+Spaces other than the current one are written to the Dock's
+desktoppicture.db, which macOS 14 and later no longer read. There only the
+current Space can be set.
+
+Examples:
+  ppr spaces set 2 ~/Pictures/wall.png
+  ppr spaces set 3 --theme nord
+  ppr spaces set current --theme gruvbox-dark --template mountains`,
+	Args: cobra.RangeArgs(1, 2),
+	RunE: runSpacesSet,
+}
+
+var (
+	spacesDisplay  string
+	spacesTheme    string
+	spacesTemplate string
+)
+
+func init() {
+	spacesSetCmd.Flags().StringVarP(&spacesDisplay, "display", "d", "Main", "Display identifier the Space index refers to")
+	spacesSetCmd.Flags().StringVarP(&spacesTheme, "theme", "t", "", "Use the generated variant of this theme")
+	spacesSetCmd.Flags().StringVar(&spacesTemplate, "template", "", "Template of the variant (defaults to current template)")
+
+	spacesCmd.AddCommand(spacesSetCmd)
+}
+
+func runSpaces(cmd *cobra.Command, args []string) error {
+	spaces, err := wallpaper.ListSpaces()
+	if err != nil {
+		return err
+	}
+
+	display := ""
+	for _, space := range spaces {
+		if space.Display != display {
+			display = space.Display
+			fmt.Printf("Display %s:\n", display)
+		}
+		marker := " "
+		if space.Current {
+			marker = "*"
+		}
+		fmt.Printf("  %s %d\n", marker, space.Index)
+	}
+	return nil
+}
+
+func runSpacesSet(cmd *cobra.Command, args []string) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	imagePath, err := spaceImagePath(cfg, args[1:])
+	if err != nil {
+		return err
+	}
+
+	spaces, err := wallpaper.ListSpaces()
+	if err != nil {
+		return err
+	}
+	space, err := findSpace(spaces, args[0], spacesDisplay)
+	if err != nil {
+		return err
+	}
+
+	setter := newWallpaperSetter(cfg)
+	if err := setter.SetSpaceWallpaper(space, imagePath); err != nil {
+		return fmt.Errorf("failed to set wallpaper: %w", err)
+	}
+
+	fmt.Printf("Wallpaper of Space %d on %s set: %s\n", space.Index, space.Display, imagePath)
+	return nil
+}
+
+// spaceImagePath returns the explicit image argument, or the generated
+// variant of --theme for --template
+func spaceImagePath(cfg *config.Config, args []string) (string, error) {
+	if len(args) > 0 {
+		if spacesTheme != "" {
+			return "", fmt.Errorf("give either an image path or --theme, not both")
+		}
+		return args[0], nil
+	}
+	if spacesTheme == "" {
+		return "", fmt.Errorf("an image path or --theme is required")
+	}
+
+	templateName := spacesTemplate
+	if templateName == "" {
+		templateName = cfg.CurrentTemplate
+	}
+	if templateName == "" {
+		templateName = cfg.DefaultTemplate
+	}
+	templateName = strings.TrimSuffix(filepath.Base(templateName), ".svg")
+
+	variantPath := filepath.Join(cfg.OutputPath, "ppr", spacesTheme, templateName+".png")
+	if _, err := os.Stat(variantPath); err != nil {
+		return "", fmt.Errorf("no generated variant at %s, run 'ppr generate --template %s --theme %s' first", variantPath, templateName, spacesTheme)
+	}
+	return variantPath, nil
+}
+
+func findSpace(spaces []wallpaper.Space, spec, display string) (wallpaper.Space, error) {
+	if spec == "current" {
+		for _, space := range spaces {
+			if space.Current && space.Display == display {
+				return space, nil
+			}
+		}
+		return wallpaper.Space{}, fmt.Errorf("no current Space found on display %s", display)
+	}
+
+	index, err := strconv.Atoi(spec)
+	if err != nil {
+		return wallpaper.Space{}, fmt.Errorf("invalid Space %q: use an index or \"current\"", spec)
+	}
+	for _, space := range spaces {
+		if space.Index == index && space.Display == display {
+			return space, nil
+		}
+	}
+	return wallpaper.Space{}, fmt.Errorf("no Space %d on display %s, see 'ppr spaces'", index, display)
+}
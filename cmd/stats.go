@@ -0,0 +1,139 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"github.com/byteowlz/ppr/pkg/config"
+	"github.com/byteowlz/ppr/pkg/state"
+	"github.com/spf13/cobra"
+)
+
+var statsCmd = &cobra.Command{
+	Use:   "stats",
+	Short: "Show local usage and render statistics",
+	Long: `Summarize how often each theme and template became the wallpaper and,
+with render_stats = true in config.toml, how long renders took and how
+often existing variants were reused instead of rendered again.
+
+Everything is read from the local state file, nothing is sent anywhere.`,
+	Args: cobra.NoArgs,
+	RunE: runStats,
+}
+
+var statsTop int
+
+func init() {
+	statsCmd.Flags().IntVarP(&statsTop, "top", "n", 10, "Number of themes and templates to show (0 shows all)")
+}
+
+func runStats(cmd *cobra.Command, args []string) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	st, err := state.Load(config.GetStatePath())
+	if err != nil {
+		return err
+	}
+
+	if len(st.Themes) == 0 && len(st.Renders) == 0 {
+		fmt.Println("No usage recorded yet")
+	}
+
+	if len(st.Themes) > 0 {
+		fmt.Printf("Themes (%d used):\n", len(st.Themes))
+		printUsage(st.Themes, "THEME")
+	}
+	if len(st.Templates) > 0 {
+		fmt.Printf("\nTemplates (%d used):\n", len(st.Templates))
+		printUsage(st.Templates, "TEMPLATE")
+	}
+
+	if len(st.Renders) == 0 {
+		if !cfg.RenderStats {
+			fmt.Printf("\nRender metrics are off, set render_stats = true in %s to record them\n", config.GetConfigPath())
+		}
+		return nil
+	}
+
+	var total state.RenderStats
+	for _, r := range st.Renders {
+		total.Rendered += r.Rendered
+		total.Reused += r.Reused
+		total.Runs += r.Runs
+		total.TotalMillis += r.TotalMillis
+	}
+	fmt.Printf("\nRenders: %d variants rendered, %d reused (%s cache hits), %s on average\n",
+		total.Rendered, total.Reused, hitRate(total), formatRenderTime(total.AverageRender()))
+
+	names := make([]string, 0, len(st.Renders))
+	for name := range st.Renders {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		a, b := st.Renders[names[i]], st.Renders[names[j]]
+		if a.Rendered+a.Reused != b.Rendered+b.Reused {
+			return a.Rendered+a.Reused > b.Rendered+b.Reused
+		}
+		return names[i] < names[j]
+	})
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "  TEMPLATE\tRENDERED\tREUSED\tCACHE HITS\tAVG RENDER")
+	for _, name := range topN(names) {
+		r := st.Renders[name]
+		fmt.Fprintf(w, "  %s\t%d\t%d\t%s\t%s\n", name, r.Rendered, r.Reused, hitRate(r), formatRenderTime(r.AverageRender()))
+	}
+	return w.Flush()
+}
+
+// printUsage lists usages by count, most used first
+func printUsage(usages map[string]state.Usage, label string) {
+	names := make([]string, 0, len(usages))
+	for name := range usages {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		a, b := usages[names[i]], usages[names[j]]
+		if a.Count != b.Count {
+			return a.Count > b.Count
+		}
+		return names[i] < names[j]
+	})
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "  %s\tUSES\tLAST USED\n", label)
+	for _, name := range topN(names) {
+		u := usages[name]
+		fmt.Fprintf(w, "  %s\t%d\t%s\n", name, u.Count, u.LastUsed.Local().Format("2006-01-02 15:04"))
+	}
+	w.Flush()
+}
+
+// topN cuts names to --top
+func topN(names []string) []string {
+	if statsTop > 0 && len(names) > statsTop {
+		return names[:statsTop]
+	}
+	return names
+}
+
+// hitRate is the share of variants reused instead of rendered
+func hitRate(r state.RenderStats) string {
+	if r.Rendered+r.Reused == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%.0f%%", float64(r.Reused)*100/float64(r.Rendered+r.Reused))
+}
+
+func formatRenderTime(d time.Duration) string {
+	if d == 0 {
+		return "-"
+	}
+	return d.Round(time.Millisecond).String()
+}
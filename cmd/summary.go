@@ -0,0 +1,100 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/byteowlz/ppr/pkg/pipeline"
+	"github.com/byteowlz/ppr/pkg/resolution"
+	"github.com/spf13/cobra"
+)
+
+// summaryFormat is the --summary flag shared by generate, cycle and
+// switch-current
+var summaryFormat string
+
+// addSummaryFlag registers --summary on cmd
+func addSummaryFlag(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&summaryFormat, "summary", "", "Print a machine-readable summary of the run to stdout (json)")
+}
+
+// runSummary is the --summary json object
+type runSummary struct {
+	Command    string           `json:"command"`
+	Theme      string           `json:"theme,omitempty"`
+	Template   string           `json:"template,omitempty"`
+	Resolution string           `json:"resolution,omitempty"`
+	SVG        string           `json:"svg,omitempty"`
+	Variants   []summaryVariant `json:"variants,omitempty"`
+	Current    string           `json:"current,omitempty"`
+	// Wallpaper is the file handed to the desktop, if it was set
+	Wallpaper    string `json:"wallpaper,omitempty"`
+	WallpaperSet bool   `json:"wallpaper_set"`
+	SetError     string `json:"set_error,omitempty"`
+	// Skipped tells why the command did nothing, e.g. cycle --min-idle
+	Skipped string `json:"skipped,omitempty"`
+}
+
+type summaryVariant struct {
+	Path       string `json:"path"`
+	Resolution string `json:"resolution"`
+	// Cached is set when an existing variant was reused
+	Cached bool `json:"cached"`
+}
+
+// startSummary moves the human-readable output to stderr when --summary
+// is given, so stdout only carries the summary. It returns where to write
+// the summary, nil without --summary, and a function restoring stdout.
+func startSummary() (io.Writer, func(), error) {
+	switch summaryFormat {
+	case "":
+		return nil, func() {}, nil
+	case "json":
+		stdout := os.Stdout
+		os.Stdout = os.Stderr
+		return stdout, func() { os.Stdout = stdout }, nil
+	default:
+		return nil, nil, fmt.Errorf("unsupported summary format: %s (expected json)", summaryFormat)
+	}
+}
+
+// newRunSummary describes a pipeline run of command
+func newRunSummary(command, themeName, template string, res *resolution.Resolution, result *pipeline.Result) *runSummary {
+	s := &runSummary{
+		Command:      command,
+		Theme:        themeName,
+		Template:     template,
+		SVG:          result.SVGPath,
+		WallpaperSet: result.WallpaperSet,
+	}
+	if res != nil {
+		s.Resolution = res.String()
+	}
+	for _, v := range result.Variants {
+		s.Variants = append(s.Variants, summaryVariant{
+			Path:       v.Path,
+			Resolution: fmt.Sprintf("%dx%d", v.Width, v.Height),
+			Cached:     v.Reused,
+		})
+	}
+	if result.VariantPath != "" {
+		s.Current = result.CurrentPath
+	}
+	if result.WallpaperSet {
+		s.Wallpaper = result.WallpaperPath
+	}
+	if result.SetError != nil {
+		s.SetError = result.SetError.Error()
+	}
+	return s
+}
+
+// writeSummary prints s as one JSON object to w
+func writeSummary(w io.Writer, s *runSummary) error {
+	if err := json.NewEncoder(w).Encode(s); err != nil {
+		return fmt.Errorf("failed to write summary: %w", err)
+	}
+	return nil
+}
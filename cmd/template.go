@@ -0,0 +1,139 @@
+package cmd
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/byteowlz/ppr/pkg/config"
+	"github.com/byteowlz/ppr/pkg/svg"
+	"github.com/spf13/cobra"
+)
+
+var templateCmd = &cobra.Command{
+	Use:   "template",
+	Short: "Tools for working on templates",
+}
+
+var templateAdaptCmd = &cobra.Command{
+	Use:   "adapt <template>",
+	Short: "Create a variant of a template for another aspect ratio",
+	Long: `Create a portrait, square or ultrawide variant of a template instead of
+cropping it. The canvas is extended to the aspect ratio, keeping one side
+and growing the other with the original centered in it, and groups marked
+with a data-ppr-adapt attribute are repositioned:
+
+  repeat             tile the group to fill the new canvas (patterns)
+  stretch            scale the group to the new canvas (backgrounds)
+  cover              scale the group uniformly until it covers the canvas
+  anchor-<position>  keep the group at an edge or corner: top, bottom,
+                     left, right, top-left, top-right, bottom-left,
+                     bottom-right or center
+
+  <g data-ppr-adapt="repeat">...</g>
+  <g data-ppr-adapt="anchor-bottom-right">...</g>
+
+Unmarked content stays centered. The variant is saved next to the template
+as <template>-<W>x<H>, e.g. waves-9x16.
+
+Examples:
+  ppr template adapt waves --aspect 9:16
+  ppr template adapt waves --aspect 21:9 --output waves-ultrawide`,
+	Args: cobra.ExactArgs(1),
+	RunE: runTemplateAdapt,
+}
+
+var (
+	adaptAspect string
+	adaptOutput string
+	adaptForce  bool
+)
+
+func init() {
+	templateAdaptCmd.Flags().StringVar(&adaptAspect, "aspect", "", "Aspect ratio of the variant, e.g. 9:16 or 21:9")
+	templateAdaptCmd.Flags().StringVarP(&adaptOutput, "output", "o", "", "Name of the variant (default <template>-<W>x<H>)")
+	templateAdaptCmd.Flags().BoolVar(&adaptForce, "force", false, "Overwrite an existing variant")
+	templateAdaptCmd.MarkFlagRequired("aspect")
+
+	templateCmd.AddCommand(templateAdaptCmd)
+}
+
+func runTemplateAdapt(cmd *cobra.Command, args []string) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	aspectWidth, aspectHeight, err := parseAspect(adaptAspect)
+	if err != nil {
+		return err
+	}
+
+	source := templateFile(cfg, args[0])
+	content, err := os.ReadFile(source)
+	if err != nil {
+		return fmt.Errorf("failed to read template: %w", err)
+	}
+	adapted, result, err := svg.Adapt(string(content), aspectWidth, aspectHeight)
+	if err != nil {
+		return fmt.Errorf("failed to adapt %s: %w", filepath.Base(source), err)
+	}
+
+	name := adaptOutput
+	if name == "" {
+		base := strings.TrimSuffix(filepath.Base(source), filepath.Ext(source))
+		name = fmt.Sprintf("%s-%sx%s", base, formatAspect(aspectWidth), formatAspect(aspectHeight))
+	}
+	target := templateFile(cfg, name)
+	if target == source {
+		return fmt.Errorf("the variant would overwrite its template, choose another --output")
+	}
+	if _, err := os.Stat(target); err == nil && !adaptForce {
+		return fmt.Errorf("%s already exists, use --force to overwrite it", target)
+	}
+	if err := os.WriteFile(target, []byte(adapted), 0644); err != nil {
+		return fmt.Errorf("failed to write template: %w", err)
+	}
+
+	fmt.Printf("Template created: %s\n", target)
+	fmt.Printf("Canvas: %sx%s -> %sx%s\n", formatAspect(result.FromWidth), formatAspect(result.FromHeight), formatAspect(result.ToWidth), formatAspect(result.ToHeight))
+	if len(result.Rules) == 0 {
+		fmt.Printf("No groups marked with %s, the original stays centered on the wider canvas\n", svg.AdaptAttr)
+		return nil
+	}
+	rules := make([]string, 0, len(result.Rules))
+	for rule := range result.Rules {
+		rules = append(rules, rule)
+	}
+	sort.Strings(rules)
+	for _, rule := range rules {
+		fmt.Printf("  %-20s %d group(s)\n", rule, result.Rules[rule])
+	}
+	return nil
+}
+
+// parseAspect reads an aspect ratio written W:H or WxH
+func parseAspect(value string) (float64, float64, error) {
+	separator := ":"
+	if !strings.Contains(value, separator) {
+		separator = "x"
+	}
+	w, h, found := strings.Cut(strings.ToLower(value), separator)
+	if found {
+		width, errW := strconv.ParseFloat(strings.TrimSpace(w), 64)
+		height, errH := strconv.ParseFloat(strings.TrimSpace(h), 64)
+		if errW == nil && errH == nil && width > 0 && height > 0 {
+			return width, height, nil
+		}
+	}
+	return 0, 0, fmt.Errorf("invalid aspect ratio %q (expected e.g. 9:16 or 21x9)", value)
+}
+
+// formatAspect prints v with at most two decimals
+func formatAspect(v float64) string {
+	return strconv.FormatFloat(math.Round(v*100)/100, 'f', -1, 64)
+}
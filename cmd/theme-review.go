@@ -0,0 +1,333 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+
+	"github.com/byteowlz/ppr/pkg/config"
+	"github.com/byteowlz/ppr/pkg/image"
+	"github.com/byteowlz/ppr/pkg/theme"
+	"github.com/spf13/cobra"
+)
+
+var themeImportCmd = &cobra.Command{
+	Use:   "import <theme.yaml|url>",
+	Short: "Stage a base16 or base24 theme file for review",
+	Long: `Validate a base16 or base24 theme file or URL and stage it for review,
+see 'ppr theme review'. The theme is named after the file unless --name is
+given.
+
+The license and source_url of the file are kept for 'ppr theme credits';
+--license and --source-url set them, and a downloaded theme records its
+URL as source.
+
+Examples:
+  ppr theme import ~/Downloads/everforest.yaml --license MIT
+  ppr theme import https://example.com/schemes/acme.yaml --name acme`,
+	Args: cobra.ExactArgs(1),
+	RunE: runThemeImport,
+}
+
+var themeReviewCmd = &cobra.Command{
+	Use:   "review",
+	Short: "List, preview and promote staged themes",
+	Long: `Extracted and imported themes are staged in <themes_path>/staging
+before they become available. Without a subcommand the staged themes are
+listed. Preview a staged theme against a reference template, edit it, and
+promote it into the active themes once it looks right.
+
+Examples:
+  ppr theme review
+  ppr theme review show acme --template shapes
+  ppr theme review edit acme
+  ppr theme review promote acme`,
+	Args: cobra.NoArgs,
+	RunE: runThemeReviewList,
+}
+
+var themeReviewShowCmd = &cobra.Command{
+	Use:   "show <theme>",
+	Short: "Render a staged theme with a reference template",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runThemeReviewShow,
+}
+
+var themeReviewEditCmd = &cobra.Command{
+	Use:   "edit <theme>",
+	Short: "Open a staged theme in $EDITOR",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runThemeReviewEdit,
+}
+
+var themeReviewPromoteCmd = &cobra.Command{
+	Use:   "promote <theme>...",
+	Short: "Move staged themes into the active themes",
+	Args:  cobra.MinimumNArgs(1),
+	RunE:  runThemeReviewPromote,
+}
+
+var themeReviewDiscardCmd = &cobra.Command{
+	Use:   "discard <theme>...",
+	Short: "Delete staged themes",
+	Args:  cobra.MinimumNArgs(1),
+	RunE:  runThemeReviewDiscard,
+}
+
+var (
+	importName      string
+	importLicense   string
+	importSourceURL string
+
+	reviewTemplate      string
+	reviewResolutionStr string
+	reviewSetWallpaper  bool
+	reviewForce         bool
+)
+
+func init() {
+	themeImportCmd.Flags().StringVarP(&importName, "name", "n", "", "Theme name (defaults to the file name)")
+	themeImportCmd.Flags().StringVar(&importLicense, "license", "", "License of the theme, e.g. MIT")
+	themeImportCmd.Flags().StringVar(&importSourceURL, "source-url", "", "Where the theme comes from")
+
+	themeReviewShowCmd.Flags().StringVarP(&reviewTemplate, "template", "s", "", "Reference template (uses default template if not specified)")
+	themeReviewShowCmd.Flags().StringVarP(&reviewResolutionStr, "resolution", "r", "", "Preview resolution (e.g., 1920x1080)")
+	themeReviewShowCmd.Flags().BoolVarP(&reviewSetWallpaper, "set-wallpaper", "w", false, "Set the preview as wallpaper")
+	themeReviewPromoteCmd.Flags().BoolVarP(&reviewForce, "force", "f", false, "Replace active themes with the same name")
+
+	themeReviewCmd.AddCommand(themeReviewShowCmd)
+	themeReviewCmd.AddCommand(themeReviewEditCmd)
+	themeReviewCmd.AddCommand(themeReviewPromoteCmd)
+	themeReviewCmd.AddCommand(themeReviewDiscardCmd)
+	themeCmd.AddCommand(themeImportCmd)
+	themeCmd.AddCommand(themeReviewCmd)
+}
+
+// loadStagedThemes returns the config and the staged themes
+func loadStagedThemes() (*config.Config, *theme.ThemeManager, error) {
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load config: %w", err)
+	}
+
+	staged := newStagingManager(cfg)
+	if err := staged.LoadThemes(); err != nil {
+		return nil, nil, fmt.Errorf("failed to load staged themes: %w", err)
+	}
+	return cfg, staged, nil
+}
+
+// stageTheme saves a new theme to the staging area and tells how to go on
+func stageTheme(cfg *config.Config, newTheme *theme.Theme) error {
+	staged := newStagingManager(cfg)
+	if err := staged.SaveTheme(newTheme); err != nil {
+		return fmt.Errorf("failed to stage theme: %w", err)
+	}
+
+	path, err := staged.ThemeFile(newTheme.Name)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Theme '%s' staged for review: %s\n", newTheme.Name, path)
+	fmt.Printf("Preview it with 'ppr theme review show %s', then run 'ppr theme review promote %s'\n", newTheme.Name, newTheme.Name)
+	return nil
+}
+
+func runThemeImport(cmd *cobra.Command, args []string) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	var imported *theme.Theme
+	staged := newStagingManager(cfg)
+	if strings.HasPrefix(args[0], "http://") || strings.HasPrefix(args[0], "https://") {
+		imported, err = staged.ReadThemeURL(args[0])
+	} else {
+		imported, err = staged.ReadTheme(args[0])
+	}
+	if err != nil {
+		return err
+	}
+	// Themes are looked up by file name, which SaveTheme takes from Name
+	imported.Name = importName
+	if imported.Name == "" {
+		imported.Name = strings.TrimSuffix(filepath.Base(args[0]), filepath.Ext(args[0]))
+	}
+	if importLicense != "" {
+		imported.License = importLicense
+	}
+	if importSourceURL != "" {
+		imported.SourceURL = importSourceURL
+	}
+
+	return stageTheme(cfg, imported)
+}
+
+func runThemeReviewList(cmd *cobra.Command, args []string) error {
+	_, staged, err := loadStagedThemes()
+	if err != nil {
+		return err
+	}
+
+	names := staged.ListThemes()
+	if len(names) == 0 {
+		fmt.Println("No themes waiting for review")
+		return nil
+	}
+	sort.Strings(names)
+
+	fmt.Printf("%d themes waiting for review:\n", len(names))
+	for _, name := range names {
+		t, _ := staged.GetTheme(name)
+		fmt.Printf("  %s (%s, %s, by %s)\n", name, t.System, t.Variant, t.Author)
+	}
+	return nil
+}
+
+func runThemeReviewShow(cmd *cobra.Command, args []string) error {
+	cfg, staged, err := loadStagedThemes()
+	if err != nil {
+		return err
+	}
+	selectedTheme, err := staged.GetTheme(args[0])
+	if err != nil {
+		return err
+	}
+
+	templateToUse := reviewTemplate
+	if templateToUse == "" {
+		templateToUse = cfg.DefaultTemplate
+		ensureDefaultTemplate(cfg)
+		fmt.Printf("Using default template: %s\n", templateToUse)
+	}
+	template := templateFile(cfg, templateToUse)
+
+	res, err := targetResolution(cfg, reviewResolutionStr)
+	if err != nil {
+		return err
+	}
+
+	svgContent, err := newProcessor(cfg).ProcessTemplate(template, selectedTheme)
+	if err != nil {
+		return fmt.Errorf("failed to process template: %w", err)
+	}
+	renderContent, generator, err := prepareRender(cfg, svgContent)
+	if err != nil {
+		return fmt.Errorf("failed to prepare render: %w", err)
+	}
+	img, err := renderWithTimeout(cmd, cfg, generator, renderContent, res.Width, res.Height)
+	if err != nil {
+		return fmt.Errorf("failed to render: %w", err)
+	}
+
+	reviewDir := filepath.Join(cfg.OutputPath, "ppr", "review")
+	if err := os.MkdirAll(reviewDir, 0755); err != nil {
+		return fmt.Errorf("failed to create review directory: %w", err)
+	}
+	base := strings.TrimSuffix(filepath.Base(template), filepath.Ext(template))
+	outPath := filepath.Join(reviewDir, fmt.Sprintf("%s-%s.png", args[0], base))
+	if err := image.WriteImage(img, outPath); err != nil {
+		return fmt.Errorf("failed to write preview: %w", err)
+	}
+	fmt.Printf("Preview of staged theme %s: %s (%s)\n", args[0], outPath, res.String())
+
+	fmt.Println("\nColors:")
+	for _, key := range selectedTheme.PaletteKeys() {
+		fmt.Printf("  %s: %s\n", key, selectedTheme.Palette[key])
+	}
+
+	if reviewSetWallpaper {
+		setter := newWallpaperSetter(cfg)
+		if err := setter.SetWallpaper(outPath); err != nil {
+			fmt.Printf("Warning: failed to set wallpaper: %v\n", err)
+		} else {
+			fmt.Println("Wallpaper set successfully!")
+		}
+	}
+	return nil
+}
+
+func runThemeReviewEdit(cmd *cobra.Command, args []string) error {
+	_, staged, err := loadStagedThemes()
+	if err != nil {
+		return err
+	}
+	path, err := staged.ThemeFile(args[0])
+	if err != nil {
+		return err
+	}
+
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if editor == "" {
+		editor = "vi"
+		if runtime.GOOS == "windows" {
+			editor = "notepad"
+		}
+	}
+
+	// EDITOR may carry arguments, e.g. "code --wait"
+	fields := strings.Fields(editor)
+	editCmd := exec.Command(fields[0], append(fields[1:], path)...)
+	editCmd.Stdin, editCmd.Stdout, editCmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	if err := editCmd.Run(); err != nil {
+		return fmt.Errorf("editor %s failed: %w", editor, err)
+	}
+
+	// Reload to catch mistakes before the theme is promoted
+	_, staged, err = loadStagedThemes()
+	if err != nil {
+		return err
+	}
+	if _, err := staged.GetTheme(args[0]); err != nil {
+		fmt.Printf("Warning: %s no longer is a valid theme, edit it again before promoting it\n", args[0])
+	}
+	return nil
+}
+
+func runThemeReviewPromote(cmd *cobra.Command, args []string) error {
+	cfg, staged, err := loadStagedThemes()
+	if err != nil {
+		return err
+	}
+
+	active := newThemeManager(cfg)
+	if !reviewForce {
+		for _, name := range args {
+			if _, err := active.ThemeFile(name); err == nil {
+				return fmt.Errorf("theme %s already exists (use --force to replace it)", name)
+			}
+		}
+	}
+
+	for _, name := range args {
+		path, err := staged.Promote(name, active)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Promoted theme '%s': %s\n", name, path)
+	}
+	return nil
+}
+
+func runThemeReviewDiscard(cmd *cobra.Command, args []string) error {
+	_, staged, err := loadStagedThemes()
+	if err != nil {
+		return err
+	}
+
+	for _, name := range args {
+		if err := staged.RemoveTheme(name); err != nil {
+			return err
+		}
+		fmt.Printf("Discarded staged theme '%s'\n", name)
+	}
+	return nil
+}
@@ -0,0 +1,568 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/byteowlz/ppr/pkg/clipboard"
+	"github.com/byteowlz/ppr/pkg/config"
+	"github.com/byteowlz/ppr/pkg/theme"
+	"github.com/spf13/cobra"
+)
+
+var themeCmd = &cobra.Command{
+	Use:   "theme",
+	Short: "Create and manage themes",
+}
+
+var themeFromColorCmd = &cobra.Command{
+	Use:   "from-color <hex>",
+	Short: "Generate a base16 theme around a seed color",
+	Long: `Generate a full base16 theme from a single brand or seed color. The
+backgrounds and foregrounds are a lightness ramp tinted with the seed's hue,
+and the accents keep their usual roles (red, green, blue...) while being
+pulled towards the seed, which itself becomes the closest accent.
+
+Examples:
+  ppr theme from-color "#7AA2F7"
+  ppr theme from-color "#E4002B" --variant light --name acme-light`,
+	Args: cobra.ExactArgs(1),
+	RunE: runThemeFromColor,
+}
+
+var themeGenerateCmd = &cobra.Command{
+	Use:   "generate",
+	Short: "Generate a base16 theme from a color harmony",
+	Long: `Generate a base16 theme whose accents use the hues of a color harmony
+around a base color, on a dark or light background ramp tinted with it.
+Accents take the harmony hue closest to their usual role, and accents
+sharing a hue differ in lightness.
+
+Harmonies: complementary, analogous, triadic, tetradic
+
+Examples:
+  ppr theme generate --harmony triadic --base "#FF6600"
+  ppr theme generate --harmony analogous --base "#2E8B57" --variant light`,
+	Args: cobra.NoArgs,
+	RunE: runThemeGenerate,
+}
+
+var themePermuteCmd = &cobra.Command{
+	Use:   "permute <theme>",
+	Short: "Create variants of a theme with its accents rotated",
+	Long: `Create variants of a theme in which another accent becomes base0D, the
+slot most templates draw their dominant color from, with the remaining
+accents rotated along. One scheme so yields several differently tinted
+wallpapers. Each variant is saved as <theme>-<accent>, e.g. nord-red.
+
+Examples:
+  ppr theme permute nord
+  ppr theme permute gruvbox-dark --accent green,magenta`,
+	Args: cobra.ExactArgs(1),
+	RunE: runThemePermute,
+}
+
+var themeExportCmd = &cobra.Command{
+	Use:   "export [theme]",
+	Short: "Export a theme as a tmux, Neovim/Vim or terminal color scheme",
+	Long: `Export a theme, by default the active one with its warmth, as a config
+snippet for another program:
+  tmux      status line, pane and mode styles to source from tmux.conf
+  nvim      a colorscheme for Neovim and Vim (needs termguicolors)
+  osc       escape sequences that recolor the running terminal
+  hex-list  one "slot #RRGGBB" line per slot
+  json      the palette as a JSON object
+  css       the palette as --base00 custom properties
+
+The snippet is printed, or written to --output.
+
+Examples:
+  ppr theme export --format tmux -o ~/.config/tmux/ppr.conf
+  ppr theme export nord --format nvim -o ~/.config/nvim/colors/ppr.vim`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runThemeExport,
+}
+
+var themeCreditsCmd = &cobra.Command{
+	Use:   "credits [theme]...",
+	Short: "Print the authors, licenses and sources of the installed themes",
+	Long: `Print the attribution of the installed themes, or of the given ones:
+author, license and where each theme comes from, as recorded in the
+author, license and source_url fields of the theme files. Themes without a
+license are marked, check them before redistributing wallpapers made with
+them.
+
+Examples:
+  ppr theme credits
+  ppr theme credits nord gruvbox-dark
+  ppr theme credits --markdown > CREDITS.md`,
+	RunE: runThemeCredits,
+}
+
+var themeIDCmd = &cobra.Command{
+	Use:   "id [theme]...",
+	Short: "Print the palette IDs of themes",
+	Long: `Print the content-addressed ID of themes, a hash of their palette such
+as sha256-3f1c9a0e2b7d4c61. The ID is accepted anywhere a theme name is,
+also abbreviated to 8 hex digits, and pins the exact colors: renaming the
+file keeps the ID, editing a color changes it. Without arguments all
+installed themes are listed.
+
+Examples:
+  ppr theme id nord
+  ppr generate -t "$(ppr theme id nord)" -s shapes`,
+	RunE: runThemeID,
+}
+
+var themeCopyCmd = &cobra.Command{
+	Use:   "copy [theme]",
+	Short: "Copy a palette to the system clipboard",
+	Long: `Place a palette on the clipboard, by default the active theme with its
+warmth, for pasting into design tools. --format takes any 'ppr theme
+export' format and defaults to hex-list.
+
+The clipboard is written with pbcopy on macOS, Set-Clipboard on Windows,
+termux-clipboard-set on Termux and wl-copy, xclip or xsel elsewhere.
+
+Examples:
+  ppr theme copy
+  ppr theme copy nord --format css`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runThemeCopy,
+}
+
+var themeAnalyzeCmd = &cobra.Command{
+	Use:   "analyze <theme>",
+	Short: "Print a colorimetry report of a theme",
+	Long: `Measure a theme in OKLCh, the perceptual lightness, chroma and hue, and
+print:
+
+  - L, C, H and the WCAG contrast against base00 of every slot
+  - the background ramp base00..base07 (from base11 for base24), which
+    should brighten step by step for dark themes and darken for light ones
+  - the OKLab distance of each accent to its nearest neighbor, flagging
+    pairs that are hard to tell apart
+
+Examples:
+  ppr theme analyze nord`,
+	Args: cobra.ExactArgs(1),
+	RunE: runThemeAnalyze,
+}
+
+var (
+	fromColorVariant string
+	fromColorName    string
+	fromColorForce   bool
+
+	harmonyName    string
+	harmonyBase    string
+	harmonyVariant string
+	harmonyTheme   string
+	harmonyForce   bool
+
+	permuteAccents []string
+	permuteForce   bool
+
+	exportFormat string
+	exportOutput string
+
+	copyFormat string
+
+	creditsMarkdown bool
+)
+
+func init() {
+	themeFromColorCmd.Flags().StringVar(&fromColorVariant, "variant", "dark", "Theme variant (dark or light)")
+	themeFromColorCmd.Flags().StringVarP(&fromColorName, "name", "n", "", "Theme name (defaults to seed-<hex>-<variant>)")
+	themeFromColorCmd.Flags().BoolVarP(&fromColorForce, "force", "f", false, "Overwrite an existing theme with the same name")
+
+	themeGenerateCmd.Flags().StringVar(&harmonyName, "harmony", "complementary", "Color harmony: "+strings.Join(theme.Harmonies(), ", "))
+	themeGenerateCmd.Flags().StringVar(&harmonyBase, "base", "", "Base color of the harmony (e.g. #FF6600)")
+	themeGenerateCmd.Flags().StringVar(&harmonyVariant, "variant", "dark", "Theme variant (dark or light)")
+	themeGenerateCmd.Flags().StringVarP(&harmonyTheme, "name", "n", "", "Theme name (defaults to <harmony>-<hex>-<variant>)")
+	themeGenerateCmd.Flags().BoolVarP(&harmonyForce, "force", "f", false, "Overwrite an existing theme with the same name")
+	themeGenerateCmd.MarkFlagRequired("base")
+
+	themePermuteCmd.Flags().StringSliceVar(&permuteAccents, "accent", nil, "Accents to make dominant: "+strings.Join(theme.AccentNames, ", ")+" (default all but blue)")
+	themePermuteCmd.Flags().BoolVarP(&permuteForce, "force", "f", false, "Overwrite existing variants")
+
+	themeExportCmd.Flags().StringVar(&exportFormat, "format", "", "Export format: "+strings.Join(theme.ExportFormats(), ", "))
+	themeExportCmd.Flags().StringVarP(&exportOutput, "output", "o", "", "Write to this file instead of stdout")
+	themeExportCmd.MarkFlagRequired("format")
+	themeExportCmd.Flags().BoolVar(&highContrast, "high-contrast", false, "Export the palette remapped for maximum foreground/background contrast")
+
+	themeCopyCmd.Flags().StringVar(&copyFormat, "format", "hex-list", "Palette format: "+strings.Join(theme.ExportFormats(), ", "))
+	themeCopyCmd.Flags().BoolVar(&highContrast, "high-contrast", false, "Copy the palette remapped for maximum foreground/background contrast")
+
+	themeCreditsCmd.Flags().BoolVar(&creditsMarkdown, "markdown", false, "Print a Markdown list, e.g. for a CREDITS file")
+
+	themeCmd.AddCommand(themeFromColorCmd)
+	themeCmd.AddCommand(themeGenerateCmd)
+	themeCmd.AddCommand(themePermuteCmd)
+	themeCmd.AddCommand(themeExportCmd)
+	themeCmd.AddCommand(themeCopyCmd)
+	themeCmd.AddCommand(themeCreditsCmd)
+	themeCmd.AddCommand(themeIDCmd)
+	themeCmd.AddCommand(themeAnalyzeCmd)
+}
+
+func runThemeFromColor(cmd *cobra.Command, args []string) error {
+	name := fromColorName
+	if name == "" {
+		name = fmt.Sprintf("seed-%s-%s", strings.ToLower(strings.TrimPrefix(args[0], "#")), fromColorVariant)
+	}
+
+	newTheme, err := theme.FromColor(name, args[0], fromColorVariant)
+	if err != nil {
+		return err
+	}
+
+	return saveGeneratedTheme(newTheme, fromColorForce)
+}
+
+// saveGeneratedTheme writes a theme built by a theme subcommand and lists
+// its colors, refusing to replace an existing theme unless force is set
+func saveGeneratedTheme(newTheme *theme.Theme, force bool) error {
+	name := newTheme.Name
+
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	if err := cfg.EnsureDirectories(); err != nil {
+		return fmt.Errorf("failed to ensure directories: %w", err)
+	}
+
+	themeManager := newThemeManager(cfg)
+	if err := themeManager.LoadThemes(); err != nil {
+		return fmt.Errorf("failed to load themes: %w", err)
+	}
+	if _, err := themeManager.GetTheme(name); err == nil && !force {
+		return fmt.Errorf("theme %s already exists (use --force to overwrite it)", name)
+	}
+
+	if err := themeManager.SaveTheme(newTheme); err != nil {
+		return fmt.Errorf("failed to save theme: %w", err)
+	}
+
+	fmt.Printf("Created theme '%s'\n", name)
+	fmt.Printf("Theme saved to: %s/base16/%s.yaml\n", cfg.ThemesPath, name)
+
+	fmt.Println("\nColors:")
+	for _, key := range newTheme.PaletteKeys() {
+		fmt.Printf("  %s: %s\n", key, newTheme.Palette[key])
+	}
+
+	return nil
+}
+
+func runThemeGenerate(cmd *cobra.Command, args []string) error {
+	name := harmonyTheme
+	if name == "" {
+		name = fmt.Sprintf("%s-%s-%s", harmonyName, strings.ToLower(strings.TrimPrefix(harmonyBase, "#")), harmonyVariant)
+	}
+
+	newTheme, err := theme.FromHarmony(name, harmonyBase, harmonyName, harmonyVariant)
+	if err != nil {
+		return err
+	}
+
+	return saveGeneratedTheme(newTheme, harmonyForce)
+}
+
+func runThemePermute(cmd *cobra.Command, args []string) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	themeManager := newThemeManager(cfg)
+	if err := themeManager.LoadThemes(); err != nil {
+		return fmt.Errorf("failed to load themes: %w", err)
+	}
+	source, err := themeManager.GetTheme(args[0])
+	if err != nil {
+		return err
+	}
+
+	accents := permuteAccents
+	if len(accents) == 0 {
+		// base0D already is the blue accent
+		for _, accent := range theme.AccentNames {
+			if accent != "blue" {
+				accents = append(accents, accent)
+			}
+		}
+	}
+
+	var variants []*theme.Theme
+	for _, accent := range accents {
+		variant, err := source.Permute(fmt.Sprintf("%s-%s", args[0], accent), accent)
+		if err != nil {
+			return err
+		}
+		if _, err := themeManager.GetTheme(variant.Name); err == nil && !permuteForce {
+			return fmt.Errorf("theme %s already exists (use --force to overwrite it)", variant.Name)
+		}
+		variants = append(variants, variant)
+	}
+
+	for _, variant := range variants {
+		if err := themeManager.SaveTheme(variant); err != nil {
+			return fmt.Errorf("failed to save theme: %w", err)
+		}
+		fmt.Printf("Created theme '%s' (base0D %s)\n", variant.Name, variant.Palette["base0D"])
+	}
+
+	return nil
+}
+
+// exportTheme loads the theme named in args, or the active theme as it is
+// rendered, warmth included, and returns it with its name
+func exportTheme(cfg *config.Config, args []string) (string, *theme.Theme, error) {
+	name, kelvin := cfg.CurrentTheme, cfg.CurrentWarmth
+	if name == "" {
+		name = cfg.DefaultTheme
+	}
+	if len(args) > 0 {
+		name, kelvin = args[0], 0
+	}
+	t, name, err := loadRenderTheme(cfg, name, nil, kelvin)
+	return name, t, err
+}
+
+func runThemeExport(cmd *cobra.Command, args []string) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	name, selectedTheme, err := exportTheme(cfg, args)
+	if err != nil {
+		return err
+	}
+
+	snippet, err := selectedTheme.Export(exportFormat)
+	if err != nil {
+		return err
+	}
+
+	if exportOutput == "" {
+		fmt.Print(snippet)
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(exportOutput), 0755); err != nil {
+		return fmt.Errorf("failed to create output directory: %w", err)
+	}
+	if err := os.WriteFile(exportOutput, []byte(snippet), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", exportOutput, err)
+	}
+	fmt.Printf("Exported theme %s as %s to %s\n", name, exportFormat, exportOutput)
+	return nil
+}
+
+func runThemeCopy(cmd *cobra.Command, args []string) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	name, selectedTheme, err := exportTheme(cfg, args)
+	if err != nil {
+		return err
+	}
+	text, err := selectedTheme.Export(copyFormat)
+	if err != nil {
+		return err
+	}
+
+	tool, err := clipboard.Write(text)
+	if err != nil {
+		return fmt.Errorf("failed to copy to the clipboard: %w", err)
+	}
+	fmt.Printf("Copied theme %s as %s with %s\n", name, copyFormat, tool)
+	return nil
+}
+
+func runThemeCredits(cmd *cobra.Command, args []string) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	themeManager := newThemeManager(cfg)
+	if err := themeManager.LoadThemes(); err != nil {
+		return fmt.Errorf("failed to load themes: %w", err)
+	}
+
+	names := args
+	if len(names) == 0 {
+		names = themeManager.ListThemes()
+		sort.Strings(names)
+	}
+
+	unlicensed := 0
+	for _, name := range names {
+		t, err := themeManager.GetTheme(name)
+		if err != nil {
+			return err
+		}
+
+		author := t.Author
+		if author == "" {
+			author = "unknown author"
+		}
+		license := t.License
+		if license == "" {
+			license = "license unknown"
+			unlicensed++
+		}
+
+		switch {
+		case creditsMarkdown && t.SourceURL != "":
+			fmt.Printf("- [%s](%s) by %s, %s\n", name, t.SourceURL, author, license)
+		case creditsMarkdown:
+			fmt.Printf("- %s by %s, %s\n", name, author, license)
+		default:
+			fmt.Printf("%s by %s, %s\n", name, author, license)
+			if t.SourceURL != "" {
+				fmt.Printf("  %s\n", t.SourceURL)
+			}
+		}
+	}
+
+	if unlicensed > 0 && !creditsMarkdown {
+		fmt.Printf("\n%d of %d themes have no license, add one with license: in the theme file\n", unlicensed, len(names))
+	}
+	return nil
+}
+
+func runThemeID(cmd *cobra.Command, args []string) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	themeManager := newThemeManager(cfg)
+	if err := themeManager.LoadThemes(); err != nil {
+		return fmt.Errorf("failed to load themes: %w", err)
+	}
+
+	// A single ID prints bare, for use in scripts
+	if len(args) == 1 {
+		t, err := themeManager.GetTheme(args[0])
+		if err != nil {
+			return err
+		}
+		fmt.Println(t.ID())
+		return nil
+	}
+
+	names := args
+	if len(names) == 0 {
+		names = themeManager.ListThemes()
+		sort.Strings(names)
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for _, name := range names {
+		t, err := themeManager.GetTheme(name)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "%s\t%s\n", t.ID(), name)
+	}
+	return w.Flush()
+}
+
+func runThemeAnalyze(cmd *cobra.Command, args []string) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	themeManager := newThemeManager(cfg)
+	if err := themeManager.LoadThemes(); err != nil {
+		return fmt.Errorf("failed to load themes: %w", err)
+	}
+	t, err := themeManager.GetTheme(args[0])
+	if err != nil {
+		return err
+	}
+	analysis, err := t.Analyze()
+	if err != nil {
+		return err
+	}
+
+	variant := "light"
+	if analysis.Dark {
+		variant = "dark"
+	}
+	fmt.Printf("%s (%s, %s)\n\n", args[0], t.System, variant)
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "SLOT\tHEX\tL\tC\tH\tCONTRAST")
+	for _, slot := range analysis.Slots {
+		fmt.Fprintf(w, "%s\t%s\t%.3f\t%.3f\t%.1f\t%.2f\n", slot.Slot, slot.Hex, slot.L, slot.Chroma, slot.Hue, slot.Contrast)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	direction := "brighten"
+	if !analysis.Dark {
+		direction = "darken"
+	}
+	fmt.Printf("\nBackground ramp (each step should %s by at least %.2f):\n", direction, theme.MinRampStep)
+	w = tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for _, step := range analysis.Ramp {
+		note := ""
+		switch {
+		case step.Delta < 0:
+			note = "\treversed"
+		case step.Delta < theme.MinRampStep:
+			note = "\ttoo close"
+		}
+		fmt.Fprintf(w, "  %s -> %s\t%+.3f%s\n", step.From, step.To, step.Delta, note)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	if analysis.RampMonotonic() {
+		fmt.Println("Ramp is monotonic")
+	} else {
+		fmt.Println("Ramp is not monotonic")
+	}
+
+	fmt.Println("\nAccent spacing (OKLab distance to the nearest accent):")
+	w = tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	tooClose := 0
+	for _, slot := range analysis.Slots {
+		nearest, distance := analysis.NearestAccent(slot.Slot)
+		if nearest == "" {
+			continue
+		}
+		note := ""
+		if distance < theme.MinAccentDistance {
+			note = "\thard to tell apart"
+		}
+		fmt.Fprintf(w, "  %s\t%s\t%.3f%s\n", slot.Slot, nearest, distance, note)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	for _, pair := range analysis.Accents {
+		if pair.Distance < theme.MinAccentDistance {
+			tooClose++
+		}
+	}
+	if len(analysis.Accents) > 0 {
+		closest := analysis.Accents[0]
+		fmt.Printf("Closest pair %s/%s at %.3f, %d pairs below %.2f\n", closest.A, closest.B, closest.Distance, tooClose, theme.MinAccentDistance)
+	}
+	return nil
+}
@@ -0,0 +1,199 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/byteowlz/ppr/pkg/config"
+	"github.com/byteowlz/ppr/pkg/headless"
+	"github.com/byteowlz/ppr/pkg/pipeline"
+	"github.com/byteowlz/ppr/pkg/theme"
+	"github.com/spf13/cobra"
+)
+
+var timerCmd = &cobra.Command{
+	Use:   "timer <duration>",
+	Short: "Turn the wallpaper into an ambient countdown",
+	Long: `Render a template as the wallpaper and re-render it every interval
+until the duration is over, so the wallpaper shows a countdown or progress
+indicator. Besides the colors the template can use these placeholders:
+
+  {{progress}}   elapsed share of the duration, 0 to 1
+  {{percent}}    elapsed share in percent, 0 to 100
+  {{elapsed}}    elapsed time, e.g. 10m
+  {{remaining}}  remaining time, rounded up to the minute, e.g. 15m
+
+Numeric values can be scaled with {{name*N}}, e.g. stroke-dasharray=
+"{{progress*1131}} 1131" fills a ring with a circumference of 1131. The
+built-in progress-ring template does just that. Outside a timer templates
+fall back to defaults declared like <!-- ppr:value progress 1 -->.
+
+The timer leaves the current theme and template as they are, --restore
+renders them again at the end.
+
+Examples:
+  ppr timer 25m --template progress-ring
+  ppr timer 1h30m --template progress-ring --theme nord --interval 5m --restore`,
+	Args: cobra.ExactArgs(1),
+	RunE: runTimer,
+}
+
+var (
+	timerTemplate string
+	timerTheme    string
+	timerInterval time.Duration
+	timerRestore  bool
+)
+
+func init() {
+	timerCmd.Flags().StringVarP(&timerTemplate, "template", "s", "progress-ring", "Template with progress placeholders")
+	timerCmd.Flags().StringVarP(&timerTheme, "theme", "t", "", "Theme to use (defaults to the current theme)")
+	timerCmd.Flags().DurationVarP(&timerInterval, "interval", "i", time.Minute, "Time between renders")
+	timerCmd.Flags().BoolVar(&timerRestore, "restore", false, "Restore the previous wallpaper when the timer ends or is interrupted")
+	addRenderFlags(timerCmd)
+}
+
+func runTimer(cmd *cobra.Command, args []string) error {
+	total, err := time.ParseDuration(args[0])
+	if err != nil {
+		return fmt.Errorf("invalid duration %q: %w", args[0], err)
+	}
+	if total <= 0 {
+		return fmt.Errorf("duration must be positive")
+	}
+	if timerInterval <= 0 {
+		return fmt.Errorf("interval must be positive")
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	if err := cfg.EnsureDirectories(); err != nil {
+		return fmt.Errorf("failed to ensure directories: %w", err)
+	}
+
+	themeToUse := timerTheme
+	if themeToUse == "" {
+		themeToUse = cfg.CurrentTheme
+	}
+	if themeToUse == "" {
+		themeToUse = cfg.DefaultTheme
+	}
+	kelvin := 0
+	if timerTheme == "" {
+		kelvin = cfg.CurrentWarmth
+	}
+	selectedTheme, themeToUse, err := loadRenderTheme(cfg, themeToUse, nil, kelvin)
+	if err != nil {
+		return err
+	}
+
+	template := templateFile(cfg, timerTemplate)
+	if _, err := os.Stat(template); err != nil {
+		return fmt.Errorf("template %s not found (run 'ppr init' to install the built-in templates): %w", timerTemplate, err)
+	}
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	ticker := time.NewTicker(timerInterval)
+	defer ticker.Stop()
+
+	start := time.Now()
+	fmt.Printf("Timer running for %s, updating every %s\n", total, timerInterval)
+	for {
+		elapsed := time.Since(start)
+		if elapsed > total {
+			elapsed = total
+		}
+		if err := renderTimer(cmd, cfg, selectedTheme, themeToUse, template, elapsed, total); err != nil {
+			fmt.Printf("Warning: %v\n", err)
+		}
+		if elapsed == total {
+			fmt.Println("Timer done")
+			break
+		}
+
+		select {
+		case <-signals:
+			fmt.Println("Timer stopped")
+			return restoreAfterTimer(cfg)
+		case <-ticker.C:
+		case <-time.After(total - elapsed):
+		}
+	}
+	return restoreAfterTimer(cfg)
+}
+
+// renderTimer sets the wallpaper to the template filled with the progress
+// after elapsed of total, without saving it as the current one
+func renderTimer(cmd *cobra.Command, cfg *config.Config, selected *theme.Theme, themeToUse, template string, elapsed, total time.Duration) error {
+	res, err := targetResolution(cfg, "")
+	if err != nil {
+		return err
+	}
+
+	progress := float64(elapsed) / float64(total)
+	_, err = pipeline.Run(commandContext(cmd), pipeline.Options{
+		Theme:        selected,
+		ThemeName:    themeToUse,
+		TemplatePath: template,
+		AllowUnsafe:  allowUnsafe,
+		Locale:       renderLocale(cfg),
+		Values: map[string]string{
+			"progress":  strconv.FormatFloat(progress, 'f', 4, 64),
+			"percent":   strconv.Itoa(int(progress * 100)),
+			"elapsed":   timerClock(elapsed),
+			"remaining": timerClock((total - elapsed + time.Minute - 1).Truncate(time.Minute)),
+		},
+		OutputDir:     cfg.OutputPath,
+		Name:          variantBaseName(template, "", 0),
+		Resolution:    res,
+		Rasterizer:    cfg.Rasterizer,
+		FontsPath:     cfg.FontsPath,
+		AspectRatio:   aspectRatio,
+		StrokeScale:   strokeScale,
+		SafeArea:      renderSafeArea(cmd, cfg),
+		Overlays:      activeOverlays(cfg, headless.Now()),
+		Regenerate:    true,
+		RenderTimeout: configTimeout("render_timeout", cfg.RenderTimeout, defaultRenderTimeout),
+		Limits:        renderLimits(cfg),
+		SetWallpaper:  true,
+		Setter:        newWallpaperSetter(cfg),
+		CacheDir:      cfg.CacheDir,
+		Manifest:      newManifest(),
+		TemplatesDir:  cfg.TemplatesPath,
+	})
+	return err
+}
+
+// restoreAfterTimer renders the current theme and template again when
+// --restore is set
+func restoreAfterTimer(cfg *config.Config) error {
+	if !timerRestore {
+		return nil
+	}
+	if cfg.CurrentTemplate == "" {
+		fmt.Println("Warning: no previous wallpaper to restore")
+		return nil
+	}
+	restoreTheme := cfg.CurrentTheme
+	if restoreTheme == "" {
+		restoreTheme = cfg.DefaultTheme
+	}
+	fmt.Println("Restoring the previous wallpaper")
+	return switchContext(restoreTheme, cfg.CurrentTemplate, cfg.CurrentWarmth)
+}
+
+// timerClock formats d as whole minutes, e.g. 15m or 1h05m
+func timerClock(d time.Duration) string {
+	minutes := int(d / time.Minute)
+	if minutes < 60 {
+		return fmt.Sprintf("%dm", minutes)
+	}
+	return fmt.Sprintf("%dh%02dm", minutes/60, minutes%60)
+}
@@ -0,0 +1,86 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/byteowlz/ppr/pkg/update"
+	"github.com/spf13/cobra"
+)
+
+var upgradeCmd = &cobra.Command{
+	Use:   "upgrade",
+	Short: "Upgrade ppr to the latest GitHub release",
+	Long: `Check GitHub for a newer ppr release and replace the running binary
+with it. The archive for this platform is verified against the release's
+SHA-256 checksums before anything is installed, and the new binary is
+renamed into place so an interrupted upgrade leaves the old one intact.
+
+The stable channel follows full releases, the prerelease channel also
+picks up release candidates. Installs managed by a package manager should
+be upgraded through it instead.
+
+Examples:
+  ppr upgrade --check
+  ppr upgrade
+  ppr upgrade --channel prerelease`,
+	Args: cobra.NoArgs,
+	RunE: runUpgrade,
+}
+
+var (
+	upgradeCheck   bool
+	upgradeChannel string
+	upgradeForce   bool
+)
+
+func init() {
+	upgradeCmd.Flags().BoolVar(&upgradeCheck, "check", false, "Only report whether an upgrade is available")
+	upgradeCmd.Flags().StringVar(&upgradeChannel, "channel", update.ChannelStable, "Release channel (stable, prerelease)")
+	upgradeCmd.Flags().BoolVar(&upgradeForce, "force", false, "Install the latest release even if it is not newer")
+}
+
+func runUpgrade(cmd *cobra.Command, args []string) error {
+	release, err := update.Latest(upgradeChannel)
+	if err != nil {
+		return err
+	}
+
+	current := versionInfo.version
+	latest := release.Version()
+	devBuild := current == "" || current == "dev"
+
+	fmt.Printf("Current version: %s\n", current)
+	fmt.Printf("Latest %s release: %s\n", upgradeChannel, latest)
+
+	if !devBuild && update.CompareVersions(latest, current) <= 0 && !upgradeForce {
+		fmt.Println("ppr is up to date")
+		return nil
+	}
+
+	if upgradeCheck {
+		if devBuild {
+			fmt.Println("This is a development build, use 'ppr upgrade --force' to install the release")
+		} else {
+			fmt.Printf("Upgrade available: run 'ppr upgrade' to install %s\n", latest)
+		}
+		return nil
+	}
+
+	if devBuild && !upgradeForce {
+		return fmt.Errorf("refusing to replace a development build, use --force to install %s", latest)
+	}
+
+	fmt.Printf("Downloading %s...\n", update.ArchiveName(latest))
+	binary, err := release.Download()
+	if err != nil {
+		return err
+	}
+
+	path, err := update.ReplaceExecutable(binary)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Upgraded %s to %s\n", path, latest)
+	return nil
+}
@@ -0,0 +1,202 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/byteowlz/ppr/pkg/config"
+	"github.com/byteowlz/ppr/pkg/image"
+	"github.com/byteowlz/ppr/pkg/resolution"
+	"github.com/byteowlz/ppr/pkg/templates"
+	"github.com/spf13/cobra"
+)
+
+var verifyCmd = &cobra.Command{
+	Use:   "verify",
+	Short: "Compare rendered templates against golden PNGs",
+	Long: `Render a fixed set of templates and themes at a small size and compare the
+results against golden PNGs stored as <golden-dir>/<theme>/<template>.png.
+
+By default the built-in templates are verified. Use --templates-dir to verify
+a template pack instead. Run once with --update to (re)write the golden files.
+The command exits non-zero if any case fails, so it can be used in CI.
+
+Examples:
+  ppr verify --golden testdata/golden --themes nord --update
+  ppr verify --golden testdata/golden --themes nord,gruvbox-dark
+  ppr verify --golden golden --templates-dir ./my-pack --threshold 0.005`,
+	RunE: runVerify,
+}
+
+var (
+	verifyGoldenDir    string
+	verifyTemplatesDir string
+	verifyThemes       []string
+	verifyResolution   string
+	verifyThreshold    float64
+	verifyUpdate       bool
+)
+
+func init() {
+	verifyCmd.Flags().StringVar(&verifyGoldenDir, "golden", "", "Directory containing golden PNGs (required)")
+	verifyCmd.Flags().StringVar(&verifyTemplatesDir, "templates-dir", "", "Verify templates from this directory instead of the built-in set")
+	verifyCmd.Flags().StringSliceVar(&verifyThemes, "themes", []string{}, "Comma-separated list of themes (defaults to the default theme)")
+	verifyCmd.Flags().StringVarP(&verifyResolution, "resolution", "r", "320x180", "Render resolution")
+	verifyCmd.Flags().Float64Var(&verifyThreshold, "threshold", 0.01, "Maximum fraction of perceptibly different pixels")
+	verifyCmd.Flags().BoolVar(&verifyUpdate, "update", false, "Write rendered images as the new golden files")
+
+	verifyCmd.MarkFlagRequired("golden")
+	addRenderFlags(verifyCmd)
+}
+
+// verifyTemplate is a template under verification, read either from disk or
+// from the embedded template set
+type verifyTemplate struct {
+	name    string
+	content string
+}
+
+func runVerify(cmd *cobra.Command, args []string) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	res, err := resolution.ParseResolution(verifyResolution)
+	if err != nil {
+		return fmt.Errorf("failed to parse resolution: %w", err)
+	}
+
+	themeNames := verifyThemes
+	if len(themeNames) == 0 {
+		themeNames = []string{cfg.DefaultTheme}
+	}
+
+	themeManager := newThemeManager(cfg)
+	if err := themeManager.LoadThemes(); err != nil {
+		return fmt.Errorf("failed to load themes: %w", err)
+	}
+
+	templateSet, err := loadVerifyTemplates(verifyTemplatesDir)
+	if err != nil {
+		return err
+	}
+	if len(templateSet) == 0 {
+		return fmt.Errorf("no templates found to verify")
+	}
+
+	processor := newProcessor(cfg)
+	generator := image.NewGenerator()
+	generator.SetLimits(*renderLimits(cfg))
+
+	var passed, failed, updated int
+	for _, themeName := range themeNames {
+		selectedTheme, err := themeManager.GetTheme(themeName)
+		if err != nil {
+			return fmt.Errorf("failed to get theme: %w", err)
+		}
+
+		themeGoldenDir := filepath.Join(verifyGoldenDir, themeName)
+
+		for _, tmpl := range templateSet {
+			caseName := fmt.Sprintf("%s/%s", themeName, tmpl.name)
+			goldenPath := filepath.Join(themeGoldenDir, strings.TrimSuffix(tmpl.name, filepath.Ext(tmpl.name))+".png")
+
+			svgContent, err := processor.ProcessContent(tmpl.content, selectedTheme.Palette)
+			if err != nil {
+				fmt.Printf("FAIL   %s: failed to process template: %v\n", caseName, err)
+				failed++
+				continue
+			}
+
+			rendered, err := renderWithTimeout(cmd, cfg, generator, svgContent, res.Width, res.Height)
+			if err != nil {
+				fmt.Printf("FAIL   %s: failed to render: %v\n", caseName, err)
+				failed++
+				continue
+			}
+
+			if verifyUpdate {
+				if err := os.MkdirAll(filepath.Dir(goldenPath), 0755); err != nil {
+					return fmt.Errorf("failed to create golden directory: %w", err)
+				}
+				if err := image.WritePNG(rendered, goldenPath); err != nil {
+					return fmt.Errorf("failed to write golden file: %w", err)
+				}
+				fmt.Printf("UPDATE %s -> %s\n", caseName, goldenPath)
+				updated++
+				continue
+			}
+
+			golden, err := image.LoadPNG(goldenPath)
+			if err != nil {
+				fmt.Printf("FAIL   %s: %v\n", caseName, err)
+				failed++
+				continue
+			}
+
+			result, err := image.Compare(rendered, golden, image.DefaultPixelTolerance)
+			if err != nil {
+				fmt.Printf("FAIL   %s: %v\n", caseName, err)
+				failed++
+				continue
+			}
+
+			if result.DiffRatio() > verifyThreshold {
+				fmt.Printf("FAIL   %s: %.2f%% pixels differ (similarity %.4f)\n", caseName, result.DiffRatio()*100, result.Similarity)
+				failed++
+			} else {
+				fmt.Printf("PASS   %s (similarity %.4f)\n", caseName, result.Similarity)
+				passed++
+			}
+		}
+	}
+
+	if verifyUpdate {
+		fmt.Printf("\nUpdated %d golden files in %s\n", updated, verifyGoldenDir)
+		return nil
+	}
+
+	fmt.Printf("\nVerification completed: %d passed, %d failed\n", passed, failed)
+	if failed > 0 {
+		return fmt.Errorf("verification failed for %d cases", failed)
+	}
+
+	return nil
+}
+
+// loadVerifyTemplates returns the templates from dir, or the embedded
+// templates when dir is empty
+func loadVerifyTemplates(dir string) ([]verifyTemplate, error) {
+	var result []verifyTemplate
+
+	if dir == "" {
+		for _, name := range templates.Names() {
+			data, err := templates.Read(name)
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, verifyTemplate{name: name, content: string(data)})
+		}
+		return result, nil
+	}
+
+	names, err := findTemplates(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find templates: %w", err)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read template %s: %w", name, err)
+		}
+		result = append(result, verifyTemplate{name: name, content: string(data)})
+	}
+
+	return result, nil
+}
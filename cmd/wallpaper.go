@@ -3,6 +3,7 @@ package cmd
 import (
 	"fmt"
 
+	"github.com/byteowlz/ppr/pkg/config"
 	"github.com/byteowlz/ppr/pkg/wallpaper"
 	"github.com/spf13/cobra"
 )
@@ -10,19 +11,64 @@ import (
 var setWallpaperCmd = &cobra.Command{
 	Use:   "set-wallpaper [image-path]",
 	Short: "Set an image as wallpaper",
-	Long:  `Set the specified image as the desktop wallpaper. Works cross-platform.`,
-	Args:  cobra.ExactArgs(1),
-	RunE:  runSetWallpaper,
+	Long: `Set the specified image as the desktop wallpaper. Works cross-platform.
+
+On Hyprland, --monitor limits it to one output (see 'hyprctl monitors').`,
+	Args: cobra.ExactArgs(1),
+	RunE: runSetWallpaper,
+}
+
+var setWallpaperMonitor string
+
+func init() {
+	setWallpaperCmd.Flags().StringVarP(&setWallpaperMonitor, "monitor", "m", "", "Only set the wallpaper of this output (Hyprland)")
 }
 
 func runSetWallpaper(cmd *cobra.Command, args []string) error {
 	imagePath := args[0]
 
-	setter := wallpaper.NewSetter()
-	if err := setter.SetWallpaper(imagePath); err != nil {
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	setter := newWallpaperSetter(cfg)
+	if setWallpaperMonitor != "" {
+		err = setter.SetMonitorWallpaper(setWallpaperMonitor, imagePath)
+	} else {
+		err = setter.SetWallpaper(imagePath)
+	}
+	if err != nil {
 		return fmt.Errorf("failed to set wallpaper: %w", err)
 	}
 
 	fmt.Printf("✅ Wallpaper set successfully: %s\n", imagePath)
 	return nil
 }
+
+// newWallpaperSetter creates a setter with the [wallpaper] options from the
+// config, warning about and ignoring invalid values
+func newWallpaperSetter(cfg *config.Config) *wallpaper.Setter {
+	setter := wallpaper.NewSetter()
+	opts := wallpaper.Options{
+		GnomePictureOptions: cfg.Wallpaper.GnomePictureOptions,
+		FehMode:             cfg.Wallpaper.FehMode,
+		SwaybgMode:          cfg.Wallpaper.SwaybgMode,
+		WindowsStyle:        cfg.Wallpaper.WindowsStyle,
+		TermuxScreen:        cfg.Wallpaper.TermuxScreen,
+		HyprlandBackend:     cfg.Wallpaper.HyprlandBackend,
+		Verify:              cfg.Wallpaper.Verify,
+		BackendPriority:     cfg.Wallpaper.BackendPriority,
+		Lock: wallpaper.LockOptions{
+			Tool:       cfg.LockIntegration.Tool,
+			ImagePath:  cfg.LockIntegration.ImagePath,
+			Blur:       cfg.LockIntegration.Blur,
+			BlurRadius: cfg.LockIntegration.BlurRadius,
+		},
+		Timeout: configTimeout("setter_timeout", cfg.SetterTimeout, defaultSetterTimeout),
+	}
+	if err := setter.SetOptions(opts); err != nil {
+		fmt.Printf("Warning: ignoring [wallpaper] and [lock_integration] options: %v\n", err)
+	}
+	return setter
+}
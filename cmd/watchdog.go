@@ -0,0 +1,100 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"runtime"
+	"syscall"
+	"time"
+
+	"github.com/byteowlz/ppr/pkg/config"
+	"github.com/byteowlz/ppr/pkg/wallpaper"
+	"github.com/spf13/cobra"
+)
+
+const defaultWatchdogInterval = 5 * time.Second
+
+var watchdogCmd = &cobra.Command{
+	Use:   "watchdog",
+	Short: "Restart crashed wallpaper daemons and restore the wallpaper",
+	Long: `Supervise the process that keeps the wallpaper on screen with swww,
+hyprpaper or swaybg. When it exits, swww-daemon and hyprpaper are started
+again, and the last wallpaper is set again (which starts a new swaybg),
+instead of leaving an empty desktop until the next change.
+
+Supervision starts once the daemon was seen running. Start the watchdog
+from the compositor's autostart, e.g. in hyprland.conf:
+
+  exec-once = ppr watchdog
+
+'ppr dbus-service --watchdog' supervises the daemon as well.`,
+	Args: cobra.NoArgs,
+	RunE: runWatchdog,
+}
+
+var watchdogInterval time.Duration
+
+func init() {
+	watchdogCmd.Flags().DurationVar(&watchdogInterval, "interval", defaultWatchdogInterval, "Time between checks")
+}
+
+func runWatchdog(cmd *cobra.Command, args []string) error {
+	if runtime.GOOS == "windows" || runtime.GOOS == "darwin" {
+		return fmt.Errorf("the watchdog supervises swww, hyprpaper and swaybg, which only run on Linux and BSD")
+	}
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	stop := make(chan struct{})
+	go func() {
+		<-signals
+		close(stop)
+	}()
+
+	fmt.Printf("Watching the wallpaper daemon every %s\n", watchdogInterval)
+	return superviseSetter(watchdogInterval, stop)
+}
+
+// superviseSetter checks the wallpaper daemon every interval until stop is
+// closed, restoring the last wallpaper after a restart
+func superviseSetter(interval time.Duration, stop <-chan struct{}) error {
+	if interval <= 0 {
+		return fmt.Errorf("invalid watchdog interval %s", interval)
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	watchdog := wallpaper.NewWatchdog(newWallpaperSetter(cfg), func() string {
+		// The wallpaper may have changed since the watchdog started
+		if current, err := config.Load(); err == nil {
+			return current.LastOutputPath
+		}
+		return cfg.LastOutputPath
+	})
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	supervised := ""
+	for {
+		restarted, err := watchdog.Check()
+		if err != nil {
+			fmt.Printf("Warning: %v\n", err)
+		}
+		if name := watchdog.Daemon(); name != supervised {
+			supervised = name
+			fmt.Printf("Supervising %s\n", name)
+		}
+		if restarted {
+			fmt.Printf("%s exited, restarted it and restored the wallpaper\n", supervised)
+		}
+
+		select {
+		case <-stop:
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,162 @@
+package battery
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"github.com/byteowlz/ppr/pkg/platform"
+)
+
+// ErrNoBattery is returned on machines without a battery
+var ErrNoBattery = errors.New("no battery found")
+
+// Status is the charge of the battery and whether the machine runs on it
+type Status struct {
+	// Level is the charge in percent
+	Level int
+	// OnBattery is false while on AC power, charging or not
+	OnBattery bool
+}
+
+func (s Status) String() string {
+	power := "AC"
+	if s.OnBattery {
+		power = "battery"
+	}
+	return fmt.Sprintf("%d%% on %s", s.Level, power)
+}
+
+// Read returns the current battery status from sysfs on Linux,
+// termux-battery-status on Android, pmset on macOS and CIM on Windows
+func Read() (*Status, error) {
+	if platform.IsTermux() {
+		return readTermux()
+	}
+	switch runtime.GOOS {
+	case "darwin":
+		return readPmset()
+	case "windows":
+		return readWindows()
+	default:
+		return readSysfs("/sys/class/power_supply")
+	}
+}
+
+// readSysfs averages the capacity of all batteries. The machine is on AC
+// when a mains supply is online, or else when no battery discharges.
+func readSysfs(dir string) (*Status, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, ErrNoBattery
+		}
+		return nil, fmt.Errorf("failed to read power supplies: %w", err)
+	}
+
+	read := func(supply, name string) string {
+		data, _ := os.ReadFile(filepath.Join(dir, supply, name))
+		return strings.TrimSpace(string(data))
+	}
+
+	var total, batteries int
+	discharging, mains, online := false, false, false
+	for _, entry := range entries {
+		name := entry.Name()
+		switch read(name, "type") {
+		case "Battery":
+			// Peripheral batteries, like those of a wireless mouse, do not power the machine
+			if read(name, "scope") == "Device" {
+				continue
+			}
+			capacity, err := strconv.Atoi(read(name, "capacity"))
+			if err != nil {
+				continue
+			}
+			total += capacity
+			batteries++
+			if read(name, "status") == "Discharging" {
+				discharging = true
+			}
+		case "Mains", "USB", "USB_C":
+			mains = true
+			if read(name, "online") == "1" {
+				online = true
+			}
+		}
+	}
+	if batteries == 0 {
+		return nil, ErrNoBattery
+	}
+
+	onBattery := discharging
+	if mains {
+		onBattery = !online
+	}
+	return &Status{Level: total / batteries, OnBattery: onBattery}, nil
+}
+
+func readTermux() (*Status, error) {
+	output, err := exec.Command("termux-battery-status").Output()
+	if err != nil {
+		return nil, fmt.Errorf("termux-battery-status failed (is Termux:API installed?): %w", err)
+	}
+	var status struct {
+		Percentage int    `json:"percentage"`
+		Plugged    string `json:"plugged"`
+	}
+	if err := json.Unmarshal(output, &status); err != nil {
+		return nil, fmt.Errorf("failed to decode termux-battery-status output: %w", err)
+	}
+	return &Status{Level: status.Percentage, OnBattery: status.Plugged == "UNPLUGGED"}, nil
+}
+
+var pmsetLevelRegex = regexp.MustCompile(`(\d+)%`)
+
+// readPmset parses 'pmset -g batt', e.g.
+//
+//	Now drawing from 'Battery Power'
+//	 -InternalBattery-0 (id=1234)	85%; discharging; 4:10 remaining present: true
+func readPmset() (*Status, error) {
+	output, err := exec.Command("pmset", "-g", "batt").Output()
+	if err != nil {
+		return nil, fmt.Errorf("pmset failed: %w", err)
+	}
+	text := string(output)
+	if !strings.Contains(text, "InternalBattery") {
+		return nil, ErrNoBattery
+	}
+	match := pmsetLevelRegex.FindStringSubmatch(text)
+	if match == nil {
+		return nil, fmt.Errorf("no battery level in pmset output")
+	}
+	level, _ := strconv.Atoi(match[1])
+	return &Status{Level: level, OnBattery: strings.Contains(text, "'Battery Power'")}, nil
+}
+
+func readWindows() (*Status, error) {
+	output, err := exec.Command("powershell", "-NoProfile", "-Command",
+		"Get-CimInstance Win32_Battery | Select-Object -First 1 EstimatedChargeRemaining,BatteryStatus | ConvertTo-Json").Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to query battery: %w", err)
+	}
+	if strings.TrimSpace(string(output)) == "" {
+		return nil, ErrNoBattery
+	}
+	var battery struct {
+		EstimatedChargeRemaining int
+		BatteryStatus            int
+	}
+	if err := json.Unmarshal(output, &battery); err != nil {
+		return nil, fmt.Errorf("failed to decode battery status: %w", err)
+	}
+	// BatteryStatus 1 is "discharging", all other states have AC power
+	return &Status{Level: battery.EstimatedChargeRemaining, OnBattery: battery.BatteryStatus == 1}, nil
+}
@@ -0,0 +1,206 @@
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// DefaultGracePeriod is how long an unreferenced entry is kept, so a desktop
+// still fading out the previous wallpaper can finish reading it
+const DefaultGracePeriod = time.Hour
+
+const indexName = "refs.json"
+
+// Cache hands desktops content-addressed copies of wallpapers. The name is
+// unique per image, which defeats desktops that ignore a set when the path
+// is unchanged (macOS, GNOME), while identical images share one file.
+// Entries are reference counted by holder, such as "desktop" or a monitor,
+// and removed once unreferenced for GracePeriod.
+type Cache struct {
+	Dir         string
+	GracePeriod time.Duration
+}
+
+// index maps each holder to the entry it currently uses
+type index struct {
+	Refs map[string]string `json:"refs"`
+}
+
+// DefaultDir is the cache location when no cache_dir is configured
+func DefaultDir() string {
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		cacheDir = os.TempDir()
+	}
+	return filepath.Join(cacheDir, "ppr", "wallpapers")
+}
+
+// New creates a cache in dir, or in DefaultDir when dir is empty
+func New(dir string) *Cache {
+	if dir == "" {
+		dir = DefaultDir()
+	}
+	return &Cache{Dir: dir, GracePeriod: DefaultGracePeriod}
+}
+
+// Acquire copies src into the cache unless an identical image is already
+// there, points holder at it and returns its path. The holder's previous
+// entry is released and expired entries are cleaned up.
+func (c *Cache) Acquire(holder, src string) (string, error) {
+	if err := os.MkdirAll(c.Dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create cache directory: %w", err)
+	}
+
+	name, err := entryName(src)
+	if err != nil {
+		return "", err
+	}
+	path := filepath.Join(c.Dir, name)
+
+	now := time.Now()
+	if _, err := os.Stat(path); err != nil {
+		if err := copyAtomic(src, path); err != nil {
+			return "", err
+		}
+	} else {
+		// The modification time records the last use
+		os.Chtimes(path, now, now)
+	}
+
+	idx := c.load()
+	if previous := idx.Refs[holder]; previous != "" && previous != name {
+		// The grace period starts when the entry is released
+		os.Chtimes(filepath.Join(c.Dir, previous), now, now)
+	}
+	idx.Refs[holder] = name
+	if err := c.save(idx); err != nil {
+		return "", err
+	}
+
+	c.cleanup(idx)
+	return path, nil
+}
+
+// Cleanup removes unreferenced entries older than the grace period and
+// returns how many were removed
+func (c *Cache) Cleanup() int {
+	return c.cleanup(c.load())
+}
+
+// Expired lists the entries Cleanup would remove
+func (c *Cache) Expired() []string {
+	return c.expired(c.load())
+}
+
+func (c *Cache) cleanup(idx index) int {
+	removed := 0
+	for _, path := range c.expired(idx) {
+		if os.Remove(path) == nil {
+			removed++
+		}
+	}
+	return removed
+}
+
+func (c *Cache) expired(idx index) []string {
+	entries, err := os.ReadDir(c.Dir)
+	if err != nil {
+		return nil
+	}
+
+	referenced := make(map[string]bool, len(idx.Refs))
+	for _, name := range idx.Refs {
+		referenced[name] = true
+	}
+
+	var expired []string
+	for _, entry := range entries {
+		if entry.IsDir() || entry.Name() == indexName || referenced[entry.Name()] {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil || time.Since(info.ModTime()) < c.GracePeriod {
+			continue
+		}
+		expired = append(expired, filepath.Join(c.Dir, entry.Name()))
+	}
+	return expired
+}
+
+func (c *Cache) load() index {
+	idx := index{Refs: make(map[string]string)}
+	if data, err := os.ReadFile(filepath.Join(c.Dir, indexName)); err == nil {
+		json.Unmarshal(data, &idx)
+	}
+	if idx.Refs == nil {
+		idx.Refs = make(map[string]string)
+	}
+	return idx
+}
+
+func (c *Cache) save(idx index) error {
+	data, err := json.MarshalIndent(idx, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode cache index: %w", err)
+	}
+	path := filepath.Join(c.Dir, indexName)
+	if err := os.WriteFile(path+".tmp", data, 0644); err != nil {
+		return fmt.Errorf("failed to write cache index: %w", err)
+	}
+	if err := os.Rename(path+".tmp", path); err != nil {
+		return fmt.Errorf("failed to write cache index: %w", err)
+	}
+	return nil
+}
+
+// entryName is the first 16 hex digits of the file's SHA-256 plus its
+// extension
+func entryName(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to open wallpaper: %w", err)
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", fmt.Errorf("failed to hash wallpaper: %w", err)
+	}
+	return hex.EncodeToString(h.Sum(nil))[:16] + filepath.Ext(path), nil
+}
+
+// copyAtomic copies src next to dst and renames it into place, so a desktop
+// never reads a partial file
+func copyAtomic(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return fmt.Errorf("failed to open wallpaper: %w", err)
+	}
+	defer in.Close()
+
+	tmp := dst + ".tmp"
+	out, err := os.Create(tmp)
+	if err != nil {
+		return fmt.Errorf("failed to create cache entry: %w", err)
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		os.Remove(tmp)
+		return fmt.Errorf("failed to copy wallpaper: %w", err)
+	}
+	if err := out.Close(); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to copy wallpaper: %w", err)
+	}
+	if err := os.Rename(tmp, dst); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to store cache entry: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+package clipboard
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	"github.com/byteowlz/ppr/pkg/platform"
+)
+
+// ErrUnavailable is returned when no clipboard tool is installed
+var ErrUnavailable = errors.New("no clipboard tool found (install wl-clipboard, xclip or xsel)")
+
+// tool is a command that reads the clipboard content from stdin
+type tool struct {
+	name string
+	args []string
+}
+
+// tools lists the clipboard commands to try, in order: pbcopy on macOS,
+// PowerShell's Set-Clipboard on Windows, termux-clipboard-set on Termux,
+// wl-copy on Wayland and xclip or xsel on X11
+func tools() []tool {
+	switch runtime.GOOS {
+	case "darwin":
+		return []tool{{name: "pbcopy"}}
+	case "windows":
+		// clip.exe mangles non-ASCII text, Set-Clipboard keeps it
+		return []tool{{name: "powershell", args: []string{"-NoProfile", "-Command", "Set-Clipboard -Value ([Console]::In.ReadToEnd())"}}}
+	}
+
+	var candidates []tool
+	if platform.IsTermux() {
+		candidates = append(candidates, tool{name: "termux-clipboard-set"})
+	}
+	if os.Getenv("WAYLAND_DISPLAY") != "" {
+		candidates = append(candidates, tool{name: "wl-copy"})
+	}
+	return append(candidates,
+		tool{name: "xclip", args: []string{"-selection", "clipboard"}},
+		tool{name: "xsel", args: []string{"--clipboard", "--input"}},
+	)
+}
+
+// Write places text on the system clipboard with the first available tool
+// and returns its name
+func Write(text string) (string, error) {
+	for _, t := range tools() {
+		if _, err := exec.LookPath(t.name); err != nil {
+			continue
+		}
+		cmd := exec.Command(t.name, t.args...)
+		cmd.Stdin = strings.NewReader(text)
+		if output, err := cmd.CombinedOutput(); err != nil {
+			return "", fmt.Errorf("%s failed: %w: %s", t.name, err, strings.TrimSpace(string(output)))
+		}
+		return t.name, nil
+	}
+	return "", ErrUnavailable
+}
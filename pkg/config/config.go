@@ -10,18 +10,157 @@ import (
 )
 
 type Config struct {
-	ThemesPath         string   `toml:"themes_path"`
-	TemplatesPath      string   `toml:"templates_path"`
-	OutputPath         string   `toml:"output_path"`
-	DefaultTheme       string   `toml:"default_theme"`
-	DefaultTemplate    string   `toml:"default_template"`
-	DefaultWidth       int      `toml:"default_width"`
-	DefaultHeight      int      `toml:"default_height"`
-	AutoSetWallpaper   bool     `toml:"auto_set_wallpaper"`
-	CurrentTheme       string   `toml:"current_theme"`
-	CurrentTemplate    string   `toml:"current_template"`
-	LastOutputPath     string   `toml:"last_output_path"`
-	PreferredTemplates []string `toml:"preferred_templates"`
+	ThemesPath         string              `toml:"themes_path"`
+	TemplatesPath      string              `toml:"templates_path"`
+	OutputPath         string              `toml:"output_path"`
+	DefaultTheme       string              `toml:"default_theme"`
+	FallbackTheme      string              `toml:"fallback_theme"`
+	DefaultTemplate    string              `toml:"default_template"`
+	DefaultWidth       int                 `toml:"default_width"`
+	DefaultHeight      int                 `toml:"default_height"`
+	AutoSetWallpaper   bool                `toml:"auto_set_wallpaper"`
+	CurrentTheme       string              `toml:"current_theme"`
+	CurrentTemplate    string              `toml:"current_template"`
+	CurrentWarmth      int                 `toml:"current_warmth,omitzero"`
+	LastOutputPath     string              `toml:"last_output_path"`
+	PreferredTemplates []string            `toml:"preferred_templates"`
+	TemplateGroups     map[string][]string `toml:"template_groups,omitempty"`
+	SSHThemes          map[string]string   `toml:"ssh_themes,omitempty"`
+	Rasterizer         string              `toml:"rasterizer"`
+	FontsPath          string              `toml:"fonts_path"`
+	CacheDir           string              `toml:"cache_dir"`
+	ColorSpace         string              `toml:"color_space"`
+	Locale             string              `toml:"locale"`
+	RenderTimeout      string              `toml:"render_timeout"`
+	SetterTimeout      string              `toml:"setter_timeout"`
+	RenderStats        bool                `toml:"render_stats"`
+	OptimizeOutput     bool                `toml:"optimize_output"`
+	Wallpaper          WallpaperConfig     `toml:"wallpaper"`
+	LockIntegration    LockConfig          `toml:"lock_integration"`
+	Weather            WeatherConfig       `toml:"weather"`
+	Limits             LimitsConfig        `toml:"limits"`
+	Hooks              HooksConfig         `toml:"hooks"`
+	Focus              FocusConfig         `toml:"focus"`
+	SafeArea           SafeAreaConfig      `toml:"safe_area"`
+	Schedule           []ScheduleRule      `toml:"schedule,omitempty"`
+	Presets            map[string]Preset   `toml:"presets,omitempty"`
+	Overlays           []Overlay           `toml:"overlays,omitempty"`
+}
+
+// WallpaperConfig holds backend-specific options applied whenever a
+// wallpaper is set. Empty values keep the backend defaults.
+type WallpaperConfig struct {
+	GnomePictureOptions string `toml:"gnome_picture_options"`
+	FehMode             string `toml:"feh_mode"`
+	SwaybgMode          string `toml:"swaybg_mode"`
+	WindowsStyle        string `toml:"windows_style"`
+	TermuxScreen        string `toml:"termux_screen"`
+	HyprlandBackend     string `toml:"hyprland_backend"`
+	Verify              string `toml:"verify"`
+	// BackendPriority is the order backends are tried in, e.g. portal,
+	// gsettings, feh
+	BackendPriority []string `toml:"backend_priority"`
+}
+
+// LockConfig mirrors each new wallpaper onto a screen locker. Tool is
+// betterlockscreen, hyprlock, swaylock or i3lock; the last three read the
+// image written to ImagePath.
+type LockConfig struct {
+	Tool       string `toml:"tool"`
+	ImagePath  string `toml:"image_path"`
+	Blur       bool   `toml:"blur"`
+	BlurRadius int    `toml:"blur_radius"`
+}
+
+// WeatherConfig selects the provider behind weather schedule rules.
+// Provider is "open-meteo" (needs latitude and longitude) or "command",
+// which runs Command and reads the condition it prints.
+type WeatherConfig struct {
+	Provider  string  `toml:"provider"`
+	Latitude  float64 `toml:"latitude"`
+	Longitude float64 `toml:"longitude"`
+	Command   string  `toml:"command"`
+	Refresh   string  `toml:"refresh"`
+}
+
+// LimitsConfig bounds every render. Sizes read like "32MB", the resolution
+// like "16384x16384"; empty values keep the defaults and "0" lifts a limit.
+type LimitsConfig struct {
+	MaxSVGSize    string `toml:"max_svg_size"`
+	MaxResolution string `toml:"max_resolution"`
+	MaxMemory     string `toml:"max_memory"`
+}
+
+// HooksConfig lists built-in presets ("gtk-css", "qt5ct") that write the
+// active theme to toolkit config files every time the wallpaper is set
+type HooksConfig struct {
+	Apply []string `toml:"apply,omitempty"`
+}
+
+// FocusConfig is the calmer setup 'ppr focus' switches to while a macOS
+// Focus mode or GNOME Do Not Disturb is on. Empty values keep the current
+// theme or template.
+type FocusConfig struct {
+	Theme    string `toml:"theme"`
+	Template string `toml:"template"`
+	Interval string `toml:"interval"`
+}
+
+// SafeAreaConfig reserves the screen edges behind a menu bar and a dock,
+// in pixels, see 'ppr generate --safe-area'. Dock is bottom, left or right;
+// Mode is shift or scale. Always applies the safe area to every render.
+type SafeAreaConfig struct {
+	MenuBar  int    `toml:"menu_bar,omitzero"`
+	Dock     string `toml:"dock"`
+	DockSize int    `toml:"dock_size,omitzero"`
+	Mode     string `toml:"mode"`
+	Always   bool   `toml:"always"`
+}
+
+// ScheduleRule selects a theme and/or template (or template group) for a time of day, weekday,
+// month, season, weather condition or battery state, see 'ppr apply-schedule'. The first
+// matching rule wins.
+type ScheduleRule struct {
+	From     string   `toml:"from,omitempty"`
+	To       string   `toml:"to,omitempty"`
+	Weekdays []string `toml:"weekdays,omitempty"`
+	Months   []string `toml:"months,omitempty"`
+	Seasons  []string `toml:"seasons,omitempty"`
+	Weather  []string `toml:"weather,omitempty"`
+	// BatteryBelow is a charge in percent, Power is "battery" or "ac"
+	BatteryBelow int    `toml:"battery_below,omitzero"`
+	Power        string `toml:"power,omitempty"`
+
+	Theme    string `toml:"theme,omitempty"`
+	Template string `toml:"template,omitempty"`
+	Group    string `toml:"group,omitempty"`
+	Warmth   int    `toml:"warmth,omitzero"`
+}
+
+// Overlay composites a template onto every wallpaper rendered from From to
+// To, yearly MM-DD dates, e.g. snowflakes in December, see 'ppr overlay'.
+// Opacity is 0-1, 1 when unset; Blend is a blend mode of 'ppr compose'.
+type Overlay struct {
+	Name     string  `toml:"name"`
+	Template string  `toml:"template"`
+	From     string  `toml:"from"`
+	To       string  `toml:"to"`
+	Opacity  float64 `toml:"opacity,omitzero"`
+	Blend    string  `toml:"blend,omitempty"`
+	Disabled bool    `toml:"disabled,omitempty"`
+}
+
+// Preset bundles render options under a name, selected with --preset.
+// Flags given on the command line override the preset.
+type Preset struct {
+	Resolution string `toml:"resolution,omitempty"`
+	Format     string `toml:"format,omitempty"`
+	Font       string `toml:"font,omitempty"`
+	Warmth     int    `toml:"warmth,omitzero"`
+	// Brightness scales the palette lightness by -100..100 percent
+	Brightness int `toml:"brightness,omitzero"`
+	// Background replaces base00 with another palette slot or a hex color
+	Background string `toml:"background,omitempty"`
 }
 
 func DefaultConfig() *Config {
@@ -31,7 +170,7 @@ func DefaultConfig() *Config {
 		TemplatesPath:      filepath.Join(homeDir, ".config", "ppr", "templates"),
 		OutputPath:         filepath.Join(homeDir, "Pictures", "ppr"),
 		DefaultTheme:       "nord",
-		DefaultTemplate:    "geometric-simple.svg",
+		DefaultTemplate:    "shapes.svg",
 		DefaultWidth:       1920,
 		DefaultHeight:      1080,
 		AutoSetWallpaper:   false,
@@ -39,6 +178,10 @@ func DefaultConfig() *Config {
 		CurrentTemplate:    "",
 		LastOutputPath:     "",
 		PreferredTemplates: []string{"all"},
+		Rasterizer:         "auto",
+		FontsPath:          filepath.Join(homeDir, ".config", "ppr", "fonts"),
+		RenderTimeout:      "5m",
+		SetterTimeout:      "1m",
 	}
 }
 
@@ -52,6 +195,11 @@ func GetConfigDir() string {
 	return filepath.Join(homeDir, ".config", "ppr")
 }
 
+// GetStatePath is the usage state file next to config.toml, see pkg/state
+func GetStatePath() string {
+	return filepath.Join(GetConfigDir(), "state.json")
+}
+
 func Load() (*Config, error) {
 	configPath := GetConfigPath()
 
@@ -66,9 +214,12 @@ func Load() (*Config, error) {
 	}
 
 	// Expand tilde in paths
-	config.ThemesPath = expandPath(config.ThemesPath)
-	config.TemplatesPath = expandPath(config.TemplatesPath)
-	config.OutputPath = expandPath(config.OutputPath)
+	config.ThemesPath = ExpandPath(config.ThemesPath)
+	config.TemplatesPath = ExpandPath(config.TemplatesPath)
+	config.OutputPath = ExpandPath(config.OutputPath)
+	config.FontsPath = ExpandPath(config.FontsPath)
+	config.CacheDir = ExpandPath(config.CacheDir)
+	config.LockIntegration.ImagePath = ExpandPath(config.LockIntegration.ImagePath)
 
 	return &config, nil
 }
@@ -106,7 +257,8 @@ func (c *Config) EnsureDirectories() error {
 	return nil
 }
 
-func expandPath(path string) string {
+// ExpandPath replaces a leading ~/ with the home directory
+func ExpandPath(path string) string {
 	if strings.HasPrefix(path, "~/") {
 		homeDir, _ := os.UserHomeDir()
 		return filepath.Join(homeDir, path[2:])
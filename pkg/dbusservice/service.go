@@ -0,0 +1,206 @@
+package dbusservice
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/godbus/dbus/v5/introspect"
+	"github.com/godbus/dbus/v5/prop"
+)
+
+// Bus names of the ppr service
+const (
+	BusName    = "dev.byteowlz.ppr"
+	ObjectPath = dbus.ObjectPath("/dev/byteowlz/ppr")
+	Interface  = "dev.byteowlz.ppr"
+)
+
+// State is what the service publishes as properties
+type State struct {
+	CurrentTheme    string
+	CurrentTemplate string
+	Wallpaper       string
+}
+
+// Handler carries out the service's methods
+type Handler interface {
+	Cycle() error
+	SetTheme(name string) error
+	SetTemplate(name string) error
+	State() State
+}
+
+// Service exports a Handler on the session bus
+type Service struct {
+	conn    *dbus.Conn
+	handler Handler
+	props   *prop.Properties
+	// Methods run one at a time since each rewrites current.png and the config
+	mu sync.Mutex
+}
+
+// exported holds the methods visible over D-Bus, so Service's own methods
+// stay out of the interface
+type exported struct {
+	service *Service
+}
+
+// New connects to the session bus and claims BusName
+func New(handler Handler) (*Service, error) {
+	conn, err := dbus.ConnectSessionBus()
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to session bus: %w", err)
+	}
+
+	s := &Service{conn: conn, handler: handler}
+	if err := s.export(); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	reply, err := conn.RequestName(BusName, dbus.NameFlagDoNotQueue)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to request bus name %s: %w", BusName, err)
+	}
+	if reply != dbus.RequestNameReplyPrimaryOwner {
+		conn.Close()
+		return nil, fmt.Errorf("bus name %s is already taken, is another ppr service running?", BusName)
+	}
+	return s, nil
+}
+
+func (s *Service) export() error {
+	state := s.handler.State()
+	props, err := prop.Export(s.conn, ObjectPath, prop.Map{
+		Interface: {
+			"CurrentTheme":    {Value: state.CurrentTheme, Emit: prop.EmitTrue},
+			"CurrentTemplate": {Value: state.CurrentTemplate, Emit: prop.EmitTrue},
+			"Wallpaper":       {Value: state.Wallpaper, Emit: prop.EmitTrue},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to export properties: %w", err)
+	}
+	s.props = props
+
+	methods := exported{service: s}
+	if err := s.conn.Export(methods, ObjectPath, Interface); err != nil {
+		return fmt.Errorf("failed to export methods: %w", err)
+	}
+
+	// Reflection only yields positional names, every method takes a name
+	introspected := introspect.Methods(methods)
+	for i := range introspected {
+		for j := range introspected[i].Args {
+			if introspected[i].Args[j].Direction == "in" {
+				introspected[i].Args[j].Name = "name"
+			}
+		}
+	}
+
+	node := &introspect.Node{
+		Name: string(ObjectPath),
+		Interfaces: []introspect.Interface{
+			introspect.IntrospectData,
+			prop.IntrospectData,
+			{
+				Name:       Interface,
+				Methods:    introspected,
+				Properties: props.Introspection(Interface),
+			},
+		},
+	}
+	if err := s.conn.Export(introspect.NewIntrospectable(node), ObjectPath, "org.freedesktop.DBus.Introspectable"); err != nil {
+		return fmt.Errorf("failed to export introspection: %w", err)
+	}
+	return nil
+}
+
+// Wait blocks until the bus connection closes
+func (s *Service) Wait() {
+	<-s.conn.Context().Done()
+}
+
+// Close releases the bus name and disconnects
+func (s *Service) Close() error {
+	s.conn.ReleaseName(BusName)
+	return s.conn.Close()
+}
+
+// run calls fn and publishes the state it leaves behind. Changed properties
+// emit PropertiesChanged.
+func (s *Service) run(fn func() error) *dbus.Error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	err := fn()
+	state := s.handler.State()
+	s.update("CurrentTheme", state.CurrentTheme)
+	s.update("CurrentTemplate", state.CurrentTemplate)
+	s.update("Wallpaper", state.Wallpaper)
+
+	if err != nil {
+		return dbus.NewError(Interface+".Error.Failed", []interface{}{err.Error()})
+	}
+	return nil
+}
+
+func (s *Service) update(name, value string) {
+	if current, ok := s.props.GetMust(Interface, name).(string); ok && current == value {
+		return
+	}
+	s.props.SetMust(Interface, name, value)
+}
+
+// Cycle moves to the next preferred template
+func (e exported) Cycle() *dbus.Error {
+	return e.service.run(e.service.handler.Cycle)
+}
+
+// SetTheme switches the current template to theme name
+func (e exported) SetTheme(name string) *dbus.Error {
+	return e.service.run(func() error { return e.service.handler.SetTheme(name) })
+}
+
+// SetTemplate renders template name with the current theme
+func (e exported) SetTemplate(name string) *dbus.Error {
+	return e.service.run(func() error { return e.service.handler.SetTemplate(name) })
+}
+
+// InstallActivationFile writes a session bus service file so the bus
+// starts execPath with args when a client first calls BusName
+func InstallActivationFile(execPath string, args ...string) (string, error) {
+	dataHome := os.Getenv("XDG_DATA_HOME")
+	if dataHome == "" {
+		homeDir, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("failed to find home directory: %w", err)
+		}
+		dataHome = filepath.Join(homeDir, ".local", "share")
+	}
+
+	dir := filepath.Join(dataHome, "dbus-1", "services")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create %s: %w", dir, err)
+	}
+
+	words := append([]string{execPath}, args...)
+	for i, word := range words {
+		if strings.ContainsAny(word, " \t\"'\\") {
+			words[i] = "\"" + strings.NewReplacer("\\", "\\\\", "\"", "\\\"").Replace(word) + "\""
+		}
+	}
+	execLine := strings.Join(words, " ")
+	content := fmt.Sprintf("[D-BUS Service]\nName=%s\nExec=%s\n", BusName, execLine)
+
+	path := filepath.Join(dir, BusName+".service")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		return "", fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return path, nil
+}
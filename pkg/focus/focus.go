@@ -0,0 +1,71 @@
+package focus
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// ErrUnsupported is returned where no Focus or Do Not Disturb state can be read
+var ErrUnsupported = errors.New("focus detection is only supported on macOS and GNOME")
+
+// Active reports whether a macOS Focus mode or GNOME Do Not Disturb is on
+func Active() (bool, error) {
+	switch runtime.GOOS {
+	case "darwin":
+		return macOSFocus()
+	case "windows":
+		return false, ErrUnsupported
+	default:
+		return gnomeDoNotDisturb()
+	}
+}
+
+// macOSFocus reads the Focus assertions of macOS 12 and later, which list
+// the modes turned on by hand or by a schedule
+func macOSFocus() (bool, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return false, err
+	}
+	data, err := os.ReadFile(filepath.Join(homeDir, "Library", "DoNotDisturb", "DB", "Assertions.json"))
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to read Focus state (does the terminal have Full Disk Access?): %w", err)
+	}
+
+	var assertions struct {
+		Data []struct {
+			StoreAssertionRecords []json.RawMessage `json:"storeAssertionRecords"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(data, &assertions); err != nil {
+		return false, fmt.Errorf("failed to decode Focus state: %w", err)
+	}
+	for _, entry := range assertions.Data {
+		if len(entry.StoreAssertionRecords) > 0 {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// gnomeDoNotDisturb reads GNOME's Do Not Disturb switch, which hides
+// notification banners
+func gnomeDoNotDisturb() (bool, error) {
+	if _, err := exec.LookPath("gsettings"); err != nil {
+		return false, ErrUnsupported
+	}
+	output, err := exec.Command("gsettings", "get", "org.gnome.desktop.notifications", "show-banners").Output()
+	if err != nil {
+		return false, fmt.Errorf("failed to read Do Not Disturb state: %w", err)
+	}
+	return strings.TrimSpace(string(output)) == "false", nil
+}
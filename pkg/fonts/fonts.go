@@ -0,0 +1,249 @@
+package fonts
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+
+	"github.com/byteowlz/ppr/pkg/headless"
+	"golang.org/x/image/font/gofont/gomono"
+	"golang.org/x/image/font/gofont/goregular"
+	"golang.org/x/image/font/sfnt"
+)
+
+// Generic CSS font families and the installed families tried for them
+var genericFamilies = map[string][]string{
+	"sans-serif": {"DejaVu Sans", "Liberation Sans", "Noto Sans", "Helvetica", "Arial", "Segoe UI"},
+	"serif":      {"DejaVu Serif", "Liberation Serif", "Noto Serif", "Times New Roman", "Georgia"},
+	"monospace":  {"DejaVu Sans Mono", "Liberation Mono", "Noto Sans Mono", "Menlo", "Consolas", "Courier New"},
+}
+
+// Resolver finds font files by family name in the system font directories
+// and any extra directories, falling back to the embedded Go fonts
+type Resolver struct {
+	dirs []string
+
+	once  sync.Once
+	index map[string]string
+	cache map[string]*sfnt.Font
+	mu    sync.Mutex
+}
+
+// NewResolver creates a resolver searching extraDirs before the system font
+// directories, which are skipped in headless mode
+func NewResolver(extraDirs ...string) *Resolver {
+	var dirs []string
+	for _, dir := range extraDirs {
+		if dir != "" {
+			dirs = append(dirs, dir)
+		}
+	}
+	if !headless.Enabled() {
+		dirs = append(dirs, SystemFontDirs()...)
+	}
+
+	return &Resolver{
+		dirs:  dirs,
+		cache: make(map[string]*sfnt.Font),
+	}
+}
+
+// SystemFontDirs returns the platform's standard font directories
+func SystemFontDirs() []string {
+	homeDir, _ := os.UserHomeDir()
+
+	switch runtime.GOOS {
+	case "darwin":
+		return []string{
+			filepath.Join(homeDir, "Library", "Fonts"),
+			"/Library/Fonts",
+			"/System/Library/Fonts",
+			"/System/Library/Fonts/Supplemental",
+		}
+	case "windows":
+		var dirs []string
+		if localAppData := os.Getenv("LOCALAPPDATA"); localAppData != "" {
+			dirs = append(dirs, filepath.Join(localAppData, "Microsoft", "Windows", "Fonts"))
+		}
+		windir := os.Getenv("WINDIR")
+		if windir == "" {
+			windir = `C:\Windows`
+		}
+		return append(dirs, filepath.Join(windir, "Fonts"))
+	default:
+		return []string{
+			filepath.Join(homeDir, ".local", "share", "fonts"),
+			filepath.Join(homeDir, ".fonts"),
+			"/usr/local/share/fonts",
+			"/usr/share/fonts",
+		}
+	}
+}
+
+// Lookup resolves a CSS font-family list (e.g. "Inter, sans-serif") to a
+// parsed font and the path of its file. The embedded Go fonts are used with
+// an empty path when nothing matches, so lookup only fails if a font file
+// exists but cannot be parsed.
+func (r *Resolver) Lookup(family string) (*sfnt.Font, string, error) {
+	for _, candidate := range r.candidates(family) {
+		path := r.Find(candidate)
+		if path == "" {
+			continue
+		}
+
+		f, err := r.load(path)
+		if err != nil {
+			return nil, "", err
+		}
+		return f, path, nil
+	}
+
+	if isMonospace(family) {
+		f, err := r.loadEmbedded("Go Mono", gomono.TTF)
+		return f, "", err
+	}
+	f, err := r.loadEmbedded("Go Regular", goregular.TTF)
+	return f, "", err
+}
+
+// Find returns the path of the font file best matching family, or an empty
+// string if no installed font matches
+func (r *Resolver) Find(family string) string {
+	r.once.Do(r.buildIndex)
+
+	key := normalizeName(family)
+	if key == "" {
+		return ""
+	}
+
+	if path, exists := r.index[key]; exists {
+		return path
+	}
+	if path, exists := r.index[key+"regular"]; exists {
+		return path
+	}
+
+	// Fall back to the shortest file name starting with the family name
+	var best string
+	for name, path := range r.index {
+		if strings.HasPrefix(name, key) && (best == "" || len(name) < len(normalizeName(filepath.Base(best)))) {
+			best = path
+		}
+	}
+	return best
+}
+
+// candidates expands a CSS font-family list, replacing generic families with
+// their installed equivalents
+func (r *Resolver) candidates(family string) []string {
+	var result []string
+	for _, part := range strings.Split(family, ",") {
+		name := strings.Trim(strings.TrimSpace(part), `"'`)
+		if name == "" {
+			continue
+		}
+		if generic, exists := genericFamilies[strings.ToLower(name)]; exists {
+			result = append(result, generic...)
+			continue
+		}
+		result = append(result, name)
+	}
+	if len(result) == 0 {
+		result = genericFamilies["sans-serif"]
+	}
+	return result
+}
+
+func (r *Resolver) buildIndex() {
+	r.index = make(map[string]string)
+	for _, dir := range r.dirs {
+		filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+			if err != nil || info.IsDir() {
+				return nil
+			}
+			switch strings.ToLower(filepath.Ext(path)) {
+			case ".ttf", ".otf", ".ttc", ".otc":
+			default:
+				return nil
+			}
+			name := normalizeName(strings.TrimSuffix(info.Name(), filepath.Ext(info.Name())))
+			// Earlier directories (fonts_path, user fonts) win
+			if _, exists := r.index[name]; !exists {
+				r.index[name] = path
+			}
+			return nil
+		})
+	}
+}
+
+func (r *Resolver) load(path string) (*sfnt.Font, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if f, exists := r.cache[path]; exists {
+		return f, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read font %s: %w", path, err)
+	}
+
+	var f *sfnt.Font
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".ttc", ".otc":
+		collection, err := sfnt.ParseCollection(data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse font collection %s: %w", path, err)
+		}
+		f, err = collection.Font(0)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read font collection %s: %w", path, err)
+		}
+	default:
+		f, err = sfnt.Parse(data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse font %s: %w", path, err)
+		}
+	}
+
+	r.cache[path] = f
+	return f, nil
+}
+
+func (r *Resolver) loadEmbedded(name string, data []byte) (*sfnt.Font, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if f, exists := r.cache[name]; exists {
+		return f, nil
+	}
+
+	f, err := sfnt.Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse embedded font %s: %w", name, err)
+	}
+	r.cache[name] = f
+	return f, nil
+}
+
+func isMonospace(family string) bool {
+	lower := strings.ToLower(family)
+	return strings.Contains(lower, "mono") || strings.Contains(lower, "courier") || strings.Contains(lower, "consolas")
+}
+
+// normalizeName lowercases a family or file name and strips separators so
+// "DejaVu Sans" matches "DejaVuSans.ttf"
+func normalizeName(name string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(name) {
+		if r == ' ' || r == '-' || r == '_' {
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
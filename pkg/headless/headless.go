@@ -0,0 +1,37 @@
+package headless
+
+import (
+	"errors"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// EnvVar enables headless mode for containers and CI: no display is
+// detected, no wallpaper is set, only the built-in rasterizer and fonts
+// from fonts_path are used, and the clock is fixed, so the same inputs
+// render byte-identical output on every machine
+const EnvVar = "PPR_HEADLESS"
+
+// ErrNoDisplay is returned by display detection and wallpaper setting in
+// headless mode
+var ErrNoDisplay = errors.New("no display access in headless mode (" + EnvVar + ")")
+
+// Enabled reports whether PPR_HEADLESS is 1 or true
+func Enabled() bool {
+	value := os.Getenv(EnvVar)
+	return value == "1" || strings.EqualFold(value, "true")
+}
+
+// Now is the current time, or in headless mode SOURCE_DATE_EPOCH and the
+// Unix epoch when that is unset
+func Now() time.Time {
+	if !Enabled() {
+		return time.Now()
+	}
+	if seconds, err := strconv.ParseInt(os.Getenv("SOURCE_DATE_EPOCH"), 10, 64); err == nil {
+		return time.Unix(seconds, 0).UTC()
+	}
+	return time.Unix(0, 0).UTC()
+}
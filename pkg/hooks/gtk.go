@@ -0,0 +1,80 @@
+package hooks
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/byteowlz/ppr/pkg/theme"
+)
+
+// gtkColors maps the named colors of GTK4 and libadwaita to palette slots
+var gtkColors = [][2]string{
+	{"accent_color", "base0D"},
+	{"accent_bg_color", "base0D"},
+	{"accent_fg_color", "base00"},
+	{"destructive_color", "base08"},
+	{"destructive_bg_color", "base08"},
+	{"destructive_fg_color", "base00"},
+	{"success_color", "base0B"},
+	{"success_bg_color", "base0B"},
+	{"success_fg_color", "base00"},
+	{"warning_color", "base0A"},
+	{"warning_bg_color", "base0A"},
+	{"warning_fg_color", "base00"},
+	{"error_color", "base08"},
+	{"error_bg_color", "base08"},
+	{"error_fg_color", "base00"},
+	{"window_bg_color", "base00"},
+	{"window_fg_color", "base05"},
+	{"view_bg_color", "base00"},
+	{"view_fg_color", "base05"},
+	{"headerbar_bg_color", "base01"},
+	{"headerbar_fg_color", "base05"},
+	{"headerbar_border_color", "base02"},
+	{"headerbar_backdrop_color", "base00"},
+	{"sidebar_bg_color", "base01"},
+	{"sidebar_fg_color", "base05"},
+	{"card_bg_color", "base01"},
+	{"card_fg_color", "base05"},
+	{"dialog_bg_color", "base01"},
+	{"dialog_fg_color", "base05"},
+	{"popover_bg_color", "base01"},
+	{"popover_fg_color", "base05"},
+}
+
+const gtkImport = `@import url("ppr.css");`
+
+// writeGTKCSS writes the palette as GTK4 named colors to gtk-4.0/ppr.css
+// and imports it from gtk.css, leaving the user's own rules in place
+func writeGTKCSS(t *theme.Theme, configDir string) (string, error) {
+	var css strings.Builder
+	fmt.Fprintf(&css, "/* Generated by ppr from the %s theme, do not edit */\n", t.Name)
+	for _, c := range gtkColors {
+		value, ok := t.Palette[c[1]]
+		if !ok {
+			return "", fmt.Errorf("theme has no %s color", c[1])
+		}
+		fmt.Fprintf(&css, "@define-color %s %s;\n", c[0], value)
+	}
+
+	dir := filepath.Join(configDir, "gtk-4.0")
+	path := filepath.Join(dir, "ppr.css")
+	if err := writeFile(path, []byte(css.String())); err != nil {
+		return "", err
+	}
+
+	gtkCSS := filepath.Join(dir, "gtk.css")
+	existing, err := os.ReadFile(gtkCSS)
+	if err != nil && !os.IsNotExist(err) {
+		return "", fmt.Errorf("failed to read gtk.css: %w", err)
+	}
+	if !strings.Contains(string(existing), gtkImport) {
+		// @import must precede all other rules
+		if err := writeFile(gtkCSS, []byte(gtkImport+"\n"+string(existing))); err != nil {
+			return "", err
+		}
+	}
+	return path, nil
+}
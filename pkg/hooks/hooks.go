@@ -0,0 +1,79 @@
+package hooks
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/byteowlz/ppr/pkg/theme"
+)
+
+// preset writes the palette of a theme to the config files of one toolkit
+// and returns the main file it wrote
+type preset func(t *theme.Theme, configDir string) (string, error)
+
+var presets = map[string]preset{
+	"gtk-css": writeGTKCSS,
+	"qt5ct":   writeQt5ct,
+}
+
+// Presets returns the names of the built-in hook presets, sorted
+func Presets() []string {
+	names := make([]string, 0, len(presets))
+	for name := range presets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Check reports names that are no built-in hook preset
+func Check(names []string) error {
+	for _, name := range names {
+		if _, ok := presets[name]; !ok {
+			return fmt.Errorf("unknown hook preset %q (expected %s)", name, strings.Join(Presets(), ", "))
+		}
+	}
+	return nil
+}
+
+// Apply runs the named presets for t, so widget toolkits follow the
+// wallpaper theme. Files are written below the user config directory
+// ($XDG_CONFIG_HOME or ~/.config). It returns the files written and stops
+// at the first failing preset.
+func Apply(names []string, t *theme.Theme) ([]string, error) {
+	if err := Check(names); err != nil {
+		return nil, err
+	}
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		return nil, fmt.Errorf("failed to find config directory: %w", err)
+	}
+
+	var written []string
+	for _, name := range names {
+		path, err := presets[name](t, configDir)
+		if err != nil {
+			return written, fmt.Errorf("hook %s: %w", name, err)
+		}
+		written = append(written, path)
+	}
+	return written, nil
+}
+
+// writeFile writes data to path, creating its directory, and replaces the
+// file atomically so a toolkit never reads it half written
+func writeFile(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+	if err := os.WriteFile(path+".tmp", data, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	if err := os.Rename(path+".tmp", path); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,130 @@
+package hooks
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/byteowlz/ppr/pkg/theme"
+)
+
+// qtRoles maps the QPalette color roles, in the order qt5ct lists them, to
+// palette slots
+var qtRoles = []string{
+	"base05", // WindowText
+	"base01", // Button
+	"base03", // Light
+	"base02", // Midlight
+	"base00", // Dark
+	"base01", // Mid
+	"base05", // Text
+	"base07", // BrightText
+	"base05", // ButtonText
+	"base01", // Base
+	"base00", // Window
+	"base00", // Shadow
+	"base0D", // Highlight
+	"base00", // HighlightedText
+	"base0D", // Link
+	"base0E", // LinkVisited
+	"base02", // AlternateBase
+	"base00", // NoRole
+	"base01", // ToolTipBase
+	"base05", // ToolTipText
+	"base04", // PlaceholderText
+}
+
+// qtDisabledRoles are the text roles drawn dimmed in the disabled group
+var qtDisabledRoles = map[int]bool{0: true, 6: true, 7: true, 8: true, 13: true}
+
+// writeQt5ct writes the palette as a qt5ct color scheme and selects it as
+// the custom palette in qt5ct.conf
+func writeQt5ct(t *theme.Theme, configDir string) (string, error) {
+	active := make([]string, len(qtRoles))
+	disabled := make([]string, len(qtRoles))
+	for i, slot := range qtRoles {
+		value, ok := t.Palette[slot]
+		if !ok {
+			return "", fmt.Errorf("theme has no %s color", slot)
+		}
+		active[i] = qtColor(value)
+		disabled[i] = active[i]
+		if qtDisabledRoles[i] {
+			disabled[i] = qtColor(t.Palette["base03"])
+		}
+	}
+
+	scheme := fmt.Sprintf("[ColorScheme]\nactive_colors=%s\ndisabled_colors=%s\ninactive_colors=%s\n",
+		strings.Join(active, ", "), strings.Join(disabled, ", "), strings.Join(active, ", "))
+
+	dir := filepath.Join(configDir, "qt5ct")
+	path := filepath.Join(dir, "colors", "ppr.conf")
+	if err := writeFile(path, []byte(scheme)); err != nil {
+		return "", err
+	}
+
+	confPath := filepath.Join(dir, "qt5ct.conf")
+	conf, err := os.ReadFile(confPath)
+	if err != nil && !os.IsNotExist(err) {
+		return "", fmt.Errorf("failed to read qt5ct.conf: %w", err)
+	}
+	updated := setINIValue(string(conf), "Appearance", "color_scheme_path", path)
+	updated = setINIValue(updated, "Appearance", "custom_palette", "true")
+	if updated != string(conf) {
+		if err := writeFile(confPath, []byte(updated)); err != nil {
+			return "", err
+		}
+	}
+	return path, nil
+}
+
+// qtColor converts #RRGGBB to the #AARRGGBB form of qt5ct
+func qtColor(hex string) string {
+	return "#ff" + strings.ToLower(strings.TrimPrefix(hex, "#"))
+}
+
+// setINIValue sets key in section of an INI document, adding the key or
+// the section when missing and keeping all other lines as they are
+func setINIValue(doc, section, key, value string) string {
+	lines := strings.Split(strings.TrimRight(doc, "\n"), "\n")
+	if doc == "" {
+		lines = nil
+	}
+	entry := key + "=" + value
+
+	inSection, sectionEnd := false, -1
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "[") {
+			if inSection {
+				break
+			}
+			inSection = trimmed == "["+section+"]"
+			if inSection {
+				sectionEnd = i + 1
+			}
+			continue
+		}
+		if !inSection {
+			continue
+		}
+		if name, _, ok := strings.Cut(trimmed, "="); ok && strings.TrimSpace(name) == key {
+			lines[i] = entry
+			return strings.Join(lines, "\n") + "\n"
+		}
+		if trimmed != "" {
+			sectionEnd = i + 1
+		}
+	}
+
+	if sectionEnd < 0 {
+		if len(lines) > 0 {
+			lines = append(lines, "")
+		}
+		lines = append(lines, "["+section+"]", entry)
+		return strings.Join(lines, "\n") + "\n"
+	}
+	lines = append(lines[:sectionEnd], append([]string{entry}, lines[sectionEnd:]...)...)
+	return strings.Join(lines, "\n") + "\n"
+}
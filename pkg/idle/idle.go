@@ -0,0 +1,148 @@
+package idle
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+)
+
+// ErrUnavailable is returned when no source of the idle time is available
+var ErrUnavailable = errors.New("cannot determine the idle time")
+
+// Duration returns how long the session has been without input: from
+// HIDIdleTime on macOS, GetLastInputInfo on Windows, Mutter's idle monitor
+// on GNOME, xprintidle on X11 and the logind idle hint elsewhere, which
+// Wayland idle daemons like swayidle or hypridle set.
+func Duration() (time.Duration, error) {
+	switch runtime.GOOS {
+	case "darwin":
+		return macOSIdle()
+	case "windows":
+		return windowsIdle()
+	}
+
+	if d, err := mutterIdle(); err == nil {
+		return d, nil
+	}
+	if os.Getenv("WAYLAND_DISPLAY") == "" && os.Getenv("DISPLAY") != "" {
+		if d, err := xprintidle(); err == nil {
+			return d, nil
+		}
+	}
+	if d, err := logindIdle(); err == nil {
+		return d, nil
+	}
+	return 0, ErrUnavailable
+}
+
+var hidIdleRegex = regexp.MustCompile(`"HIDIdleTime" = (\d+)`)
+
+func macOSIdle() (time.Duration, error) {
+	output, err := exec.Command("ioreg", "-c", "IOHIDSystem", "-d", "4").Output()
+	if err != nil {
+		return 0, fmt.Errorf("ioreg failed: %w", err)
+	}
+	match := hidIdleRegex.FindSubmatch(output)
+	if match == nil {
+		return 0, ErrUnavailable
+	}
+	ns, err := strconv.ParseInt(string(match[1]), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid HIDIdleTime: %w", err)
+	}
+	return time.Duration(ns), nil
+}
+
+const windowsIdleScript = `Add-Type @'
+using System;
+using System.Runtime.InteropServices;
+public static class PprIdle {
+    [StructLayout(LayoutKind.Sequential)]
+    struct LASTINPUTINFO { public uint cbSize; public uint dwTime; }
+    [DllImport("user32.dll")]
+    static extern bool GetLastInputInfo(ref LASTINPUTINFO info);
+    public static uint Milliseconds() {
+        LASTINPUTINFO info = new LASTINPUTINFO();
+        info.cbSize = (uint)Marshal.SizeOf(info);
+        GetLastInputInfo(ref info);
+        return (uint)Environment.TickCount - info.dwTime;
+    }
+}
+'@
+[PprIdle]::Milliseconds()`
+
+func windowsIdle() (time.Duration, error) {
+	output, err := exec.Command("powershell", "-NoProfile", "-Command", windowsIdleScript).Output()
+	if err != nil {
+		return 0, fmt.Errorf("failed to query idle time: %w", err)
+	}
+	return parseMilliseconds(output)
+}
+
+func xprintidle() (time.Duration, error) {
+	output, err := exec.Command("xprintidle").Output()
+	if err != nil {
+		return 0, err
+	}
+	return parseMilliseconds(output)
+}
+
+func parseMilliseconds(output []byte) (time.Duration, error) {
+	ms, err := strconv.ParseUint(strings.TrimSpace(string(output)), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid idle time %q", strings.TrimSpace(string(output)))
+	}
+	return time.Duration(ms) * time.Millisecond, nil
+}
+
+func mutterIdle() (time.Duration, error) {
+	conn, err := dbus.ConnectSessionBus()
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Close()
+
+	var ms uint64
+	err = conn.Object("org.gnome.Mutter.IdleMonitor", "/org/gnome/Mutter/IdleMonitor/Core").
+		Call("org.gnome.Mutter.IdleMonitor.GetIdletime", 0).Store(&ms)
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(ms) * time.Millisecond, nil
+}
+
+// logindIdle reads the idle hint of the caller's session, which is only
+// set while an idle daemon reports the session idle
+func logindIdle() (time.Duration, error) {
+	conn, err := dbus.ConnectSystemBus()
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Close()
+
+	session := conn.Object("org.freedesktop.login1", "/org/freedesktop/login1/session/auto")
+	hint, err := session.GetProperty("org.freedesktop.login1.Session.IdleHint")
+	if err != nil {
+		return 0, err
+	}
+	if idle, _ := hint.Value().(bool); !idle {
+		return 0, nil
+	}
+	since, err := session.GetProperty("org.freedesktop.login1.Session.IdleSinceHint")
+	if err != nil {
+		return 0, err
+	}
+	usec, ok := since.Value().(uint64)
+	if !ok || usec == 0 {
+		return 0, ErrUnavailable
+	}
+	return time.Since(time.UnixMicro(int64(usec))), nil
+}
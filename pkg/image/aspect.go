@@ -0,0 +1,105 @@
+package image
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// DefaultAspectRatio is used for templates without preserveAspectRatio:
+// wallpapers cover the screen and are cropped evenly, unlike the SVG
+// default of xMidYMid meet
+const DefaultAspectRatio = "xMidYMid slice"
+
+// aspectRatio is a parsed preserveAspectRatio value. alignX and alignY
+// are 0, 0.5 or 1 for Min, Mid and Max.
+type aspectRatio struct {
+	none           bool
+	slice          bool
+	alignX, alignY float64
+}
+
+var (
+	alignRegex   = regexp.MustCompile(`^x(Min|Mid|Max)Y(Min|Mid|Max)$`)
+	alignFactors = map[string]float64{"Min": 0, "Mid": 0.5, "Max": 1}
+)
+
+// parseAspectRatio reads a preserveAspectRatio value such as
+// "xMaxYMid meet", "xMinYMin slice" or "none"
+func parseAspectRatio(value string) (aspectRatio, error) {
+	fields := strings.Fields(value)
+	if len(fields) > 0 && fields[0] == "defer" {
+		fields = fields[1:]
+	}
+	if len(fields) == 0 || len(fields) > 2 {
+		return aspectRatio{}, fmt.Errorf("invalid preserveAspectRatio %q", value)
+	}
+
+	var ratio aspectRatio
+	if fields[0] == "none" {
+		ratio.none = true
+	} else {
+		match := alignRegex.FindStringSubmatch(fields[0])
+		if match == nil {
+			return aspectRatio{}, fmt.Errorf("invalid preserveAspectRatio alignment %q (expected none or e.g. xMidYMid)", fields[0])
+		}
+		ratio.alignX, ratio.alignY = alignFactors[match[1]], alignFactors[match[2]]
+	}
+
+	if len(fields) == 2 {
+		switch fields[1] {
+		case "meet":
+		case "slice":
+			ratio.slice = true
+		default:
+			return aspectRatio{}, fmt.Errorf("invalid preserveAspectRatio %q (expected meet or slice)", fields[1])
+		}
+	}
+	return ratio, nil
+}
+
+// ValidateAspectRatio reports a preserveAspectRatio value that cannot be
+// parsed
+func ValidateAspectRatio(value string) error {
+	_, err := parseAspectRatio(value)
+	return err
+}
+
+// SetAspectRatio makes every render use value instead of the template's
+// preserveAspectRatio, empty to follow the template again
+func (g *Generator) SetAspectRatio(value string) error {
+	if value != "" {
+		if err := ValidateAspectRatio(value); err != nil {
+			return err
+		}
+	}
+	g.aspect = value
+	return nil
+}
+
+// templateAspectRatio picks the override, the template's own value or
+// DefaultAspectRatio. Invalid template values fall back to the default.
+func (g *Generator) templateAspectRatio(svgContent string) aspectRatio {
+	value := g.aspect
+	if value == "" {
+		value, _ = svgAttribute(svgTagRegex.FindString(svgContent), "preserveAspectRatio")
+	}
+	if ratio, err := parseAspectRatio(value); err == nil {
+		return ratio
+	}
+	ratio, _ := parseAspectRatio(DefaultAspectRatio)
+	return ratio
+}
+
+var aspectAttributeRegex = regexp.MustCompile(`\spreserveAspectRatio\s*=\s*(?:"[^"]*"|'[^']*')`)
+
+// stretchSVG sets preserveAspectRatio="none" on the root element, so
+// external backends stretch the content to the size they are given
+func stretchSVG(svgContent string) string {
+	tag := svgTagRegex.FindString(svgContent)
+	if tag == "" {
+		return svgContent
+	}
+	stretched := `<svg preserveAspectRatio="none"` + strings.TrimPrefix(aspectAttributeRegex.ReplaceAllString(tag, ""), "<svg")
+	return strings.Replace(svgContent, tag, stretched, 1)
+}
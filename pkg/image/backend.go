@@ -0,0 +1,175 @@
+package image
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"image"
+	"image/draw"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Rasterizer backends
+const (
+	// BackendOKSVG is the built-in pure Go rasterizer
+	BackendOKSVG = "oksvg"
+	// BackendRsvg uses rsvg-convert from librsvg
+	BackendRsvg = "rsvg-convert"
+	// BackendResvg uses the resvg CLI
+	BackendResvg = "resvg"
+	// BackendInkscape uses the Inkscape CLI
+	BackendInkscape = "inkscape"
+)
+
+// ExternalBackends lists the supported external rasterizers in order of preference
+var ExternalBackends = []string{BackendResvg, BackendRsvg, BackendInkscape}
+
+// AvailableBackends returns the built-in backend followed by every external
+// backend found on PATH
+func AvailableBackends() []string {
+	backends := []string{BackendOKSVG}
+	for _, name := range ExternalBackends {
+		if BackendAvailable(name) {
+			backends = append(backends, name)
+		}
+	}
+	return backends
+}
+
+// BackendAvailable reports whether the named backend can be used
+func BackendAvailable(name string) bool {
+	if name == BackendOKSVG {
+		return true
+	}
+	for _, external := range ExternalBackends {
+		if external == name {
+			_, err := exec.LookPath(name)
+			return err == nil
+		}
+	}
+	return false
+}
+
+// FirstExternalBackend returns the preferred external backend on PATH, or
+// an empty string if none is installed
+func FirstExternalBackend() string {
+	for _, name := range ExternalBackends {
+		if BackendAvailable(name) {
+			return name
+		}
+	}
+	return ""
+}
+
+// SetBackend selects the rasterizer used by Render
+func (g *Generator) SetBackend(name string) error {
+	if name == "" {
+		name = BackendOKSVG
+	}
+	if !BackendAvailable(name) {
+		return fmt.Errorf("rasterizer backend not available: %s", name)
+	}
+	g.backend = name
+	return nil
+}
+
+// Backend returns the name of the selected rasterizer
+func (g *Generator) Backend() string {
+	if g.backend == "" {
+		return BackendOKSVG
+	}
+	return g.backend
+}
+
+// SetFontDirs adds directories external backends search for fonts
+func (g *Generator) SetFontDirs(dirs ...string) {
+	g.fontDirs = nil
+	for _, dir := range dirs {
+		if info, err := os.Stat(dir); err == nil && info.IsDir() {
+			g.fontDirs = append(g.fontDirs, dir)
+		}
+	}
+}
+
+// renderExternal rasterizes the SVG at exactly width x height with an
+// external backend
+func (g *Generator) renderExternal(ctx context.Context, svgContent string, width, height int) (*image.RGBA, error) {
+	tempDir, err := os.MkdirTemp("", "ppr-render-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp directory: %w", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	inputPath := filepath.Join(tempDir, "input.svg")
+	outputPath := filepath.Join(tempDir, "output.png")
+	if err := os.WriteFile(inputPath, []byte(svgContent), 0644); err != nil {
+		return nil, fmt.Errorf("failed to write temp SVG: %w", err)
+	}
+
+	w, h := strconv.Itoa(width), strconv.Itoa(height)
+
+	var cmd *exec.Cmd
+	switch g.backend {
+	case BackendRsvg:
+		cmd = exec.CommandContext(ctx, BackendRsvg, "-w", w, "-h", h, "-f", "png", "-o", outputPath, inputPath)
+	case BackendResvg:
+		args := []string{"-w", w, "-h", h}
+		for _, dir := range g.fontDirs {
+			args = append(args, "--use-fonts-dir", dir)
+		}
+		cmd = exec.CommandContext(ctx, BackendResvg, append(args, inputPath, outputPath)...)
+	case BackendInkscape:
+		cmd = exec.CommandContext(ctx, BackendInkscape, inputPath, "--export-type=png", "--export-filename="+outputPath, "--export-width="+w, "--export-height="+h)
+	default:
+		return nil, fmt.Errorf("unknown rasterizer backend: %s", g.backend)
+	}
+
+	// rsvg-convert and inkscape find fonts through fontconfig
+	if len(g.fontDirs) > 0 && g.backend != BackendResvg {
+		fontConfig, err := writeFontConfig(tempDir, g.fontDirs)
+		if err != nil {
+			return nil, err
+		}
+		cmd.Env = append(os.Environ(), "FONTCONFIG_FILE="+fontConfig)
+	}
+
+	if output, err := cmd.CombinedOutput(); err != nil {
+		if ctx.Err() != nil {
+			return nil, fmt.Errorf("%s stopped: %w", g.backend, ctx.Err())
+		}
+		return nil, fmt.Errorf("%s failed: %w: %s", g.backend, err, string(output))
+	}
+
+	rendered, err := LoadPNG(outputPath)
+	if err != nil {
+		return nil, err
+	}
+
+	rgba := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(rgba, rgba.Bounds(), rendered, rendered.Bounds().Min, draw.Src)
+
+	return rgba, nil
+}
+
+// writeFontConfig writes a fontconfig file adding dirs to the system configuration
+func writeFontConfig(tempDir string, dirs []string) (string, error) {
+	var b strings.Builder
+	b.WriteString("<?xml version=\"1.0\"?>\n<!DOCTYPE fontconfig SYSTEM \"fonts.dtd\">\n<fontconfig>\n")
+	b.WriteString("  <include ignore_missing=\"yes\">/etc/fonts/fonts.conf</include>\n")
+	for _, dir := range dirs {
+		b.WriteString("  <dir>")
+		xml.EscapeText(&b, []byte(dir))
+		b.WriteString("</dir>\n")
+	}
+	b.WriteString("</fontconfig>\n")
+
+	path := filepath.Join(tempDir, "fonts.conf")
+	if err := os.WriteFile(path, []byte(b.String()), 0644); err != nil {
+		return "", fmt.Errorf("failed to write fontconfig file: %w", err)
+	}
+	return path, nil
+}
@@ -0,0 +1,56 @@
+package image
+
+import (
+	"image"
+	"image/draw"
+)
+
+// Blur approximates a gaussian blur of the given radius with three box
+// blur passes in each direction
+func Blur(src image.Image, radius int) *image.RGBA {
+	bounds := src.Bounds()
+	out := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(out, out.Bounds(), src, bounds.Min, draw.Src)
+	if radius < 1 {
+		return out
+	}
+
+	width, height := out.Rect.Dx(), out.Rect.Dy()
+	tmp := make([]uint8, len(out.Pix))
+	for pass := 0; pass < 3; pass++ {
+		// Rows, then columns
+		boxBlur(out.Pix, tmp, height, width, out.Stride, 4, radius)
+		boxBlur(tmp, out.Pix, width, height, 4, out.Stride, radius)
+	}
+	return out
+}
+
+// boxBlur averages each line of n pixels over a 2*radius+1 window with a
+// running sum, clamping at the edges. step is the distance between pixels
+// in a line and lineStride the distance between lines, so the same code
+// blurs rows and columns.
+func boxBlur(src, dst []uint8, lines, n, lineStride, step, radius int) {
+	window := 2*radius + 1
+	for line := 0; line < lines; line++ {
+		base := line * lineStride
+		for c := 0; c < 4; c++ {
+			at := func(i int) int {
+				if i < 0 {
+					i = 0
+				} else if i >= n {
+					i = n - 1
+				}
+				return int(src[base+i*step+c])
+			}
+
+			sum := 0
+			for i := -radius; i <= radius; i++ {
+				sum += at(i)
+			}
+			for i := 0; i < n; i++ {
+				dst[base+i*step+c] = uint8((sum + window/2) / window)
+				sum += at(i+radius+1) - at(i-radius)
+			}
+		}
+	}
+}
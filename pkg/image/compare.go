@@ -0,0 +1,118 @@
+package image
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+)
+
+// DefaultPixelTolerance is the per-pixel perceptual delta (0..1) below which
+// two pixels are considered identical. It absorbs anti-aliasing jitter.
+const DefaultPixelTolerance = 0.02
+
+// CompareResult summarizes the perceptual difference between two images
+type CompareResult struct {
+	// Similarity is 1 minus the mean perceptual delta, 1.0 means identical
+	Similarity float64
+	// MeanDelta is the average per-pixel perceptual delta (0..1)
+	MeanDelta float64
+	// MaxDelta is the largest per-pixel perceptual delta (0..1)
+	MaxDelta float64
+	// DiffPixels counts pixels whose delta exceeds the tolerance
+	DiffPixels  int
+	TotalPixels int
+}
+
+// DiffRatio returns the fraction of pixels that differ beyond the tolerance
+func (r CompareResult) DiffRatio() float64 {
+	if r.TotalPixels == 0 {
+		return 0
+	}
+	return float64(r.DiffPixels) / float64(r.TotalPixels)
+}
+
+// Compare computes a perceptual difference between two equally sized images.
+// Pixels differing by more than tolerance are counted in DiffPixels.
+func Compare(a, b image.Image, tolerance float64) (CompareResult, error) {
+	var result CompareResult
+
+	ab, bb := a.Bounds(), b.Bounds()
+	if ab.Dx() != bb.Dx() || ab.Dy() != bb.Dy() {
+		return result, fmt.Errorf("image sizes differ: %dx%d vs %dx%d", ab.Dx(), ab.Dy(), bb.Dx(), bb.Dy())
+	}
+
+	var total float64
+	for y := 0; y < ab.Dy(); y++ {
+		for x := 0; x < ab.Dx(); x++ {
+			delta := PixelDelta(a, b, ab.Min.X+x, ab.Min.Y+y, bb.Min.X+x, bb.Min.Y+y)
+			total += delta
+			if delta > result.MaxDelta {
+				result.MaxDelta = delta
+			}
+			if delta > tolerance {
+				result.DiffPixels++
+			}
+		}
+	}
+
+	result.TotalPixels = ab.Dx() * ab.Dy()
+	if result.TotalPixels > 0 {
+		result.MeanDelta = total / float64(result.TotalPixels)
+	}
+	result.Similarity = 1 - result.MeanDelta
+
+	return result, nil
+}
+
+// PixelDelta returns the perceptual distance (0..1) between pixel (ax, ay) of
+// a and pixel (bx, by) of b, using the "redmean" weighted RGB approximation
+// with alpha treated as an extra channel
+func PixelDelta(a, b image.Image, ax, ay, bx, by int) float64 {
+	r1, g1, b1, a1 := a.At(ax, ay).RGBA()
+	r2, g2, b2, a2 := b.At(bx, by).RGBA()
+
+	fr1, fg1, fb1 := float64(r1>>8), float64(g1>>8), float64(b1>>8)
+	fr2, fg2, fb2 := float64(r2>>8), float64(g2>>8), float64(b2>>8)
+
+	rmean := (fr1 + fr2) / 2
+	dr, dg, db := fr1-fr2, fg1-fg2, fb1-fb2
+	da := float64(a1>>8) - float64(a2>>8)
+
+	dist := math.Sqrt((2+rmean/256)*dr*dr + 4*dg*dg + (2+(255-rmean)/256)*db*db + 3*da*da)
+
+	// Maximum distance is reached for black vs. white with opposite alpha
+	maxDist := math.Sqrt((2+0.5)*255*255 + 4*255*255 + (2+0.5)*255*255 + 3*255*255)
+	return dist / maxDist
+}
+
+// DiffImage returns a visualization of the differences between a and b: a
+// faded grayscale copy of a with every pixel beyond tolerance highlighted in
+// red, brighter for larger deltas
+func DiffImage(a, b image.Image, tolerance float64) (*image.RGBA, error) {
+	ab, bb := a.Bounds(), b.Bounds()
+	if ab.Dx() != bb.Dx() || ab.Dy() != bb.Dy() {
+		return nil, fmt.Errorf("image sizes differ: %dx%d vs %dx%d", ab.Dx(), ab.Dy(), bb.Dx(), bb.Dy())
+	}
+
+	out := image.NewRGBA(image.Rect(0, 0, ab.Dx(), ab.Dy()))
+	for y := 0; y < ab.Dy(); y++ {
+		for x := 0; x < ab.Dx(); x++ {
+			delta := PixelDelta(a, b, ab.Min.X+x, ab.Min.Y+y, bb.Min.X+x, bb.Min.Y+y)
+
+			if delta > tolerance {
+				// Deltas above 0.25 are already very visible, saturate there
+				strength := math.Min(delta*4, 1)
+				out.Set(x, y, color.RGBA{R: uint8(128 + strength*127), A: 255})
+				continue
+			}
+
+			r, g, bl, _ := a.At(ab.Min.X+x, ab.Min.Y+y).RGBA()
+			luma := (299*(r>>8) + 587*(g>>8) + 114*(bl>>8)) / 1000
+			faded := uint8(191 + luma/4)
+			out.Set(x, y, color.RGBA{R: faded, G: faded, B: faded, A: 255})
+		}
+	}
+
+	return out, nil
+}
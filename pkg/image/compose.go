@@ -0,0 +1,146 @@
+package image
+
+import (
+	"fmt"
+	"image"
+	"image/draw"
+	"math"
+)
+
+// Blend modes for composing layers
+const (
+	BlendNormal   = "normal"
+	BlendMultiply = "multiply"
+	BlendScreen   = "screen"
+	BlendOverlay  = "overlay"
+	BlendDarken   = "darken"
+	BlendLighten  = "lighten"
+)
+
+// BlendModes lists the supported blend modes
+func BlendModes() []string {
+	return []string{BlendNormal, BlendMultiply, BlendScreen, BlendOverlay, BlendDarken, BlendLighten}
+}
+
+// Layer is one rendered image in a composition
+type Layer struct {
+	Image   *image.RGBA
+	Opacity float64
+	Blend   string
+}
+
+// Compose stacks layers bottom to top. Each layer is blended with what is
+// below it and then drawn over it at its opacity, following the W3C
+// compositing model. All layers must have the same size.
+func Compose(layers []Layer) (*image.RGBA, error) {
+	if len(layers) == 0 {
+		return nil, fmt.Errorf("no layers to compose")
+	}
+
+	bounds := layers[0].Image.Bounds()
+	for i, layer := range layers {
+		if layer.Image.Bounds().Size() != bounds.Size() {
+			return nil, fmt.Errorf("layer %d is %dx%d, expected %dx%d", i+1,
+				layer.Image.Bounds().Dx(), layer.Image.Bounds().Dy(), bounds.Dx(), bounds.Dy())
+		}
+		if layer.Opacity < 0 || layer.Opacity > 1 {
+			return nil, fmt.Errorf("layer %d opacity %g is outside 0-1", i+1, layer.Opacity)
+		}
+		if _, err := blendFunc(layer.Blend); err != nil {
+			return nil, fmt.Errorf("layer %d: %w", i+1, err)
+		}
+	}
+
+	width, height := bounds.Dx(), bounds.Dy()
+	// Straight (non-premultiplied) RGBA accumulator
+	acc := make([]float64, width*height*4)
+
+	for _, layer := range layers {
+		blend, _ := blendFunc(layer.Blend)
+		src := layer.Image
+		for y := 0; y < height; y++ {
+			row := src.Pix[src.PixOffset(src.Rect.Min.X, src.Rect.Min.Y+y):]
+			for x := 0; x < width; x++ {
+				p := row[x*4 : x*4+4]
+				as := float64(p[3]) / 255 * layer.Opacity
+				if as == 0 {
+					continue
+				}
+				i := (y*width + x) * 4
+				ab := acc[i+3]
+				ao := as + ab*(1-as)
+
+				for c := 0; c < 3; c++ {
+					// image.RGBA stores premultiplied color
+					cs := float64(p[c]) / float64(p[3])
+					cb := acc[i+c]
+					mixed := (1-ab)*cs + ab*blend(cb, cs)
+					acc[i+c] = (as*mixed + ab*(1-as)*cb) / ao
+				}
+				acc[i+3] = ao
+			}
+		}
+	}
+
+	out := image.NewRGBA(image.Rect(0, 0, width, height))
+	for i := 0; i < len(acc); i += 4 {
+		a := acc[i+3]
+		for c := 0; c < 3; c++ {
+			out.Pix[i+c] = uint8(math.Round(acc[i+c] * a * 255))
+		}
+		out.Pix[i+3] = uint8(math.Round(a * 255))
+	}
+	return out, nil
+}
+
+// blendFunc returns the separable blend function B(backdrop, source)
+func blendFunc(mode string) (func(cb, cs float64) float64, error) {
+	switch mode {
+	case "", BlendNormal:
+		return func(cb, cs float64) float64 { return cs }, nil
+	case BlendMultiply:
+		return func(cb, cs float64) float64 { return cb * cs }, nil
+	case BlendScreen:
+		return screen, nil
+	case BlendOverlay:
+		// Hard light with the layers swapped
+		return func(cb, cs float64) float64 {
+			if cb <= 0.5 {
+				return 2 * cb * cs
+			}
+			return screen(cs, 2*cb-1)
+		}, nil
+	case BlendDarken:
+		return math.Min, nil
+	case BlendLighten:
+		return math.Max, nil
+	default:
+		return nil, fmt.Errorf("unknown blend mode: %s", mode)
+	}
+}
+
+func screen(cb, cs float64) float64 {
+	return cb + cs - cb*cs
+}
+
+// Collage tiles a width x height canvas with a cols x rows grid, calling
+// render for every cell in row order. Cell edges are rounded so the grid
+// covers the canvas exactly.
+func Collage(width, height, cols, rows int, render func(cell, w, h int) (*image.RGBA, error)) (*image.RGBA, error) {
+	if cols < 1 || rows < 1 || cols > width || rows > height {
+		return nil, fmt.Errorf("invalid grid %dx%d for %dx%d", cols, rows, width, height)
+	}
+
+	out := image.NewRGBA(image.Rect(0, 0, width, height))
+	for row := 0; row < rows; row++ {
+		for col := 0; col < cols; col++ {
+			rect := image.Rect(col*width/cols, row*height/rows, (col+1)*width/cols, (row+1)*height/rows)
+			cell, err := render(row*cols+col, rect.Dx(), rect.Dy())
+			if err != nil {
+				return nil, err
+			}
+			draw.Draw(out, rect, cell, cell.Bounds().Min, draw.Src)
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,151 @@
+package image
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// cssDPI is the pixel density SVG and CSS define their absolute units in
+const cssDPI = 96.0
+
+// unitPixels converts SVG length units to pixels. em and ex assume the
+// default 16px font size.
+var unitPixels = map[string]float64{
+	"":   1,
+	"px": 1,
+	"pt": cssDPI / 72,
+	"pc": cssDPI / 6,
+	"in": cssDPI,
+	"cm": cssDPI / 2.54,
+	"mm": cssDPI / 25.4,
+	"q":  cssDPI / 101.6,
+	"em": 16,
+	"ex": 8,
+}
+
+var (
+	svgTagRegex = regexp.MustCompile(`<svg\b[^>]*>`)
+	lengthRegex = regexp.MustCompile(`^([+-]?(?:\d+\.?\d*|\.\d+)(?:[eE][+-]?\d+)?)\s*([a-zA-Z%]*)$`)
+)
+
+// svgAttribute returns the value of attribute name in tag
+func svgAttribute(tag, name string) (string, bool) {
+	re := regexp.MustCompile(`\s` + name + `\s*=\s*(?:"([^"]*)"|'([^']*)')`)
+	match := re.FindStringSubmatch(tag)
+	if match == nil {
+		return "", false
+	}
+	return strings.TrimSpace(match[1] + match[2]), true
+}
+
+// parseLength converts an SVG length to pixels. Percentages and unknown
+// units are relative to a viewport ppr does not have, so they report false.
+func parseLength(value string) (float64, bool) {
+	match := lengthRegex.FindStringSubmatch(value)
+	if match == nil {
+		return 0, false
+	}
+	factor, ok := unitPixels[strings.ToLower(match[2])]
+	if !ok {
+		return 0, false
+	}
+	number, err := strconv.ParseFloat(match[1], 64)
+	if err != nil || number <= 0 {
+		return 0, false
+	}
+	return number * factor, true
+}
+
+// viewBoxFields splits a viewBox value at commas and whitespace
+func viewBoxFields(value string) []string {
+	return strings.FieldsFunc(value, func(r rune) bool { return r == ',' || r == ' ' || r == '\t' || r == '\n' || r == '\r' })
+}
+
+// parseViewBox returns the width and height of a viewBox value
+func parseViewBox(value string) (float64, float64, bool) {
+	fields := viewBoxFields(value)
+	if len(fields) != 4 {
+		return 0, 0, false
+	}
+	width, err := strconv.ParseFloat(fields[2], 64)
+	if err != nil || width <= 0 {
+		return 0, 0, false
+	}
+	height, err := strconv.ParseFloat(fields[3], 64)
+	if err != nil || height <= 0 {
+		return 0, 0, false
+	}
+	return width, height, true
+}
+
+// svgSize returns the intrinsic size of an SVG in pixels from the width
+// and height of its root element, converting units at 96 DPI. A missing or
+// relative (e.g. 100%) dimension is taken from the viewBox, keeping its
+// aspect ratio when the other dimension is known.
+func svgSize(svgContent string) (width, height float64, err error) {
+	tag := svgTagRegex.FindString(svgContent)
+	if tag == "" {
+		return 0, 0, fmt.Errorf("no <svg> element found")
+	}
+
+	var hasWidth, hasHeight bool
+	if value, ok := svgAttribute(tag, "width"); ok {
+		width, hasWidth = parseLength(value)
+	}
+	if value, ok := svgAttribute(tag, "height"); ok {
+		height, hasHeight = parseLength(value)
+	}
+	if hasWidth && hasHeight {
+		return width, height, nil
+	}
+
+	value, _ := svgAttribute(tag, "viewBox")
+	boxWidth, boxHeight, ok := parseViewBox(value)
+	switch {
+	case !ok:
+		return 0, 0, fmt.Errorf("could not find width and height or a viewBox on the <svg> element")
+	case hasWidth:
+		height = width * boxHeight / boxWidth
+	case hasHeight:
+		width = height * boxWidth / boxHeight
+	default:
+		width, height = boxWidth, boxHeight
+	}
+	return width, height, nil
+}
+
+func (g *Generator) extractSVGDimensions(svgContent string) (int, int, error) {
+	width, height, err := svgSize(svgContent)
+	if err != nil {
+		return 0, 0, err
+	}
+	return int(math.Max(1, math.Round(width))), int(math.Max(1, math.Round(height))), nil
+}
+
+var sizeAttributeRegex = regexp.MustCompile(`\s(?:width|height)\s*=\s*(?:"[^"]*"|'[^']*')`)
+
+// normalizeSVGSize rewrites the root element for oksvg, which only reads
+// unitless width and height: they become pixel values, and a missing
+// viewBox is added so the content scales as with the other backends
+func normalizeSVGSize(svgContent string) string {
+	width, height, err := svgSize(svgContent)
+	if err != nil {
+		return svgContent
+	}
+
+	tag := svgTagRegex.FindString(svgContent)
+	normalized := sizeAttributeRegex.ReplaceAllString(tag, "")
+	size := fmt.Sprintf(` width="%s" height="%s"`, formatPixels(width), formatPixels(height))
+	if _, ok := svgAttribute(tag, "viewBox"); !ok {
+		size += fmt.Sprintf(` viewBox="0 0 %s %s"`, formatPixels(width), formatPixels(height))
+	}
+	normalized = "<svg" + size + strings.TrimPrefix(normalized, "<svg")
+	return strings.Replace(svgContent, tag, normalized, 1)
+}
+
+func formatPixels(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
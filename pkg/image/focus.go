@@ -0,0 +1,109 @@
+package image
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var focusCommentRegex = regexp.MustCompile(`<!--\s*ppr:focus\s+([^-]*?)\s*-->`)
+
+// focus is the part of a template that a crop keeps visible, as fractions
+// of the template size. A point has x0 == x1 and y0 == y1.
+type focus struct {
+	x0, y0, x1, y1 float64
+}
+
+// parseFocus reads the focal point or region a template declares:
+//
+//	<!-- ppr:focus 1400 300 -->
+//	<!-- ppr:focus 60% 10% 30% 40% -->
+//
+// Two values are a point, four a region given by its corner, width and
+// height. Numbers are in viewBox units, percentages of the template size.
+// It returns nil without a comment.
+func parseFocus(svgContent string) (*focus, error) {
+	match := focusCommentRegex.FindStringSubmatch(svgContent)
+	if match == nil {
+		return nil, nil
+	}
+	fields := strings.Fields(match[1])
+	if len(fields) != 2 && len(fields) != 4 {
+		return nil, fmt.Errorf("invalid ppr:focus %q (expected x y or x y width height)", match[1])
+	}
+
+	// Plain numbers are mapped from the viewBox, or the size without one
+	viewBox, _ := svgAttribute(svgTagRegex.FindString(svgContent), "viewBox")
+	minX, minY := 0.0, 0.0
+	boxWidth, boxHeight, ok := parseViewBox(viewBox)
+	if ok {
+		box := viewBoxFields(viewBox)
+		minX, _ = strconv.ParseFloat(box[0], 64)
+		minY, _ = strconv.ParseFloat(box[1], 64)
+	} else {
+		var err error
+		if boxWidth, boxHeight, err = svgSize(svgContent); err != nil {
+			return nil, err
+		}
+	}
+
+	values := make([]float64, len(fields))
+	for i, field := range fields {
+		size, origin := boxWidth, minX
+		if i%2 == 1 {
+			size, origin = boxHeight, minY
+		}
+		if i >= 2 {
+			// Width and height are lengths, not positions
+			origin = 0
+		}
+		if percent, found := strings.CutSuffix(field, "%"); found {
+			value, err := strconv.ParseFloat(percent, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid ppr:focus value %q", field)
+			}
+			values[i] = value / 100
+			continue
+		}
+		value, err := strconv.ParseFloat(field, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ppr:focus value %q", field)
+		}
+		values[i] = (value - origin) / size
+	}
+
+	f := &focus{x0: values[0], y0: values[1], x1: values[0], y1: values[1]}
+	if len(values) == 4 {
+		if values[2] < 0 || values[3] < 0 {
+			return nil, fmt.Errorf("invalid ppr:focus %q: negative size", match[1])
+		}
+		f.x1, f.y1 = f.x0+values[2], f.y0+values[3]
+	}
+	f.x0, f.x1 = clampFraction(f.x0), clampFraction(f.x1)
+	f.y0, f.y1 = clampFraction(f.y0), clampFraction(f.y1)
+	return f, nil
+}
+
+func clampFraction(v float64) float64 {
+	return math.Max(0, math.Min(1, v))
+}
+
+// focusOffset places a window of size along a scaled template edge. A focal
+// point is centered, a region is kept inside the window with as little
+// movement from the aligned offset as possible, and centered when it is
+// larger than the window. The result stays within the template.
+func focusOffset(aligned, scaled, size int, from, to float64) int {
+	start, end := from*float64(scaled), to*float64(scaled)
+	offset := float64(aligned)
+	switch {
+	case end-start >= float64(size) || from == to:
+		offset = (start+end)/2 - float64(size)/2
+	case start < offset:
+		offset = start
+	case end > offset+float64(size):
+		offset = end - float64(size)
+	}
+	return max(0, min(int(math.Round(offset)), scaled-size))
+}
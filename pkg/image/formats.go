@@ -0,0 +1,92 @@
+package image
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime/trace"
+	"strings"
+
+	"golang.org/x/image/bmp"
+	"golang.org/x/image/tiff"
+)
+
+// Raster output formats
+const (
+	FormatPNG  = "png"
+	FormatBMP  = "bmp"
+	FormatTIFF = "tiff"
+)
+
+// ParseFormat normalizes a format name, accepting tif as an alias for tiff
+func ParseFormat(name string) (string, error) {
+	switch strings.ToLower(strings.TrimPrefix(name, ".")) {
+	case "", FormatPNG:
+		return FormatPNG, nil
+	case FormatBMP:
+		return FormatBMP, nil
+	case FormatTIFF, "tif":
+		return FormatTIFF, nil
+	default:
+		return "", fmt.Errorf("unsupported output format: %s (expected png, bmp or tiff)", name)
+	}
+}
+
+// FormatFromPath returns the output format implied by the file extension
+func FormatFromPath(path string) (string, error) {
+	return ParseFormat(filepath.Ext(path))
+}
+
+// WriteImage encodes img to outputPath in the format given by its extension
+func WriteImage(img image.Image, outputPath string) error {
+	format, err := FormatFromPath(outputPath)
+	if err != nil {
+		return err
+	}
+	if format == FormatPNG {
+		return WritePNG(img, outputPath)
+	}
+
+	encodeRegion := trace.StartRegion(context.Background(), "ppr.encode")
+	defer encodeRegion.End()
+
+	return writeAtomic(outputPath, func(w io.Writer) error {
+		var err error
+		switch format {
+		case FormatBMP:
+			err = bmp.Encode(w, img)
+		case FormatTIFF:
+			err = tiff.Encode(w, img, &tiff.Options{Compression: tiff.Deflate, Predictor: true})
+		}
+		if err != nil {
+			return fmt.Errorf("failed to encode %s: %w", strings.ToUpper(format), err)
+		}
+		return nil
+	})
+}
+
+// ConvertToPNG writes the image at src to dst as PNG, copying the file
+// unchanged when it already is one
+func ConvertToPNG(src, dst string) error {
+	format, err := FormatFromPath(src)
+	if err != nil {
+		return err
+	}
+
+	if format == FormatPNG {
+		data, err := os.ReadFile(src)
+		if err != nil {
+			return fmt.Errorf("failed to read image: %w", err)
+		}
+		return WriteFileAtomic(dst, data)
+	}
+
+	img, err := LoadImage(src)
+	if err != nil {
+		return err
+	}
+	return WritePNG(img, dst)
+}
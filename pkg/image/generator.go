@@ -1,115 +1,296 @@
 package image
 
 import (
+	"context"
 	"fmt"
 	"image"
+	"image/draw"
+	_ "image/gif"
+	_ "image/jpeg"
 	"image/png"
+	"io"
+	"math"
 	"os"
-	"regexp"
-	"strconv"
+	"runtime"
+	"runtime/trace"
 	"strings"
+	"sync"
 
 	"github.com/srwiley/oksvg"
 	"github.com/srwiley/rasterx"
 )
 
-type Generator struct{}
+type Generator struct {
+	backend  string
+	fontDirs []string
+	limits   Limits
+	// aspect overrides the preserveAspectRatio of templates
+	aspect string
+	// safeArea keeps renders clear of reserved screen edges
+	safeArea *SafeArea
+	// paletteLimit quantizes renders for low-color displays
+	paletteLimit *PaletteLimit
+}
 
 func NewGenerator() *Generator {
-	return &Generator{}
+	return &Generator{backend: BackendOKSVG, limits: DefaultLimits}
 }
 
 func (g *Generator) SVGToPNG(svgContent string, width, height int, outputPath string) error {
-	icon, err := oksvg.ReadIconStream(strings.NewReader(svgContent))
+	defer trace.StartRegion(context.Background(), "ppr.rasterize").End()
+
+	finalRGBA, err := g.Render(svgContent, width, height)
 	if err != nil {
-		return fmt.Errorf("failed to parse SVG: %w", err)
+		return err
 	}
 
-	// Extract original SVG dimensions
-	svgWidth, svgHeight, err := g.extractSVGDimensions(svgContent)
+	return WritePNG(g.limitPalette(finalRGBA), outputPath)
+}
+
+// Render rasterizes the SVG scaled to cover width x height, center-cropping
+// whatever overflows the target aspect ratio
+func (g *Generator) Render(svgContent string, width, height int) (*image.RGBA, error) {
+	return g.RenderContext(context.Background(), svgContent, width, height)
+}
+
+// RenderContext is Render that gives up when ctx is done. External
+// backends are killed; an abandoned oksvg render finishes in the background.
+func (g *Generator) RenderContext(ctx context.Context, svgContent string, width, height int) (*image.RGBA, error) {
+	p, err := g.Parse(svgContent)
 	if err != nil {
-		return fmt.Errorf("failed to extract SVG dimensions: %w", err)
+		return nil, err
 	}
+	return g.renderSafe(ctx, p, width, height)
+}
 
-	// Calculate scaling to maintain aspect ratio
-	scaleX := float64(width) / float64(svgWidth)
-	scaleY := float64(height) / float64(svgHeight)
-	scale := scaleX
-	if scaleY > scaleX {
-		scale = scaleY
+// render rasterizes a parsed SVG, which lets several sizes share one parse.
+// The content is moved by shiftX, shiftY pixels, see SafeArea.
+func (g *Generator) render(ctx context.Context, p *Parsed, width, height, shiftX, shiftY int) (*image.RGBA, error) {
+	// Scale and align as preserveAspectRatio asks, by default covering the
+	// target and cropping evenly
+	ratio := p.ratio
+	scaleX := float64(width) / float64(p.width)
+	scaleY := float64(height) / float64(p.height)
+	if !ratio.none {
+		scale := math.Min(scaleX, scaleY)
+		if ratio.slice {
+			// Zoom in far enough to still cover the target once shifted
+			scale = math.Max(scaleX, scaleY)
+			scale = math.Max(scale, float64(width+2*abs(shiftX))/float64(p.width))
+			scale = math.Max(scale, float64(height+2*abs(shiftY))/float64(p.height))
+		}
+		scaleX, scaleY = scale, scale
 	}
 
 	// Calculate scaled dimensions
-	scaledWidth := int(float64(svgWidth) * scale)
-	scaledHeight := int(float64(svgHeight) * scale)
+	scaledWidth := int(float64(p.width) * scaleX)
+	scaledHeight := int(float64(p.height) * scaleY)
+	if err := g.limits.checkRender(width, height, scaledWidth, scaledHeight); err != nil {
+		return nil, err
+	}
+
+	var scaledRGBA *image.RGBA
+	var err error
+	if p.icon != nil {
+		scaledRGBA, err = renderOKSVGContext(ctx, p.icon, scaledWidth, scaledHeight)
+	} else {
+		drawRegion := trace.StartRegion(ctx, "ppr.draw")
+		scaledRGBA, err = g.renderExternal(ctx, p.content, scaledWidth, scaledHeight)
+		drawRegion.End()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Crop a sliced render, or place a smaller one, at the alignment or
+	// around the focus. Areas meet leaves uncovered stay transparent.
+	finalRGBA := image.NewRGBA(image.Rect(0, 0, width, height))
+	offsetX := int(float64(scaledWidth-width) * ratio.alignX)
+	offsetY := int(float64(scaledHeight-height) * ratio.alignY)
+	if ratio.slice && p.focus != nil {
+		offsetX = focusOffset(offsetX, scaledWidth, width, p.focus.x0, p.focus.x1)
+		offsetY = focusOffset(offsetY, scaledHeight, height, p.focus.y0, p.focus.y1)
+	}
+	offsetX -= shiftX
+	offsetY -= shiftY
+	if ratio.slice {
+		offsetX = max(0, min(offsetX, scaledWidth-width))
+		offsetY = max(0, min(offsetY, scaledHeight-height))
+	}
+	draw.Draw(finalRGBA, finalRGBA.Bounds(), scaledRGBA, image.Pt(offsetX, offsetY), draw.Src)
+
+	return finalRGBA, nil
+}
+
+func parseOKSVG(svgContent string) (*oksvg.SvgIcon, error) {
+	icon, err := oksvg.ReadIconStream(strings.NewReader(normalizeSVGSize(svgContent)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse SVG: %w", err)
+	}
+	return icon, nil
+}
 
-	// Set target to scaled dimensions to maintain aspect ratio
-	icon.SetTarget(0, 0, float64(scaledWidth), float64(scaledHeight))
+// renderOKSVG rasterizes the icon at exactly width x height. The icon is
+// copied before setting its target, so concurrent renders of one parsed
+// icon do not interfere.
+func renderOKSVG(parsed *oksvg.SvgIcon, width, height int) *image.RGBA {
+	icon := *parsed
+	icon.SetTarget(0, 0, float64(width), float64(height))
 
-	// Create image with scaled dimensions
-	scaledRGBA := image.NewRGBA(image.Rect(0, 0, scaledWidth, scaledHeight))
+	rgba := image.NewRGBA(image.Rect(0, 0, width, height))
 
-	scanner := rasterx.NewScannerGV(scaledWidth, scaledHeight, scaledRGBA, scaledRGBA.Bounds())
-	raster := rasterx.NewDasher(scaledWidth, scaledHeight, scanner)
+	scanner := rasterx.NewScannerGV(width, height, rgba, rgba.Bounds())
+	raster := rasterx.NewDasher(width, height, scanner)
 
+	drawRegion := trace.StartRegion(context.Background(), "ppr.draw")
 	icon.Draw(raster, 1.0)
+	drawRegion.End()
 
-	// Crop to target dimensions
-	finalRGBA := image.NewRGBA(image.Rect(0, 0, width, height))
+	return rgba
+}
 
-	// Calculate crop offset to center the image
-	offsetX := (scaledWidth - width) / 2
-	offsetY := (scaledHeight - height) / 2
-
-	// Copy the cropped portion
-	for y := 0; y < height; y++ {
-		for x := 0; x < width; x++ {
-			srcX := x + offsetX
-			srcY := y + offsetY
-			if srcX >= 0 && srcX < scaledWidth && srcY >= 0 && srcY < scaledHeight {
-				finalRGBA.Set(x, y, scaledRGBA.At(srcX, srcY))
-			}
-		}
+// renderOKSVGContext runs renderOKSVG, returning early when ctx is done.
+// oksvg cannot be interrupted, so the render is left to finish on its own.
+func renderOKSVGContext(ctx context.Context, icon *oksvg.SvgIcon, width, height int) (*image.RGBA, error) {
+	if ctx.Done() == nil {
+		return renderOKSVG(icon, width, height), nil
 	}
 
-	file, err := os.Create(outputPath)
+	done := make(chan *image.RGBA, 1)
+	go func() {
+		done <- renderOKSVG(icon, width, height)
+	}()
+	select {
+	case rgba := <-done:
+		return rgba, nil
+	case <-ctx.Done():
+		return nil, fmt.Errorf("render stopped: %w", ctx.Err())
+	}
+}
+
+// Target is one output size of a multi-size render
+type Target struct {
+	Width      int
+	Height     int
+	OutputPath string
+}
+
+// GenerateWallpapers renders the SVG once per target, in parallel. The SVG
+// is parsed a single time and shared by all sizes.
+func (g *Generator) GenerateWallpapers(svgContent string, targets []Target) error {
+	return g.GenerateWallpapersContext(context.Background(), svgContent, targets)
+}
+
+// GenerateWallpapersContext is GenerateWallpapers that stops when ctx is done
+func (g *Generator) GenerateWallpapersContext(ctx context.Context, svgContent string, targets []Target) error {
+	p, err := g.Parse(svgContent)
 	if err != nil {
-		return fmt.Errorf("failed to create output file: %w", err)
+		return err
 	}
-	defer file.Close()
 
-	if err := png.Encode(file, finalRGBA); err != nil {
-		return fmt.Errorf("failed to encode PNG: %w", err)
+	workers := runtime.NumCPU()
+	if workers > len(targets) {
+		workers = len(targets)
 	}
 
+	jobs := make(chan int)
+	errs := make([]error, len(targets))
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				if ctx.Err() != nil {
+					errs[i] = fmt.Errorf("render stopped: %w", ctx.Err())
+					continue
+				}
+				t := targets[i]
+				region := trace.StartRegion(ctx, "ppr.rasterize")
+				img, err := g.renderSafe(ctx, p, t.Width, t.Height)
+				if err == nil {
+					err = WriteImage(g.limitPalette(img), t.OutputPath)
+				}
+				region.End()
+				if err != nil {
+					errs[i] = fmt.Errorf("failed to render %dx%d: %w", t.Width, t.Height, err)
+				}
+			}
+		}()
+	}
+	for i := range targets {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
-func (g *Generator) GenerateWallpaper(svgContent string, width, height int, outputPath string) error {
-	return g.SVGToPNG(svgContent, width, height, outputPath)
+// WritePNG encodes img as PNG to outputPath, replacing it atomically
+func WritePNG(img image.Image, outputPath string) error {
+	encodeRegion := trace.StartRegion(context.Background(), "ppr.encode")
+	defer encodeRegion.End()
+
+	return writeAtomic(outputPath, func(w io.Writer) error {
+		if err := png.Encode(w, img); err != nil {
+			return fmt.Errorf("failed to encode PNG: %w", err)
+		}
+		return nil
+	})
 }
 
-func (g *Generator) extractSVGDimensions(svgContent string) (int, int, error) {
-	// Look for width and height attributes in the SVG tag
-	widthRegex := regexp.MustCompile(`width="(\d+)"`)
-	heightRegex := regexp.MustCompile(`height="(\d+)"`)
+// LoadImage decodes a PNG, JPEG, GIF, BMP or TIFF file
+func LoadImage(path string) (image.Image, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open image: %w", err)
+	}
+	defer file.Close()
 
-	widthMatch := widthRegex.FindStringSubmatch(svgContent)
-	heightMatch := heightRegex.FindStringSubmatch(svgContent)
+	img, _, err := image.Decode(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode image %s: %w", path, err)
+	}
 
-	if len(widthMatch) < 2 || len(heightMatch) < 2 {
-		return 0, 0, fmt.Errorf("could not find width or height attributes in SVG")
+	return img, nil
+}
+
+// LoadPNG decodes the PNG file at path
+func LoadPNG(path string) (image.Image, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open image: %w", err)
 	}
+	defer file.Close()
 
-	width, err := strconv.Atoi(widthMatch[1])
+	img, err := png.Decode(file)
 	if err != nil {
-		return 0, 0, fmt.Errorf("invalid width value: %w", err)
+		return nil, fmt.Errorf("failed to decode PNG %s: %w", path, err)
 	}
 
-	height, err := strconv.Atoi(heightMatch[1])
+	return img, nil
+}
+
+// GenerateWallpaper renders the SVG and writes it in the format given by the
+// extension of outputPath (PNG, BMP or TIFF)
+func (g *Generator) GenerateWallpaper(svgContent string, width, height int, outputPath string) error {
+	return g.GenerateWallpaperContext(context.Background(), svgContent, width, height, outputPath)
+}
+
+// GenerateWallpaperContext is GenerateWallpaper that stops when ctx is done
+func (g *Generator) GenerateWallpaperContext(ctx context.Context, svgContent string, width, height int, outputPath string) error {
+	defer trace.StartRegion(ctx, "ppr.rasterize").End()
+
+	img, err := g.RenderContext(ctx, svgContent, width, height)
 	if err != nil {
-		return 0, 0, fmt.Errorf("invalid height value: %w", err)
+		return err
 	}
 
-	return width, height, nil
+	return WriteImage(g.limitPalette(img), outputPath)
 }
@@ -0,0 +1,171 @@
+package image
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"image"
+	"image/png"
+	"sort"
+)
+
+// Icon container formats
+const (
+	IconFormatICO  = "ico"
+	IconFormatICNS = "icns"
+)
+
+// ICOSizes are the sizes packed into Windows .ico files
+var ICOSizes = []int{16, 24, 32, 48, 64, 128, 256}
+
+// icnsTypes maps the PNG-based ICNS element types to their pixel size.
+// Retina types (ic11-ic14, ic10) reuse the next larger rendering.
+var icnsTypes = []struct {
+	kind string
+	size int
+}{
+	{"icp4", 16},
+	{"ic11", 32},
+	{"icp5", 32},
+	{"ic12", 64},
+	{"icp6", 64},
+	{"ic07", 128},
+	{"ic13", 256},
+	{"ic08", 256},
+	{"ic14", 512},
+	{"ic09", 512},
+	{"ic10", 1024},
+}
+
+// ICNSSizes returns the distinct sizes needed for a macOS .icns file
+func ICNSSizes() []int {
+	seen := make(map[int]bool)
+	var sizes []int
+	for _, t := range icnsTypes {
+		if !seen[t.size] {
+			seen[t.size] = true
+			sizes = append(sizes, t.size)
+		}
+	}
+	sort.Ints(sizes)
+	return sizes
+}
+
+// IconSizes returns the sizes rendered for the given icon format
+func IconSizes(format string) ([]int, error) {
+	switch format {
+	case IconFormatICO:
+		return ICOSizes, nil
+	case IconFormatICNS:
+		return ICNSSizes(), nil
+	default:
+		return nil, fmt.Errorf("unknown icon format: %s (expected %s or %s)", format, IconFormatICO, IconFormatICNS)
+	}
+}
+
+// WriteIcon packs square renderings keyed by edge length into an ICO or ICNS file
+func WriteIcon(format string, images map[int]image.Image, outputPath string) error {
+	var data []byte
+	var err error
+	switch format {
+	case IconFormatICO:
+		data, err = encodeICO(images)
+	case IconFormatICNS:
+		data, err = encodeICNS(images)
+	default:
+		return fmt.Errorf("unknown icon format: %s (expected %s or %s)", format, IconFormatICO, IconFormatICNS)
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := WriteFileAtomic(outputPath, data); err != nil {
+		return fmt.Errorf("failed to write icon: %w", err)
+	}
+	return nil
+}
+
+func encodePNGBytes(img image.Image) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, fmt.Errorf("failed to encode PNG: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// encodeICO writes an ICONDIR with PNG-compressed entries, supported since
+// Windows Vista
+func encodeICO(images map[int]image.Image) ([]byte, error) {
+	var sizes []int
+	for size := range images {
+		if size > 256 {
+			return nil, fmt.Errorf("ICO entries are limited to 256x256, got %d", size)
+		}
+		sizes = append(sizes, size)
+	}
+	sort.Ints(sizes)
+
+	var entries [][]byte
+	for _, size := range sizes {
+		data, err := encodePNGBytes(images[size])
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, data)
+	}
+
+	var buf bytes.Buffer
+	binary.Write(&buf, binary.LittleEndian, [3]uint16{0, 1, uint16(len(sizes))})
+
+	offset := 6 + 16*len(sizes)
+	for i, size := range sizes {
+		// A stored dimension of 0 means 256
+		dim := uint8(size)
+		if size == 256 {
+			dim = 0
+		}
+		buf.Write([]byte{dim, dim, 0, 0})
+		binary.Write(&buf, binary.LittleEndian, [2]uint16{1, 32})
+		binary.Write(&buf, binary.LittleEndian, [2]uint32{uint32(len(entries[i])), uint32(offset)})
+		offset += len(entries[i])
+	}
+	for _, data := range entries {
+		buf.Write(data)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// encodeICNS writes an Apple icon family with PNG elements
+func encodeICNS(images map[int]image.Image) ([]byte, error) {
+	encoded := make(map[int][]byte)
+	var body bytes.Buffer
+	for _, t := range icnsTypes {
+		img, exists := images[t.size]
+		if !exists {
+			continue
+		}
+		data, cached := encoded[t.size]
+		if !cached {
+			var err error
+			if data, err = encodePNGBytes(img); err != nil {
+				return nil, err
+			}
+			encoded[t.size] = data
+		}
+
+		body.WriteString(t.kind)
+		binary.Write(&body, binary.BigEndian, uint32(8+len(data)))
+		body.Write(data)
+	}
+
+	if body.Len() == 0 {
+		return nil, fmt.Errorf("no images for ICNS sizes")
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("icns")
+	binary.Write(&buf, binary.BigEndian, uint32(8+body.Len()))
+	buf.Write(body.Bytes())
+	return buf.Bytes(), nil
+}
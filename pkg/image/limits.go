@@ -0,0 +1,85 @@
+package image
+
+import "fmt"
+
+// Limits bounds the resources of one render, so a rogue template fails with
+// a clear error instead of exhausting memory. Zero fields are unlimited.
+type Limits struct {
+	// MaxSVGBytes bounds the SVG content handed to the rasterizer
+	MaxSVGBytes int64
+	// MaxWidth and MaxHeight bound the output size
+	MaxWidth  int
+	MaxHeight int
+	// MaxMemory bounds the estimated pixel buffers of a render in bytes
+	MaxMemory int64
+}
+
+// DefaultLimits allow 16K output and are applied by NewGenerator
+var DefaultLimits = Limits{
+	MaxSVGBytes: 32 << 20,
+	MaxWidth:    16384,
+	MaxHeight:   16384,
+	MaxMemory:   4 << 30,
+}
+
+// LimitError reports a render refused by Limits. Limit is the config key.
+type LimitError struct {
+	Limit  string
+	Actual string
+	Max    string
+}
+
+func (e *LimitError) Error() string {
+	return fmt.Sprintf("render exceeds %s: %s > %s", e.Limit, e.Actual, e.Max)
+}
+
+// SetLimits replaces the limits applied to every render
+func (g *Generator) SetLimits(limits Limits) {
+	g.limits = limits
+}
+
+// CheckSVGSize reports SVG content of size bytes that is over the limit
+func (l Limits) CheckSVGSize(size int64) error {
+	if l.MaxSVGBytes > 0 && size > l.MaxSVGBytes {
+		return &LimitError{Limit: "max_svg_size", Actual: FormatBytes(size), Max: FormatBytes(l.MaxSVGBytes)}
+	}
+	return nil
+}
+
+// checkRender validates the output size and the memory of rendering at
+// scaledWidth x scaledHeight before cropping to width x height
+func (l Limits) checkRender(width, height, scaledWidth, scaledHeight int) error {
+	if (l.MaxWidth > 0 && width > l.MaxWidth) || (l.MaxHeight > 0 && height > l.MaxHeight) {
+		return &LimitError{
+			Limit:  "max_resolution",
+			Actual: fmt.Sprintf("%dx%d", width, height),
+			Max:    fmt.Sprintf("%dx%d", l.MaxWidth, l.MaxHeight),
+		}
+	}
+	if l.MaxMemory > 0 {
+		if memory := EstimateMemory(width, height, scaledWidth, scaledHeight); memory > l.MaxMemory {
+			return &LimitError{Limit: "max_memory", Actual: FormatBytes(memory), Max: FormatBytes(l.MaxMemory)}
+		}
+	}
+	return nil
+}
+
+// EstimateMemory is the size of the RGBA buffers of one render: the scaled
+// raster plus the cropped output
+func EstimateMemory(width, height, scaledWidth, scaledHeight int) int64 {
+	return (int64(scaledWidth)*int64(scaledHeight) + int64(width)*int64(height)) * 4
+}
+
+// FormatBytes renders a byte count using binary units, e.g. 1.5 MB
+func FormatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(n)/float64(div), "KMGT"[exp])
+}
@@ -0,0 +1,169 @@
+package image
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"os/exec"
+	"path/filepath"
+)
+
+// PNG optimizers, see OptimizePNG
+const (
+	OptimizerNative = "native"
+	OptimizerOxipng = "oxipng"
+)
+
+// OptimizePNG recompresses the PNG at path losslessly, with oxipng when it
+// is installed and natively otherwise. It returns the optimizer used and
+// how many bytes were saved; the file is left alone when nothing is gained.
+func OptimizePNG(path string) (string, int64, error) {
+	optimizer := OptimizerNative
+	if _, err := exec.LookPath("oxipng"); err == nil {
+		optimizer = OptimizerOxipng
+	}
+	saved, err := OptimizePNGWith(path, optimizer)
+	return optimizer, saved, err
+}
+
+// OptimizePNGWith is OptimizePNG with the given optimizer
+func OptimizePNGWith(path, optimizer string) (int64, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read image: %w", err)
+	}
+
+	switch optimizer {
+	case OptimizerNative:
+		return optimizeNative(path, info.Size())
+	case OptimizerOxipng:
+		return optimizeOxipng(path, info.Size())
+	default:
+		return 0, fmt.Errorf("unknown PNG optimizer: %s", optimizer)
+	}
+}
+
+// optimizeNative reduces flat images to a palette or grayscale and
+// re-encodes them with the best compression
+func optimizeNative(path string, size int64) (int64, error) {
+	img, err := LoadPNG(path)
+	if err != nil {
+		return 0, err
+	}
+
+	var buf bytes.Buffer
+	encoder := png.Encoder{CompressionLevel: png.BestCompression}
+	if err := encoder.Encode(&buf, reduceColors(img)); err != nil {
+		return 0, fmt.Errorf("failed to encode PNG: %w", err)
+	}
+	if int64(buf.Len()) >= size {
+		return 0, nil
+	}
+	if err := WriteFileAtomic(path, buf.Bytes()); err != nil {
+		return 0, err
+	}
+	return size - int64(buf.Len()), nil
+}
+
+// reduceColors returns img as a paletted image when it has at most 256
+// colors, as grayscale when it is opaque gray, or unchanged. Only 8-bit
+// images as decoded from PNG are reduced, so the pixels stay identical.
+func reduceColors(img image.Image) image.Image {
+	var pix []uint8
+	var stride int
+	switch m := img.(type) {
+	case *image.NRGBA:
+		pix, stride = m.Pix, m.Stride
+	case *image.RGBA:
+		// The PNG decoder only returns RGBA for opaque images, where
+		// premultiplied and straight alpha agree
+		pix, stride = m.Pix, m.Stride
+	default:
+		return img
+	}
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+
+	palette := make(color.Palette, 0, 256)
+	index := make(map[color.NRGBA]uint8, 256)
+	paletted := image.NewPaletted(bounds, nil)
+	gray := true
+	for y := 0; y < height && (palette != nil || gray); y++ {
+		row := pix[y*stride : y*stride+width*4]
+		for x := 0; x < width; x++ {
+			c := color.NRGBA{row[x*4], row[x*4+1], row[x*4+2], row[x*4+3]}
+			if gray && (c.A != 255 || c.R != c.G || c.G != c.B) {
+				gray = false
+			}
+			if palette == nil {
+				continue
+			}
+			i, exists := index[c]
+			if !exists {
+				if len(palette) == 256 {
+					palette = nil
+					continue
+				}
+				i = uint8(len(palette))
+				index[c] = i
+				palette = append(palette, c)
+			}
+			paletted.Pix[y*paletted.Stride+x] = i
+		}
+	}
+
+	switch {
+	case palette != nil:
+		paletted.Palette = palette
+		return paletted
+	case gray:
+		g := image.NewGray(bounds)
+		for y := 0; y < height; y++ {
+			for x := 0; x < width; x++ {
+				g.Pix[y*g.Stride+x] = pix[y*stride+x*4]
+			}
+		}
+		return g
+	default:
+		return img
+	}
+}
+
+// optimizeOxipng runs oxipng into a temporary file next to path and keeps
+// the result when it is smaller
+func optimizeOxipng(path string, size int64) (int64, error) {
+	dir, name := filepath.Split(path)
+	if dir == "" {
+		dir = "."
+	}
+	tmp, err := os.CreateTemp(dir, "."+name+".*.tmp")
+	if err != nil {
+		return 0, fmt.Errorf("failed to create output file: %w", err)
+	}
+	tmpPath := tmp.Name()
+	tmp.Close()
+	defer os.Remove(tmpPath)
+
+	output, err := exec.Command("oxipng", "-o", "2", "-q", "--force", "--out", tmpPath, path).CombinedOutput()
+	if err != nil {
+		return 0, fmt.Errorf("oxipng failed: %w: %s", err, bytes.TrimSpace(output))
+	}
+
+	info, err := os.Stat(tmpPath)
+	if err != nil {
+		return 0, fmt.Errorf("oxipng wrote no output: %w", err)
+	}
+	if info.Size() == 0 || info.Size() >= size {
+		return 0, nil
+	}
+	if err := os.Chmod(tmpPath, 0644); err != nil {
+		return 0, fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		return 0, fmt.Errorf("failed to replace %s: %w", path, err)
+	}
+	return size - info.Size(), nil
+}
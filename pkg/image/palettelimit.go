@@ -0,0 +1,176 @@
+package image
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"sort"
+)
+
+// Bounds of PaletteLimit.Colors
+const (
+	MinPaletteColors = 2
+	MaxPaletteColors = 256
+	// GrayLevels is the default depth of grayscale output, the 4 bits most
+	// e-ink panels can show
+	GrayLevels = 16
+)
+
+// PaletteLimit reduces the final image of a render to a few colors, for
+// e-ink dashboards and low-color displays
+type PaletteLimit struct {
+	// Colors is the number of colors kept, GrayLevels when zero in
+	// grayscale mode
+	Colors int `json:"colors,omitempty"`
+	// Grayscale quantizes to evenly spaced gray levels instead of the
+	// colors of the image
+	Grayscale bool `json:"grayscale,omitempty"`
+	// Dither diffuses the quantization error with Floyd-Steinberg
+	Dither bool `json:"dither,omitempty"`
+}
+
+// Validate reports a color count out of range
+func (l *PaletteLimit) Validate() error {
+	if l.Colors == 0 && l.Grayscale {
+		return nil
+	}
+	if l.Colors < MinPaletteColors || l.Colors > MaxPaletteColors {
+		return fmt.Errorf("invalid palette limit %d (expected %d-%d colors)", l.Colors, MinPaletteColors, MaxPaletteColors)
+	}
+	return nil
+}
+
+// SetPaletteLimit quantizes every render to limit, nil to keep all colors
+func (g *Generator) SetPaletteLimit(limit *PaletteLimit) error {
+	if limit != nil {
+		if err := limit.Validate(); err != nil {
+			return err
+		}
+	}
+	g.paletteLimit = limit
+	return nil
+}
+
+// limitPalette applies the palette limit of g to img, if any
+func (g *Generator) limitPalette(img *image.RGBA) *image.RGBA {
+	if g.paletteLimit == nil {
+		return img
+	}
+	return LimitPalette(img, g.paletteLimit)
+}
+
+// LimitPalette maps img onto limit.Colors colors: gray levels in grayscale
+// mode, otherwise a palette picked from the image by median cut
+func LimitPalette(img image.Image, limit *PaletteLimit) *image.RGBA {
+	if limit.Grayscale {
+		levels := limit.Colors
+		if levels == 0 {
+			levels = GrayLevels
+		}
+		colors := make([]color.RGBA, levels)
+		for i := range colors {
+			v := uint8(i * 255 / (levels - 1))
+			colors[i] = color.RGBA{R: v, G: v, B: v, A: 255}
+		}
+		return quantize(grayscale(img), colors, limit.Dither)
+	}
+	return quantize(img, medianCut(img, limit.Colors), limit.Dither)
+}
+
+// grayscale converts img to gray by luma, keeping alpha
+func grayscale(img image.Image) *image.RGBA {
+	bounds := img.Bounds()
+	out := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	for y := 0; y < bounds.Dy(); y++ {
+		for x := 0; x < bounds.Dx(); x++ {
+			c := img.At(bounds.Min.X+x, bounds.Min.Y+y)
+			_, _, _, a := c.RGBA()
+			g := color.GrayModel.Convert(c).(color.Gray)
+			out.Set(x, y, color.RGBA{R: g.Y, G: g.Y, B: g.Y, A: uint8(a >> 8)})
+		}
+	}
+	return out
+}
+
+// maxPaletteSamples bounds the pixels median cut looks at; wallpapers are
+// smooth enough that a grid sample finds the same palette
+const maxPaletteSamples = 1 << 16
+
+// colorCount is a distinct color of an image and how often it occurs
+type colorCount struct {
+	rgb   [3]uint8
+	count int
+}
+
+// medianCut picks n colors representing img. The distinct colors of a
+// sample are split at the middle of the widest channel range until there
+// are n boxes, and each box contributes its average weighted by count.
+// Splitting the range rather than the pixel count keeps small accents from
+// being averaged into a dominant background.
+func medianCut(img image.Image, n int) []color.RGBA {
+	bounds := img.Bounds()
+	step := 1
+	for (bounds.Dx()/step)*(bounds.Dy()/step) > maxPaletteSamples {
+		step++
+	}
+
+	counts := make(map[[3]uint8]int)
+	for y := bounds.Min.Y; y < bounds.Max.Y; y += step {
+		for x := bounds.Min.X; x < bounds.Max.X; x += step {
+			r, g, b, _ := img.At(x, y).RGBA()
+			counts[[3]uint8{uint8(r >> 8), uint8(g >> 8), uint8(b >> 8)}]++
+		}
+	}
+	if len(counts) == 0 {
+		return []color.RGBA{{A: 255}}
+	}
+	all := make([]colorCount, 0, len(counts))
+	for rgb, count := range counts {
+		all = append(all, colorCount{rgb, count})
+	}
+
+	boxes := [][]colorCount{all}
+	for len(boxes) < n {
+		widest, channel, spread, cut := -1, 0, 0, uint8(0)
+		for i, box := range boxes {
+			for c := 0; c < 3; c++ {
+				lo, hi := box[0].rgb[c], box[0].rgb[c]
+				for _, p := range box {
+					lo, hi = min(lo, p.rgb[c]), max(hi, p.rgb[c])
+				}
+				if int(hi-lo) > spread {
+					widest, channel, spread, cut = i, c, int(hi-lo), lo+(hi-lo)/2
+				}
+			}
+		}
+		if widest < 0 {
+			// Fewer distinct colors than n
+			break
+		}
+
+		box := boxes[widest]
+		sort.Slice(box, func(a, b int) bool { return box[a].rgb[channel] < box[b].rgb[channel] })
+		mid := sort.Search(len(box), func(i int) bool { return box[i].rgb[channel] > cut })
+		boxes[widest] = box[:mid]
+		boxes = append(boxes, box[mid:])
+	}
+
+	colors := make([]color.RGBA, 0, len(boxes))
+	for _, box := range boxes {
+		var sum [3]int
+		total := 0
+		for _, p := range box {
+			for c := 0; c < 3; c++ {
+				sum[c] += int(p.rgb[c]) * p.count
+			}
+			total += p.count
+		}
+		colors = append(colors, color.RGBA{
+			R: uint8(sum[0] / total),
+			G: uint8(sum[1] / total),
+			B: uint8(sum[2] / total),
+			A: 255,
+		})
+	}
+	return colors
+}
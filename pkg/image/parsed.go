@@ -0,0 +1,61 @@
+package image
+
+import (
+	"context"
+	"fmt"
+	"image"
+
+	"github.com/srwiley/oksvg"
+)
+
+// Parsed is an SVG prepared once for rendering at any number of sizes: its
+// dimensions and aspect ratio are read, and with oksvg the SVG is parsed,
+// a single time. A Parsed belongs to the Generator that made it and may be
+// rendered concurrently.
+type Parsed struct {
+	content string
+	icon    *oksvg.SvgIcon
+	width   int
+	height  int
+	ratio   aspectRatio
+	// focus is kept visible when cropping, unless the aspect ratio is
+	// overridden
+	focus *focus
+}
+
+// Parse prepares svgContent for RenderParsed
+func (g *Generator) Parse(svgContent string) (*Parsed, error) {
+	if err := g.limits.CheckSVGSize(int64(len(svgContent))); err != nil {
+		return nil, err
+	}
+
+	width, height, err := g.extractSVGDimensions(svgContent)
+	if err != nil {
+		return nil, fmt.Errorf("failed to extract SVG dimensions: %w", err)
+	}
+
+	p := &Parsed{content: svgContent, width: width, height: height, ratio: g.templateAspectRatio(svgContent)}
+	if g.aspect == "" {
+		if p.focus, err = parseFocus(svgContent); err != nil {
+			return nil, err
+		}
+	}
+	if g.Backend() == BackendOKSVG {
+		if p.icon, err = parseOKSVG(svgContent); err != nil {
+			return nil, err
+		}
+	} else if p.ratio.none {
+		p.content = stretchSVG(svgContent)
+	}
+	return p, nil
+}
+
+// RenderParsed is Render for an SVG prepared by Parse
+func (g *Generator) RenderParsed(p *Parsed, width, height int) (*image.RGBA, error) {
+	return g.RenderParsedContext(context.Background(), p, width, height)
+}
+
+// RenderParsedContext is RenderContext for an SVG prepared by Parse
+func (g *Generator) RenderParsedContext(ctx context.Context, p *Parsed, width, height int) (*image.RGBA, error) {
+	return g.renderSafe(ctx, p, width, height)
+}
@@ -0,0 +1,174 @@
+package image
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"sort"
+
+	"github.com/byteowlz/ppr/pkg/palette"
+)
+
+// Recolor modes
+const (
+	// RecolorNearest maps every pixel to the perceptually nearest palette color
+	RecolorNearest = "nearest"
+	// RecolorLuminance builds a gradient through the palette sorted by lightness
+	// and maps each pixel by its lightness, preserving the image's tonal structure
+	RecolorLuminance = "luminance"
+)
+
+// RecolorOptions controls how an image is mapped onto a palette
+type RecolorOptions struct {
+	Mode   string
+	Dither bool
+}
+
+// Recolor maps the colors of src onto the given palette
+func Recolor(src image.Image, colors []color.RGBA, opts RecolorOptions) (*image.RGBA, error) {
+	if len(colors) == 0 {
+		return nil, fmt.Errorf("palette is empty")
+	}
+
+	switch opts.Mode {
+	case "", RecolorNearest:
+		return quantize(src, colors, opts.Dither), nil
+	case RecolorLuminance:
+		return gradientMap(src, colors), nil
+	default:
+		return nil, fmt.Errorf("unknown recolor mode: %s (expected %s or %s)", opts.Mode, RecolorNearest, RecolorLuminance)
+	}
+}
+
+// quantize maps each pixel to the nearest palette color in OKLab, optionally
+// diffusing the quantization error with Floyd-Steinberg dithering
+func quantize(src image.Image, colors []color.RGBA, dither bool) *image.RGBA {
+	bounds := src.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	out := image.NewRGBA(image.Rect(0, 0, width, height))
+
+	labs := make([]palette.OKLab, len(colors))
+	for i, c := range colors {
+		labs[i] = palette.ToOKLab(c)
+	}
+
+	// Error buffers for the current and next row, 3 channels per pixel
+	var curErr, nextErr []float64
+	if dither {
+		curErr = make([]float64, (width+2)*3)
+		nextErr = make([]float64, (width+2)*3)
+	}
+
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			r, g, b, a := src.At(bounds.Min.X+x, bounds.Min.Y+y).RGBA()
+			fr, fg, fb := float64(r>>8), float64(g>>8), float64(b>>8)
+
+			if dither {
+				i := (x + 1) * 3
+				fr, fg, fb = fr+curErr[i], fg+curErr[i+1], fb+curErr[i+2]
+			}
+
+			want := color.RGBA{R: clampByte(fr), G: clampByte(fg), B: clampByte(fb), A: 255}
+			best := nearestColor(palette.ToOKLab(want), labs)
+			chosen := colors[best]
+			out.Set(x, y, color.RGBA{R: chosen.R, G: chosen.G, B: chosen.B, A: uint8(a >> 8)})
+
+			if dither {
+				er, eg, eb := fr-float64(chosen.R), fg-float64(chosen.G), fb-float64(chosen.B)
+				diffuse(curErr, (x+2)*3, er, eg, eb, 7.0/16)
+				diffuse(nextErr, x*3, er, eg, eb, 3.0/16)
+				diffuse(nextErr, (x+1)*3, er, eg, eb, 5.0/16)
+				diffuse(nextErr, (x+2)*3, er, eg, eb, 1.0/16)
+			}
+		}
+
+		if dither {
+			curErr, nextErr = nextErr, curErr
+			for i := range nextErr {
+				nextErr[i] = 0
+			}
+		}
+	}
+
+	return out
+}
+
+// gradientMap maps pixel lightness onto a ramp through the palette colors
+// sorted by lightness, interpolating in OKLab between neighbouring stops
+func gradientMap(src image.Image, colors []color.RGBA) *image.RGBA {
+	bounds := src.Bounds()
+	out := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+
+	stops := make([]palette.OKLab, len(colors))
+	for i, c := range colors {
+		stops[i] = palette.ToOKLab(c)
+	}
+	sort.Slice(stops, func(i, j int) bool {
+		return stops[i].L < stops[j].L
+	})
+
+	minL, maxL := stops[0].L, stops[len(stops)-1].L
+
+	for y := 0; y < bounds.Dy(); y++ {
+		for x := 0; x < bounds.Dx(); x++ {
+			r, g, b, a := src.At(bounds.Min.X+x, bounds.Min.Y+y).RGBA()
+			lab := palette.ToOKLab(color.RGBA{R: uint8(r >> 8), G: uint8(g >> 8), B: uint8(b >> 8), A: 255})
+
+			// Stretch the source lightness onto the palette's lightness range
+			target := minL + lab.L*(maxL-minL)
+			mapped := stops[len(stops)-1]
+			for i := 1; i < len(stops); i++ {
+				if target <= stops[i].L {
+					lo, hi := stops[i-1], stops[i]
+					t := 0.0
+					if hi.L > lo.L {
+						t = (target - lo.L) / (hi.L - lo.L)
+					}
+					mapped = palette.OKLab{
+						L: target,
+						A: lo.A + (hi.A-lo.A)*t,
+						B: lo.B + (hi.B-lo.B)*t,
+					}
+					break
+				}
+			}
+			if len(stops) == 1 {
+				mapped = stops[0]
+			}
+
+			c := palette.FromOKLab(mapped)
+			c.A = uint8(a >> 8)
+			out.Set(x, y, c)
+		}
+	}
+
+	return out
+}
+
+func nearestColor(target palette.OKLab, labs []palette.OKLab) int {
+	best := 0
+	bestDist := target.Distance(labs[0])
+	for i := 1; i < len(labs); i++ {
+		if d := target.Distance(labs[i]); d < bestDist {
+			best, bestDist = i, d
+		}
+	}
+	return best
+}
+
+func diffuse(buf []float64, i int, er, eg, eb, weight float64) {
+	buf[i] += er * weight
+	buf[i+1] += eg * weight
+	buf[i+2] += eb * weight
+}
+
+func clampByte(v float64) uint8 {
+	if v < 0 {
+		return 0
+	}
+	if v > 255 {
+		return 255
+	}
+	return uint8(v + 0.5)
+}
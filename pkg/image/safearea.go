@@ -0,0 +1,96 @@
+package image
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+)
+
+// Safe area modes, see SafeArea
+const (
+	SafeAreaShift = "shift"
+	SafeAreaScale = "scale"
+)
+
+// SafeArea reserves screen edges covered by a menu bar or dock, in pixels
+// of the rendered wallpaper. Shift keeps the render covering the screen and
+// moves its center to the center of the free area, zooming in as far as
+// the move needs. Scale renders the template into the free area and fills
+// the reserved edges with Fill.
+type SafeArea struct {
+	Mode   string `json:"mode"`
+	Top    int    `json:"top,omitempty"`
+	Right  int    `json:"right,omitempty"`
+	Bottom int    `json:"bottom,omitempty"`
+	Left   int    `json:"left,omitempty"`
+	// Fill colors the reserved edges in scale mode, transparent when nil
+	Fill color.Color `json:"-"`
+}
+
+// Validate reports an unknown mode or negative insets
+func (a *SafeArea) Validate() error {
+	if a.Mode != SafeAreaShift && a.Mode != SafeAreaScale {
+		return fmt.Errorf("unknown safe area mode %q (expected %s or %s)", a.Mode, SafeAreaShift, SafeAreaScale)
+	}
+	if a.Top < 0 || a.Right < 0 || a.Bottom < 0 || a.Left < 0 {
+		return fmt.Errorf("safe area insets cannot be negative")
+	}
+	return nil
+}
+
+// Empty reports whether a reserves nothing
+func (a *SafeArea) Empty() bool {
+	return a.Top == 0 && a.Right == 0 && a.Bottom == 0 && a.Left == 0
+}
+
+// SetSafeArea keeps every render clear of the reserved edges of area, nil
+// to use the whole screen again
+func (g *Generator) SetSafeArea(area *SafeArea) error {
+	if area != nil {
+		if err := area.Validate(); err != nil {
+			return err
+		}
+	}
+	g.safeArea = area
+	return nil
+}
+
+// renderSafe renders p at width x height around the safe area, or plainly
+// without one
+func (g *Generator) renderSafe(ctx context.Context, p *Parsed, width, height int) (*image.RGBA, error) {
+	area := g.safeArea
+	if area == nil || area.Empty() {
+		return g.render(ctx, p, width, height, 0, 0)
+	}
+
+	freeWidth, freeHeight := width-area.Left-area.Right, height-area.Top-area.Bottom
+	if freeWidth <= 0 || freeHeight <= 0 {
+		return nil, fmt.Errorf("safe area leaves no room at %dx%d", width, height)
+	}
+
+	// A stretched template has no focal point to move, it is fit into the
+	// free area instead
+	if area.Mode == SafeAreaShift && !p.ratio.none {
+		return g.render(ctx, p, width, height, (area.Left-area.Right)/2, (area.Top-area.Bottom)/2)
+	}
+
+	inner, err := g.render(ctx, p, freeWidth, freeHeight, 0, 0)
+	if err != nil {
+		return nil, err
+	}
+	canvas := image.NewRGBA(image.Rect(0, 0, width, height))
+	if area.Fill != nil {
+		draw.Draw(canvas, canvas.Bounds(), image.NewUniform(area.Fill), image.Point{}, draw.Src)
+	}
+	draw.Draw(canvas, inner.Bounds().Add(image.Pt(area.Left, area.Top)), inner, image.Point{}, draw.Over)
+	return canvas, nil
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
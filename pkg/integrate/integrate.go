@@ -0,0 +1,306 @@
+package integrate
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/byteowlz/ppr/pkg/platform"
+)
+
+// ActionName labels the context menu entry
+const ActionName = "Set as themed wallpaper with ppr"
+
+// marker is written into every installed entry, so Remove never deletes a
+// script or workflow of the same name that ppr did not create
+const marker = "Installed by 'ppr integrate install'"
+
+// ErrNotInstalled is returned by Remove when there is no entry to remove
+var ErrNotInstalled = errors.New("the file manager action is not installed")
+
+// windowsKey is the context menu verb of all image types for the user
+const windowsKey = `HKCU\Software\Classes\SystemFileAssociations\image\shell\ppr`
+
+// Integration is the context menu entry of a file manager
+type Integration struct {
+	// FileManager names the file manager showing the entry
+	FileManager string
+	// Location is the script, workflow or registry key holding it
+	Location string
+}
+
+// Install registers an entry in the file manager that recolors the selected
+// image to the current theme and sets it as wallpaper with execPath: a
+// Nautilus script on Linux and BSD, a Finder Quick Action on macOS and an
+// Explorer context menu entry on Windows. Installing again updates it.
+func Install(execPath string) (*Integration, error) {
+	integration, err := location()
+	if err != nil {
+		return nil, err
+	}
+
+	switch runtime.GOOS {
+	case "windows":
+		command := `"` + execPath + `" recolor "%1" --set-wallpaper`
+		for _, args := range [][]string{
+			{"add", windowsKey, "/ve", "/d", ActionName, "/f"},
+			{"add", windowsKey, "/v", "Icon", "/d", execPath, "/f"},
+			{"add", windowsKey + `\command`, "/ve", "/d", command, "/f"},
+		} {
+			if output, err := exec.Command("reg", args...).CombinedOutput(); err != nil {
+				return nil, fmt.Errorf("failed to register %s: %w: %s", windowsKey, err, strings.TrimSpace(string(output)))
+			}
+		}
+
+	case "darwin":
+		contents := filepath.Join(integration.Location, "Contents")
+		if err := os.MkdirAll(contents, 0755); err != nil {
+			return nil, fmt.Errorf("failed to create %s: %w", contents, err)
+		}
+		script := fmt.Sprintf("# %s\nexec %s recolor \"$1\" --set-wallpaper\n", marker, shellQuote(execPath))
+		files := map[string]string{
+			"Info.plist":     infoPlist,
+			"document.wflow": strings.Replace(documentWflow, "{{script}}", xmlEscape(script), 1),
+		}
+		for name, content := range files {
+			path := filepath.Join(contents, name)
+			if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+				return nil, fmt.Errorf("failed to write %s: %w", path, err)
+			}
+		}
+		// Finder picks up new services after the pasteboard server rescans
+		exec.Command("/System/Library/CoreServices/pbs", "-update").Run()
+
+	default:
+		if err := os.MkdirAll(filepath.Dir(integration.Location), 0755); err != nil {
+			return nil, fmt.Errorf("failed to create %s: %w", filepath.Dir(integration.Location), err)
+		}
+		// Scripts run without a terminal, failures are shown as a notification
+		script := fmt.Sprintf(`#!/bin/sh
+# %s
+[ -n "$1" ] || exit 0
+if ! output=$(%s recolor "$1" --set-wallpaper 2>&1); then
+	command -v notify-send >/dev/null && notify-send "ppr" "$(printf '%%s\n' "$output" | tail -n 1)"
+	exit 1
+fi
+`, marker, shellQuote(execPath))
+		if err := os.WriteFile(integration.Location, []byte(script), 0755); err != nil {
+			return nil, fmt.Errorf("failed to write %s: %w", integration.Location, err)
+		}
+	}
+	return integration, nil
+}
+
+// Remove deletes the entry Install registered, ErrNotInstalled when there
+// is none
+func Remove() (*Integration, error) {
+	integration, err := location()
+	if err != nil {
+		return nil, err
+	}
+
+	switch runtime.GOOS {
+	case "windows":
+		if exec.Command("reg", "query", windowsKey).Run() != nil {
+			return nil, ErrNotInstalled
+		}
+		if output, err := exec.Command("reg", "delete", windowsKey, "/f").CombinedOutput(); err != nil {
+			return nil, fmt.Errorf("failed to delete %s: %w: %s", windowsKey, err, strings.TrimSpace(string(output)))
+		}
+		return integration, nil
+
+	case "darwin":
+		if err := checkMarker(filepath.Join(integration.Location, "Contents", "document.wflow")); err != nil {
+			return nil, err
+		}
+		if err := os.RemoveAll(integration.Location); err != nil {
+			return nil, fmt.Errorf("failed to remove %s: %w", integration.Location, err)
+		}
+		exec.Command("/System/Library/CoreServices/pbs", "-update").Run()
+		return integration, nil
+	}
+
+	if err := checkMarker(integration.Location); err != nil {
+		return nil, err
+	}
+	if err := os.Remove(integration.Location); err != nil {
+		return nil, fmt.Errorf("failed to remove %s: %w", integration.Location, err)
+	}
+	return integration, nil
+}
+
+// location finds where the entry of the running OS lives
+func location() (*Integration, error) {
+	if runtime.GOOS == "windows" {
+		return &Integration{FileManager: "Explorer", Location: windowsKey}, nil
+	}
+	if platform.IsTermux() {
+		return nil, fmt.Errorf("file manager integration is not supported on Android")
+	}
+
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return nil, fmt.Errorf("failed to find home directory: %w", err)
+	}
+	if runtime.GOOS == "darwin" {
+		return &Integration{FileManager: "Finder", Location: filepath.Join(homeDir, "Library", "Services", ActionName+".workflow")}, nil
+	}
+
+	dataHome := os.Getenv("XDG_DATA_HOME")
+	if dataHome == "" {
+		dataHome = filepath.Join(homeDir, ".local", "share")
+	}
+	return &Integration{FileManager: "Nautilus", Location: filepath.Join(dataHome, "nautilus", "scripts", ActionName)}, nil
+}
+
+// checkMarker makes sure path exists and was written by Install
+func checkMarker(path string) error {
+	content, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return ErrNotInstalled
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	if !strings.Contains(string(content), marker) {
+		return fmt.Errorf("%s was not installed by ppr, remove it by hand", path)
+	}
+	return nil
+}
+
+// shellQuote quotes s for a POSIX shell
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+func xmlEscape(s string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", `"`, "&quot;").Replace(s)
+}
+
+// infoPlist offers the workflow as a Finder service for images
+const infoPlist = `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+	<key>NSServices</key>
+	<array>
+		<dict>
+			<key>NSMenuItem</key>
+			<dict>
+				<key>default</key>
+				<string>` + ActionName + `</string>
+			</dict>
+			<key>NSMessage</key>
+			<string>runWorkflowAsService</string>
+			<key>NSRequiredContext</key>
+			<dict>
+				<key>NSApplicationIdentifier</key>
+				<string>com.apple.finder</string>
+			</dict>
+			<key>NSSendFileTypes</key>
+			<array>
+				<string>public.image</string>
+			</array>
+		</dict>
+	</array>
+</dict>
+</plist>
+`
+
+// documentWflow is a Quick Action running a shell script with the selected
+// files as arguments
+const documentWflow = `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+	<key>AMApplicationBuild</key>
+	<string>523</string>
+	<key>AMApplicationVersion</key>
+	<string>2.10</string>
+	<key>AMDocumentVersion</key>
+	<string>2</string>
+	<key>actions</key>
+	<array>
+		<dict>
+			<key>action</key>
+			<dict>
+				<key>AMAccepts</key>
+				<dict>
+					<key>Container</key>
+					<string>List</string>
+					<key>Optional</key>
+					<true/>
+					<key>Types</key>
+					<array>
+						<string>com.apple.cocoa.string</string>
+					</array>
+				</dict>
+				<key>AMActionVersion</key>
+				<string>2.0.3</string>
+				<key>AMApplication</key>
+				<array>
+					<string>Automator</string>
+				</array>
+				<key>AMProvides</key>
+				<dict>
+					<key>Container</key>
+					<string>List</string>
+					<key>Types</key>
+					<array>
+						<string>com.apple.cocoa.string</string>
+					</array>
+				</dict>
+				<key>ActionBundlePath</key>
+				<string>/System/Library/Automator/Run Shell Script.action</string>
+				<key>ActionName</key>
+				<string>Run Shell Script</string>
+				<key>ActionParameters</key>
+				<dict>
+					<key>COMMAND_STRING</key>
+					<string>{{script}}</string>
+					<key>CheckedForUserDefaultShell</key>
+					<true/>
+					<key>inputMethod</key>
+					<integer>1</integer>
+					<key>shell</key>
+					<string>/bin/sh</string>
+					<key>source</key>
+					<string></string>
+				</dict>
+				<key>BundleIdentifier</key>
+				<string>com.apple.RunShellScript</string>
+				<key>CFBundleVersion</key>
+				<string>2.0.3</string>
+				<key>Class Name</key>
+				<string>RunShellScriptAction</string>
+				<key>InputUUID</key>
+				<string>5F1E7A52-8D0B-4C61-9A3E-2B7D4C61F1C9</string>
+				<key>OutputUUID</key>
+				<string>A0E2B7D4-C613-4F1C-9A0E-2B7D4C61A3E5</string>
+				<key>UUID</key>
+				<string>3F1C9A0E-2B7D-4C61-8D0B-5F1E7A52A3E5</string>
+			</dict>
+		</dict>
+	</array>
+	<key>connectors</key>
+	<dict/>
+	<key>workflowMetaData</key>
+	<dict>
+		<key>serviceApplicationBundleID</key>
+		<string>com.apple.finder</string>
+		<key>serviceInputTypeIdentifier</key>
+		<string>com.apple.Automator.fileSystemObject.image</string>
+		<key>serviceOutputTypeIdentifier</key>
+		<string>com.apple.Automator.nothing</string>
+		<key>serviceProcessesInput</key>
+		<false/>
+		<key>workflowTypeIdentifier</key>
+		<string>com.apple.Automator.servicesMenu</string>
+	</dict>
+</dict>
+</plist>
+`
@@ -0,0 +1,230 @@
+package locale
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Locale holds the names and separators text placeholders are formatted
+// with
+type Locale struct {
+	// Language is the language code, e.g. de
+	Language string
+	// RTL is set for languages written right to left
+	RTL bool
+
+	months     [12]string
+	days       [7]string
+	dateLayout string
+	decimal    string
+	group      string
+	// abbrev is the number of letters of short names, 0 keeps full names
+	abbrev int
+}
+
+// English is the default locale
+var English = &Locale{
+	Language:   "en",
+	months:     [12]string{"January", "February", "March", "April", "May", "June", "July", "August", "September", "October", "November", "December"},
+	days:       [7]string{"Sunday", "Monday", "Tuesday", "Wednesday", "Thursday", "Friday", "Saturday"},
+	dateLayout: "Monday, January 2, 2006",
+	decimal:    ".",
+	group:      ",",
+	abbrev:     3,
+}
+
+var locales = map[string]*Locale{
+	"en": English,
+	"de": {
+		Language:   "de",
+		months:     [12]string{"Januar", "Februar", "März", "April", "Mai", "Juni", "Juli", "August", "September", "Oktober", "November", "Dezember"},
+		days:       [7]string{"Sonntag", "Montag", "Dienstag", "Mittwoch", "Donnerstag", "Freitag", "Samstag"},
+		dateLayout: "Monday, 2. January 2006",
+		decimal:    ",",
+		group:      ".",
+		abbrev:     3,
+	},
+	"fr": {
+		Language:   "fr",
+		months:     [12]string{"janvier", "février", "mars", "avril", "mai", "juin", "juillet", "août", "septembre", "octobre", "novembre", "décembre"},
+		days:       [7]string{"dimanche", "lundi", "mardi", "mercredi", "jeudi", "vendredi", "samedi"},
+		dateLayout: "Monday 2 January 2006",
+		decimal:    ",",
+		group:      "\u00a0",
+		abbrev:     4,
+	},
+	"es": {
+		Language:   "es",
+		months:     [12]string{"enero", "febrero", "marzo", "abril", "mayo", "junio", "julio", "agosto", "septiembre", "octubre", "noviembre", "diciembre"},
+		days:       [7]string{"domingo", "lunes", "martes", "miércoles", "jueves", "viernes", "sábado"},
+		dateLayout: "Monday, 2 de January de 2006",
+		decimal:    ",",
+		group:      ".",
+		abbrev:     3,
+	},
+	"it": {
+		Language:   "it",
+		months:     [12]string{"gennaio", "febbraio", "marzo", "aprile", "maggio", "giugno", "luglio", "agosto", "settembre", "ottobre", "novembre", "dicembre"},
+		days:       [7]string{"domenica", "lunedì", "martedì", "mercoledì", "giovedì", "venerdì", "sabato"},
+		dateLayout: "Monday 2 January 2006",
+		decimal:    ",",
+		group:      ".",
+		abbrev:     3,
+	},
+	"nl": {
+		Language:   "nl",
+		months:     [12]string{"januari", "februari", "maart", "april", "mei", "juni", "juli", "augustus", "september", "oktober", "november", "december"},
+		days:       [7]string{"zondag", "maandag", "dinsdag", "woensdag", "donderdag", "vrijdag", "zaterdag"},
+		dateLayout: "Monday 2 January 2006",
+		decimal:    ",",
+		group:      ".",
+		abbrev:     3,
+	},
+	"pt": {
+		Language:   "pt",
+		months:     [12]string{"janeiro", "fevereiro", "março", "abril", "maio", "junho", "julho", "agosto", "setembro", "outubro", "novembro", "dezembro"},
+		days:       [7]string{"domingo", "segunda-feira", "terça-feira", "quarta-feira", "quinta-feira", "sexta-feira", "sábado"},
+		dateLayout: "Monday, 2 de January de 2006",
+		decimal:    ",",
+		group:      ".",
+		abbrev:     3,
+	},
+	"sv": {
+		Language:   "sv",
+		months:     [12]string{"januari", "februari", "mars", "april", "maj", "juni", "juli", "augusti", "september", "oktober", "november", "december"},
+		days:       [7]string{"söndag", "måndag", "tisdag", "onsdag", "torsdag", "fredag", "lördag"},
+		dateLayout: "Monday 2 January 2006",
+		decimal:    ",",
+		group:      "\u00a0",
+		abbrev:     3,
+	},
+	"pl": {
+		Language: "pl",
+		// Dates use the genitive, 2 stycznia
+		months:     [12]string{"stycznia", "lutego", "marca", "kwietnia", "maja", "czerwca", "lipca", "sierpnia", "września", "października", "listopada", "grudnia"},
+		days:       [7]string{"niedziela", "poniedziałek", "wtorek", "środa", "czwartek", "piątek", "sobota"},
+		dateLayout: "Monday, 2 January 2006",
+		decimal:    ",",
+		group:      "\u00a0",
+		abbrev:     3,
+	},
+	"ar": {
+		Language:   "ar",
+		RTL:        true,
+		months:     [12]string{"يناير", "فبراير", "مارس", "أبريل", "مايو", "يونيو", "يوليو", "أغسطس", "سبتمبر", "أكتوبر", "نوفمبر", "ديسمبر"},
+		days:       [7]string{"الأحد", "الإثنين", "الثلاثاء", "الأربعاء", "الخميس", "الجمعة", "السبت"},
+		dateLayout: "Monday، 2 January 2006",
+		decimal:    ".",
+		group:      ",",
+	},
+	"he": {
+		Language:   "he",
+		RTL:        true,
+		months:     [12]string{"ינואר", "פברואר", "מרץ", "אפריל", "מאי", "יוני", "יולי", "אוגוסט", "ספטמבר", "אוקטובר", "נובמבר", "דצמבר"},
+		days:       [7]string{"יום ראשון", "יום שני", "יום שלישי", "יום רביעי", "יום חמישי", "יום שישי", "שבת"},
+		dateLayout: "Monday, 2 בJanuary 2006",
+		decimal:    ".",
+		group:      ",",
+	},
+}
+
+// Languages lists the supported language codes
+func Languages() []string {
+	return []string{"en", "de", "fr", "es", "it", "nl", "pt", "sv", "pl", "ar", "he"}
+}
+
+// Parse returns the locale of a POSIX or BCP 47 name such as de_DE,
+// de_DE.UTF-8, de-AT or de. Only the language part is used.
+func Parse(name string) (*Locale, error) {
+	language := strings.ToLower(name)
+	if i := strings.IndexAny(language, "_-.@"); i >= 0 {
+		language = language[:i]
+	}
+	if language == "c" || language == "posix" {
+		return English, nil
+	}
+	l, ok := locales[language]
+	if !ok {
+		return nil, fmt.Errorf("unsupported locale: %s (supported languages: %s)", name, strings.Join(Languages(), ", "))
+	}
+	return l, nil
+}
+
+// FromEnv returns the locale of LC_ALL, LC_TIME or LANG, English when none
+// is set or supported
+func FromEnv() *Locale {
+	for _, key := range []string{"LC_ALL", "LC_TIME", "LANG"} {
+		if value := os.Getenv(key); value != "" {
+			if l, err := Parse(value); err == nil {
+				return l
+			}
+			return English
+		}
+	}
+	return English
+}
+
+// Layout sentinels keep localized names out of time.Format, which would
+// read parts of them as layout elements
+const (
+	longMonth  = "\x01"
+	shortMonth = "\x02"
+	longDay    = "\x03"
+	shortDay   = "\x04"
+)
+
+// FormatDate formats t like time.Format with localized month and weekday
+// names. An empty layout uses the long date format of the locale.
+func (l *Locale) FormatDate(t time.Time, layout string) string {
+	if layout == "" {
+		layout = l.dateLayout
+	}
+	layout = strings.NewReplacer("January", longMonth, "Jan", shortMonth, "Monday", longDay, "Mon", shortDay).Replace(layout)
+
+	month, day := l.months[t.Month()-1], l.days[t.Weekday()]
+	return strings.NewReplacer(
+		longMonth, month,
+		shortMonth, l.short(month),
+		longDay, day,
+		shortDay, l.short(day),
+	).Replace(t.Format(layout))
+}
+
+func (l *Locale) short(name string) string {
+	runes := []rune(name)
+	if l.abbrev == 0 || len(runes) <= l.abbrev {
+		return name
+	}
+	return string(runes[:l.abbrev])
+}
+
+// FormatNumber formats v with decimals digits and the separators of the
+// locale, e.g. 1.234,5 in German
+func (l *Locale) FormatNumber(v float64, decimals int) string {
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+
+	digits := strconv.FormatFloat(math.Abs(v), 'f', decimals, 64)
+	integer, fraction, _ := strings.Cut(digits, ".")
+
+	var b strings.Builder
+	if v < 0 && strings.Trim(digits, "0.") != "" {
+		b.WriteString("-")
+	}
+	for i, digit := range integer {
+		if i > 0 && (len(integer)-i)%3 == 0 {
+			b.WriteString(l.group)
+		}
+		b.WriteRune(digit)
+	}
+	if fraction != "" {
+		b.WriteString(l.decimal)
+		b.WriteString(fraction)
+	}
+	return b.String()
+}
@@ -0,0 +1,110 @@
+package manifest
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/byteowlz/ppr/pkg/image"
+)
+
+// Manifest records everything a wallpaper was rendered from, so 'ppr
+// reproduce' can render the identical file later. The palette is stored
+// as rendered, with warmth and color space conversions applied, and the
+// template by path and hash.
+type Manifest struct {
+	// Version is the ppr version that rendered the output
+	Version string    `json:"version"`
+	Created time.Time `json:"created"`
+	Theme   string    `json:"theme"`
+	// ThemeID is the palette ID of the source theme, which 'ppr generate
+	// -t' accepts as well
+	ThemeID string            `json:"theme_id,omitempty"`
+	Palette map[string]string `json:"palette"`
+	// Template is an absolute path, or in headless mode relative to the
+	// templates directory when it lies there
+	Template       string `json:"template"`
+	TemplateSHA256 string `json:"template_sha256"`
+	Font           string `json:"font,omitempty"`
+	// StrokeScale multiplied the stroke widths, see svg.ScaleStrokes
+	StrokeScale float64           `json:"stroke_scale,omitempty"`
+	Values      map[string]string `json:"values,omitempty"`
+	Width       int               `json:"width"`
+	Height      int               `json:"height"`
+	Format      string            `json:"format"`
+	// Rasterizer is the backend that rendered the output, never auto
+	Rasterizer   string `json:"rasterizer"`
+	Output       string `json:"output"`
+	OutputSHA256 string `json:"output_sha256"`
+	// Optimizer recompressed the output after rendering, see
+	// image.OptimizePNG
+	Optimizer string `json:"optimizer,omitempty"`
+	// SafeArea kept the output clear of reserved screen edges, filled with
+	// base00 of the palette
+	SafeArea *image.SafeArea `json:"safe_area,omitempty"`
+	// PaletteLimit quantized the output, after the overlays
+	PaletteLimit *image.PaletteLimit `json:"palette_limit,omitempty"`
+	// Overlays were composited onto the output in order
+	Overlays []Overlay `json:"overlays,omitempty"`
+}
+
+// Overlay is a template composited onto the output, see pipeline.Overlay
+type Overlay struct {
+	Name           string  `json:"name"`
+	Template       string  `json:"template"`
+	TemplateSHA256 string  `json:"template_sha256"`
+	Opacity        float64 `json:"opacity"`
+	Blend          string  `json:"blend"`
+}
+
+// Path is the manifest file written next to output
+func Path(output string) string {
+	return output + ".manifest.json"
+}
+
+// Load reads the manifest at path
+func Load(path string) (*Manifest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read manifest: %w", err)
+	}
+	m := &Manifest{}
+	if err := json.Unmarshal(data, m); err != nil {
+		return nil, fmt.Errorf("failed to decode manifest %s: %w", path, err)
+	}
+	if m.Template == "" || len(m.Palette) == 0 || m.Width <= 0 || m.Height <= 0 {
+		return nil, fmt.Errorf("incomplete manifest %s", path)
+	}
+	return m, nil
+}
+
+// Save writes m to path
+func (m *Manifest) Save(path string) error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode manifest: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write manifest: %w", err)
+	}
+	return nil
+}
+
+// HashFile returns the hex SHA-256 of the file at path
+func HashFile(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
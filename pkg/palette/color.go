@@ -0,0 +1,228 @@
+package palette
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// ParseHex parses a #RRGGBB or #RGB color (the leading # is optional)
+func ParseHex(hex string) (color.RGBA, error) {
+	s := strings.TrimPrefix(strings.TrimSpace(hex), "#")
+	if len(s) == 3 {
+		s = string([]byte{s[0], s[0], s[1], s[1], s[2], s[2]})
+	}
+	if len(s) != 6 {
+		return color.RGBA{}, fmt.Errorf("invalid hex color: %s", hex)
+	}
+
+	value, err := strconv.ParseUint(s, 16, 32)
+	if err != nil {
+		return color.RGBA{}, fmt.Errorf("invalid hex color: %s", hex)
+	}
+
+	return color.RGBA{
+		R: uint8(value >> 16),
+		G: uint8(value >> 8),
+		B: uint8(value),
+		A: 255,
+	}, nil
+}
+
+// ToHex formats c as an uppercase #RRGGBB string
+func ToHex(c color.RGBA) string {
+	return fmt.Sprintf("#%02X%02X%02X", c.R, c.G, c.B)
+}
+
+// OKLab is a color in the OKLab perceptual color space
+type OKLab struct {
+	L, A, B float64
+}
+
+// Distance returns the euclidean distance between two OKLab colors, a good
+// approximation of perceived color difference
+func (c OKLab) Distance(o OKLab) float64 {
+	dl, da, db := c.L-o.L, c.A-o.A, c.B-o.B
+	return math.Sqrt(dl*dl + da*da + db*db)
+}
+
+// ToOKLab converts an sRGB color to OKLab
+func ToOKLab(c color.RGBA) OKLab {
+	r := SRGBToLinear(float64(c.R) / 255)
+	g := SRGBToLinear(float64(c.G) / 255)
+	b := SRGBToLinear(float64(c.B) / 255)
+
+	l := math.Cbrt(0.4122214708*r + 0.5363325363*g + 0.0514459929*b)
+	m := math.Cbrt(0.2119034982*r + 0.6806995451*g + 0.1073969566*b)
+	s := math.Cbrt(0.0883024619*r + 0.2817188376*g + 0.6299787005*b)
+
+	return OKLab{
+		L: 0.2104542553*l + 0.7936177850*m - 0.0040720468*s,
+		A: 1.9779984951*l - 2.4285922050*m + 0.4505937099*s,
+		B: 0.0259040371*l + 0.7827717662*m - 0.8086757660*s,
+	}
+}
+
+// FromOKLab converts an OKLab color to sRGB, clipping out-of-gamut values
+func FromOKLab(c OKLab) color.RGBA {
+	r, g, b := c.linear()
+	return color.RGBA{
+		R: toByte(LinearToSRGB(r)),
+		G: toByte(LinearToSRGB(g)),
+		B: toByte(LinearToSRGB(b)),
+		A: 255,
+	}
+}
+
+// linear returns the linear sRGB channels of c, outside 0..1 when c is out
+// of gamut
+func (c OKLab) linear() (r, g, b float64) {
+	l := c.L + 0.3963377774*c.A + 0.2158037573*c.B
+	m := c.L - 0.1055613458*c.A - 0.0638541728*c.B
+	s := c.L - 0.0894841775*c.A - 1.2914855480*c.B
+	l, m, s = l*l*l, m*m*m, s*s*s
+
+	r = 4.0767416621*l - 3.3077115913*m + 0.2309699292*s
+	g = -1.2684380046*l + 2.6097574011*m - 0.3413193965*s
+	b = -0.0041960863*l - 0.7034186147*m + 1.7076147010*s
+	return r, g, b
+}
+
+// InGamut reports whether c is representable in sRGB
+func (c OKLab) InGamut() bool {
+	const eps = 1e-4
+	r, g, b := c.linear()
+	return r >= -eps && r <= 1+eps && g >= -eps && g <= 1+eps && b >= -eps && b <= 1+eps
+}
+
+// Chroma returns the colorfulness of c, its distance from the gray axis
+func (c OKLab) Chroma() float64 {
+	return math.Hypot(c.A, c.B)
+}
+
+// Hue returns the hue angle of c in degrees (0..360)
+func (c OKLab) Hue() float64 {
+	h := math.Atan2(c.B, c.A) * 180 / math.Pi
+	if h < 0 {
+		h += 360
+	}
+	return h
+}
+
+// FromOKLCh converts a lightness, chroma and hue in degrees to sRGB. Chroma
+// is reduced until the color fits the sRGB gamut, which keeps its hue and
+// lightness intact where clipping would shift them.
+func FromOKLCh(l, chroma, hue float64) color.RGBA {
+	lch := func(c float64) OKLab {
+		rad := hue * math.Pi / 180
+		return OKLab{L: l, A: c * math.Cos(rad), B: c * math.Sin(rad)}
+	}
+	if lab := lch(chroma); lab.InGamut() {
+		return FromOKLab(lab)
+	}
+	low, high := 0.0, chroma
+	for i := 0; i < 20; i++ {
+		mid := (low + high) / 2
+		if lch(mid).InGamut() {
+			low = mid
+		} else {
+			high = mid
+		}
+	}
+	return FromOKLab(lch(low))
+}
+
+// Brighten scales the OKLab lightness of c by percent (-100..100), keeping
+// its hue. -100 gives black.
+func Brighten(c color.RGBA, percent int) color.RGBA {
+	if percent == 0 {
+		return c
+	}
+	lab := ToOKLab(c)
+	lab.L = math.Min(lab.L*(1+float64(percent)/100), 1)
+	out := FromOKLab(lab)
+	out.A = c.A
+	return out
+}
+
+// Luminance returns the WCAG relative luminance (0..1) of an sRGB color
+func Luminance(c color.RGBA) float64 {
+	r := SRGBToLinear(float64(c.R) / 255)
+	g := SRGBToLinear(float64(c.G) / 255)
+	b := SRGBToLinear(float64(c.B) / 255)
+	return 0.2126*r + 0.7152*g + 0.0722*b
+}
+
+// ContrastRatio returns the WCAG contrast ratio (1..21) between two colors
+func ContrastRatio(a, b color.RGBA) float64 {
+	la, lb := Luminance(a), Luminance(b)
+	if la < lb {
+		la, lb = lb, la
+	}
+	return (la + 0.05) / (lb + 0.05)
+}
+
+// SRGBToLinear removes the sRGB transfer function from a 0..1 channel value
+func SRGBToLinear(v float64) float64 {
+	if v <= 0.04045 {
+		return v / 12.92
+	}
+	return math.Pow((v+0.055)/1.055, 2.4)
+}
+
+// LinearToSRGB applies the sRGB transfer function to a linear 0..1 channel value
+func LinearToSRGB(v float64) float64 {
+	if v <= 0.0031308 {
+		return v * 12.92
+	}
+	return 1.055*math.Pow(v, 1/2.4) - 0.055
+}
+
+func toByte(v float64) uint8 {
+	v = math.Round(v * 255)
+	if v < 0 {
+		return 0
+	}
+	if v > 255 {
+		return 255
+	}
+	return uint8(v)
+}
+
+// EnsureContrast moves the OKLab lightness of c away from bg until their
+// WCAG contrast ratio reaches ratio, keeping its hue. Chroma shrinks only
+// where the lighter or darker color leaves the sRGB gamut. A ratio out of
+// reach gives the furthest color, close to white or black.
+func EnsureContrast(c, bg color.RGBA, ratio float64) color.RGBA {
+	if ContrastRatio(c, bg) >= ratio {
+		return c
+	}
+	lab := ToOKLab(c)
+	chroma, hue := lab.Chroma(), lab.Hue()
+
+	// Lighten on dark backgrounds and darken on light ones
+	target := 1.0
+	if Luminance(bg) > 0.18 {
+		target = 0
+	}
+	at := func(l float64) color.RGBA {
+		out := FromOKLCh(l, chroma, hue)
+		out.A = c.A
+		return out
+	}
+	if ContrastRatio(at(target), bg) < ratio {
+		return at(target)
+	}
+	near, far := lab.L, target
+	for i := 0; i < 20; i++ {
+		mid := (near + far) / 2
+		if ContrastRatio(at(mid), bg) >= ratio {
+			far = mid
+		} else {
+			near = mid
+		}
+	}
+	return at(far)
+}
@@ -0,0 +1,51 @@
+package palette
+
+import (
+	"fmt"
+	"image/color"
+)
+
+// Color spaces theme hex values can be authored in. Rendered wallpapers are
+// always sRGB, which every display color-manages correctly.
+const (
+	ColorSpaceSRGB      = "srgb"
+	ColorSpaceDisplayP3 = "display-p3"
+)
+
+// displayP3ToSRGB maps linear Display P3 to linear sRGB, both with the D65
+// white point
+var displayP3ToSRGB = [3][3]float64{
+	{1.2249401, -0.2249404, 0},
+	{-0.0420569, 1.0420571, 0},
+	{-0.0196376, -0.0786361, 1.0982735},
+}
+
+// CheckColorSpace reports names other than srgb and display-p3. Empty means
+// sRGB.
+func CheckColorSpace(space string) error {
+	switch space {
+	case "", ColorSpaceSRGB, ColorSpaceDisplayP3:
+		return nil
+	}
+	return fmt.Errorf("invalid color space %q (expected %s or %s)", space, ColorSpaceSRGB, ColorSpaceDisplayP3)
+}
+
+// DisplayP3ToSRGB converts a Display P3 color to sRGB. Both share the sRGB
+// transfer function; colors outside the sRGB gamut are clipped per channel.
+func DisplayP3ToSRGB(c color.RGBA) color.RGBA {
+	linear := [3]float64{
+		SRGBToLinear(float64(c.R) / 255),
+		SRGBToLinear(float64(c.G) / 255),
+		SRGBToLinear(float64(c.B) / 255),
+	}
+
+	var out [3]uint8
+	for i, row := range displayP3ToSRGB {
+		v := row[0]*linear[0] + row[1]*linear[1] + row[2]*linear[2]
+		if v < 0 {
+			v = 0
+		}
+		out[i] = toByte(LinearToSRGB(v))
+	}
+	return color.RGBA{R: out[0], G: out[1], B: out[2], A: c.A}
+}
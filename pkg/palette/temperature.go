@@ -0,0 +1,60 @@
+package palette
+
+import (
+	"image/color"
+	"math"
+)
+
+// NeutralKelvin is the color temperature of the sRGB white point; warming
+// to it leaves colors unchanged
+const NeutralKelvin = 6500
+
+// MinKelvin is the warmest supported color temperature
+const MinKelvin = 1000
+
+// WhitePoint approximates the sRGB color of a blackbody at the given
+// temperature, after Tanner Helland's fit of the CIE 1964 data
+func WhitePoint(kelvin int) color.RGBA {
+	t := float64(kelvin) / 100
+
+	var r, g, b float64
+	if t <= 66 {
+		r = 255
+		g = 99.4708025861*math.Log(t) - 161.1195681661
+	} else {
+		r = 329.698727446 * math.Pow(t-60, -0.1332047592)
+		g = 288.1221695283 * math.Pow(t-60, -0.0755148492)
+	}
+	switch {
+	case t >= 66:
+		b = 255
+	case t <= 19:
+		b = 0
+	default:
+		b = 138.5177312231*math.Log(t-10) - 305.0447927307
+	}
+
+	return color.RGBA{R: toByte(r / 255), G: toByte(g / 255), B: toByte(b / 255), A: 255}
+}
+
+// Warm shifts c towards the white point of kelvin, relative to
+// NeutralKelvin, like a display night mode. Channels are scaled in linear
+// light so dark colors warm as much as light ones.
+func Warm(c color.RGBA, kelvin int) color.RGBA {
+	if kelvin == NeutralKelvin {
+		return c
+	}
+
+	target, neutral := WhitePoint(kelvin), WhitePoint(NeutralKelvin)
+	scale := func(v, t, n uint8) uint8 {
+		factor := SRGBToLinear(float64(t)/255) / SRGBToLinear(float64(n)/255)
+		return toByte(LinearToSRGB(SRGBToLinear(float64(v)/255) * factor))
+	}
+
+	return color.RGBA{
+		R: scale(c.R, target.R, neutral.R),
+		G: scale(c.G, target.G, neutral.G),
+		B: scale(c.B, target.B, neutral.B),
+		A: c.A,
+	}
+}
@@ -0,0 +1,125 @@
+package pipeline
+
+import (
+	"context"
+	"fmt"
+	stdimage "image"
+	"image/draw"
+	"os"
+	"strings"
+
+	"github.com/byteowlz/ppr/pkg/image"
+	"github.com/byteowlz/ppr/pkg/manifest"
+	"github.com/byteowlz/ppr/pkg/svg"
+)
+
+// Overlay is a template composited onto every raster variant of a run,
+// e.g. seasonal decorations
+type Overlay struct {
+	// Name is appended to the variant name, e.g. shapes+snow.png, so a
+	// decorated render never replaces the plain one
+	Name         string
+	TemplatePath string
+	// Opacity is 0-1 and Blend a mode of image.BlendModes
+	Opacity float64
+	Blend   string
+}
+
+// overlaySuffix names the overlays in a variant file name
+func overlaySuffix(overlays []Overlay) string {
+	var suffix strings.Builder
+	for _, overlay := range overlays {
+		suffix.WriteString("+" + overlay.Name)
+	}
+	return suffix.String()
+}
+
+// ComposeOverlays renders overlays with colors at the size of base and
+// stacks them on it in order. The rasterizer, fonts, limits, locale and
+// sanitizing of opts apply to the overlay templates as well, the aspect
+// ratio and safe area do not: overlays always cover the whole screen.
+func ComposeOverlays(ctx context.Context, base stdimage.Image, overlays []Overlay, colors map[string]string, opts Options) (*stdimage.RGBA, error) {
+	bounds := base.Bounds()
+	canvas := stdimage.NewRGBA(stdimage.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(canvas, canvas.Bounds(), base, bounds.Min, draw.Src)
+
+	layers := []image.Layer{{Image: canvas, Opacity: 1, Blend: image.BlendNormal}}
+	for _, overlay := range overlays {
+		content, err := os.ReadFile(overlay.TemplatePath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read overlay %s: %w", overlay.Name, err)
+		}
+		processor := &svg.Processor{AllowUnsafe: opts.AllowUnsafe, Locale: opts.Locale}
+		svgContent, err := processor.ProcessContent(string(content), colors)
+		if err != nil {
+			return nil, fmt.Errorf("failed to process overlay %s: %w", overlay.Name, err)
+		}
+
+		renderContent, generator, err := PrepareRender(svgContent, opts.Rasterizer, opts.FontsPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to prepare overlay %s: %w", overlay.Name, err)
+		}
+		if opts.Limits != nil {
+			generator.SetLimits(*opts.Limits)
+		}
+		rendered, err := generator.RenderContext(ctx, renderContent, bounds.Dx(), bounds.Dy())
+		if err != nil {
+			return nil, fmt.Errorf("failed to render overlay %s: %w", overlay.Name, err)
+		}
+		layers = append(layers, image.Layer{Image: rendered, Opacity: overlay.Opacity, Blend: overlay.Blend})
+	}
+
+	composed, err := image.Compose(layers)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compose overlays: %w", err)
+	}
+	// The base is rendered with all colors, so the palette is limited in a
+	// single pass over the composited image
+	if opts.PaletteLimit != nil {
+		if err := opts.PaletteLimit.Validate(); err != nil {
+			return nil, err
+		}
+		composed = image.LimitPalette(composed, opts.PaletteLimit)
+	}
+	return composed, nil
+}
+
+// applyOverlays composes opts.Overlays onto the raster at path in place
+func applyOverlays(ctx context.Context, opts Options, path string) error {
+	if len(opts.Overlays) == 0 {
+		return nil
+	}
+	base, err := image.LoadImage(path)
+	if err != nil {
+		return err
+	}
+	composed, err := ComposeOverlays(ctx, base, opts.Overlays, opts.Theme.Palette, opts)
+	if err != nil {
+		return err
+	}
+	return image.WriteImage(composed, path)
+}
+
+// manifestOverlays records the overlays of opts with the hashes of their
+// templates
+func manifestOverlays(opts Options) ([]manifest.Overlay, error) {
+	var recorded []manifest.Overlay
+	for _, overlay := range opts.Overlays {
+		template, err := manifestTemplate(opts, overlay.TemplatePath)
+		if err != nil {
+			return nil, err
+		}
+		hash, err := manifest.HashFile(overlay.TemplatePath)
+		if err != nil {
+			return nil, err
+		}
+		recorded = append(recorded, manifest.Overlay{
+			Name:           overlay.Name,
+			Template:       template,
+			TemplateSHA256: hash,
+			Opacity:        overlay.Opacity,
+			Blend:          overlay.Blend,
+		})
+	}
+	return recorded, nil
+}
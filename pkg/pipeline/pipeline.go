@@ -0,0 +1,510 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/byteowlz/ppr/pkg/cache"
+	"github.com/byteowlz/ppr/pkg/headless"
+	"github.com/byteowlz/ppr/pkg/image"
+	"github.com/byteowlz/ppr/pkg/locale"
+	"github.com/byteowlz/ppr/pkg/manifest"
+	"github.com/byteowlz/ppr/pkg/palette"
+	"github.com/byteowlz/ppr/pkg/resolution"
+	"github.com/byteowlz/ppr/pkg/svg"
+	"github.com/byteowlz/ppr/pkg/theme"
+	"github.com/byteowlz/ppr/pkg/wallpaper"
+)
+
+// Options configures one run. Theme and template are resolved by the caller.
+type Options struct {
+	Theme *theme.Theme
+	// ThemeName names the output subdirectory, ppr/<ThemeName>
+	ThemeName    string
+	TemplatePath string
+	// Font replaces the font family of all template text
+	Font string
+	// AllowUnsafe renders the template without sanitizing it
+	AllowUnsafe bool
+	// Values fill the non-color placeholders, see svg.Processor
+	Values map[string]string
+	// Locale formats date and number directives, English when nil
+	Locale *locale.Locale
+
+	OutputDir string
+	// Name is the variant file name without extension, Filename replaces
+	// the whole file name
+	Name     string
+	Filename string
+	// Format is the raster format, png when empty
+	Format string
+	// SVG also writes the processed template as a variant
+	SVG bool
+	// Optimize losslessly recompresses newly rendered PNG variants
+	Optimize bool
+
+	// Sizes are rendered in parallel with a size suffix each, the first one
+	// becomes the current wallpaper. Resolution is used without Sizes.
+	Sizes      []*resolution.Resolution
+	Resolution *resolution.Resolution
+	Rasterizer string
+	FontsPath  string
+	// AspectRatio overrides the preserveAspectRatio of the template
+	AspectRatio string
+	// StrokeScale multiplies the stroke widths of the template, see
+	// svg.ScaleStrokes
+	StrokeScale float64
+	// SafeArea keeps the content clear of reserved screen edges, which are
+	// filled with base00 unless it sets a fill
+	SafeArea *image.SafeArea
+	// Overlays are composited onto the raster variants in order
+	Overlays []Overlay
+	// PaletteLimit quantizes the rendered variants, nil keeps all colors
+	PaletteLimit *image.PaletteLimit
+	// Regenerate renders variants that already exist
+	Regenerate bool
+	// Limits replaces image.DefaultLimits when set
+	Limits *image.Limits
+	// RenderTimeout bounds processing and rasterizing, zero waits
+	// indefinitely. The setter has its own timeout option.
+	RenderTimeout time.Duration
+
+	SetWallpaper bool
+	Setter       *wallpaper.Setter
+	// CacheDir holds the copies handed to the desktop, see cache.New
+	CacheDir string
+
+	// Manifest, when set, is completed and written next to every raster
+	// variant this run renders. The caller fills in the version.
+	Manifest *manifest.Manifest
+	// TemplatesDir is the templates directory. Headless manifests record
+	// the templates in it by relative path, so they match across machines.
+	TemplatesDir string
+
+	// SaveState records the result, e.g. as the current theme in the config
+	SaveState func(*Result) error
+}
+
+// Result lists the files a run produced
+type Result struct {
+	SVGPath string
+	// VariantPath is the raster variant, empty when only an SVG was written
+	VariantPath string
+	// CurrentPath is current.png in the output directory
+	CurrentPath string
+	// WallpaperPath is the file handed to the desktop, or CurrentPath when
+	// the wallpaper was not set
+	WallpaperPath string
+	WallpaperSet  bool
+	// SetError is why setting the wallpaper failed, which Run only warns
+	// about
+	SetError error
+	// Variants are the raster variants, the first one is VariantPath
+	Variants []Variant
+	// RenderTime is how long processing and storing the variants took
+	RenderTime time.Duration
+}
+
+// Variant is one raster variant of a run
+type Variant struct {
+	Path   string
+	Width  int
+	Height int
+	// Reused is set when an existing file was kept instead of rendering it
+	Reused bool
+}
+
+// Run processes the template, renders and stores the variants, sets the
+// wallpaper and saves the state. Only errors that leave no usable output
+// are returned, failures to set the wallpaper or save state are warnings
+// (except a verification mismatch in strict mode). Commands still running
+// when ctx is done are killed.
+func Run(ctx context.Context, opts Options) (*Result, error) {
+	result, err := render(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.SetWallpaper && headless.Enabled() {
+		fmt.Println("Headless mode, not setting the wallpaper")
+	} else if opts.SetWallpaper {
+		if err := setWallpaper(ctx, opts, result); err != nil {
+			return nil, err
+		}
+	}
+
+	if opts.SaveState != nil {
+		if err := opts.SaveState(result); err != nil {
+			fmt.Printf("Warning: failed to save current state: %v\n", err)
+		}
+	}
+	return result, nil
+}
+
+// render processes the template and stores the variants within the render
+// timeout
+func render(ctx context.Context, opts Options) (*Result, error) {
+	if opts.RenderTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.RenderTimeout)
+		defer cancel()
+	}
+
+	start := time.Now()
+	result, err := func() (*Result, error) {
+		svgContent, dated, err := process(ctx, opts)
+		if err != nil {
+			return nil, err
+		}
+		// A stored render of a template showing the date may be outdated
+		if dated {
+			opts.Regenerate = true
+		}
+		return store(ctx, opts, svgContent)
+	}()
+	if result != nil {
+		result.RenderTime = time.Since(start)
+	}
+	if err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return nil, fmt.Errorf("rendering timed out after %s (render_timeout): %w", opts.RenderTimeout, err)
+	}
+	return result, err
+}
+
+// process applies the theme, and the font override, to the template. The
+// processor cannot be interrupted, so a run that outlives ctx is abandoned.
+// It also reports whether the template shows the date.
+func process(ctx context.Context, opts Options) (string, bool, error) {
+	// Refuse oversized templates before reading them
+	limits := image.DefaultLimits
+	if opts.Limits != nil {
+		limits = *opts.Limits
+	}
+	if info, err := os.Stat(opts.TemplatePath); err == nil {
+		if err := limits.CheckSVGSize(info.Size()); err != nil {
+			return "", false, err
+		}
+	}
+
+	type processed struct {
+		content string
+		dated   bool
+		err     error
+	}
+	done := make(chan processed, 1)
+	go func() {
+		processor := &svg.Processor{AllowUnsafe: opts.AllowUnsafe, Values: opts.Values, Locale: opts.Locale}
+		content, err := processor.ProcessTemplate(opts.TemplatePath, opts.Theme)
+		done <- processed{content, processor.Dated, err}
+	}()
+
+	var svgContent string
+	var dated bool
+	select {
+	case p := <-done:
+		if p.err != nil {
+			return "", false, fmt.Errorf("failed to process template: %w", p.err)
+		}
+		svgContent, dated = p.content, p.dated
+	case <-ctx.Done():
+		return "", false, fmt.Errorf("failed to process template: %w", ctx.Err())
+	}
+
+	if opts.Font != "" {
+		svgContent = svg.SetFontFamily(svgContent, opts.Font)
+	}
+	if opts.StrokeScale < 0 {
+		return "", false, fmt.Errorf("invalid stroke scale %g", opts.StrokeScale)
+	}
+	if opts.StrokeScale != 0 {
+		svgContent = svg.ScaleStrokes(svgContent, opts.StrokeScale)
+	}
+	return svgContent, dated, nil
+}
+
+// store writes the SVG and raster variants under ppr/<theme> and copies the
+// raster to current.png
+func store(ctx context.Context, opts Options, svgContent string) (*Result, error) {
+	format := opts.Format
+	if format == "" {
+		format = image.FormatPNG
+	}
+	rasterExt := "." + format
+
+	themeSubDir := filepath.Join(opts.OutputDir, "ppr", opts.ThemeName)
+	if err := os.MkdirAll(themeSubDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create theme subdirectory: %w", err)
+	}
+
+	result := &Result{CurrentPath: filepath.Join(opts.OutputDir, "current.png")}
+	result.WallpaperPath = result.CurrentPath
+
+	if opts.SVG {
+		filename := opts.Filename
+		if filename == "" {
+			filename = opts.Name + ".svg"
+		}
+		result.SVGPath = filepath.Join(themeSubDir, filename)
+		if _, err := os.Stat(result.SVGPath); err == nil && !opts.Regenerate {
+			fmt.Printf("Reusing existing SVG: %s\n", result.SVGPath)
+		} else {
+			if err := svg.NewProcessor().WriteSVG(svgContent, result.SVGPath); err != nil {
+				return nil, fmt.Errorf("failed to write SVG: %w", err)
+			}
+			fmt.Printf("Generated SVG: %s\n", result.SVGPath)
+		}
+		// A raster is still needed to set the wallpaper
+		if !opts.SetWallpaper {
+			return result, nil
+		}
+	}
+
+	filename := opts.Filename
+	if opts.SVG || filename == "" {
+		filename = opts.Name + overlaySuffix(opts.Overlays) + rasterExt
+		if opts.SVG && opts.Filename != "" {
+			filename = strings.TrimSuffix(opts.Filename, ".svg") + rasterExt
+		}
+	}
+	rasterPath := filepath.Join(themeSubDir, filename)
+
+	if len(opts.Sizes) > 0 {
+		variants, err := renderSizes(ctx, opts, svgContent, rasterPath)
+		if err != nil {
+			return nil, err
+		}
+		result.Variants = variants
+		rasterPath = variants[0].Path
+	} else if _, err := os.Stat(rasterPath); err == nil && !opts.Regenerate {
+		fmt.Printf("Reusing existing wallpaper: %s (%s)\n", rasterPath, opts.Resolution.String())
+		result.Variants = []Variant{{Path: rasterPath, Width: opts.Resolution.Width, Height: opts.Resolution.Height, Reused: true}}
+	} else {
+		renderContent, generator, err := prepareRender(opts, svgContent)
+		if err != nil {
+			return nil, fmt.Errorf("failed to prepare render: %w", err)
+		}
+		if err := generator.GenerateWallpaperContext(ctx, renderContent, opts.Resolution.Width, opts.Resolution.Height, rasterPath); err != nil {
+			return nil, fmt.Errorf("failed to generate wallpaper: %w", err)
+		}
+		if err := applyOverlays(ctx, opts, rasterPath); err != nil {
+			return nil, err
+		}
+		fmt.Printf("Generated wallpaper: %s (%s)\n", rasterPath, opts.Resolution.String())
+		optimizer := optimize(opts, rasterPath)
+		writeManifest(opts, generator.Backend(), optimizer, rasterPath, opts.Resolution.Width, opts.Resolution.Height)
+		result.Variants = []Variant{{Path: rasterPath, Width: opts.Resolution.Width, Height: opts.Resolution.Height}}
+	}
+	result.VariantPath = rasterPath
+
+	// BMP and TIFF variants are converted since current.png is always PNG
+	if err := image.ConvertToPNG(rasterPath, result.CurrentPath); err != nil {
+		return nil, fmt.Errorf("failed to copy to current wallpaper: %w", err)
+	}
+	fmt.Printf("Current wallpaper saved as: %s\n", result.CurrentPath)
+	return result, nil
+}
+
+// prepareRender is PrepareRender with the configured limits and aspect
+// ratio
+func prepareRender(opts Options, svgContent string) (string, *image.Generator, error) {
+	renderContent, generator, err := PrepareRender(svgContent, opts.Rasterizer, opts.FontsPath)
+	if err != nil {
+		return "", nil, err
+	}
+	if opts.Limits != nil {
+		generator.SetLimits(*opts.Limits)
+	}
+	if err := generator.SetAspectRatio(opts.AspectRatio); err != nil {
+		return "", nil, err
+	}
+	if err := generator.SetSafeArea(safeArea(opts)); err != nil {
+		return "", nil, err
+	}
+	// With overlays, ComposeOverlays limits the palette once they are
+	// composited
+	if len(opts.Overlays) == 0 {
+		if err := generator.SetPaletteLimit(opts.PaletteLimit); err != nil {
+			return "", nil, err
+		}
+	}
+	return renderContent, generator, nil
+}
+
+// safeArea is opts.SafeArea filled with the background of the theme
+func safeArea(opts Options) *image.SafeArea {
+	if opts.SafeArea == nil || opts.SafeArea.Fill != nil {
+		return opts.SafeArea
+	}
+	area := *opts.SafeArea
+	if fill, err := palette.ParseHex(opts.Theme.Palette["base00"]); err == nil {
+		area.Fill = fill
+	}
+	return &area
+}
+
+// renderSizes renders every size from one processed SVG in parallel. Each
+// file gets a size suffix, e.g. shapes-2560x1440.png; existing variants are
+// reused. It returns the variants in the order of the sizes.
+func renderSizes(ctx context.Context, opts Options, svgContent, basePath string) ([]Variant, error) {
+	ext := filepath.Ext(basePath)
+	stem := strings.TrimSuffix(basePath, ext)
+
+	var variants []Variant
+	var targets []image.Target
+	for _, size := range opts.Sizes {
+		path := fmt.Sprintf("%s-%s%s", stem, size.String(), ext)
+
+		if _, err := os.Stat(path); err == nil && !opts.Regenerate {
+			fmt.Printf("Reusing existing wallpaper: %s (%s)\n", path, size.String())
+			variants = append(variants, Variant{Path: path, Width: size.Width, Height: size.Height, Reused: true})
+			continue
+		}
+		variants = append(variants, Variant{Path: path, Width: size.Width, Height: size.Height})
+		targets = append(targets, image.Target{Width: size.Width, Height: size.Height, OutputPath: path})
+	}
+
+	if len(targets) > 0 {
+		renderContent, generator, err := prepareRender(opts, svgContent)
+		if err != nil {
+			return nil, fmt.Errorf("failed to prepare render: %w", err)
+		}
+		if err := generator.GenerateWallpapersContext(ctx, renderContent, targets); err != nil {
+			return nil, fmt.Errorf("failed to generate wallpapers: %w", err)
+		}
+		for _, t := range targets {
+			if err := applyOverlays(ctx, opts, t.OutputPath); err != nil {
+				return nil, err
+			}
+			fmt.Printf("Generated wallpaper: %s (%dx%d)\n", t.OutputPath, t.Width, t.Height)
+			optimizer := optimize(opts, t.OutputPath)
+			writeManifest(opts, generator.Backend(), optimizer, t.OutputPath, t.Width, t.Height)
+		}
+	}
+
+	return variants, nil
+}
+
+// optimize recompresses the PNG at path when opts.Optimize is set and
+// returns the optimizer that changed it. Failures are only warnings.
+func optimize(opts Options, path string) string {
+	if !opts.Optimize {
+		return ""
+	}
+	if format, _ := image.FormatFromPath(path); format != image.FormatPNG {
+		return ""
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		fmt.Printf("Warning: failed to optimize %s: %v\n", path, err)
+		return ""
+	}
+	optimizer, saved, err := image.OptimizePNG(path)
+	if err != nil {
+		fmt.Printf("Warning: failed to optimize %s: %v\n", path, err)
+		return ""
+	}
+	if saved == 0 {
+		return ""
+	}
+	fmt.Printf("Optimized with %s: saved %.1f KB (%.0f%%)\n", optimizer, float64(saved)/1024, float64(saved)*100/float64(info.Size()))
+	return optimizer
+}
+
+// writeManifest records how the raster at path was rendered when
+// opts.Manifest is set. Failures are only warnings.
+func writeManifest(opts Options, backend, optimizer, path string, width, height int) {
+	if opts.Manifest == nil {
+		return
+	}
+
+	m := *opts.Manifest
+	m.Created = headless.Now().UTC()
+	m.Theme = opts.ThemeName
+	m.ThemeID = opts.Theme.ID()
+	m.Palette = opts.Theme.Palette
+	m.Font = opts.Font
+	if opts.StrokeScale != 1 {
+		m.StrokeScale = opts.StrokeScale
+	}
+	m.Values = opts.Values
+	m.Width, m.Height = width, height
+	m.Rasterizer = backend
+	m.Output = filepath.Base(path)
+	m.Format, _ = image.FormatFromPath(path)
+	m.Optimizer = optimizer
+	m.SafeArea = opts.SafeArea
+	m.PaletteLimit = opts.PaletteLimit
+
+	var err error
+	if m.Overlays, err = manifestOverlays(opts); err != nil {
+		fmt.Printf("Warning: failed to write manifest for %s: %v\n", path, err)
+		return
+	}
+	if m.Template, err = manifestTemplate(opts, opts.TemplatePath); err == nil {
+		if m.TemplateSHA256, err = manifest.HashFile(opts.TemplatePath); err == nil {
+			m.OutputSHA256, err = manifest.HashFile(path)
+		}
+	}
+	if err == nil {
+		err = m.Save(manifest.Path(path))
+	}
+	if err != nil {
+		fmt.Printf("Warning: failed to write manifest for %s: %v\n", path, err)
+	}
+}
+
+// manifestTemplate is the path a manifest records template by: absolute,
+// or in headless mode relative to opts.TemplatesDir when it lies there
+func manifestTemplate(opts Options, template string) (string, error) {
+	abs, err := filepath.Abs(template)
+	if err != nil || !headless.Enabled() || opts.TemplatesDir == "" {
+		return abs, err
+	}
+	dir, err := filepath.Abs(opts.TemplatesDir)
+	if err != nil {
+		return abs, nil
+	}
+	if rel, err := filepath.Rel(dir, abs); err == nil && filepath.IsLocal(rel) {
+		return filepath.ToSlash(rel), nil
+	}
+	return abs, nil
+}
+
+// setWallpaper hands a content-named copy of current.png to the desktop
+func setWallpaper(ctx context.Context, opts Options, result *Result) error {
+	if result.VariantPath == "" {
+		fmt.Printf("Warning: Cannot set wallpaper without PNG file\n")
+		return nil
+	}
+
+	wallpaperPath, err := cache.New(opts.CacheDir).Acquire("desktop", result.CurrentPath)
+	if err != nil {
+		fmt.Printf("Warning: failed to cache wallpaper: %v\n", err)
+		wallpaperPath = result.CurrentPath
+	}
+	result.WallpaperPath = wallpaperPath
+
+	setter := opts.Setter
+	if setter == nil {
+		setter = wallpaper.NewSetter()
+	}
+	if err := setter.WithContext(ctx).SetWallpaper(wallpaperPath); err != nil {
+		// Only returned in strict verify mode
+		var mismatch *wallpaper.VerifyError
+		if errors.As(err, &mismatch) {
+			return err
+		}
+		fmt.Printf("Warning: failed to set wallpaper: %v\n", err)
+		result.SetError = err
+		return nil
+	}
+	result.WallpaperSet = true
+	fmt.Println("Wallpaper set successfully!")
+	return nil
+}
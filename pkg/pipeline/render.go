@@ -0,0 +1,84 @@
+package pipeline
+
+import (
+	"fmt"
+
+	"github.com/byteowlz/ppr/pkg/fonts"
+	"github.com/byteowlz/ppr/pkg/headless"
+	"github.com/byteowlz/ppr/pkg/image"
+	"github.com/byteowlz/ppr/pkg/svg"
+)
+
+// PrepareRender picks the rasterizer for svgContent and degrades gracefully
+// when the template uses features the built-in backend cannot draw: with
+// rasterizer = "auto" an installed external backend takes over, otherwise
+// declared fallbacks are substituted, and as a last resort the unsupported
+// features are listed as warnings. Text is converted to glyph outlines for
+// the built-in backend. In headless mode an external backend is only used
+// when configured explicitly. It returns the content to rasterize.
+func PrepareRender(svgContent, rasterizer, fontsPath string) (string, *image.Generator, error) {
+	generator := image.NewGenerator()
+
+	backend := rasterizer
+	auto := backend == "" || backend == "auto"
+	if auto {
+		backend = image.BackendOKSVG
+	}
+
+	if err := generator.SetBackend(backend); err != nil {
+		fmt.Printf("Warning: %v, using %s\n", err, image.BackendOKSVG)
+		generator.SetBackend(image.BackendOKSVG)
+	}
+
+	generator.SetFontDirs(fontsPath)
+
+	if generator.Backend() != image.BackendOKSVG {
+		content, err := svg.ApplyFallbacks(svgContent, false)
+		return svg.MarkRTL(content), generator, err
+	}
+
+	if svg.HasRTLText(svgContent) {
+		fmt.Println("Warning: the built-in rasterizer draws right-to-left text in logical order, use rasterizer = \"resvg\" or another external backend")
+	}
+
+	svgContent, missing, err := svg.TextToPaths(svgContent, fonts.NewResolver(fontsPath))
+	if err != nil {
+		fmt.Printf("Warning: failed to convert text to paths: %v\n", err)
+	}
+	for _, family := range missing {
+		fmt.Printf("Warning: font '%s' not found, using the built-in font\n", family)
+	}
+
+	features, err := svg.UnsupportedFeatures(svgContent)
+	if err != nil {
+		fmt.Printf("Warning: failed to analyze template: %v\n", err)
+	}
+
+	if len(features) == 0 {
+		content, err := svg.ApplyFallbacks(svgContent, false)
+		return content, generator, err
+	}
+
+	if external := image.FirstExternalBackend(); auto && external != "" && !headless.Enabled() {
+		if err := generator.SetBackend(external); err != nil {
+			return "", nil, err
+		}
+		fmt.Printf("Template uses features %s cannot render, using %s\n", image.BackendOKSVG, external)
+		content, err := svg.ApplyFallbacks(svgContent, false)
+		return content, generator, err
+	}
+
+	if svg.HasFallbacks(svgContent) {
+		fmt.Println("Template uses unsupported features, substituting declared fallbacks")
+		content, err := svg.ApplyFallbacks(svgContent, true)
+		return content, generator, err
+	}
+
+	fmt.Printf("Warning: template uses features %s cannot render:\n", image.BackendOKSVG)
+	for _, feature := range features {
+		fmt.Printf("  %s\n", feature)
+	}
+	fmt.Println("Install resvg, rsvg-convert or inkscape and set rasterizer = \"auto\" for full support.")
+
+	return svgContent, generator, nil
+}
@@ -0,0 +1,16 @@
+package platform
+
+import (
+	"os"
+	"runtime"
+	"strings"
+)
+
+// IsTermux reports whether ppr runs inside Termux on Android, either as an
+// android build or as a linux binary started from the Termux shell
+func IsTermux() bool {
+	if runtime.GOOS == "android" {
+		return true
+	}
+	return os.Getenv("TERMUX_VERSION") != "" || strings.Contains(os.Getenv("PREFIX"), "com.termux")
+}
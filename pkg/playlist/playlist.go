@@ -0,0 +1,104 @@
+package playlist
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/BurntSushi/toml"
+)
+
+// DateLayout is the format of the From and Until dates
+const DateLayout = "2006-01-02"
+
+// Playlist is an ordered sequence of wallpapers for signage, played once
+// or looped. Entries outside their date range are skipped.
+type Playlist struct {
+	Loop    bool    `toml:"loop"`
+	Entries []Entry `toml:"entry"`
+}
+
+// Entry shows Template with Theme for Duration. From and Until limit it to
+// a range of days, both included; either may be left out.
+type Entry struct {
+	Theme    string `toml:"theme"`
+	Template string `toml:"template"`
+	Duration string `toml:"duration"`
+	// Warmth is a color temperature in kelvin, 0 renders the palette as is
+	Warmth int    `toml:"warmth,omitzero"`
+	From   string `toml:"from"`
+	Until  string `toml:"until"`
+
+	length time.Duration
+	from   time.Time
+	until  time.Time
+}
+
+// Load reads and validates the playlist file at path
+func Load(path string) (*Playlist, error) {
+	p := &Playlist{}
+	if _, err := toml.DecodeFile(path, p); err != nil {
+		return nil, fmt.Errorf("failed to read playlist: %w", err)
+	}
+	if len(p.Entries) == 0 {
+		return nil, fmt.Errorf("playlist %s has no [[entry]]", path)
+	}
+
+	for i := range p.Entries {
+		if err := p.Entries[i].parse(); err != nil {
+			return nil, fmt.Errorf("playlist entry %d: %w", i+1, err)
+		}
+	}
+	return p, nil
+}
+
+func (e *Entry) parse() error {
+	if e.Theme == "" || e.Template == "" {
+		return fmt.Errorf("theme and template are required")
+	}
+
+	var err error
+	if e.length, err = time.ParseDuration(e.Duration); err != nil || e.length <= 0 {
+		return fmt.Errorf("invalid duration %q (expected e.g. 30s or 5m)", e.Duration)
+	}
+	if e.From != "" {
+		if e.from, err = time.ParseInLocation(DateLayout, e.From, time.Local); err != nil {
+			return fmt.Errorf("invalid from date %q (expected %s)", e.From, DateLayout)
+		}
+	}
+	if e.Until != "" {
+		if e.until, err = time.ParseInLocation(DateLayout, e.Until, time.Local); err != nil {
+			return fmt.Errorf("invalid until date %q (expected %s)", e.Until, DateLayout)
+		}
+		// Until includes the whole day
+		e.until = e.until.AddDate(0, 0, 1)
+	}
+	if !e.from.IsZero() && !e.until.IsZero() && !e.from.Before(e.until) {
+		return fmt.Errorf("from %s is after until %s", e.From, e.Until)
+	}
+	return nil
+}
+
+// Length is how long the entry is shown
+func (e *Entry) Length() time.Duration {
+	return e.length
+}
+
+// Active reports whether t is within the date range of the entry
+func (e *Entry) Active(t time.Time) bool {
+	if !e.from.IsZero() && t.Before(e.from) {
+		return false
+	}
+	return e.until.IsZero() || t.Before(e.until)
+}
+
+// NextStart returns when the next entry that is not active at t becomes
+// active, false if none will
+func (p *Playlist) NextStart(t time.Time) (time.Time, bool) {
+	var next time.Time
+	for _, e := range p.Entries {
+		if e.from.After(t) && (next.IsZero() || e.from.Before(next)) {
+			next = e.from
+		}
+	}
+	return next, !next.IsZero()
+}
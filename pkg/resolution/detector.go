@@ -6,6 +6,9 @@ import (
 	"runtime"
 	"strconv"
 	"strings"
+
+	"github.com/byteowlz/ppr/pkg/headless"
+	"github.com/byteowlz/ppr/pkg/platform"
 )
 
 type Resolution struct {
@@ -24,10 +27,17 @@ func NewDetector() *Detector {
 }
 
 func (d *Detector) GetPrimaryDisplayResolution() (*Resolution, error) {
+	if headless.Enabled() {
+		return nil, headless.ErrNoDisplay
+	}
+	if platform.IsTermux() {
+		return d.getAndroidResolution()
+	}
+
 	switch runtime.GOOS {
 	case "darwin":
 		return d.getMacOSResolution()
-	case "linux":
+	case "linux", "freebsd", "openbsd", "netbsd":
 		return d.getLinuxResolution()
 	case "windows":
 		return d.getWindowsResolution()
@@ -70,10 +80,19 @@ func (d *Detector) getMacOSResolution() (*Resolution, error) {
 	return &Resolution{Width: 1920, Height: 1080}, nil
 }
 
+// getLinuxResolution covers Linux and the BSDs, which share X11 and Wayland
 func (d *Detector) getLinuxResolution() (*Resolution, error) {
+	if resolutions := d.getHyprlandResolutions(); len(resolutions) > 0 {
+		return resolutions[0], nil
+	}
+
 	cmd := exec.Command("xrandr")
 	output, err := cmd.Output()
 	if err != nil {
+		// Wayland sessions without Xwayland have no X server to ask
+		if resolutions := d.getWaylandResolutions(); len(resolutions) > 0 {
+			return resolutions[0], nil
+		}
 		return d.getLinuxResolutionFallback()
 	}
 
@@ -159,6 +178,173 @@ func (d *Detector) getWindowsResolution() (*Resolution, error) {
 	return &Resolution{Width: 1920, Height: 1080}, nil
 }
 
+// GetAllDisplayResolutions returns the resolution of every connected display,
+// primary first and without duplicates
+func (d *Detector) GetAllDisplayResolutions() ([]*Resolution, error) {
+	if headless.Enabled() {
+		return nil, headless.ErrNoDisplay
+	}
+
+	var resolutions []*Resolution
+	switch {
+	case platform.IsTermux():
+		// Android drives a single display
+	case runtime.GOOS == "darwin":
+		resolutions = d.getMacOSResolutions()
+	case runtime.GOOS == "linux", isBSD():
+		resolutions = d.getLinuxResolutions()
+	case runtime.GOOS == "windows":
+		resolutions = d.getWindowsResolutions()
+	default:
+		return nil, fmt.Errorf("unsupported operating system: %s", runtime.GOOS)
+	}
+
+	if len(resolutions) == 0 {
+		primary, err := d.GetPrimaryDisplayResolution()
+		if err != nil {
+			return nil, err
+		}
+		return []*Resolution{primary}, nil
+	}
+
+	return Unique(resolutions), nil
+}
+
+func (d *Detector) getMacOSResolutions() []*Resolution {
+	output, err := exec.Command("system_profiler", "SPDisplaysDataType").Output()
+	if err != nil {
+		return nil
+	}
+
+	var resolutions []*Resolution
+	for _, line := range strings.Split(string(output), "\n") {
+		line = strings.TrimSpace(line)
+		if !strings.Contains(line, "Resolution:") {
+			continue
+		}
+		parts := strings.Fields(line)
+		if len(parts) < 4 {
+			continue
+		}
+		width, err1 := strconv.Atoi(parts[1])
+		height, err2 := strconv.Atoi(parts[3])
+		if err1 == nil && err2 == nil {
+			resolutions = append(resolutions, &Resolution{Width: width, Height: height})
+		}
+	}
+	return resolutions
+}
+
+func (d *Detector) getLinuxResolutions() []*Resolution {
+	if resolutions := d.getHyprlandResolutions(); len(resolutions) > 0 {
+		return resolutions
+	}
+
+	output, err := exec.Command("xrandr").Output()
+	if err != nil {
+		return d.getWaylandResolutions()
+	}
+
+	var primary, others []*Resolution
+	for _, line := range strings.Split(string(output), "\n") {
+		if !strings.Contains(line, " connected") {
+			continue
+		}
+		for _, part := range strings.Fields(line) {
+			if !strings.Contains(part, "x") || !strings.Contains(part, "+") {
+				continue
+			}
+			res, err := ParseResolution(strings.Split(part, "+")[0])
+			if err != nil {
+				continue
+			}
+			if strings.Contains(line, " connected primary") {
+				primary = append(primary, res)
+			} else {
+				others = append(others, res)
+			}
+			break
+		}
+	}
+	return append(primary, others...)
+}
+
+// getWaylandResolutions reads the current mode of every enabled output from
+// wlr-randr, which works on wlroots compositors such as sway
+func (d *Detector) getWaylandResolutions() []*Resolution {
+	output, err := exec.Command("wlr-randr").Output()
+	if err != nil {
+		return nil
+	}
+
+	// Modes are listed as "    1920x1080 px, 60.000000 Hz (preferred, current)"
+	var resolutions []*Resolution
+	for _, line := range strings.Split(string(output), "\n") {
+		if !strings.Contains(line, " px") || !strings.Contains(line, "current") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if res, err := ParseResolution(fields[0]); err == nil {
+			resolutions = append(resolutions, res)
+		}
+	}
+	return resolutions
+}
+
+func isBSD() bool {
+	switch runtime.GOOS {
+	case "freebsd", "openbsd", "netbsd":
+		return true
+	}
+	return false
+}
+
+func (d *Detector) getWindowsResolutions() []*Resolution {
+	output, err := exec.Command("powershell", "-NoProfile", "-Command",
+		"Add-Type -AssemblyName System.Windows.Forms; [System.Windows.Forms.Screen]::AllScreens | Sort-Object -Property Primary -Descending | ForEach-Object { '{0}x{1}' -f $_.Bounds.Width, $_.Bounds.Height }").Output()
+	if err != nil {
+		return nil
+	}
+
+	var resolutions []*Resolution
+	for _, line := range strings.Split(string(output), "\n") {
+		if res, err := ParseResolution(strings.TrimSpace(line)); err == nil {
+			resolutions = append(resolutions, res)
+		}
+	}
+	return resolutions
+}
+
+// ParseResolutions parses a list of WIDTHxHEIGHT values, dropping duplicates
+func ParseResolutions(values []string) ([]*Resolution, error) {
+	var resolutions []*Resolution
+	for _, value := range values {
+		value = strings.TrimSpace(value)
+		if value == "" {
+			continue
+		}
+		res, err := ParseResolution(value)
+		if err != nil {
+			return nil, err
+		}
+		resolutions = append(resolutions, res)
+	}
+	return Unique(resolutions), nil
+}
+
+// Unique drops repeated resolutions, keeping the first occurrence
+func Unique(resolutions []*Resolution) []*Resolution {
+	seen := make(map[Resolution]bool)
+	var result []*Resolution
+	for _, res := range resolutions {
+		if !seen[*res] {
+			seen[*res] = true
+			result = append(result, res)
+		}
+	}
+	return result
+}
+
 func ParseResolution(resStr string) (*Resolution, error) {
 	parts := strings.Split(resStr, "x")
 	if len(parts) != 2 {
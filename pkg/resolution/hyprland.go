@@ -0,0 +1,74 @@
+package resolution
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+)
+
+// Monitor is an output reported by the compositor
+type Monitor struct {
+	Name    string
+	Width   int
+	Height  int
+	Focused bool
+}
+
+// hyprMonitor is the part of 'hyprctl monitors -j' ppr reads
+type hyprMonitor struct {
+	Name      string `json:"name"`
+	Width     int    `json:"width"`
+	Height    int    `json:"height"`
+	Transform int    `json:"transform"`
+	Focused   bool   `json:"focused"`
+}
+
+// HyprlandMonitors lists the monitors of the running Hyprland instance in
+// physical pixels, swapping the sides of rotated outputs
+func HyprlandMonitors() ([]Monitor, error) {
+	if os.Getenv("HYPRLAND_INSTANCE_SIGNATURE") == "" {
+		return nil, fmt.Errorf("not running under Hyprland")
+	}
+
+	output, err := exec.Command("hyprctl", "monitors", "-j").Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to query Hyprland monitors: %w", err)
+	}
+
+	var raw []hyprMonitor
+	if err := json.Unmarshal(output, &raw); err != nil {
+		return nil, fmt.Errorf("failed to parse hyprctl output: %w", err)
+	}
+
+	monitors := make([]Monitor, 0, len(raw))
+	for _, m := range raw {
+		width, height := m.Width, m.Height
+		// Transforms 1, 3, 5 and 7 rotate by 90 or 270 degrees
+		if m.Transform%2 == 1 {
+			width, height = height, width
+		}
+		monitors = append(monitors, Monitor{Name: m.Name, Width: width, Height: height, Focused: m.Focused})
+	}
+	return monitors, nil
+}
+
+// getHyprlandResolutions reads the monitors of a running Hyprland instance,
+// focused monitor first. xrandr only sees the scaled Xwayland outputs there.
+func (d *Detector) getHyprlandResolutions() []*Resolution {
+	monitors, err := HyprlandMonitors()
+	if err != nil {
+		return nil
+	}
+
+	var focused, others []*Resolution
+	for _, m := range monitors {
+		res := &Resolution{Width: m.Width, Height: m.Height}
+		if m.Focused {
+			focused = append(focused, res)
+		} else {
+			others = append(others, res)
+		}
+	}
+	return append(focused, others...)
+}
@@ -0,0 +1,38 @@
+package resolution
+
+import (
+	"os/exec"
+	"strings"
+)
+
+// getAndroidResolution reads the screen size from the window manager, which
+// Termux can query without root. An override size set with 'wm size WxH'
+// takes precedence over the physical size. Phones are portrait, so the
+// fallback is a common portrait resolution.
+func (d *Detector) getAndroidResolution() (*Resolution, error) {
+	output, err := exec.Command("wm", "size").Output()
+	if err != nil {
+		return &Resolution{Width: 1080, Height: 2400}, nil
+	}
+
+	var physical *Resolution
+	for _, line := range strings.Split(string(output), "\n") {
+		name, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		res, err := ParseResolution(strings.TrimSpace(value))
+		if err != nil {
+			continue
+		}
+		if strings.Contains(name, "Override") {
+			return res, nil
+		}
+		physical = res
+	}
+
+	if physical != nil {
+		return physical, nil
+	}
+	return &Resolution{Width: 1080, Height: 2400}, nil
+}
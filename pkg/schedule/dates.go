@@ -0,0 +1,35 @@
+package schedule
+
+import (
+	"fmt"
+	"time"
+)
+
+// InDateRange reports whether t falls on a day from from to to, both
+// inclusive yearly dates as MM-DD. A range ending before it starts wraps
+// past new year, e.g. 12-20 to 01-06.
+func InDateRange(from, to string, t time.Time) (bool, error) {
+	start, err := parseDay(from)
+	if err != nil {
+		return false, err
+	}
+	end, err := parseDay(to)
+	if err != nil {
+		return false, err
+	}
+
+	today := int(t.Month())*100 + t.Day()
+	if start <= end {
+		return today >= start && today <= end, nil
+	}
+	return today >= start || today <= end, nil
+}
+
+// parseDay reads MM-DD as month*100 + day
+func parseDay(value string) (int, error) {
+	var month, day int
+	if _, err := fmt.Sscanf(value, "%d-%d", &month, &day); err != nil || month < 1 || month > 12 || day < 1 || day > 31 {
+		return 0, fmt.Errorf("invalid date %q (expected MM-DD)", value)
+	}
+	return month*100 + day, nil
+}
@@ -0,0 +1,312 @@
+package schedule
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/byteowlz/ppr/pkg/battery"
+	"github.com/byteowlz/ppr/pkg/palette"
+	"github.com/byteowlz/ppr/pkg/weather"
+)
+
+// Rule picks a theme, template and/or palette warmth while the clock is
+// within From and To, optionally only on some weekdays, months, seasons,
+// weather conditions or battery states. Ranges ending before they start wrap past midnight,
+// and a rule without times matches all day. Theme, Template and Group may
+// contain {weekday}, {month}, {season} and {weather}.
+type Rule struct {
+	From     string
+	To       string
+	Weekdays []string
+	Months   []string
+	Seasons  []string
+	Weather  []string
+	// BatteryBelow matches while the battery charge is below this percentage
+	BatteryBelow int
+	// Power matches "battery" or "ac" power
+	Power    string
+	Theme    string
+	Template string
+	// Group names a template group to pick the template from instead
+	Group string
+	// Warmth is a color temperature in kelvin, 0 renders the palette as is
+	Warmth int
+}
+
+var weekdayNames = map[string]time.Weekday{
+	"sun": time.Sunday, "mon": time.Monday, "tue": time.Tuesday, "wed": time.Wednesday,
+	"thu": time.Thursday, "fri": time.Friday, "sat": time.Saturday,
+}
+
+var monthNames = map[string]time.Month{
+	"jan": time.January, "feb": time.February, "mar": time.March, "apr": time.April,
+	"may": time.May, "jun": time.June, "jul": time.July, "aug": time.August,
+	"sep": time.September, "oct": time.October, "nov": time.November, "dec": time.December,
+}
+
+var seasonNames = map[string]bool{"spring": true, "summer": true, "autumn": true, "winter": true}
+
+// Season returns the meteorological season of t in the northern
+// hemisphere: spring starts in March, summer in June, autumn in September
+// and winter in December
+func Season(t time.Time) string {
+	switch t.Month() {
+	case time.March, time.April, time.May:
+		return "spring"
+	case time.June, time.July, time.August:
+		return "summer"
+	case time.September, time.October, time.November:
+		return "autumn"
+	default:
+		return "winter"
+	}
+}
+
+// Expand replaces {weekday}, {month} and {season} in value with the
+// lowercase names for t, e.g. "fri", "oct" and "autumn", and {weather}
+// with the current condition
+func Expand(value string, t time.Time, condition string) string {
+	return strings.NewReplacer(
+		"{weekday}", strings.ToLower(t.Weekday().String()[:3]),
+		"{month}", strings.ToLower(t.Month().String()[:3]),
+		"{season}", Season(t),
+		"{weather}", condition,
+	).Replace(value)
+}
+
+// Conditions are the live inputs rules can depend on besides the time
+type Conditions struct {
+	// Weather is the current condition, empty when unknown
+	Weather string
+	// Battery is nil without a battery or when unknown, which no battery
+	// rule matches
+	Battery *battery.Status
+}
+
+// UsesBattery reports whether any rule depends on the battery
+func UsesBattery(rules []Rule) bool {
+	for _, rule := range rules {
+		if rule.BatteryBelow != 0 || rule.Power != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// UsesWeather reports whether any rule depends on the weather
+func UsesWeather(rules []Rule) bool {
+	for _, rule := range rules {
+		if len(rule.Weather) > 0 || strings.Contains(rule.Theme+rule.Template+rule.Group, "{weather}") {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate checks the rule's times and names and that it selects something
+func (r Rule) Validate() error {
+	if r.Theme == "" && r.Template == "" && r.Group == "" && r.Warmth == 0 {
+		return fmt.Errorf("rule sets no theme, template, group or warmth")
+	}
+	if r.Template != "" && r.Group != "" {
+		return fmt.Errorf("rule sets both a template and a group")
+	}
+	if r.Warmth != 0 && (r.Warmth < palette.MinKelvin || r.Warmth > palette.NeutralKelvin) {
+		return fmt.Errorf("invalid warmth %d (expected %d-%d kelvin)", r.Warmth, palette.MinKelvin, palette.NeutralKelvin)
+	}
+	if (r.From == "") != (r.To == "") {
+		return fmt.Errorf("rule needs both from and to, or neither")
+	}
+	if r.From != "" {
+		if _, err := parseClock(r.From); err != nil {
+			return err
+		}
+		if _, err := parseClock(r.To); err != nil {
+			return err
+		}
+	}
+	for _, day := range r.Weekdays {
+		if _, ok := weekdayNames[normalizeName(day)]; !ok && !isDayGroup(day) {
+			return fmt.Errorf("invalid weekday %q (expected mon-sun, weekdays or weekend)", day)
+		}
+	}
+	for _, month := range r.Months {
+		if _, ok := monthNames[normalizeName(month)]; !ok {
+			return fmt.Errorf("invalid month %q (expected jan-dec)", month)
+		}
+	}
+	for _, season := range r.Seasons {
+		if !seasonNames[normalizeSeason(season)] {
+			return fmt.Errorf("invalid season %q (expected spring, summer, autumn or winter)", season)
+		}
+	}
+	for _, condition := range r.Weather {
+		if !isCondition(condition) {
+			return fmt.Errorf("invalid weather %q (expected one of %s)", condition, strings.Join(weather.Conditions(), ", "))
+		}
+	}
+	if r.BatteryBelow < 0 || r.BatteryBelow > 100 {
+		return fmt.Errorf("invalid battery_below %d (expected 1-100 percent)", r.BatteryBelow)
+	}
+	if r.Power != "" && r.Power != "battery" && r.Power != "ac" {
+		return fmt.Errorf("invalid power %q (expected battery or ac)", r.Power)
+	}
+	return nil
+}
+
+// Matches reports whether the rule applies at t under the given
+// conditions. Weekday, month and season refer to t's date, also for ranges
+// that wrap past midnight.
+func (r Rule) Matches(t time.Time, c Conditions) bool {
+	if len(r.Weather) > 0 && !matchesWeather(r.Weather, c.Weather) {
+		return false
+	}
+	if (r.BatteryBelow != 0 || r.Power != "") && !r.matchesBattery(c.Battery) {
+		return false
+	}
+	if len(r.Weekdays) > 0 && !matchesWeekday(r.Weekdays, t.Weekday()) {
+		return false
+	}
+	if len(r.Months) > 0 && !matchesMonth(r.Months, t.Month()) {
+		return false
+	}
+	if len(r.Seasons) > 0 && !matchesSeason(r.Seasons, Season(t)) {
+		return false
+	}
+
+	if r.From == "" {
+		return true
+	}
+	from, err := parseClock(r.From)
+	if err != nil {
+		return false
+	}
+	to, err := parseClock(r.To)
+	if err != nil {
+		return false
+	}
+
+	now := t.Hour()*60 + t.Minute()
+	if from <= to {
+		return now >= from && now < to
+	}
+	return now >= from || now < to
+}
+
+// Active returns the first rule matching t and c, or nil when none does.
+// Invalid rules are reported with their position.
+func Active(rules []Rule, t time.Time, c Conditions) (*Rule, error) {
+	for i, rule := range rules {
+		if err := rule.Validate(); err != nil {
+			return nil, fmt.Errorf("schedule rule %d: %w", i+1, err)
+		}
+	}
+	for i := range rules {
+		if rules[i].Matches(t, c) {
+			return &rules[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// normalizeName accepts full or abbreviated day and month names
+func normalizeName(name string) string {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if len(name) > 3 {
+		name = name[:3]
+	}
+	return name
+}
+
+func normalizeSeason(name string) string {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "fall" {
+		return "autumn"
+	}
+	return name
+}
+
+func isDayGroup(name string) bool {
+	name = strings.ToLower(strings.TrimSpace(name))
+	return name == "weekdays" || name == "weekend"
+}
+
+func matchesWeekday(days []string, day time.Weekday) bool {
+	weekend := day == time.Saturday || day == time.Sunday
+	for _, name := range days {
+		switch strings.ToLower(strings.TrimSpace(name)) {
+		case "weekdays":
+			if !weekend {
+				return true
+			}
+		case "weekend":
+			if weekend {
+				return true
+			}
+		default:
+			if d, ok := weekdayNames[normalizeName(name)]; ok && d == day {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func matchesMonth(months []string, month time.Month) bool {
+	for _, name := range months {
+		if m, ok := monthNames[normalizeName(name)]; ok && m == month {
+			return true
+		}
+	}
+	return false
+}
+
+func matchesSeason(seasons []string, season string) bool {
+	for _, name := range seasons {
+		if normalizeSeason(name) == season {
+			return true
+		}
+	}
+	return false
+}
+
+func isCondition(name string) bool {
+	for _, condition := range weather.Conditions() {
+		if strings.EqualFold(strings.TrimSpace(name), condition) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchesWeather(conditions []string, current string) bool {
+	for _, condition := range conditions {
+		if current != "" && strings.EqualFold(strings.TrimSpace(condition), current) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r Rule) matchesBattery(status *battery.Status) bool {
+	if status == nil {
+		return false
+	}
+	if r.BatteryBelow != 0 && status.Level >= r.BatteryBelow {
+		return false
+	}
+	if r.Power != "" && (r.Power == "battery") != status.OnBattery {
+		return false
+	}
+	return true
+}
+
+// parseClock reads HH:MM as minutes after midnight. 24:00 ends a day.
+func parseClock(value string) (int, error) {
+	var hour, minute int
+	if _, err := fmt.Sscanf(value, "%d:%d", &hour, &minute); err != nil || hour < 0 || minute < 0 || minute > 59 || hour > 24 || (hour == 24 && minute != 0) {
+		return 0, fmt.Errorf("invalid time %q (expected HH:MM)", value)
+	}
+	return hour*60 + minute, nil
+}
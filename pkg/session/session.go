@@ -0,0 +1,121 @@
+package session
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Session is a recording of the wallpaper changes between record start and
+// stop, replayed with the same timing
+type Session struct {
+	Name    string    `json:"name"`
+	Started time.Time `json:"started"`
+	// Stopped is zero while the session is being recorded
+	Stopped time.Time `json:"stopped,omitempty"`
+	Events  []Event   `json:"events"`
+
+	path string
+}
+
+// Event is one wallpaper change, OffsetMillis after the start of the
+// session
+type Event struct {
+	OffsetMillis int64  `json:"offset_ms"`
+	Theme        string `json:"theme"`
+	Template     string `json:"template"`
+	Warmth       int    `json:"warmth,omitempty"`
+}
+
+// Offset is how long after the start of the session the change happened
+func (e Event) Offset() time.Duration {
+	return time.Duration(e.OffsetMillis) * time.Millisecond
+}
+
+// Dir is where sessions are kept below the config directory
+func Dir(configDir string) string {
+	return filepath.Join(configDir, "sessions")
+}
+
+// Path is the file of session name in dir
+func Path(dir, name string) string {
+	return filepath.Join(dir, name+".json")
+}
+
+// New returns an empty session named name in dir, started at started
+func New(dir, name string, started time.Time) *Session {
+	return &Session{Name: name, Started: started, path: Path(dir, name)}
+}
+
+// Load reads the session file at path
+func Load(path string) (*Session, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read session: %w", err)
+	}
+	s := &Session{path: path}
+	if err := json.Unmarshal(data, s); err != nil {
+		return nil, fmt.Errorf("failed to decode session %s: %w", path, err)
+	}
+	return s, nil
+}
+
+// List returns the names of the sessions in dir, sorted
+func List(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read sessions: %w", err)
+	}
+	var names []string
+	for _, entry := range entries {
+		if !entry.IsDir() && filepath.Ext(entry.Name()) == ".json" {
+			names = append(names, strings.TrimSuffix(entry.Name(), ".json"))
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// Add records a change to theme, template and warmth at time at
+func (s *Session) Add(at time.Time, theme, template string, warmth int) {
+	s.Events = append(s.Events, Event{
+		OffsetMillis: at.Sub(s.Started).Milliseconds(),
+		Theme:        theme,
+		Template:     template,
+		Warmth:       warmth,
+	})
+}
+
+// Length is how long the session was recorded, up to its last event while
+// it is still running
+func (s *Session) Length() time.Duration {
+	if !s.Stopped.IsZero() {
+		return s.Stopped.Sub(s.Started).Round(time.Millisecond)
+	}
+	if len(s.Events) == 0 {
+		return 0
+	}
+	return s.Events[len(s.Events)-1].Offset()
+}
+
+// Save writes the session file atomically
+func (s *Session) Save() error {
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return fmt.Errorf("failed to create sessions directory: %w", err)
+	}
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode session: %w", err)
+	}
+	if err := os.WriteFile(s.path+".tmp", data, 0644); err != nil {
+		return fmt.Errorf("failed to write session: %w", err)
+	}
+	if err := os.Rename(s.path+".tmp", s.path); err != nil {
+		return fmt.Errorf("failed to write session: %w", err)
+	}
+	return nil
+}
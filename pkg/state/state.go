@@ -0,0 +1,160 @@
+package state
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Usage counts how often something became the wallpaper and when it last did
+type Usage struct {
+	Count    int       `json:"count"`
+	LastUsed time.Time `json:"last_used"`
+}
+
+// State records wallpaper usage per theme, per template and per
+// theme/template combination, so rotation can favor what was not seen lately
+type State struct {
+	Themes       map[string]Usage `json:"themes"`
+	Templates    map[string]Usage `json:"templates"`
+	Combinations map[string]Usage `json:"combinations"`
+	// Context is the setup to return to once a temporary context, like an
+	// SSH session with its own theme, ends
+	Context *Context `json:"context,omitempty"`
+	// Focus is the setup to return to once Focus or Do Not Disturb ends
+	Focus *Context `json:"focus,omitempty"`
+	// Renders are the render metrics per template, kept when render_stats
+	// is enabled
+	Renders map[string]RenderStats `json:"renders,omitempty"`
+	// Recording names the session wallpaper changes are recorded to, see
+	// pkg/session
+	Recording string `json:"recording,omitempty"`
+	// Pin suspends automatic wallpaper changes, see Pinned
+	Pin *Pin `json:"pin,omitempty"`
+
+	path string
+}
+
+// Context is the wallpaper setup replaced by a temporary context. Host is
+// the SSH host that started it, if any.
+type Context struct {
+	Host     string `json:"host,omitempty"`
+	Theme    string `json:"theme"`
+	Template string `json:"template"`
+	Warmth   int    `json:"warmth,omitempty"`
+}
+
+// Pin holds the wallpaper from Since until Until, or until it is removed
+// when Until is nil
+type Pin struct {
+	Since time.Time  `json:"since"`
+	Until *time.Time `json:"until,omitempty"`
+}
+
+// Pinned reports whether a pin holds the wallpaper at t. Expired pins are
+// ignored, they need not be removed.
+func (s *State) Pinned(t time.Time) bool {
+	return s.Pin != nil && (s.Pin.Until == nil || t.Before(*s.Pin.Until))
+}
+
+// RenderStats sums up the runs rendering one template
+type RenderStats struct {
+	// Rendered and Reused count the variants rendered and the existing
+	// ones kept instead
+	Rendered int `json:"rendered"`
+	Reused   int `json:"reused"`
+	// Runs counts the runs that rendered a variant, which took TotalMillis
+	Runs        int   `json:"runs"`
+	TotalMillis int64 `json:"total_ms"`
+}
+
+// AverageRender is the mean time of the runs that rendered a variant
+func (r RenderStats) AverageRender() time.Duration {
+	if r.Runs == 0 {
+		return 0
+	}
+	return time.Duration(r.TotalMillis/int64(r.Runs)) * time.Millisecond
+}
+
+// Load reads the state file at path. A missing file is an empty state.
+func Load(path string) (*State, error) {
+	s := &State{path: path}
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read state file: %w", err)
+	}
+	if err == nil {
+		if err := json.Unmarshal(data, s); err != nil {
+			return nil, fmt.Errorf("failed to decode state file: %w", err)
+		}
+	}
+	if s.Themes == nil {
+		s.Themes = make(map[string]Usage)
+	}
+	if s.Templates == nil {
+		s.Templates = make(map[string]Usage)
+	}
+	if s.Combinations == nil {
+		s.Combinations = make(map[string]Usage)
+	}
+	if s.Renders == nil {
+		s.Renders = make(map[string]RenderStats)
+	}
+	return s, nil
+}
+
+// Record counts one use of the theme with the template at the given time
+func (s *State) Record(theme, template string, at time.Time) {
+	record(s.Themes, theme, at)
+	record(s.Templates, template, at)
+	record(s.Combinations, combinationKey(theme, template), at)
+}
+
+func record(usages map[string]Usage, key string, at time.Time) {
+	usage := usages[key]
+	usage.Count++
+	usage.LastUsed = at
+	usages[key] = usage
+}
+
+// RecordRender adds a run of template that rendered and reused variants.
+// took only counts towards the average when something was rendered.
+func (s *State) RecordRender(template string, rendered, reused int, took time.Duration) {
+	stats := s.Renders[template]
+	stats.Rendered += rendered
+	stats.Reused += reused
+	if rendered > 0 {
+		stats.Runs++
+		stats.TotalMillis += took.Milliseconds()
+	}
+	s.Renders[template] = stats
+}
+
+// Combination returns the usage of the theme with the template
+func (s *State) Combination(theme, template string) Usage {
+	return s.Combinations[combinationKey(theme, template)]
+}
+
+func combinationKey(theme, template string) string {
+	return theme + "/" + template
+}
+
+// Save writes the state file atomically
+func (s *State) Save() error {
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return fmt.Errorf("failed to create state directory: %w", err)
+	}
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode state: %w", err)
+	}
+	if err := os.WriteFile(s.path+".tmp", data, 0644); err != nil {
+		return fmt.Errorf("failed to write state file: %w", err)
+	}
+	if err := os.Rename(s.path+".tmp", s.path); err != nil {
+		return fmt.Errorf("failed to write state file: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,284 @@
+package svg
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// AdaptAttr marks a group with the rule 'ppr template adapt' repositions
+// it by, see Adapt
+const AdaptAttr = "data-ppr-adapt"
+
+// Adapt rules
+const (
+	AdaptRepeat  = "repeat"
+	AdaptStretch = "stretch"
+	AdaptCover   = "cover"
+	// AdaptAnchor is followed by a position, e.g. anchor-top-left
+	AdaptAnchor = "anchor-"
+)
+
+// anchorPositions map a position to the edge it sticks to: -1 the left or
+// top edge, 1 the right or bottom edge, 0 the center
+var anchorPositions = map[string][2]float64{
+	"center": {0, 0}, "top": {0, -1}, "bottom": {0, 1}, "left": {-1, 0}, "right": {1, 0},
+	"top-left": {-1, -1}, "top-right": {1, -1}, "bottom-left": {-1, 1}, "bottom-right": {1, 1},
+}
+
+var (
+	viewBoxAttrRegex = regexp.MustCompile(`\sviewBox\s*=\s*("[^"]*"|'[^']*')`)
+	widthAttrRegex   = regexp.MustCompile(`\swidth\s*=\s*("[^"]*"|'[^']*')`)
+	heightAttrRegex  = regexp.MustCompile(`\sheight\s*=\s*("[^"]*"|'[^']*')`)
+	idAttrRegex      = regexp.MustCompile(`\sid\s*=\s*("[^"]*"|'[^']*')`)
+)
+
+// AdaptResult reports what Adapt changed
+type AdaptResult struct {
+	// From and To are the canvas sizes in user units
+	FromWidth, FromHeight float64
+	ToWidth, ToHeight     float64
+	// Rules counts the adapted groups per rule
+	Rules map[string]int
+}
+
+// Adapt extends the canvas of a template to the aspect ratio
+// aspectWidth:aspectHeight. One side keeps its length and the other grows,
+// and the content is moved so the original canvas is centered in the new
+// one; nothing is cropped.
+// Groups marked with data-ppr-adapt follow a rule:
+//
+//	repeat             tile the group to fill the new canvas, for patterns
+//	stretch            scale the group to the new canvas, for backgrounds
+//	cover              scale the group uniformly until it covers the canvas
+//	anchor-<position>  keep the group at an edge or corner: top, bottom,
+//	                   left, right, top-left, ..., or center
+//
+// Unmarked content stays centered. Nested marked groups follow their
+// outermost marked ancestor.
+func Adapt(content string, aspectWidth, aspectHeight float64) (string, *AdaptResult, error) {
+	if aspectWidth <= 0 || aspectHeight <= 0 {
+		return "", nil, fmt.Errorf("invalid aspect ratio %g:%g", aspectWidth, aspectHeight)
+	}
+	loc := svgOpenTagRegex.FindStringIndex(content)
+	if loc == nil {
+		return "", nil, fmt.Errorf("no <svg> element found")
+	}
+	tagEnd := strings.Index(content[loc[0]:], ">")
+	if tagEnd < 0 {
+		return "", nil, fmt.Errorf("unterminated <svg> element")
+	}
+	rootTag := content[loc[0] : loc[0]+tagEnd]
+
+	minX, minY, width, height, err := canvasBox(rootTag)
+	if err != nil {
+		return "", nil, err
+	}
+	newWidth, newHeight := width, height
+	if aspect := aspectWidth / aspectHeight; aspect > width/height {
+		newWidth = height * aspect
+	} else {
+		newHeight = width / aspect
+	}
+	dx, dy := (newWidth-width)/2, (newHeight-height)/2
+	result := &AdaptResult{FromWidth: width, FromHeight: height, ToWidth: newWidth, ToHeight: newHeight, Rules: make(map[string]int)}
+
+	// Groups are rewritten first, the root tag keeps its offsets until then
+	body, err := adaptGroups(content, minX, minY, width, height, dx, dy, result)
+	if err != nil {
+		return "", nil, err
+	}
+
+	// The viewBox keeps its origin and the content moves instead, oksvg
+	// misplaces content under a viewBox whose origin is not scaled 1:1
+	newTag := rootTag
+	viewBox := fmt.Sprintf(` viewBox="%s %s %s %s"`, formatLength(minX), formatLength(minY), formatLength(newWidth), formatLength(newHeight))
+	if viewBoxAttrRegex.MatchString(newTag) {
+		newTag = viewBoxAttrRegex.ReplaceAllLiteralString(newTag, viewBox)
+	} else {
+		newTag += viewBox
+	}
+	newTag = scaleLengthAttr(newTag, widthAttrRegex, " width", newWidth/width)
+	newTag = scaleLengthAttr(newTag, heightAttrRegex, " height", newHeight/height)
+	rest := body[loc[0]+tagEnd:]
+	if closing := strings.LastIndex(rest, "</svg>"); closing >= 0 && !strings.HasSuffix(rootTag, "/") {
+		rest = ">" + wrapTransform(rest[1:closing], fmt.Sprintf("translate(%s %s)", formatLength(dx), formatLength(dy))) + rest[closing:]
+	}
+	return body[:loc[0]] + newTag + rest, result, nil
+}
+
+// canvasBox reads the viewBox of the root tag, or its width and height
+func canvasBox(rootTag string) (minX, minY, width, height float64, err error) {
+	if match := viewBoxAttrRegex.FindStringSubmatch(rootTag); match != nil {
+		fields := strings.FieldsFunc(strings.Trim(match[1], `"'`), func(r rune) bool { return r == ' ' || r == ',' })
+		if len(fields) == 4 {
+			values := make([]float64, 4)
+			for i, field := range fields {
+				if values[i], err = strconv.ParseFloat(field, 64); err != nil {
+					break
+				}
+			}
+			if err == nil && values[2] > 0 && values[3] > 0 {
+				return values[0], values[1], values[2], values[3], nil
+			}
+		}
+		return 0, 0, 0, 0, fmt.Errorf("invalid viewBox %s", match[1])
+	}
+
+	lengths := make([]float64, 2)
+	for i, re := range []*regexp.Regexp{widthAttrRegex, heightAttrRegex} {
+		match := re.FindStringSubmatch(rootTag)
+		if match == nil {
+			return 0, 0, 0, 0, fmt.Errorf("template has neither a viewBox nor a width and height")
+		}
+		value := strings.TrimSuffix(strings.Trim(match[1], `"'`), "px")
+		if lengths[i], err = strconv.ParseFloat(value, 64); err != nil || lengths[i] <= 0 {
+			return 0, 0, 0, 0, fmt.Errorf("cannot adapt a template sized %s without a viewBox", match[1])
+		}
+	}
+	return 0, 0, lengths[0], lengths[1], nil
+}
+
+// scaleLengthAttr multiplies an absolute width or height of the root tag,
+// percentages and other relative lengths are left alone
+func scaleLengthAttr(tag string, re *regexp.Regexp, name string, factor float64) string {
+	match := re.FindStringSubmatch(tag)
+	if match == nil {
+		return tag
+	}
+	value := strings.Trim(match[1], `"'`)
+	number, unit := value, ""
+	for _, u := range []string{"px", "pt", "pc", "mm", "cm", "in"} {
+		if strings.HasSuffix(value, u) {
+			number, unit = strings.TrimSuffix(value, u), u
+		}
+	}
+	v, err := strconv.ParseFloat(number, 64)
+	if err != nil {
+		return tag
+	}
+	return re.ReplaceAllLiteralString(tag, fmt.Sprintf(`%s="%s%s"`, name, formatLength(v*factor), unit))
+}
+
+// adaptGroups wraps every outermost group carrying AdaptAttr according to
+// its rule
+func adaptGroups(content string, minX, minY, width, height, dx, dy float64, result *AdaptResult) (string, error) {
+	type replacement struct {
+		start, end int64
+		text       string
+	}
+	var replacements []replacement
+
+	decoder := xml.NewDecoder(strings.NewReader(content))
+	decoder.Strict = false
+	depth, groupDepth := 0, 0
+	var groupStart int64
+	var rule string
+	for {
+		offset := decoder.InputOffset()
+		token, err := decoder.RawToken()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", fmt.Errorf("failed to parse SVG: %w", err)
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			depth++
+			if groupDepth != 0 {
+				continue
+			}
+			if value := attrValue(t.Attr, AdaptAttr); value != "" {
+				groupDepth, groupStart, rule = depth, offset, strings.TrimSpace(value)
+			}
+		case xml.EndElement:
+			if groupDepth == depth {
+				groupDepth = 0
+				end := decoder.InputOffset()
+				element := content[groupStart:end]
+				text, err := adaptElement(element, rule, minX, minY, width, height, dx, dy)
+				if err != nil {
+					return "", err
+				}
+				result.Rules[rule]++
+				replacements = append(replacements, replacement{groupStart, end, text})
+			}
+			depth--
+		}
+	}
+
+	var out strings.Builder
+	var pos int64
+	for _, r := range replacements {
+		out.WriteString(content[pos:r.start])
+		out.WriteString(r.text)
+		pos = r.end
+	}
+	out.WriteString(content[pos:])
+	return out.String(), nil
+}
+
+// adaptElement wraps one marked element in the transforms of its rule
+func adaptElement(element, rule string, minX, minY, width, height, dx, dy float64) (string, error) {
+	newWidth, newHeight := width+2*dx, height+2*dy
+	switch {
+	case rule == AdaptStretch:
+		sx, sy := newWidth/width, newHeight/height
+		return wrapTransform(element, fmt.Sprintf("translate(%s %s) scale(%s %s) translate(%s %s)",
+			formatLength(minX-dx), formatLength(minY-dy), formatLength(sx), formatLength(sy), formatLength(-minX), formatLength(-minY))), nil
+
+	case rule == AdaptCover:
+		s := math.Max(newWidth/width, newHeight/height)
+		cx, cy := minX+width/2, minY+height/2
+		// oksvg reads scale(s) as scale(s 0), both factors are written out
+		return wrapTransform(element, fmt.Sprintf("translate(%s %s) scale(%s %s) translate(%s %s)",
+			formatLength(cx), formatLength(cy), formatLength(s), formatLength(s), formatLength(-cx), formatLength(-cy))), nil
+
+	case rule == AdaptRepeat:
+		// Copies drop their ids, references keep resolving to the original
+		stripped := idAttrRegex.ReplaceAllString(element, "")
+		nx, ny := int(math.Ceil(dx/width)), int(math.Ceil(dy/height))
+		var b strings.Builder
+		for j := -ny; j <= ny; j++ {
+			for i := -nx; i <= nx; i++ {
+				if i == 0 && j == 0 {
+					b.WriteString(element)
+					continue
+				}
+				b.WriteString(wrapTransform(stripped, fmt.Sprintf("translate(%s %s)", formatLength(float64(i)*width), formatLength(float64(j)*height))))
+			}
+		}
+		return b.String(), nil
+
+	case strings.HasPrefix(rule, AdaptAnchor):
+		position, ok := anchorPositions[strings.TrimPrefix(rule, AdaptAnchor)]
+		if !ok {
+			return "", fmt.Errorf("unknown adapt anchor %q (expected e.g. anchor-top or anchor-bottom-left)", rule)
+		}
+		if position[0] == 0 && position[1] == 0 {
+			return element, nil
+		}
+		return wrapTransform(element, fmt.Sprintf("translate(%s %s)", formatLength(position[0]*dx), formatLength(position[1]*dy))), nil
+	}
+	return "", fmt.Errorf("unknown %s rule %q (expected %s, %s, %s or %s<position>)", AdaptAttr, rule, AdaptRepeat, AdaptStretch, AdaptCover, AdaptAnchor)
+}
+
+func wrapTransform(element, transform string) string {
+	return `<g transform="` + transform + `">` + element + `</g>`
+}
+
+// formatLength prints v with at most three decimals and without a sign on
+// zero
+func formatLength(v float64) string {
+	v = math.Round(v*1000) / 1000
+	if v == 0 {
+		return "0"
+	}
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
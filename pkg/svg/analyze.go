@@ -0,0 +1,216 @@
+package svg
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Feature describes an SVG construct the built-in oksvg rasterizer cannot
+// render faithfully
+type Feature struct {
+	Name   string
+	Count  int
+	Impact string
+}
+
+func (f Feature) String() string {
+	return fmt.Sprintf("%s (%dx): %s", f.Name, f.Count, f.Impact)
+}
+
+// Elements rendered by oksvg, everything else is skipped while its children
+// are still drawn as regular shapes
+var oksvgElements = map[string]bool{
+	"svg": true, "g": true, "line": true, "stop": true, "rect": true,
+	"circle": true, "ellipse": true, "polyline": true, "polygon": true,
+	"path": true, "desc": true, "defs": true, "style": true, "title": true,
+	"linearGradient": true, "radialGradient": true, "use": true,
+	"metadata": true,
+}
+
+// Known impact descriptions for common unsupported elements and attributes
+var featureImpacts = map[string]string{
+	"<filter>":            "filter effects are ignored",
+	"filter attribute":    "filtered elements render without their effect",
+	"<mask>":              "mask contents are drawn as regular shapes",
+	"mask attribute":      "masked elements render unmasked",
+	"<clipPath>":          "clip paths are drawn as regular shapes",
+	"clip-path attribute": "clipped elements render unclipped",
+	"<text>":              "text is not rendered",
+	"<tspan>":             "text is not rendered",
+	"<textPath>":          "text on path is not rendered",
+	"<pattern>":           "pattern fills are missing and pattern contents are drawn in place",
+	"<image>":             "raster and external images are not rendered",
+	"<foreignObject>":     "foreign content is not rendered",
+	"@font-face":          "external fonts are not loaded",
+	"<symbol>":            "symbol contents are drawn in place",
+	"<marker>":            "markers are drawn in place instead of on path vertices",
+}
+
+var fontFaceRegex = regexp.MustCompile(`@font-face`)
+
+// UnsupportedFeatures scans SVG content for constructs oksvg cannot handle
+func UnsupportedFeatures(content string) ([]Feature, error) {
+	counts := make(map[string]int)
+
+	decoder := xml.NewDecoder(strings.NewReader(content))
+	decoder.Strict = false
+	for {
+		token, err := decoder.RawToken()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse SVG: %w", err)
+		}
+
+		start, ok := token.(xml.StartElement)
+		if !ok {
+			continue
+		}
+
+		// Namespaced editor metadata (inkscape:, sodipodi:) is harmless and
+		// filter primitives are already covered by their <filter> parent
+		name := start.Name.Local
+		if start.Name.Space == "" && !oksvgElements[name] && !strings.HasPrefix(name, "fe") {
+			counts["<"+name+">"]++
+		}
+
+		for _, attr := range start.Attr {
+			if attr.Name.Space != "" {
+				continue
+			}
+			switch attr.Name.Local {
+			case "filter", "mask", "clip-path":
+				counts[attr.Name.Local+" attribute"]++
+			}
+		}
+	}
+
+	if n := len(fontFaceRegex.FindAllString(content, -1)); n > 0 {
+		counts["@font-face"] = n
+	}
+
+	var features []Feature
+	for name, count := range counts {
+		impact, known := featureImpacts[name]
+		if !known {
+			impact = "element is skipped, its children are drawn as regular shapes"
+		}
+		features = append(features, Feature{Name: name, Count: count, Impact: impact})
+	}
+	sort.Slice(features, func(i, j int) bool {
+		return features[i].Name < features[j].Name
+	})
+
+	return features, nil
+}
+
+// HasFallbacks reports whether the template declares fallback content with
+// data-ppr-fallback or data-ppr-requires attributes
+func HasFallbacks(content string) bool {
+	return strings.Contains(content, "data-ppr-fallback") || strings.Contains(content, "data-ppr-requires")
+}
+
+// ApplyFallbacks resolves declared fallbacks. Elements marked with
+// data-ppr-requires need a capable rasterizer, elements marked with
+// data-ppr-fallback replace them when the rasterizer lacks support.
+//
+// With substitute set, required elements are removed and fallback elements
+// are made visible. Otherwise the fallback elements are removed.
+func ApplyFallbacks(content string, substitute bool) (string, error) {
+	if !HasFallbacks(content) {
+		return content, nil
+	}
+
+	removeAttr := "data-ppr-fallback"
+	if substitute {
+		removeAttr = "data-ppr-requires"
+	}
+
+	type span struct{ start, end int64 }
+	var removals []span
+	var reveals []span
+
+	decoder := xml.NewDecoder(strings.NewReader(content))
+	decoder.Strict = false
+
+	depth := 0
+	removeDepth := -1
+	var removeStart int64
+
+	for {
+		offset := decoder.InputOffset()
+		token, err := decoder.RawToken()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return content, fmt.Errorf("failed to parse SVG: %w", err)
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			depth++
+			if removeDepth != -1 {
+				continue
+			}
+			// Self-closing elements produce their EndElement right away,
+			// so the removal span still covers the whole tag
+			if hasAttr(t, removeAttr) {
+				removeDepth = depth
+				removeStart = offset
+			} else if substitute && hasAttr(t, "data-ppr-fallback") {
+				reveals = append(reveals, span{offset, decoder.InputOffset()})
+			}
+		case xml.EndElement:
+			if removeDepth == depth {
+				removals = append(removals, span{removeStart, decoder.InputOffset()})
+				removeDepth = -1
+			}
+			depth--
+		}
+	}
+
+	var out strings.Builder
+	var pos int64
+	ri, vi := 0, 0
+	for ri < len(removals) || vi < len(reveals) {
+		if vi < len(reveals) && (ri >= len(removals) || reveals[vi].start < removals[ri].start) {
+			r := reveals[vi]
+			out.WriteString(content[pos:r.start])
+			out.WriteString(revealStartTag(content[r.start:r.end]))
+			pos = r.end
+			vi++
+			continue
+		}
+		r := removals[ri]
+		out.WriteString(content[pos:r.start])
+		pos = r.end
+		ri++
+	}
+	out.WriteString(content[pos:])
+
+	return out.String(), nil
+}
+
+func hasAttr(start xml.StartElement, name string) bool {
+	for _, attr := range start.Attr {
+		if attr.Name.Space == "" && attr.Name.Local == name {
+			return true
+		}
+	}
+	return false
+}
+
+var hiddenAttrRegex = regexp.MustCompile(`\s+(?:display\s*=\s*"none"|visibility\s*=\s*"hidden")`)
+var hiddenStyleRegex = regexp.MustCompile(`(?:display\s*:\s*none|visibility\s*:\s*hidden)\s*;?`)
+
+// revealStartTag strips display="none"/visibility="hidden" from a start tag
+func revealStartTag(tag string) string {
+	tag = hiddenAttrRegex.ReplaceAllString(tag, "")
+	return hiddenStyleRegex.ReplaceAllString(tag, "")
+}
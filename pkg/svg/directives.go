@@ -0,0 +1,79 @@
+package svg
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// directiveRegex matches {{name "argument" key=value ...}} placeholders
+var directiveRegex = regexp.MustCompile(`\{\{\s*([a-z]+)\s+"((?:[^"\\]|\\.)*)"((?:\s+[a-zA-Z-]+=(?:"[^"]*"|[^\s}]+))*)\s*\}\}`)
+
+var directiveOptionRegex = regexp.MustCompile(`([a-zA-Z-]+)=("[^"]*"|[^\s}]+)`)
+
+var paletteKeyRegex = regexp.MustCompile(`^base[0-9A-Fa-f]{2}$`)
+
+// expandDirectives replaces generator placeholders such as {{icon "arch"}}
+// and {{qrcode "text"}} with inline SVG markup. Palette references in their
+// options are emitted as color placeholders, so they must be expanded
+// before colors are replaced.
+func expandDirectives(content string) (string, error) {
+	var expandErr error
+	result := directiveRegex.ReplaceAllStringFunc(content, func(match string) string {
+		if expandErr != nil {
+			return match
+		}
+
+		parts := directiveRegex.FindStringSubmatch(match)
+		name, arg := parts[1], strings.ReplaceAll(parts[2], `\"`, `"`)
+
+		opts := make(map[string]string)
+		for _, opt := range directiveOptionRegex.FindAllStringSubmatch(parts[3], -1) {
+			opts[opt[1]] = strings.Trim(opt[2], `"`)
+		}
+
+		var markup string
+		var err error
+		switch name {
+		case "icon":
+			markup, err = renderIcon(arg, opts)
+		case "qrcode":
+			markup, err = renderQRCode(arg, opts)
+		default:
+			return match
+		}
+		if err != nil {
+			expandErr = fmt.Errorf("%s directive: %w", name, err)
+			return match
+		}
+		return markup
+	})
+
+	return result, expandErr
+}
+
+func directiveFloat(opts map[string]string, key string, fallback float64) (float64, error) {
+	value, exists := opts[key]
+	if !exists {
+		return fallback, nil
+	}
+	v, err := strconv.ParseFloat(strings.TrimSuffix(value, "px"), 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s: %s", key, value)
+	}
+	return v, nil
+}
+
+// directiveColor returns a literal color option or a color placeholder for
+// palette keys like base0D
+func directiveColor(opts map[string]string, key, fallback string) string {
+	value, exists := opts[key]
+	if !exists {
+		value = fallback
+	}
+	if paletteKeyRegex.MatchString(value) {
+		return "{{base" + strings.ToUpper(value[4:]) + "}}"
+	}
+	return value
+}
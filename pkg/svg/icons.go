@@ -0,0 +1,292 @@
+package svg
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// iconSize is the edge length of the square box every icon is drawn in
+const iconSize = 24.0
+
+// Bundled icons as path data on a 24x24 box, filled with the nonzero rule.
+// OS logos are simplified single-color glyphs in the spirit of Nerd Font
+// symbols rather than exact trademarks.
+var icons = map[string]string{
+	"arch": "M12 1.5L22.5 22.5C19.8 20.8 17.3 19.6 15 19C14.8 15.6 13.7 12.6 12 12.6" +
+		"C10.3 12.6 9.2 15.6 9 19C6.7 19.6 4.2 20.8 1.5 22.5Z",
+	"windows": "M2 2L11.5 2L11.5 11.5L2 11.5Z" +
+		"M12.5 2L22 2L22 11.5L12.5 11.5Z" +
+		"M2 12.5L11.5 12.5L11.5 22L2 22Z" +
+		"M12.5 12.5L22 12.5L22 22L12.5 22Z",
+	"square":   "M2 2L22 2L22 22L2 22Z",
+	"triangle": "M12 2L22.5 21L1.5 21Z",
+	"heart": "M12 21.2C5 16.2 1.5 12.4 1.5 8.2C1.5 5 4 2.8 6.9 2.8C9 2.8 10.9 4 12 5.8" +
+		"C13.1 4 15 2.8 17.1 2.8C20 2.8 22.5 5 22.5 8.2C22.5 12.4 19 16.2 12 21.2Z",
+	"bolt": "M13.5 1.5L4 13.5L11 13.5L10 22.5L20 10L13 10Z",
+}
+
+func init() {
+	icons["circle"] = circlePath(12, 12, 10, false)
+	icons["hexagon"] = starPath(12, 12, 10.5, 10.5, 3)
+	icons["star"] = starPath(12, 12, 10.5, 4.4, 5)
+	icons["ubuntu"] = ubuntuPath()
+	icons["debian"] = debianPath()
+	icons["nixos"] = nixosPath()
+	icons["moon"] = moonPath()
+	icons["sun"] = sunPath()
+	icons["gear"] = gearPath()
+	icons["terminal"] = terminalPath()
+}
+
+// IconNames returns the names of all bundled icons, sorted
+func IconNames() []string {
+	names := make([]string, 0, len(icons))
+	for name := range icons {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// IconPath returns the path data of a bundled icon on a 24x24 box
+func IconPath(name string) (string, bool) {
+	d, exists := icons[strings.ToLower(name)]
+	return d, exists
+}
+
+// renderIcon expands {{icon "name" size=120 x=0 y=0 fill=base05}} into a
+// scaled and positioned <path>
+func renderIcon(name string, opts map[string]string) (string, error) {
+	d, exists := IconPath(name)
+	if !exists {
+		return "", fmt.Errorf("unknown icon %q (available: %s)", name, strings.Join(IconNames(), ", "))
+	}
+
+	size, err := directiveFloat(opts, "size", iconSize)
+	if err != nil {
+		return "", err
+	}
+	x, err := directiveFloat(opts, "x", 0)
+	if err != nil {
+		return "", err
+	}
+	y, err := directiveFloat(opts, "y", 0)
+	if err != nil {
+		return "", err
+	}
+
+	fill := directiveColor(opts, "fill", "base05")
+
+	// Both scale factors are written out since oksvg reads scale(s) as scale(s, 0)
+	scale := formatScale(size / iconSize)
+	var b strings.Builder
+	fmt.Fprintf(&b, `<path transform="translate(%s %s) scale(%s %s)" d="%s" fill="%s"`,
+		formatCoord(x), formatCoord(y), scale, scale, d, fill)
+	if opacity, exists := opts["opacity"]; exists {
+		fmt.Fprintf(&b, ` opacity="%s"`, opacity)
+	}
+	b.WriteString("/>")
+	return b.String(), nil
+}
+
+func formatScale(v float64) string {
+	return strings.TrimRight(strings.TrimRight(fmt.Sprintf("%.4f", v), "0"), ".")
+}
+
+// polygonPath builds a closed path through points
+func polygonPath(points [][2]float64) string {
+	var b strings.Builder
+	for i, p := range points {
+		if i == 0 {
+			b.WriteString("M")
+		} else {
+			b.WriteString("L")
+		}
+		b.WriteString(formatCoord(p[0]) + " " + formatCoord(p[1]))
+	}
+	b.WriteString("Z")
+	return b.String()
+}
+
+// reversed returns points in the opposite order, turning a shape into a hole
+// under the nonzero fill rule
+func reversed(points [][2]float64) [][2]float64 {
+	out := make([][2]float64, len(points))
+	for i, p := range points {
+		out[len(points)-1-i] = p
+	}
+	return out
+}
+
+// circlePoints samples a circle clockwise in SVG coordinates
+func circlePoints(cx, cy, r float64, segments int) [][2]float64 {
+	points := make([][2]float64, segments)
+	for i := range points {
+		a := 2 * math.Pi * float64(i) / float64(segments)
+		points[i] = [2]float64{cx + r*math.Cos(a), cy + r*math.Sin(a)}
+	}
+	return points
+}
+
+// circlePath draws a circle with four cubic curves, counter-clockwise when hole is set
+func circlePath(cx, cy, r float64, hole bool) string {
+	k := r * 0.5523
+	p := func(x, y float64) string { return formatCoord(x) + " " + formatCoord(y) }
+	if hole {
+		return "M" + p(cx+r, cy) +
+			"C" + p(cx+r, cy-k) + " " + p(cx+k, cy-r) + " " + p(cx, cy-r) +
+			"C" + p(cx-k, cy-r) + " " + p(cx-r, cy-k) + " " + p(cx-r, cy) +
+			"C" + p(cx-r, cy+k) + " " + p(cx-k, cy+r) + " " + p(cx, cy+r) +
+			"C" + p(cx+k, cy+r) + " " + p(cx+r, cy+k) + " " + p(cx+r, cy) + "Z"
+	}
+	return "M" + p(cx+r, cy) +
+		"C" + p(cx+r, cy+k) + " " + p(cx+k, cy+r) + " " + p(cx, cy+r) +
+		"C" + p(cx-k, cy+r) + " " + p(cx-r, cy+k) + " " + p(cx-r, cy) +
+		"C" + p(cx-r, cy-k) + " " + p(cx-k, cy-r) + " " + p(cx, cy-r) +
+		"C" + p(cx+k, cy-r) + " " + p(cx+r, cy-k) + " " + p(cx+r, cy) + "Z"
+}
+
+// starPath draws a star with n points alternating between the outer and
+// inner radius, pointing up. Equal radii give a regular 2n-gon.
+func starPath(cx, cy, outer, inner float64, n int) string {
+	var points [][2]float64
+	for i := 0; i < 2*n; i++ {
+		r := outer
+		if i%2 == 1 {
+			r = inner
+		}
+		a := -math.Pi/2 + math.Pi*float64(i)/float64(n)
+		points = append(points, [2]float64{cx + r*math.Cos(a), cy + r*math.Sin(a)})
+	}
+	return polygonPath(points)
+}
+
+// rotatedRect returns the corners of a w x h rectangle centered at
+// (dx, dy), rotated by angle around (cx, cy)
+func rotatedRect(cx, cy, dx, dy, w, h, angle float64) [][2]float64 {
+	corners := [][2]float64{{-w / 2, -h / 2}, {w / 2, -h / 2}, {w / 2, h / 2}, {-w / 2, h / 2}}
+	sin, cos := math.Sincos(angle)
+	points := make([][2]float64, len(corners))
+	for i, c := range corners {
+		x, y := dx+c[0], dy+c[1]
+		points[i] = [2]float64{cx + x*cos - y*sin, cy + x*sin + y*cos}
+	}
+	return points
+}
+
+func ubuntuPath() string {
+	d := circlePath(12, 12, 7.2, false) + circlePath(12, 12, 4.6, true)
+	for i := 0; i < 3; i++ {
+		a := -math.Pi/6 + 2*math.Pi*float64(i)/3
+		d += circlePath(12+9.3*math.Cos(a), 12+9.3*math.Sin(a), 2.5, false)
+	}
+	return d
+}
+
+// debianPath draws a swirl that tapers towards its center
+func debianPath() string {
+	const steps = 48
+	var outer, inner [][2]float64
+	for i := 0; i <= steps; i++ {
+		t := float64(i) / steps
+		a := -math.Pi/4 - t*2.6*math.Pi
+		r := 9.5 - 6*t
+		w := 3.2*(1-t) + 0.8
+		sin, cos := math.Sincos(a)
+		outer = append(outer, [2]float64{12 + (r+w/2)*cos, 12 + (r+w/2)*sin})
+		inner = append(inner, [2]float64{12 + (r-w/2)*cos, 12 + (r-w/2)*sin})
+	}
+	return polygonPath(append(outer, reversed(inner)...))
+}
+
+// nixosPath draws six bars arranged as a pinwheel snowflake
+func nixosPath() string {
+	var d string
+	for i := 0; i < 6; i++ {
+		d += polygonPath(rotatedRect(12, 12, 4.2, -2.6, 3, 13, math.Pi*float64(i)/3))
+	}
+	return d
+}
+
+// moonPath draws a crescent: the part of a circle not covered by a second,
+// offset circle
+func moonPath() string {
+	const (
+		cx1, cy1, r1 = 12.0, 12.0, 10.0
+		cx2, cy2, r2 = 16.0, 8.0, 8.0
+		steps        = 64
+		tolerance    = 1e-9
+	)
+	inside := func(x, y, cx, cy, r float64) bool {
+		return (x-cx)*(x-cx)+(y-cy)*(y-cy) < r*r-tolerance
+	}
+
+	var points [][2]float64
+	// Outer edge, clockwise, skipping points hidden by the second circle
+	for i := 0; i < steps; i++ {
+		a := 2 * math.Pi * float64(i) / steps
+		x, y := cx1+r1*math.Cos(a), cy1+r1*math.Sin(a)
+		if !inside(x, y, cx2, cy2, r2) {
+			points = append(points, [2]float64{x, y})
+		}
+	}
+	// Rotate so the visible arc is contiguous
+	for i := 1; i < len(points); i++ {
+		a, b := points[i-1], points[i]
+		if math.Hypot(b[0]-a[0], b[1]-a[1]) > 2*math.Pi*r1/steps*1.5 {
+			points = append(points[i:], points[:i]...)
+			break
+		}
+	}
+	// Inner edge, counter-clockwise along the second circle inside the first
+	var inner [][2]float64
+	for i := steps - 1; i >= 0; i-- {
+		a := 2 * math.Pi * float64(i) / steps
+		x, y := cx2+r2*math.Cos(a), cy2+r2*math.Sin(a)
+		if inside(x, y, cx1, cy1, r1) {
+			inner = append(inner, [2]float64{x, y})
+		}
+	}
+	for i := 1; i < len(inner); i++ {
+		a, b := inner[i-1], inner[i]
+		if math.Hypot(b[0]-a[0], b[1]-a[1]) > 2*math.Pi*r2/steps*1.5 {
+			inner = append(inner[i:], inner[:i]...)
+			break
+		}
+	}
+	return polygonPath(append(points, inner...))
+}
+
+func sunPath() string {
+	d := circlePath(12, 12, 4.8, false)
+	for i := 0; i < 8; i++ {
+		d += polygonPath(rotatedRect(12, 12, 0, -9, 2.2, 4, math.Pi*float64(i)/4))
+	}
+	return d
+}
+
+// gearPath draws a gear with eight teeth and a center hole
+func gearPath() string {
+	const teeth = 8
+	var points [][2]float64
+	for i := 0; i < teeth*4; i++ {
+		r := 7.6
+		if i%4 == 1 || i%4 == 2 {
+			r = 10.5
+		}
+		a := 2*math.Pi*float64(i)/(teeth*4) - math.Pi/2
+		points = append(points, [2]float64{12 + r*math.Cos(a), 12 + r*math.Sin(a)})
+	}
+	return polygonPath(points) + polygonPath(reversed(circlePoints(12, 12, 3.4, 32)))
+}
+
+// terminalPath draws a window frame with a prompt chevron and cursor
+func terminalPath() string {
+	frame := polygonPath([][2]float64{{1.5, 3}, {22.5, 3}, {22.5, 21}, {1.5, 21}})
+	hole := polygonPath(reversed([][2]float64{{3.2, 4.7}, {20.8, 4.7}, {20.8, 19.3}, {3.2, 19.3}}))
+	chevron := polygonPath([][2]float64{{5.5, 8}, {7, 6.6}, {12, 11.5}, {7, 16.4}, {5.5, 15}, {9, 11.5}})
+	cursor := polygonPath([][2]float64{{12.5, 15}, {18.5, 15}, {18.5, 16.8}, {12.5, 16.8}})
+	return frame + hole + chevron + cursor
+}
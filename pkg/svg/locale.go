@@ -0,0 +1,144 @@
+package svg
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/byteowlz/ppr/pkg/locale"
+)
+
+// usesDate reports whether content has a {{date}} directive, whose render
+// goes stale
+func usesDate(content string) bool {
+	for _, match := range directiveRegex.FindAllStringSubmatch(content, -1) {
+		if match[1] == "date" {
+			return true
+		}
+	}
+	return false
+}
+
+// expandLocaleDirectives replaces {{date "layout"}} with the current date
+// and {{number "value" decimals=N}} with a number, both formatted for loc.
+// The number is a literal or the name of a value. They are expanded after
+// the values, so the numbers of values can be formatted.
+func expandLocaleDirectives(content string, loc *locale.Locale, values map[string]string, now time.Time) (string, error) {
+	if loc == nil {
+		loc = locale.English
+	}
+
+	var expandErr error
+	result := directiveRegex.ReplaceAllStringFunc(content, func(match string) string {
+		if expandErr != nil {
+			return match
+		}
+
+		parts := directiveRegex.FindStringSubmatch(match)
+		name, arg := parts[1], strings.ReplaceAll(parts[2], `\"`, `"`)
+		opts := make(map[string]string)
+		for _, opt := range directiveOptionRegex.FindAllStringSubmatch(parts[3], -1) {
+			opts[opt[1]] = strings.Trim(opt[2], `"`)
+		}
+
+		switch name {
+		case "date":
+			return escapeText(loc.FormatDate(now, arg))
+		case "number":
+			text, err := formatNumber(loc, arg, values, opts)
+			if err != nil {
+				expandErr = fmt.Errorf("number directive: %w", err)
+				return match
+			}
+			return text
+		default:
+			return match
+		}
+	})
+
+	return result, expandErr
+}
+
+func formatNumber(loc *locale.Locale, arg string, values map[string]string, opts map[string]string) (string, error) {
+	raw := arg
+	if value, ok := values[arg]; ok {
+		raw = value
+	}
+	number, err := strconv.ParseFloat(raw, 64)
+	if err != nil {
+		return "", fmt.Errorf("%q is neither a number nor a numeric value", arg)
+	}
+
+	decimals := 0
+	if value, ok := opts["decimals"]; ok {
+		if decimals, err = strconv.Atoi(value); err != nil || decimals < 0 {
+			return "", fmt.Errorf("invalid decimals: %s", value)
+		}
+	}
+	return loc.FormatNumber(number, decimals), nil
+}
+
+// escapeText escapes text for use as SVG character data
+func escapeText(text string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(text)
+}
+
+var (
+	textElementRegex = regexp.MustCompile(`(?s)<text\b((?:[^>/]|/[^>])*)>(.*?)</text>`)
+	textAnchorRegex  = regexp.MustCompile(`(text-anchor\s*[=:]\s*["']?)(start|end)`)
+)
+
+// hasRTL reports whether text contains letters of a right-to-left script
+func hasRTL(text string) bool {
+	for _, r := range text {
+		if unicode.In(r, unicode.Hebrew, unicode.Arabic, unicode.Syriac, unicode.Thaana, unicode.Nko) {
+			return true
+		}
+	}
+	return false
+}
+
+// MarkRTL sets direction="rtl" on <text> elements containing right-to-left
+// script, so rasterizers with bidi support order mixed text correctly. The
+// text anchor is swapped to keep the text where the template placed it.
+// Elements that declare a direction are left alone.
+func MarkRTL(content string) string {
+	if !hasRTL(content) {
+		return content
+	}
+
+	return textElementRegex.ReplaceAllStringFunc(content, func(match string) string {
+		parts := textElementRegex.FindStringSubmatch(match)
+		attrs, inner := parts[1], parts[2]
+		if !hasRTL(inner) || strings.Contains(attrs, "direction") {
+			return match
+		}
+
+		if textAnchorRegex.MatchString(attrs) {
+			attrs = textAnchorRegex.ReplaceAllStringFunc(attrs, func(anchor string) string {
+				if strings.HasSuffix(anchor, "start") {
+					return strings.TrimSuffix(anchor, "start") + "end"
+				}
+				return strings.TrimSuffix(anchor, "end") + "start"
+			})
+		} else if !strings.Contains(attrs, "text-anchor") {
+			// start is the default, which is the right edge in RTL
+			attrs += ` text-anchor="end"`
+		}
+		return "<text" + attrs + ` direction="rtl" unicode-bidi="embed">` + inner + "</text>"
+	})
+}
+
+// HasRTLText reports whether a <text> element of content contains
+// right-to-left script
+func HasRTLText(content string) bool {
+	for _, match := range textElementRegex.FindAllStringSubmatch(content, -1) {
+		if hasRTL(match[2]) {
+			return true
+		}
+	}
+	return false
+}
@@ -1,38 +1,48 @@
 package svg
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"regexp"
+	"runtime/trace"
+	"strconv"
 	"strings"
 
+	"github.com/byteowlz/ppr/pkg/headless"
+	"github.com/byteowlz/ppr/pkg/image"
+	"github.com/byteowlz/ppr/pkg/locale"
 	"github.com/byteowlz/ppr/pkg/theme"
 )
 
-type Processor struct{}
+type Processor struct {
+	// AllowUnsafe skips Sanitize for templates from trusted sources
+	AllowUnsafe bool
+	// Values fill {{name}} placeholders besides the colors. Numeric values
+	// can be scaled in place with {{name*N}}, e.g. to a circumference. A
+	// template gives defaults with <!-- ppr:value name value --> comments,
+	// so it still renders without them.
+	Values map[string]string
+	// Locale formats {{date}} and {{number}} directives, English when nil
+	Locale *locale.Locale
+	// Dated is set by ProcessContent when the template shows the date, so
+	// a stored render goes stale
+	Dated bool
+}
 
 func NewProcessor() *Processor {
 	return &Processor{}
 }
 
 func (p *Processor) ProcessTemplate(templatePath string, theme *theme.Theme) (string, error) {
+	defer trace.StartRegion(context.Background(), "ppr.process-template").End()
+
 	content, err := os.ReadFile(templatePath)
 	if err != nil {
 		return "", fmt.Errorf("failed to read template file: %w", err)
 	}
 
-	svgContent := string(content)
-
-	for colorKey, colorValue := range theme.Palette {
-		placeholder := fmt.Sprintf("{{%s}}", colorKey)
-		svgContent = strings.ReplaceAll(svgContent, placeholder, colorValue)
-	}
-
-	if err := p.validateProcessedSVG(svgContent); err != nil {
-		return "", fmt.Errorf("validation failed: %w", err)
-	}
-
-	return svgContent, nil
+	return p.ProcessContent(string(content), theme.Palette)
 }
 
 func (p *Processor) ProcessTemplateWithColors(templatePath string, colors map[string]string) (string, error) {
@@ -41,13 +51,40 @@ func (p *Processor) ProcessTemplateWithColors(templatePath string, colors map[st
 		return "", fmt.Errorf("failed to read template file: %w", err)
 	}
 
-	svgContent := string(content)
+	return p.ProcessContent(string(content), colors)
+}
+
+// ProcessContent expands the directives of already loaded template
+// content, replaces the color and value placeholders and sanitizes the
+// result. Colors and values come from themes and templates as untrusted as
+// the markup, so only the final content is checked.
+func (p *Processor) ProcessContent(svgContent string, colors map[string]string) (string, error) {
+	p.Dated = usesDate(svgContent)
+	svgContent, err := expandDirectives(svgContent)
+	if err != nil {
+		return "", err
+	}
 
 	for colorKey, colorValue := range colors {
 		placeholder := fmt.Sprintf("{{%s}}", colorKey)
 		svgContent = strings.ReplaceAll(svgContent, placeholder, colorValue)
 	}
+	values, err := templateValues(svgContent, p.Values, p.AllowUnsafe)
+	if err != nil {
+		return "", err
+	}
+	if len(values) > 0 {
+		svgContent = replaceValues(svgContent, values)
+	}
+	if svgContent, err = expandLocaleDirectives(svgContent, p.Locale, values, headless.Now()); err != nil {
+		return "", err
+	}
 
+	if !p.AllowUnsafe {
+		if svgContent, err = Sanitize(svgContent); err != nil {
+			return "", err
+		}
+	}
 	if err := p.validateProcessedSVG(svgContent); err != nil {
 		return "", fmt.Errorf("validation failed: %w", err)
 	}
@@ -55,6 +92,59 @@ func (p *Processor) ProcessTemplateWithColors(templatePath string, colors map[st
 	return svgContent, nil
 }
 
+var valueCommentPattern = regexp.MustCompile(`<!--\s*ppr:value\s+([A-Za-z_][A-Za-z0-9_]*)\s+(.*?)\s*-->`)
+
+// unsafeValueChars would let a value add markup or attributes
+const unsafeValueChars = `<>"=`
+
+// templateValues merges values over the defaults content declares. The
+// defaults are spliced in verbatim, so unless allowUnsafe is set those with
+// markup characters are rejected with an *UnsafeError.
+func templateValues(content string, values map[string]string, allowUnsafe bool) (map[string]string, error) {
+	matches := valueCommentPattern.FindAllStringSubmatch(content, -1)
+	if len(matches) == 0 {
+		return values, nil
+	}
+	var reasons []string
+	merged := make(map[string]string, len(matches)+len(values))
+	for _, match := range matches {
+		if !allowUnsafe && strings.ContainsAny(match[2], unsafeValueChars) {
+			reasons = append(reasons, fmt.Sprintf("markup in the default of value %s", match[1]))
+		}
+		merged[match[1]] = match[2]
+	}
+	if len(reasons) > 0 {
+		return nil, &UnsafeError{Reasons: reasons}
+	}
+	for name, value := range values {
+		merged[name] = value
+	}
+	return merged, nil
+}
+
+var valuePattern = regexp.MustCompile(`\{\{([A-Za-z_][A-Za-z0-9_]*)(?:\*([0-9]*\.?[0-9]+))?\}\}`)
+
+// replaceValues fills the value placeholders, unknown names and scaled
+// placeholders of non-numeric values are left untouched
+func replaceValues(content string, values map[string]string) string {
+	return valuePattern.ReplaceAllStringFunc(content, func(match string) string {
+		parts := valuePattern.FindStringSubmatch(match)
+		value, ok := values[parts[1]]
+		if !ok {
+			return match
+		}
+		if parts[2] == "" {
+			return value
+		}
+		number, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return match
+		}
+		factor, _ := strconv.ParseFloat(parts[2], 64)
+		return strconv.FormatFloat(number*factor, 'f', 2, 64)
+	})
+}
+
 func (p *Processor) validateProcessedSVG(content string) error {
 	placeholderPattern := regexp.MustCompile(`\{\{base[0-9A-F]{2}\}\}`)
 	matches := placeholderPattern.FindAllString(content, -1)
@@ -94,17 +184,10 @@ func (p *Processor) ExtractPlaceholders(templatePath string) ([]string, error) {
 	return placeholders, nil
 }
 
+// WriteSVG writes svgContent to outputPath, replacing it atomically
 func (p *Processor) WriteSVG(svgContent, outputPath string) error {
-	file, err := os.Create(outputPath)
-	if err != nil {
-		return fmt.Errorf("failed to create SVG file: %w", err)
-	}
-	defer file.Close()
-
-	_, err = file.WriteString(svgContent)
-	if err != nil {
+	if err := image.WriteFileAtomic(outputPath, []byte(svgContent)); err != nil {
 		return fmt.Errorf("failed to write SVG content: %w", err)
 	}
-
 	return nil
 }
@@ -0,0 +1,139 @@
+package svg
+
+import (
+	"errors"
+	"maps"
+	"strings"
+	"testing"
+)
+
+// TestProcessContentSanitizesSubstitutions checks that markup arriving
+// through palette colors, values and template defaults is sanitized like
+// the template itself
+func TestProcessContentSanitizesSubstitutions(t *testing.T) {
+	tests := []struct {
+		name     string
+		template string
+		colors   map[string]string
+		values   map[string]string
+		// absent must not be left in the output of an accepted template
+		absent string
+	}{
+		{
+			name:     "attribute name from a template default",
+			template: `<svg><!-- ppr:value h href --><image {{h}}="https://evil.example/x.png"/></svg>`,
+		},
+		{
+			name:     "remote reference from a palette color",
+			template: `<svg><rect fill="{{base00}}"/></svg>`,
+			colors:   map[string]string{"base00": `#000" href="https://evil.example/x.png`},
+		},
+		{
+			name:     "script from a palette color",
+			template: `<svg><rect fill="{{base00}}"/></svg>`,
+			colors:   map[string]string{"base00": `#000"/><script>alert(1)</script><rect fill="#000`},
+			absent:   "alert",
+		},
+		{
+			name:     "event handler from a value",
+			template: `<svg><rect width="{{w}}"/></svg>`,
+			values:   map[string]string{"w": `10" onload="alert(1)`},
+			absent:   "onload",
+		},
+		{
+			name:     "css url from a value",
+			template: `<svg><rect style="{{style}}"/></svg>`,
+			values:   map[string]string{"style": "fill:url(https://evil.example/p)"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &Processor{Values: tt.values}
+			got, err := p.ProcessContent(tt.template, tt.colors)
+			if tt.absent != "" {
+				if err != nil {
+					t.Fatalf("ProcessContent() error = %v", err)
+				}
+				if strings.Contains(got, tt.absent) {
+					t.Errorf("ProcessContent() = %s, still contains %q", got, tt.absent)
+				}
+				return
+			}
+			var unsafe *UnsafeError
+			if !errors.As(err, &unsafe) {
+				t.Errorf("ProcessContent() = %s, %v, want an *UnsafeError", got, err)
+			}
+		})
+	}
+}
+
+func TestProcessContentAllowUnsafe(t *testing.T) {
+	p := &Processor{AllowUnsafe: true}
+	template := `<svg><image href="https://example.com/x.png"/></svg>`
+	got, err := p.ProcessContent(template, nil)
+	if err != nil {
+		t.Fatalf("ProcessContent() error = %v", err)
+	}
+	if got != template {
+		t.Errorf("ProcessContent() = %s, want %s", got, template)
+	}
+}
+
+func TestTemplateValues(t *testing.T) {
+	tests := []struct {
+		name        string
+		content     string
+		values      map[string]string
+		allowUnsafe bool
+		want        map[string]string
+		wantErr     bool
+	}{
+		{
+			name:    "defaults",
+			content: `<!-- ppr:value progress 0.5 --><!-- ppr:value label Next up -->`,
+			want:    map[string]string{"progress": "0.5", "label": "Next up"},
+		},
+		{
+			name:    "values override defaults",
+			content: `<!-- ppr:value progress 0.5 -->`,
+			values:  map[string]string{"progress": "0.8"},
+			want:    map[string]string{"progress": "0.8"},
+		},
+		{
+			name:    "markup default",
+			content: `<!-- ppr:value x "/><image href="y -->`,
+			wantErr: true,
+		},
+		{
+			name:    "attribute default",
+			content: `<!-- ppr:value x a=b -->`,
+			wantErr: true,
+		},
+		{
+			name:        "markup default allowed",
+			content:     `<!-- ppr:value x a=b -->`,
+			allowUnsafe: true,
+			want:        map[string]string{"x": "a=b"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := templateValues(tt.content, tt.values, tt.allowUnsafe)
+			if tt.wantErr {
+				var unsafe *UnsafeError
+				if !errors.As(err, &unsafe) {
+					t.Errorf("templateValues() error = %v, want an *UnsafeError", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("templateValues() error = %v", err)
+			}
+			if !maps.Equal(got, tt.want) {
+				t.Errorf("templateValues() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
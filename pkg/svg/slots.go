@@ -0,0 +1,64 @@
+package svg
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+)
+
+var slotCommentRegex = regexp.MustCompile(`<!--\s*ppr:(background|foreground)\s+([^-]*?)\s*-->`)
+
+var placeholderRegex = regexp.MustCompile(`\{\{(base[0-9A-F]{2})\}\}`)
+
+// SlotUsage describes which palette slots a template draws its background
+// and its foreground shapes or text with
+type SlotUsage struct {
+	Background  string
+	Foregrounds []string
+	// Declared is false when the usage was inferred from the placeholders
+	Declared bool
+}
+
+// ParseSlotUsage reads the slot usage a template declares with comments:
+//
+//	<!-- ppr:background base00 -->
+//	<!-- ppr:foreground base05 base0D -->
+//
+// Without them the background is base00 and every other placeholder of the
+// template counts as foreground.
+func ParseSlotUsage(content string) SlotUsage {
+	var usage SlotUsage
+	for _, match := range slotCommentRegex.FindAllStringSubmatch(content, -1) {
+		slots := strings.Fields(match[2])
+		for i, slot := range slots {
+			if paletteKeyRegex.MatchString(slot) {
+				slots[i] = "base" + strings.ToUpper(slot[4:])
+			}
+		}
+		if len(slots) == 0 {
+			continue
+		}
+		usage.Declared = true
+		if match[1] == "background" {
+			usage.Background = slots[0]
+		} else {
+			usage.Foregrounds = append(usage.Foregrounds, slots...)
+		}
+	}
+	if usage.Background == "" {
+		usage.Background = "base00"
+	}
+	if len(usage.Foregrounds) > 0 {
+		return usage
+	}
+
+	seen := map[string]bool{usage.Background: true}
+	for _, match := range placeholderRegex.FindAllStringSubmatch(content, -1) {
+		if !seen[match[1]] {
+			seen[match[1]] = true
+			usage.Foregrounds = append(usage.Foregrounds, match[1])
+		}
+	}
+	sort.Strings(usage.Foregrounds)
+	return usage
+}
@@ -0,0 +1,47 @@
+package svg
+
+import (
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var strokeWidthAttrRegex = regexp.MustCompile(`stroke-width\s*=\s*(["'])\s*([0-9]*\.?[0-9]+)`)
+var strokeWidthStyleRegex = regexp.MustCompile(`stroke-width\s*:\s*([0-9]*\.?[0-9]+)`)
+
+// ScaleStrokes multiplies every stroke-width in the SVG by factor, in
+// attributes and styles alike, keeping the units. The root element gets
+// stroke-width="factor" when it has none, so strokes relying on the
+// default width of 1 are thickened as well.
+func ScaleStrokes(content string, factor float64) string {
+	if factor == 1 || factor <= 0 {
+		return content
+	}
+	scale := func(value string) string {
+		v, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return value
+		}
+		return strconv.FormatFloat(math.Round(v*factor*1000)/1000, 'f', -1, 64)
+	}
+
+	content = strokeWidthAttrRegex.ReplaceAllStringFunc(content, func(match string) string {
+		groups := strokeWidthAttrRegex.FindStringSubmatch(match)
+		return "stroke-width=" + groups[1] + scale(groups[2])
+	})
+	content = strokeWidthStyleRegex.ReplaceAllStringFunc(content, func(match string) string {
+		return "stroke-width:" + scale(strokeWidthStyleRegex.FindStringSubmatch(match)[1])
+	})
+
+	if loc := svgOpenTagRegex.FindStringIndex(content); loc != nil {
+		rootTag := content[loc[0]:]
+		if end := strings.Index(rootTag, ">"); end != -1 {
+			rootTag = rootTag[:end]
+		}
+		if !strokeWidthAttrRegex.MatchString(rootTag) {
+			content = content[:loc[1]] + ` stroke-width="` + scale("1") + `"` + content[loc[1]:]
+		}
+	}
+	return content
+}
@@ -0,0 +1,361 @@
+package svg
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/byteowlz/ppr/pkg/fonts"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/sfnt"
+	"golang.org/x/image/math/fixed"
+)
+
+const defaultFontSize = 16.0
+
+// Text attributes consumed by the conversion and dropped from the generated path
+var textOnlyAttrs = map[string]bool{
+	"x": true, "y": true, "dx": true, "dy": true,
+	"font-family": true, "font-size": true, "font-weight": true, "font-style": true,
+	"text-anchor": true, "letter-spacing": true, "dominant-baseline": true,
+}
+
+// textProps holds the inheritable text properties of an element
+type textProps struct {
+	family string
+	size   float64
+	anchor string
+}
+
+// TextToPaths converts <text> elements to <path> outlines so rasterizers
+// without text support draw them. Fonts are looked up with resolver, and the
+// names of requested families that fell back to the embedded font are
+// returned.
+func TextToPaths(content string, resolver *fonts.Resolver) (string, []string, error) {
+	if !strings.Contains(content, "<text") {
+		return content, nil, nil
+	}
+
+	type replacement struct {
+		start, end int64
+		path       string
+	}
+	var replacements []replacement
+	missing := make(map[string]bool)
+	var missingList []string
+
+	decoder := xml.NewDecoder(strings.NewReader(content))
+	decoder.Strict = false
+
+	stack := []textProps{{family: "sans-serif", size: defaultFontSize, anchor: "start"}}
+	var buf sfnt.Buffer
+
+	var (
+		inText    bool
+		textDepth int
+		textStart int64
+		textAttrs []xml.Attr
+		textX     float64
+		textY     float64
+		textValue strings.Builder
+		textStyle textProps
+	)
+
+	for {
+		offset := decoder.InputOffset()
+		token, err := decoder.RawToken()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return content, nil, fmt.Errorf("failed to parse SVG: %w", err)
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			props := inheritProps(stack[len(stack)-1], t.Attr)
+			stack = append(stack, props)
+
+			if inText {
+				continue
+			}
+			if t.Name.Space == "" && t.Name.Local == "text" {
+				inText = true
+				textDepth = len(stack)
+				textStart = offset
+				textAttrs = t.Attr
+				textStyle = props
+				textX = firstLength(attrValue(t.Attr, "x")) + firstLength(attrValue(t.Attr, "dx"))
+				textY = firstLength(attrValue(t.Attr, "y")) + firstLength(attrValue(t.Attr, "dy"))
+				textValue.Reset()
+			}
+		case xml.CharData:
+			if inText {
+				textValue.Write(t)
+			}
+		case xml.EndElement:
+			if inText && len(stack) == textDepth {
+				inText = false
+
+				f, path, err := resolver.Lookup(textStyle.family)
+				if err != nil {
+					return content, nil, err
+				}
+				if path == "" {
+					if name := firstFamily(textStyle.family); name != "" && !missing[name] {
+						missing[name] = true
+						missingList = append(missingList, name)
+					}
+				}
+
+				text := strings.Join(strings.Fields(textValue.String()), " ")
+				d, err := textPath(f, &buf, text, textX, textY, textStyle)
+				if err != nil {
+					return content, nil, err
+				}
+
+				replacements = append(replacements, replacement{
+					start: textStart,
+					end:   decoder.InputOffset(),
+					path:  pathElement(textAttrs, d),
+				})
+			}
+			if len(stack) > 1 {
+				stack = stack[:len(stack)-1]
+			}
+		}
+	}
+
+	var out strings.Builder
+	var pos int64
+	for _, r := range replacements {
+		out.WriteString(content[pos:r.start])
+		out.WriteString(r.path)
+		pos = r.end
+	}
+	out.WriteString(content[pos:])
+
+	return out.String(), missingList, nil
+}
+
+// textPath lays out text on a single line starting at (x, y) on the
+// baseline and returns the glyph outlines as path data
+func textPath(f *sfnt.Font, buf *sfnt.Buffer, text string, x, y float64, props textProps) (string, error) {
+	ppem := fixed.Int26_6(props.size * 64)
+
+	// Measure first so text-anchor can shift the start position
+	var advance fixed.Int26_6
+	var prev sfnt.GlyphIndex
+	var glyphs []sfnt.GlyphIndex
+	var offsets []fixed.Int26_6
+	for i, r := range text {
+		index, err := f.GlyphIndex(buf, r)
+		if err != nil {
+			return "", fmt.Errorf("failed to look up glyph %q: %w", r, err)
+		}
+		if i > 0 {
+			if kern, err := f.Kern(buf, prev, index, ppem, font.HintingNone); err == nil {
+				advance += kern
+			}
+		}
+		glyphs = append(glyphs, index)
+		offsets = append(offsets, advance)
+
+		adv, err := f.GlyphAdvance(buf, index, ppem, font.HintingNone)
+		if err != nil {
+			return "", fmt.Errorf("failed to measure glyph %q: %w", r, err)
+		}
+		advance += adv
+		prev = index
+	}
+
+	width := float64(advance) / 64
+	switch props.anchor {
+	case "middle":
+		x -= width / 2
+	case "end":
+		x -= width
+	}
+
+	var d strings.Builder
+	for i, index := range glyphs {
+		segments, err := f.LoadGlyph(buf, index, ppem, nil)
+		if err != nil {
+			return "", fmt.Errorf("failed to load glyph: %w", err)
+		}
+
+		ox := x + float64(offsets[i])/64
+		open := false
+		for _, seg := range segments {
+			switch seg.Op {
+			case sfnt.SegmentOpMoveTo:
+				if open {
+					d.WriteString("Z")
+				}
+				d.WriteString("M" + pointString(seg.Args[0], ox, y))
+				open = true
+			case sfnt.SegmentOpLineTo:
+				d.WriteString("L" + pointString(seg.Args[0], ox, y))
+			case sfnt.SegmentOpQuadTo:
+				d.WriteString("Q" + pointString(seg.Args[0], ox, y) + " " + pointString(seg.Args[1], ox, y))
+			case sfnt.SegmentOpCubeTo:
+				d.WriteString("C" + pointString(seg.Args[0], ox, y) + " " + pointString(seg.Args[1], ox, y) + " " + pointString(seg.Args[2], ox, y))
+			}
+		}
+		if open {
+			d.WriteString("Z")
+		}
+	}
+
+	return d.String(), nil
+}
+
+func pointString(p fixed.Point26_6, ox, oy float64) string {
+	return formatCoord(ox+float64(p.X)/64) + " " + formatCoord(oy+float64(p.Y)/64)
+}
+
+func formatCoord(v float64) string {
+	return strconv.FormatFloat(v, 'f', 2, 64)
+}
+
+// pathElement builds a <path> carrying the presentation attributes of the
+// original text element
+func pathElement(attrs []xml.Attr, d string) string {
+	var b strings.Builder
+	b.WriteString("<path")
+	for _, attr := range attrs {
+		if attr.Name.Space == "" && textOnlyAttrs[attr.Name.Local] {
+			continue
+		}
+		name := attr.Name.Local
+		if attr.Name.Space != "" {
+			name = attr.Name.Space + ":" + name
+		}
+		b.WriteString(" " + name + `="`)
+		xml.EscapeText(&b, []byte(attr.Value))
+		b.WriteString(`"`)
+	}
+	b.WriteString(` d="` + d + `"/>`)
+	return b.String()
+}
+
+// inheritProps applies the text properties set on an element, either as
+// presentation attributes or inline style, on top of the parent's
+func inheritProps(parent textProps, attrs []xml.Attr) textProps {
+	props := parent
+	apply := func(name, value string) {
+		value = strings.TrimSpace(value)
+		switch name {
+		case "font-family":
+			if value != "" && value != "inherit" {
+				props.family = value
+			}
+		case "font-size":
+			if size, ok := parseFontSize(value, parent.size); ok {
+				props.size = size
+			}
+		case "text-anchor":
+			if value == "start" || value == "middle" || value == "end" {
+				props.anchor = value
+			}
+		}
+	}
+
+	for _, attr := range attrs {
+		if attr.Name.Space == "" {
+			apply(attr.Name.Local, attr.Value)
+		}
+	}
+	// Inline style takes precedence over presentation attributes
+	for _, decl := range strings.Split(attrValue(attrs, "style"), ";") {
+		if name, value, ok := strings.Cut(decl, ":"); ok {
+			apply(strings.TrimSpace(name), value)
+		}
+	}
+
+	return props
+}
+
+func attrValue(attrs []xml.Attr, name string) string {
+	for _, attr := range attrs {
+		if attr.Name.Space == "" && attr.Name.Local == name {
+			return attr.Value
+		}
+	}
+	return ""
+}
+
+var lengthRegex = regexp.MustCompile(`^\s*(-?[0-9]*\.?[0-9]+(?:[eE][-+]?[0-9]+)?)`)
+
+// firstLength parses the first number of a coordinate list such as "10 20"
+func firstLength(value string) float64 {
+	match := lengthRegex.FindStringSubmatch(value)
+	if match == nil {
+		return 0
+	}
+	v, _ := strconv.ParseFloat(match[1], 64)
+	return v
+}
+
+// parseFontSize accepts plain numbers, px, pt, em and percentages
+func parseFontSize(value string, parentSize float64) (float64, bool) {
+	value = strings.TrimSpace(value)
+	scale := 1.0
+	switch {
+	case strings.HasSuffix(value, "px"):
+		value = strings.TrimSuffix(value, "px")
+	case strings.HasSuffix(value, "pt"):
+		value = strings.TrimSuffix(value, "pt")
+		scale = 4.0 / 3
+	case strings.HasSuffix(value, "em"):
+		value = strings.TrimSuffix(value, "em")
+		scale = parentSize
+	case strings.HasSuffix(value, "%"):
+		value = strings.TrimSuffix(value, "%")
+		scale = parentSize / 100
+	}
+
+	size, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+	if err != nil || size <= 0 {
+		return 0, false
+	}
+	return size * scale, true
+}
+
+func firstFamily(family string) string {
+	name, _, _ := strings.Cut(family, ",")
+	name = strings.Trim(strings.TrimSpace(name), `"'`)
+	switch strings.ToLower(name) {
+	case "sans-serif", "serif", "monospace":
+		return ""
+	}
+	return name
+}
+
+var fontFamilyAttrRegex = regexp.MustCompile(`font-family\s*=\s*("[^"]*"|'[^']*')`)
+var fontFamilyStyleRegex = regexp.MustCompile(`font-family\s*:\s*[^;"}<>]*`)
+var svgOpenTagRegex = regexp.MustCompile(`<svg\b`)
+
+// SetFontFamily replaces every font-family in the SVG with family and sets
+// it on the root element so text without an explicit family inherits it
+func SetFontFamily(content, family string) string {
+	quoted := strings.ReplaceAll(family, `"`, "'")
+	attr := `font-family="` + quoted + `"`
+
+	content = fontFamilyAttrRegex.ReplaceAllString(content, attr)
+	content = fontFamilyStyleRegex.ReplaceAllLiteralString(content, "font-family:"+quoted)
+
+	if loc := svgOpenTagRegex.FindStringIndex(content); loc != nil {
+		rootTag := content[loc[0]:]
+		if end := strings.Index(rootTag, ">"); end != -1 {
+			rootTag = rootTag[:end]
+		}
+		if !fontFamilyAttrRegex.MatchString(rootTag) {
+			content = content[:loc[1]] + " " + attr + content[loc[1]:]
+		}
+	}
+	return content
+}
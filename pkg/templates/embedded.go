@@ -2,9 +2,11 @@ package templates
 
 import (
 	"embed"
+	"fmt"
 	"io/fs"
 	"os"
 	"path/filepath"
+	"sort"
 )
 
 //go:embed data/*
@@ -39,3 +41,30 @@ func CopyEmbeddedTemplates(destDir string) error {
 		return os.WriteFile(destPath, data, 0644)
 	})
 }
+
+// Names returns the file names of all embedded templates, sorted
+func Names() []string {
+	var names []string
+	entries, err := templatesFS.ReadDir("data")
+	if err != nil {
+		return names
+	}
+
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			names = append(names, entry.Name())
+		}
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// Read returns the content of the embedded template with the given file name
+func Read(name string) ([]byte, error) {
+	data, err := templatesFS.ReadFile("data/" + name)
+	if err != nil {
+		return nil, fmt.Errorf("embedded template not found: %s", name)
+	}
+	return data, nil
+}
@@ -0,0 +1,42 @@
+package templates
+
+import (
+	"embed"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Commented skeletons for 'ppr new-template', named by their base
+//
+//go:embed starters/*.svg
+var startersFS embed.FS
+
+// DefaultStarter is the base of new templates
+const DefaultStarter = "geometric"
+
+// StarterNames returns the bases new templates can start from, sorted
+func StarterNames() []string {
+	var names []string
+	entries, err := startersFS.ReadDir("starters")
+	if err != nil {
+		return names
+	}
+
+	for _, entry := range entries {
+		names = append(names, strings.TrimSuffix(entry.Name(), ".svg"))
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// Starter returns the starter template base with the name of the new
+// template filled in
+func Starter(base, name string) ([]byte, error) {
+	data, err := startersFS.ReadFile("starters/" + base + ".svg")
+	if err != nil {
+		return nil, fmt.Errorf("unknown template base: %s (expected %s)", base, strings.Join(StarterNames(), ", "))
+	}
+	return []byte(strings.ReplaceAll(string(data), "{{name}}", name)), nil
+}
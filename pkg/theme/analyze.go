@@ -0,0 +1,164 @@
+package theme
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/byteowlz/ppr/pkg/palette"
+)
+
+// MinRampStep is the smallest OKLab lightness step between neighbors of
+// the base00..base07 ramp that still reads as a separate shade
+const MinRampStep = 0.02
+
+// MinAccentDistance is the OKLab distance below which two accents are hard
+// to tell apart
+const MinAccentDistance = 0.08
+
+// SlotAnalysis holds the OKLCh coordinates of one palette slot and its
+// WCAG contrast ratio against base00
+type SlotAnalysis struct {
+	Slot     string
+	Hex      string
+	L        float64
+	Chroma   float64
+	Hue      float64
+	Contrast float64
+}
+
+// RampStep is the lightness change between two neighboring ramp slots,
+// positive when it goes the way the theme variant expects
+type RampStep struct {
+	From, To string
+	Delta    float64
+}
+
+// AccentPair is the OKLab distance between two accents
+type AccentPair struct {
+	A, B     string
+	Distance float64
+}
+
+// Analysis is the colorimetry report of a theme, see Analyze
+type Analysis struct {
+	Dark  bool
+	Slots []SlotAnalysis
+	// Ramp runs from the darkest base24 background (base11, base10) through
+	// base00..base07 for dark themes and the reverse for light ones
+	Ramp []RampStep
+	// Accents are the pairs of base08..base0F (and base12..base17), closest
+	// first
+	Accents []AccentPair
+}
+
+// Analyze measures the palette of t in OKLCh: the lightness, chroma and hue
+// of every slot, whether the background ramp brightens (or darkens, for
+// light themes) step by step, and how far apart the accents are.
+func (t *Theme) Analyze() (*Analysis, error) {
+	colors := make(map[string]palette.OKLab)
+	analysis := &Analysis{}
+	for _, key := range t.PaletteKeys() {
+		value, ok := t.Palette[key]
+		if !ok {
+			continue
+		}
+		c, err := palette.ParseHex(value)
+		if err != nil {
+			return nil, fmt.Errorf("theme %s: %s: %w", t.Name, key, err)
+		}
+		lab := palette.ToOKLab(c)
+		colors[key] = lab
+		analysis.Slots = append(analysis.Slots, SlotAnalysis{
+			Slot:   key,
+			Hex:    palette.ToHex(c),
+			L:      lab.L,
+			Chroma: lab.Chroma(),
+			Hue:    lab.Hue(),
+		})
+	}
+	background, ok := t.Palette["base00"]
+	if !ok {
+		return nil, fmt.Errorf("theme %s has no base00", t.Name)
+	}
+	bg, _ := palette.ParseHex(background)
+	for i := range analysis.Slots {
+		c, _ := palette.ParseHex(analysis.Slots[i].Hex)
+		analysis.Slots[i].Contrast = palette.ContrastRatio(c, bg)
+	}
+	analysis.Dark = colors["base00"].L <= colors["base05"].L
+
+	ramp := []string{"base00", "base01", "base02", "base03", "base04", "base05", "base06", "base07"}
+	if t.System == "base24" {
+		ramp = append([]string{"base11", "base10"}, ramp...)
+	}
+	direction := 1.0
+	if !analysis.Dark {
+		direction = -1
+	}
+	for i := 1; i < len(ramp); i++ {
+		from, okFrom := colors[ramp[i-1]]
+		to, okTo := colors[ramp[i]]
+		if !okFrom || !okTo {
+			continue
+		}
+		analysis.Ramp = append(analysis.Ramp, RampStep{From: ramp[i-1], To: ramp[i], Delta: (to.L - from.L) * direction})
+	}
+
+	var accents []string
+	for _, key := range t.PaletteKeys() {
+		if isAccentSlot(key) {
+			if _, ok := colors[key]; ok {
+				accents = append(accents, key)
+			}
+		}
+	}
+	for i, a := range accents {
+		for _, b := range accents[i+1:] {
+			analysis.Accents = append(analysis.Accents, AccentPair{A: a, B: b, Distance: colors[a].Distance(colors[b])})
+		}
+	}
+	sort.SliceStable(analysis.Accents, func(i, j int) bool {
+		return analysis.Accents[i].Distance < analysis.Accents[j].Distance
+	})
+	return analysis, nil
+}
+
+// isAccentSlot reports base08..base0F and the base24 bright accents
+// base12..base17
+func isAccentSlot(key string) bool {
+	var n int
+	if _, err := fmt.Sscanf(key, "base%X", &n); err != nil {
+		return false
+	}
+	return (n >= 0x08 && n <= 0x0F) || (n >= 0x12 && n <= 0x17)
+}
+
+// RampMonotonic reports whether every ramp step goes the expected way by at
+// least MinRampStep
+func (a *Analysis) RampMonotonic() bool {
+	for _, step := range a.Ramp {
+		if step.Delta < MinRampStep {
+			return false
+		}
+	}
+	return true
+}
+
+// NearestAccent returns the accent closest to slot and their distance
+func (a *Analysis) NearestAccent(slot string) (string, float64) {
+	nearest, distance := "", math.Inf(1)
+	for _, pair := range a.Accents {
+		other := ""
+		switch slot {
+		case pair.A:
+			other = pair.B
+		case pair.B:
+			other = pair.A
+		}
+		if other != "" && pair.Distance < distance {
+			nearest, distance = other, pair.Distance
+		}
+	}
+	return nearest, distance
+}
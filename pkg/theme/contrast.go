@@ -0,0 +1,78 @@
+package theme
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+
+	"github.com/byteowlz/ppr/pkg/palette"
+)
+
+// WCAG contrast ratios HighContrast raises the palette to against base00
+const (
+	HighContrastText  = 7.0
+	HighContrastMuted = 4.5
+)
+
+// Background slots, the darker base24 backgrounds included
+var backgroundSlots = map[string]bool{
+	"base00": true, "base01": true, "base02": true,
+	"base10": true, "base11": true,
+}
+
+// Comments and other muted foregrounds
+var mutedSlots = map[string]bool{"base03": true, "base04": true}
+
+// HighContrast returns a copy of t remapped for low-vision users. The
+// backgrounds move halfway toward black, or white for a light theme, and
+// lose half their chroma; every other slot keeps its hue and is moved away
+// from base00 until it reaches a contrast ratio of HighContrastText, or
+// HighContrastMuted for the muted base03 and base04.
+func (t *Theme) HighContrast() (*Theme, error) {
+	colors := make(map[string]color.RGBA, len(t.Palette))
+	for key, value := range t.Palette {
+		c, err := palette.ParseHex(value)
+		if err != nil {
+			return nil, fmt.Errorf("theme %s: %s: %w", t.Name, key, err)
+		}
+		colors[key] = c
+	}
+	background, ok := colors["base00"]
+	if !ok {
+		return nil, fmt.Errorf("theme %s has no base00", t.Name)
+	}
+	dark := palette.Luminance(background) <= palette.Luminance(colors["base05"])
+
+	adjusted := *t
+	adjusted.Palette = make(map[string]string, len(t.Palette))
+	for key, c := range colors {
+		if backgroundSlots[key] {
+			colors[key] = deepen(c, dark)
+		}
+	}
+	background = colors["base00"]
+	for key, c := range colors {
+		switch {
+		case backgroundSlots[key]:
+		case mutedSlots[key]:
+			c = palette.EnsureContrast(c, background, HighContrastMuted)
+		default:
+			c = palette.EnsureContrast(c, background, HighContrastText)
+		}
+		adjusted.Palette[key] = palette.ToHex(c)
+	}
+	return &adjusted, nil
+}
+
+// deepen moves a background halfway toward black when dark, toward white
+// otherwise, halving its chroma
+func deepen(c color.RGBA, dark bool) color.RGBA {
+	lab := palette.ToOKLab(c)
+	l := lab.L / 2
+	if !dark {
+		l = 1 - (1-lab.L)/2
+	}
+	out := palette.FromOKLCh(math.Max(0, math.Min(1, l)), lab.Chroma()/2, lab.Hue())
+	out.A = c.A
+	return out
+}
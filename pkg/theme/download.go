@@ -0,0 +1,138 @@
+package theme
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// SchemesURL is the archive of the tinted-theming base16 and base24 schemes
+const SchemesURL = "https://github.com/tinted-theming/schemes/archive/refs/heads/spec-0.11.tar.gz"
+
+// SchemesLicense and SchemesSource are recorded in the downloaded schemes
+const (
+	SchemesLicense = "MIT"
+	SchemesSource  = "https://github.com/tinted-theming/schemes"
+)
+
+// maxSchemeSize guards against oversized archive entries
+const maxSchemeSize = 1 << 20
+
+// DownloadSchemes fetches the tinted-theming scheme archive and extracts
+// its base16/ and base24/ YAML files into themesDir, keeping files that
+// already exist. It returns the number of themes written.
+func DownloadSchemes(url, themesDir string) (int, error) {
+	client := &http.Client{Timeout: 2 * time.Minute}
+	resp, err := client.Get(url)
+	if err != nil {
+		return 0, fmt.Errorf("failed to download schemes: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("failed to download schemes: %s", resp.Status)
+	}
+
+	gz, err := gzip.NewReader(resp.Body)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read schemes archive: %w", err)
+	}
+	defer gz.Close()
+
+	written := 0
+	archive := tar.NewReader(gz)
+	for {
+		header, err := archive.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return written, fmt.Errorf("failed to read schemes archive: %w", err)
+		}
+		if header.Typeflag != tar.TypeReg || header.Size > maxSchemeSize {
+			continue
+		}
+
+		// Entries look like schemes-spec-0.11/base16/nord.yaml
+		dir, name := path.Split(header.Name)
+		system := path.Base(dir)
+		if (system != "base16" && system != "base24") || !strings.HasSuffix(name, ".yaml") || strings.Contains(name, "..") {
+			continue
+		}
+
+		destPath := filepath.Join(themesDir, system, name)
+		if _, err := os.Stat(destPath); err == nil {
+			continue
+		}
+
+		data, err := io.ReadAll(archive)
+		if err != nil {
+			return written, fmt.Errorf("failed to read %s: %w", header.Name, err)
+		}
+		if err := os.MkdirAll(filepath.Dir(destPath), 0755); err != nil {
+			return written, fmt.Errorf("failed to create theme directory: %w", err)
+		}
+		data = withAttribution(data, SchemesLicense, SchemesSource+"/blob/spec-0.11/"+system+"/"+name)
+		if err := os.WriteFile(destPath, data, 0644); err != nil {
+			return written, fmt.Errorf("failed to write theme %s: %w", destPath, err)
+		}
+		written++
+	}
+
+	return written, nil
+}
+
+// withAttribution adds license and source_url to a scheme file that does
+// not declare them, leaving the rest of the file as it is
+func withAttribution(data []byte, license, sourceURL string) []byte {
+	content := string(data)
+	if !strings.HasSuffix(content, "\n") {
+		content += "\n"
+	}
+	if !strings.HasPrefix(content, "license:") && !strings.Contains(content, "\nlicense:") {
+		content += fmt.Sprintf("license: %q\n", license)
+	}
+	if !strings.HasPrefix(content, "source_url:") && !strings.Contains(content, "\nsource_url:") {
+		content += fmt.Sprintf("source_url: %q\n", sourceURL)
+	}
+	return []byte(content)
+}
+
+// ReadThemeURL downloads and validates the theme file at url, without
+// adding it to tm. The URL is recorded as its source unless the file names
+// one.
+func (tm *ThemeManager) ReadThemeURL(url string) (*Theme, error) {
+	client := &http.Client{Timeout: time.Minute}
+	resp, err := client.Get(url)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download theme: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to download theme: %s", resp.Status)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxSchemeSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to download theme: %w", err)
+	}
+	if len(data) > maxSchemeSize {
+		return nil, fmt.Errorf("theme %s is larger than %d bytes", url, maxSchemeSize)
+	}
+
+	theme, err := tm.parseTheme(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid theme %s: %w", url, err)
+	}
+	if theme.SourceURL == "" {
+		theme.SourceURL = url
+	}
+	return theme, nil
+}
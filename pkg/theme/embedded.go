@@ -0,0 +1,71 @@
+package theme
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// Starter schemes shipped with the binary, laid out like a themes directory
+//
+//go:embed starter
+var starterFS embed.FS
+
+// CopyStarterThemes writes the embedded starter themes into themesDir,
+// keeping files that already exist. It returns the number of themes written.
+func CopyStarterThemes(themesDir string) (int, error) {
+	written := 0
+	err := fs.WalkDir(starterFS, "starter", func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+
+		rel, err := filepath.Rel("starter", filepath.FromSlash(path))
+		if err != nil {
+			return err
+		}
+		destPath := filepath.Join(themesDir, rel)
+		if _, err := os.Stat(destPath); err == nil {
+			return nil
+		}
+
+		data, err := starterFS.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		if err := os.MkdirAll(filepath.Dir(destPath), 0755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(destPath, data, 0644); err != nil {
+			return err
+		}
+		written++
+		return nil
+	})
+	return written, err
+}
+
+// loadEmbeddedThemes loads the starter themes compiled into the binary
+func (tm *ThemeManager) loadEmbeddedThemes() error {
+	return fs.WalkDir(starterFS, "starter", func(name string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || !strings.HasSuffix(name, ".yaml") {
+			return err
+		}
+
+		data, err := starterFS.ReadFile(name)
+		if err != nil {
+			return err
+		}
+		theme, err := tm.parseTheme(data)
+		if err != nil {
+			return fmt.Errorf("invalid embedded theme %s: %w", name, err)
+		}
+
+		tm.themes[strings.TrimSuffix(path.Base(name), ".yaml")] = theme
+		return nil
+	})
+}
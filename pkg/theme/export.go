@@ -0,0 +1,196 @@
+package theme
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// exporters render a theme as a config snippet for another program
+var exporters = map[string]func(t *Theme) string{
+	"tmux": exportTmux,
+	"nvim": exportVim,
+	"osc":  exportOSC,
+	// Plain palettes for design tools
+	"hex-list": exportHexList,
+	"json":     exportJSON,
+	"css":      exportCSS,
+}
+
+// ExportFormats returns the supported export formats, sorted
+func ExportFormats() []string {
+	formats := make([]string, 0, len(exporters))
+	for format := range exporters {
+		formats = append(formats, format)
+	}
+	sort.Strings(formats)
+	return formats
+}
+
+// Export renders t in format, see ExportFormats
+func (t *Theme) Export(format string) (string, error) {
+	exporter, ok := exporters[format]
+	if !ok {
+		return "", fmt.Errorf("unknown export format %q (expected %s)", format, strings.Join(ExportFormats(), ", "))
+	}
+	return exporter(t), nil
+}
+
+// exportTmux styles the status line, panes and modes, to be sourced from
+// tmux.conf
+func exportTmux(t *Theme) string {
+	p := t.Palette
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Generated by ppr from the %s theme\n", t.Name)
+	fmt.Fprintf(&b, "set -g status-style \"bg=%s,fg=%s\"\n", p["base01"], p["base04"])
+	fmt.Fprintf(&b, "set -g window-status-style \"bg=%s,fg=%s\"\n", p["base01"], p["base04"])
+	fmt.Fprintf(&b, "set -g window-status-current-style \"bg=%s,fg=%s\"\n", p["base0D"], p["base00"])
+	fmt.Fprintf(&b, "set -g window-status-activity-style \"bg=%s,fg=%s\"\n", p["base01"], p["base0A"])
+	fmt.Fprintf(&b, "set -g pane-border-style \"fg=%s\"\n", p["base02"])
+	fmt.Fprintf(&b, "set -g pane-active-border-style \"fg=%s\"\n", p["base0D"])
+	fmt.Fprintf(&b, "set -g message-style \"bg=%s,fg=%s\"\n", p["base01"], p["base05"])
+	fmt.Fprintf(&b, "set -g message-command-style \"bg=%s,fg=%s\"\n", p["base01"], p["base05"])
+	fmt.Fprintf(&b, "set -g mode-style \"bg=%s,fg=%s\"\n", p["base02"], p["base05"])
+	fmt.Fprintf(&b, "set -g display-panes-active-colour \"%s\"\n", p["base0D"])
+	fmt.Fprintf(&b, "set -g display-panes-colour \"%s\"\n", p["base03"])
+	fmt.Fprintf(&b, "set -g clock-mode-colour \"%s\"\n", p["base0D"])
+	return b.String()
+}
+
+// vimGroups maps highlight groups to their foreground and background
+// slots, after the usual base16 styling
+var vimGroups = [][3]string{
+	{"Normal", "base05", "base00"},
+	{"LineNr", "base03", "base00"},
+	{"CursorLine", "", "base01"},
+	{"CursorLineNr", "base04", "base01"},
+	{"SignColumn", "base03", "base00"},
+	{"Visual", "", "base02"},
+	{"Search", "base01", "base0A"},
+	{"IncSearch", "base01", "base09"},
+	{"MatchParen", "", "base03"},
+	{"Pmenu", "base05", "base01"},
+	{"PmenuSel", "base01", "base05"},
+	{"StatusLine", "base04", "base02"},
+	{"StatusLineNC", "base03", "base01"},
+	{"VertSplit", "base02", "base00"},
+	{"WinSeparator", "base02", "base00"},
+	{"Folded", "base03", "base01"},
+	{"NonText", "base03", ""},
+	{"Title", "base0D", ""},
+	{"Directory", "base0D", ""},
+	{"ErrorMsg", "base08", "base00"},
+	{"WarningMsg", "base08", ""},
+	{"Comment", "base03", ""},
+	{"Constant", "base09", ""},
+	{"String", "base0B", ""},
+	{"Character", "base08", ""},
+	{"Number", "base09", ""},
+	{"Boolean", "base09", ""},
+	{"Identifier", "base08", ""},
+	{"Function", "base0D", ""},
+	{"Statement", "base08", ""},
+	{"Keyword", "base0E", ""},
+	{"Operator", "base05", ""},
+	{"PreProc", "base0A", ""},
+	{"Type", "base0A", ""},
+	{"Special", "base0C", ""},
+	{"Delimiter", "base0F", ""},
+	{"Todo", "base0A", "base01"},
+	{"Error", "base00", "base08"},
+	{"DiffAdd", "base0B", "base01"},
+	{"DiffChange", "base03", "base01"},
+	{"DiffDelete", "base08", "base01"},
+	{"DiffText", "base0D", "base01"},
+}
+
+// exportVim is a colorscheme for Neovim and Vim (with termguicolors), to
+// be saved as colors/ppr.vim
+func exportVim(t *Theme) string {
+	background := "dark"
+	if t.Variant == "light" {
+		background = "light"
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "\" Generated by ppr from the %s theme\n", t.Name)
+	fmt.Fprintf(&b, "set background=%s\n", background)
+	b.WriteString("highlight clear\n")
+	b.WriteString("if exists(\"syntax_on\")\n  syntax reset\nendif\n")
+	b.WriteString("let g:colors_name = \"ppr\"\n\n")
+	for _, group := range vimGroups {
+		fmt.Fprintf(&b, "highlight %s", group[0])
+		if group[1] != "" {
+			fmt.Fprintf(&b, " guifg=%s", t.Palette[group[1]])
+		}
+		if group[2] != "" {
+			fmt.Fprintf(&b, " guibg=%s", t.Palette[group[2]])
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// ansiSlots are the palette slots of the 16 ANSI terminal colors, as in
+// base16-shell
+var ansiSlots = []string{
+	"base00", "base08", "base0B", "base0A", "base0D", "base0E", "base0C", "base05",
+	"base03", "base08", "base0B", "base0A", "base0D", "base0E", "base0C", "base07",
+}
+
+// exportOSC is the escape sequences that recolor a running terminal: the
+// ANSI palette, foreground, background and cursor
+func exportOSC(t *Theme) string {
+	var b strings.Builder
+	for i, slot := range ansiSlots {
+		fmt.Fprintf(&b, "\033]4;%d;%s\033\\", i, oscColor(t.Palette[slot]))
+	}
+	fmt.Fprintf(&b, "\033]10;%s\033\\", oscColor(t.Palette["base05"]))
+	fmt.Fprintf(&b, "\033]11;%s\033\\", oscColor(t.Palette["base00"]))
+	fmt.Fprintf(&b, "\033]12;%s\033\\", oscColor(t.Palette["base05"]))
+	return b.String()
+}
+
+// oscColor converts #RRGGBB to the rgb:RR/GG/BB form of xterm
+func oscColor(hex string) string {
+	h := strings.ToLower(strings.TrimPrefix(hex, "#"))
+	if len(h) != 6 {
+		return hex
+	}
+	return fmt.Sprintf("rgb:%s/%s/%s", h[0:2], h[2:4], h[4:6])
+}
+
+// exportHexList prints one slot per line, e.g. "base00 #2E3440"
+func exportHexList(t *Theme) string {
+	var b strings.Builder
+	for _, key := range t.PaletteKeys() {
+		if value, ok := t.Palette[key]; ok {
+			fmt.Fprintf(&b, "%s %s\n", key, strings.ToUpper(value))
+		}
+	}
+	return b.String()
+}
+
+// exportJSON prints the palette as a JSON object in slot order
+func exportJSON(t *Theme) string {
+	var lines []string
+	for _, key := range t.PaletteKeys() {
+		if value, ok := t.Palette[key]; ok {
+			lines = append(lines, fmt.Sprintf("  %q: %q", key, strings.ToUpper(value)))
+		}
+	}
+	return "{\n" + strings.Join(lines, ",\n") + "\n}\n"
+}
+
+// exportCSS declares the palette as custom properties, e.g. --base00
+func exportCSS(t *Theme) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "/* Generated by ppr from the %s theme */\n:root {\n", t.Name)
+	for _, key := range t.PaletteKeys() {
+		if value, ok := t.Palette[key]; ok {
+			fmt.Fprintf(&b, "  --%s: %s;\n", key, strings.ToUpper(value))
+		}
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
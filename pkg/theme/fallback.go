@@ -0,0 +1,131 @@
+package theme
+
+import (
+	"fmt"
+	"io/fs"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+func validSystem(system string) bool {
+	return system == "base16" || system == "base24"
+}
+
+// SetFallback makes name fill the slots missing from partial themes that
+// name no fallback of their own, or that it leaves out too. Call it before
+// LoadThemes; without a fallback partial themes are rejected.
+func (tm *ThemeManager) SetFallback(name string) {
+	tm.fallback = name
+}
+
+// fillPartialThemes completes every partial theme from its fallback chain,
+// dropping the ones it cannot complete with a warning
+func (tm *ThemeManager) fillPartialThemes() {
+	for name, t := range tm.themes {
+		if !t.partial {
+			continue
+		}
+		if err := tm.fillPartial(name, t, map[string]bool{}); err != nil {
+			fmt.Printf("Warning: failed to load theme %s: %v\n", name, err)
+			delete(tm.themes, name)
+		}
+	}
+}
+
+// fillPartial copies the slots t leaves out from its own fallback, then
+// from the configured one. Partial fallbacks are filled first, so themes
+// can build a chain.
+func (tm *ThemeManager) fillPartial(name string, t *Theme, seen map[string]bool) error {
+	if seen[name] {
+		return fmt.Errorf("fallback cycle through %s", name)
+	}
+	seen[name] = true
+
+	for _, link := range []string{t.Fallback, tm.fallback} {
+		if link == "" || link == name || !hasMissing(t) {
+			continue
+		}
+		source, err := tm.fallbackTheme(link)
+		if err != nil {
+			return err
+		}
+		if source.partial {
+			if err := tm.fillPartial(link, source, seen); err != nil {
+				return err
+			}
+		}
+		for _, key := range t.PaletteKeys() {
+			if _, ok := t.Palette[key]; !ok {
+				if value, ok := source.Palette[key]; ok {
+					if t.Palette == nil {
+						t.Palette = make(map[string]string)
+					}
+					t.Palette[key] = value
+				}
+			}
+		}
+	}
+
+	if err := tm.validateTheme(t); err != nil {
+		return fmt.Errorf("%w and no fallback provides it", err)
+	}
+	t.partial = false
+	return nil
+}
+
+func hasMissing(t *Theme) bool {
+	for _, key := range t.PaletteKeys() {
+		if _, ok := t.Palette[key]; !ok {
+			return true
+		}
+	}
+	return false
+}
+
+// fallbackTheme finds a fallback among the loaded themes, the active
+// themes for a staging manager, then the embedded ones
+func (tm *ThemeManager) fallbackTheme(name string) (*Theme, error) {
+	if t, err := tm.GetTheme(name); err == nil {
+		return t, nil
+	}
+	if tm.staging {
+		if tm.active == nil {
+			tm.active = NewThemeManager(filepath.Dir(tm.themesPath))
+			tm.active.fallback = tm.fallback
+			if err := tm.active.LoadThemes(); err != nil {
+				return nil, err
+			}
+		}
+		if t, err := tm.active.GetTheme(name); err == nil {
+			return t, nil
+		}
+	}
+	if t, err := tm.embeddedTheme(name); err == nil {
+		return t, nil
+	}
+	return nil, fmt.Errorf("fallback theme not found: %s", name)
+}
+
+// embeddedTheme parses the embedded starter theme called name
+func (tm *ThemeManager) embeddedTheme(name string) (*Theme, error) {
+	var found *Theme
+	err := fs.WalkDir(starterFS, "starter", func(p string, d fs.DirEntry, err error) error {
+		if err != nil || found != nil || d.IsDir() || strings.TrimSuffix(path.Base(p), ".yaml") != name {
+			return err
+		}
+		data, err := starterFS.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		found, err = tm.parseTheme(data)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if found == nil {
+		return nil, fmt.Errorf("theme not found: %s", name)
+	}
+	return found, nil
+}
@@ -0,0 +1,87 @@
+package theme
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/byteowlz/ppr/pkg/palette"
+)
+
+// harmonies are the hue offsets from the base color of each color harmony
+var harmonies = map[string][]float64{
+	"complementary": {0, 180},
+	"analogous":     {-30, 0, 30},
+	"triadic":       {0, 120, 240},
+	"tetradic":      {0, 90, 180, 270},
+}
+
+// Harmonies returns the supported harmony names, sorted
+func Harmonies() []string {
+	names := make([]string, 0, len(harmonies))
+	for name := range harmonies {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// lightnessSteps separate accents that share a harmony hue
+var lightnessSteps = []float64{0, 0.08, -0.08, 0.14, -0.14}
+
+// FromHarmony builds a base16 theme whose accents only use the hues of a
+// color harmony around base. Each accent takes the harmony hue closest to
+// its conventional one, so red stays as red as the harmony allows, and
+// accents sharing a hue are told apart by lightness. The first accent on
+// the base hue is base itself. variant is "dark" or "light".
+func FromHarmony(name, base, harmony, variant string) (*Theme, error) {
+	offsets, ok := harmonies[harmony]
+	if !ok {
+		return nil, fmt.Errorf("unknown harmony %q (expected %s)", harmony, strings.Join(Harmonies(), ", "))
+	}
+	if err := checkVariant(variant); err != nil {
+		return nil, err
+	}
+	c, err := palette.ParseHex(base)
+	if err != nil {
+		return nil, err
+	}
+
+	lab := palette.ToOKLab(c)
+	hue, chroma := lab.Hue(), lab.Chroma()
+	dark := variant == "dark"
+
+	colors := backgroundRamp(hue, chroma, dark)
+	accentL, accentChroma := accentLightness(dark), accentChromaFor(chroma)
+	used := make([]int, len(offsets))
+	baseUsed := false
+	for i, conventional := range accentHues[:7] {
+		nearest := 0
+		for j, offset := range offsets {
+			if math.Abs(hueDifference(hue+offset, conventional)) < math.Abs(hueDifference(hue+offsets[nearest], conventional)) {
+				nearest = j
+			}
+		}
+		step := lightnessSteps[used[nearest]%len(lightnessSteps)]
+		used[nearest]++
+
+		key := fmt.Sprintf("base%02X", 8+i)
+		if offsets[nearest] == 0 && step == 0 && !baseUsed {
+			colors[key] = palette.ToHex(c)
+			baseUsed = true
+			continue
+		}
+		colors[key] = palette.ToHex(palette.FromOKLCh(accentL+step, accentChroma, hue+offsets[nearest]))
+	}
+	// base0F is the muted, darker variant of the base color
+	colors["base0F"] = palette.ToHex(palette.FromOKLCh(accentL-0.12, accentChroma*0.7, hue))
+
+	return &Theme{
+		System:  "base16",
+		Name:    name,
+		Author:  fmt.Sprintf("ppr theme generate --harmony %s %s", harmony, palette.ToHex(c)),
+		Variant: variant,
+		Palette: colors,
+	}, nil
+}
@@ -0,0 +1,92 @@
+package theme
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/byteowlz/ppr/pkg/palette"
+)
+
+// IDPrefix starts every theme ID, see Theme.ID
+const IDPrefix = "sha256-"
+
+var idRegex = regexp.MustCompile(`^` + IDPrefix + `[0-9a-f]{8,16}$`)
+
+// IsID reports whether name is a theme ID or an abbreviation of one, at
+// least 8 hex digits long
+func IsID(name string) bool {
+	return idRegex.MatchString(name)
+}
+
+// ID identifies a theme by its palette, e.g. sha256-3f1c9a0e2b7d4c61, so the
+// same colors keep their ID when the file is renamed and any edit changes
+// it. Themes loaded by a ThemeManager keep the ID of the palette they were
+// loaded with through MapPalette, so a warmed or recolored render names its
+// source theme.
+func (t *Theme) ID() string {
+	if t.id != "" {
+		return t.id
+	}
+	return t.paletteID()
+}
+
+// paletteID hashes the normalized slots of the palette in key order
+func (t *Theme) paletteID() string {
+	keys := make([]string, 0, len(t.Palette))
+	for key := range t.Palette {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	hash := sha256.New()
+	for _, key := range keys {
+		value := strings.ToUpper(t.Palette[key])
+		if c, err := palette.ParseHex(value); err == nil {
+			value = palette.ToHex(c)
+		}
+		fmt.Fprintf(hash, "%s=%s\n", key, value)
+	}
+	return IDPrefix + hex.EncodeToString(hash.Sum(nil))[:16]
+}
+
+// findByID returns the name of the theme whose ID starts with id, the first
+// by name when several files hold the same palette
+func (tm *ThemeManager) findByID(id string) (string, error) {
+	var matches []string
+	for name, t := range tm.themes {
+		if strings.HasPrefix(t.ID(), id) {
+			matches = append(matches, name)
+		}
+	}
+	sort.Strings(matches)
+
+	if len(matches) == 0 {
+		return "", fmt.Errorf("no theme with ID %s", id)
+	}
+	// Copies of a theme share the palette and so the ID, any of them has
+	// the same colors; an abbreviation may match different palettes though
+	first := tm.themes[matches[0]]
+	for _, name := range matches[1:] {
+		if tm.themes[name].ID() != first.ID() {
+			return "", fmt.Errorf("theme ID %s is ambiguous, it matches %s", id, strings.Join(matches, ", "))
+		}
+	}
+	return matches[0], nil
+}
+
+// ResolveName returns the name the theme GetTheme finds for name is stored
+// under, so a theme given by ID renders, caches and records its state under
+// its name. Other names are returned unchanged.
+func (tm *ThemeManager) ResolveName(name string) string {
+	if _, exists := tm.themes[name]; exists || !IsID(name) {
+		return name
+	}
+	if resolved, err := tm.findByID(name); err == nil {
+		return resolved
+	}
+	return name
+}
@@ -0,0 +1,44 @@
+package theme
+
+import "fmt"
+
+// accentSlots are the rotating accents of Permute, base0F is left in place
+// as it is a muted tone and not a hue of its own
+var accentSlots = []string{"base08", "base09", "base0A", "base0B", "base0C", "base0D", "base0E"}
+
+// AccentNames are the conventional base16 roles of base08..base0E
+var AccentNames = []string{"red", "orange", "yellow", "green", "cyan", "blue", "magenta"}
+
+// primarySlot is base0D, the accent most templates draw their dominant
+// color from
+const primarySlot = 5
+
+// Permute returns the accent-rotated variant of t in which the accent
+// named accent (see AccentNames) becomes base0D. The other accents keep
+// their order around it, so the variant is as varied as the original.
+// The variant is named name.
+func (t *Theme) Permute(name, accent string) (*Theme, error) {
+	index := -1
+	for i, candidate := range AccentNames {
+		if candidate == accent {
+			index = i
+		}
+	}
+	if index < 0 {
+		return nil, fmt.Errorf("unknown accent %q (expected one of %v)", accent, AccentNames)
+	}
+
+	permuted := *t
+	permuted.Name = name
+	permuted.Palette = make(map[string]string, len(t.Palette))
+	for key, value := range t.Palette {
+		permuted.Palette[key] = value
+	}
+
+	shift := primarySlot - index
+	for i, slot := range accentSlots {
+		source := accentSlots[((i-shift)%len(accentSlots)+len(accentSlots))%len(accentSlots)]
+		permuted.Palette[slot] = t.Palette[source]
+	}
+	return &permuted, nil
+}
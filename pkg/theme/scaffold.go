@@ -0,0 +1,63 @@
+package theme
+
+import (
+	"fmt"
+	"strings"
+)
+
+// slotRoles describes what templates and programs use each palette slot for
+var slotRoles = map[string]string{
+	"base00": "default background",
+	"base01": "lighter background, status bars",
+	"base02": "selection background",
+	"base03": "comments, invisibles",
+	"base04": "dark foreground, status bars",
+	"base05": "default foreground",
+	"base06": "light foreground",
+	"base07": "lightest background or foreground",
+	"base08": "red: variables, errors",
+	"base09": "orange: numbers, constants",
+	"base0A": "yellow: classes, search highlights",
+	"base0B": "green: strings, success",
+	"base0C": "cyan: escapes, support",
+	"base0D": "blue: functions, the dominant accent of most templates",
+	"base0E": "purple: keywords",
+	"base0F": "brown: deprecated, embedded code",
+	"base10": "darker background",
+	"base11": "darkest background",
+	"base12": "bright red",
+	"base13": "bright yellow",
+	"base14": "bright green",
+	"base15": "bright cyan",
+	"base16": "bright blue",
+	"base17": "bright purple",
+}
+
+// Scaffold formats t as a theme file to edit by hand, every palette slot
+// commented with its role
+func Scaffold(t *Theme) []byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s theme for ppr, see 'ppr new-theme'\n", t.System)
+	b.WriteString("#\n")
+	b.WriteString("# Colors are 6-digit hex values. Dark themes run base00-base07 from the\n")
+	b.WriteString("# darkest background to the lightest foreground, light themes the other\n")
+	b.WriteString("# way around. Preview changes with 'ppr generate -t " + t.Name + "'.\n")
+	fmt.Fprintf(&b, "system: %q\n", t.System)
+	fmt.Fprintf(&b, "name: %q\n", t.Name)
+	fmt.Fprintf(&b, "author: %q\n", t.Author)
+	b.WriteString("# dark or light\n")
+	fmt.Fprintf(&b, "variant: %q\n", t.Variant)
+	b.WriteString("# License and origin of the colors, listed by 'ppr theme credits'\n")
+	fmt.Fprintf(&b, "license: %q\n", t.License)
+	fmt.Fprintf(&b, "source_url: %q\n", t.SourceURL)
+	b.WriteString("# Theme filling any slot left out below, see fallback_theme\n")
+	fmt.Fprintf(&b, "fallback: %q\n", t.Fallback)
+	b.WriteString("palette:\n")
+	for _, key := range t.PaletteKeys() {
+		if key == "base10" {
+			b.WriteString("  # base24 extensions\n")
+		}
+		fmt.Fprintf(&b, "  %s: %q # %s\n", key, t.Palette[key], slotRoles[key])
+	}
+	return []byte(b.String())
+}
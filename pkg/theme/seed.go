@@ -0,0 +1,117 @@
+package theme
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/byteowlz/ppr/pkg/palette"
+)
+
+// rampLightness is the OKLab lightness of base00..base07 for dark themes;
+// light themes mirror it
+var rampLightness = [8]float64{0.20, 0.25, 0.32, 0.48, 0.64, 0.84, 0.91, 0.97}
+
+// accentHues are the conventional base16 hues of base08..base0F: red,
+// orange, yellow, green, cyan, blue, magenta and brown
+var accentHues = [8]float64{25, 55, 95, 145, 200, 260, 330, 50}
+
+// harmonize is the share of the way each accent hue is pulled towards the
+// seed hue, so the accents read as one family without losing their role
+const harmonize = 0.1
+
+// FromColor builds a base16 theme around a seed color. The backgrounds and
+// foregrounds are a lightness ramp tinted with the seed hue, the accents
+// keep their conventional hues pulled slightly towards the seed at the
+// seed's chroma, and the accent closest in hue to the seed is the seed
+// itself. variant is "dark" or "light".
+func FromColor(name, seed, variant string) (*Theme, error) {
+	if err := checkVariant(variant); err != nil {
+		return nil, err
+	}
+	c, err := palette.ParseHex(seed)
+	if err != nil {
+		return nil, err
+	}
+
+	lab := palette.ToOKLab(c)
+	hue, chroma := lab.Hue(), lab.Chroma()
+	dark := variant == "dark"
+
+	colors := backgroundRamp(hue, chroma, dark)
+	accentL, accentChroma := accentLightness(dark), accentChromaFor(chroma)
+	closest, closestDistance := 0, 360.0
+	for i, h := range accentHues {
+		l := accentL
+		switch i {
+		case 2:
+			// Yellow only looks yellow when light
+			l += 0.1
+		case 7:
+			// base0F is the muted, darker brown of deprecated markers
+			l -= 0.12
+		}
+		diff := hueDifference(hue, h)
+		accent := palette.FromOKLCh(l, accentChroma, h+diff*harmonize)
+		colors[fmt.Sprintf("base%02X", 8+i)] = palette.ToHex(accent)
+		if i < 7 && math.Abs(diff) < closestDistance {
+			closest, closestDistance = i, math.Abs(diff)
+		}
+	}
+	// A near-gray seed has no meaningful hue to place among the accents
+	if chroma >= 0.04 {
+		colors[fmt.Sprintf("base%02X", 8+closest)] = palette.ToHex(c)
+	}
+
+	return &Theme{
+		System:  "base16",
+		Name:    name,
+		Author:  "ppr theme from-color " + palette.ToHex(c),
+		Variant: variant,
+		Palette: colors,
+	}, nil
+}
+
+func checkVariant(variant string) error {
+	if variant != "dark" && variant != "light" {
+		return fmt.Errorf("invalid variant %q (expected dark or light)", variant)
+	}
+	return nil
+}
+
+// backgroundRamp returns base00..base07 tinted with hue, from the darkest
+// background to the lightest foreground, reversed for light themes
+func backgroundRamp(hue, chroma float64, dark bool) map[string]string {
+	colors := make(map[string]string, 16)
+	for i, l := range rampLightness {
+		if !dark {
+			l = 1.17 - l
+		}
+		// Backgrounds carry more of the tint than the text drawn on them
+		tint := math.Min(chroma*0.2, 0.03)
+		if i >= 4 {
+			tint /= 2
+		}
+		colors[fmt.Sprintf("base%02X", i)] = palette.ToHex(palette.FromOKLCh(l, tint, hue))
+	}
+	return colors
+}
+
+// accentLightness is the accent lightness that reads well on the variant's
+// background
+func accentLightness(dark bool) float64 {
+	if dark {
+		return 0.74
+	}
+	return 0.56
+}
+
+// accentChromaFor keeps accents colorful for a dull seed and wearable for
+// a neon one
+func accentChromaFor(chroma float64) float64 {
+	return math.Max(0.08, math.Min(chroma, 0.18))
+}
+
+// hueDifference returns the signed shortest angle from 'from' to 'to'
+func hueDifference(to, from float64) float64 {
+	return math.Mod(to-from+540, 360) - 180
+}
@@ -0,0 +1,84 @@
+package theme
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// StagingDir is the staging area below themesPath where extracted and
+// imported themes wait for review. It is laid out like a themes directory.
+func StagingDir(themesPath string) string {
+	return filepath.Join(themesPath, "staging")
+}
+
+// NewStagingManager manages the staged themes of themesPath
+func NewStagingManager(themesPath string) *ThemeManager {
+	tm := NewThemeManager(StagingDir(themesPath))
+	tm.staging = true
+	return tm
+}
+
+// ThemeFile returns the path of the file theme name was loaded from
+func (tm *ThemeManager) ThemeFile(name string) (string, error) {
+	for _, system := range []string{"base16", "base24"} {
+		path := filepath.Join(tm.themesPath, system, name+".yaml")
+		if _, err := os.Stat(path); err == nil {
+			return path, nil
+		}
+	}
+	return "", fmt.Errorf("theme not found: %s", name)
+}
+
+// ReadTheme validates and returns the theme file at path, without adding
+// it to tm
+func (tm *ThemeManager) ReadTheme(path string) (*Theme, error) {
+	theme, err := tm.loadTheme(path)
+	if err != nil {
+		return nil, fmt.Errorf("invalid theme %s: %w", path, err)
+	}
+	return theme, nil
+}
+
+// Promote moves theme name, as it is on disk, into the themes directory of
+// active and returns its new path
+func (tm *ThemeManager) Promote(name string, active *ThemeManager) (string, error) {
+	src, err := tm.ThemeFile(name)
+	if err != nil {
+		return "", err
+	}
+	// Reject themes broken while editing them
+	theme, err := tm.loadTheme(src)
+	if err != nil {
+		return "", fmt.Errorf("invalid theme %s: %w", name, err)
+	}
+
+	rel, err := filepath.Rel(tm.themesPath, src)
+	if err != nil {
+		return "", err
+	}
+	dst := filepath.Join(active.themesPath, rel)
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return "", fmt.Errorf("failed to create theme directory: %w", err)
+	}
+	if err := os.Rename(src, dst); err != nil {
+		return "", fmt.Errorf("failed to promote theme %s: %w", name, err)
+	}
+
+	delete(tm.themes, name)
+	active.themes[name] = theme
+	return dst, nil
+}
+
+// RemoveTheme deletes the file of theme name
+func (tm *ThemeManager) RemoveTheme(name string) error {
+	path, err := tm.ThemeFile(name)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil {
+		return fmt.Errorf("failed to remove theme %s: %w", name, err)
+	}
+	delete(tm.themes, name)
+	return nil
+}
@@ -2,24 +2,48 @@ package theme
 
 import (
 	"fmt"
+	"image/color"
 	"os"
 	"path/filepath"
+	"runtime"
 	"strings"
+	"sync"
 
+	"github.com/byteowlz/ppr/pkg/palette"
 	"gopkg.in/yaml.v3"
 )
 
+// maxLoadWorkers bounds the number of theme files parsed concurrently
+const maxLoadWorkers = 16
+
 type Theme struct {
-	System  string            `yaml:"system"`
-	Name    string            `yaml:"name"`
-	Author  string            `yaml:"author"`
-	Variant string            `yaml:"variant"`
-	Palette map[string]string `yaml:"palette"`
+	System  string `yaml:"system"`
+	Name    string `yaml:"name"`
+	Author  string `yaml:"author"`
+	Variant string `yaml:"variant"`
+	// License and SourceURL credit the original scheme, see 'ppr theme
+	// credits'
+	License   string            `yaml:"license,omitempty"`
+	SourceURL string            `yaml:"source_url,omitempty"`
+	Palette   map[string]string `yaml:"palette"`
+	// Fallback names the theme filling the slots this one leaves out
+	Fallback string `yaml:"fallback,omitempty"`
+
+	// partial is set until the missing slots are filled in, see fillPartial
+	partial bool
+	// id is the ID of the palette as loaded, see ID
+	id string
 }
 
 type ThemeManager struct {
 	themesPath string
 	themes     map[string]*Theme
+	// staging managers never fall back to the embedded themes
+	staging bool
+	// fallback fills the slots partial themes leave out, see SetFallback
+	fallback string
+	// active are the themes staged ones fall back on, loaded when needed
+	active *ThemeManager
 }
 
 func NewThemeManager(themesPath string) *ThemeManager {
@@ -43,10 +67,31 @@ func (tm *ThemeManager) LoadThemes() error {
 		}
 	}
 
+	// An empty or missing themes directory falls back to the embedded themes
+	if len(tm.themes) == 0 && !tm.staging {
+		if err := tm.loadEmbeddedThemes(); err != nil {
+			return err
+		}
+	}
+
+	tm.fillPartialThemes()
+	for _, t := range tm.themes {
+		t.id = t.paletteID()
+	}
 	return nil
 }
 
 func (tm *ThemeManager) loadThemesFromDir(dir string) error {
+	workers := runtime.NumCPU()
+	if workers > maxLoadWorkers {
+		workers = maxLoadWorkers
+	}
+	return tm.loadThemesWithWorkers(dir, workers)
+}
+
+// loadThemesWithWorkers loads the themes in dir with up to workers files
+// parsed concurrently
+func (tm *ThemeManager) loadThemesWithWorkers(dir string, workers int) error {
 	entries, err := os.ReadDir(dir)
 	if err != nil {
 		if os.IsNotExist(err) {
@@ -55,20 +100,53 @@ func (tm *ThemeManager) loadThemesFromDir(dir string) error {
 		return err
 	}
 
+	var names []string
 	for _, entry := range entries {
 		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".yaml") {
 			continue
 		}
+		names = append(names, entry.Name())
+	}
 
-		themePath := filepath.Join(dir, entry.Name())
-		theme, err := tm.loadTheme(themePath)
-		if err != nil {
-			fmt.Printf("Warning: failed to load theme %s: %v\n", entry.Name(), err)
+	// Read and parse theme files with a bounded worker pool; results are
+	// collected by index so warnings and map insertion stay in directory order
+	type loadResult struct {
+		theme *Theme
+		err   error
+	}
+	results := make([]loadResult, len(names))
+	jobs := make(chan int)
+
+	if workers > len(names) {
+		workers = len(names)
+	}
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				theme, err := tm.loadTheme(filepath.Join(dir, names[i]))
+				results[i] = loadResult{theme: theme, err: err}
+			}
+		}()
+	}
+
+	for i := range names {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	for i, name := range names {
+		if results[i].err != nil {
+			fmt.Printf("Warning: failed to load theme %s: %v\n", name, results[i].err)
 			continue
 		}
 
-		themeName := strings.TrimSuffix(entry.Name(), ".yaml")
-		tm.themes[themeName] = theme
+		themeName := strings.TrimSuffix(name, ".yaml")
+		tm.themes[themeName] = results[i].theme
 	}
 
 	return nil
@@ -80,13 +158,22 @@ func (tm *ThemeManager) loadTheme(path string) (*Theme, error) {
 		return nil, err
 	}
 
+	return tm.parseTheme(data)
+}
+
+func (tm *ThemeManager) parseTheme(data []byte) (*Theme, error) {
 	var theme Theme
 	if err := yaml.Unmarshal(data, &theme); err != nil {
 		return nil, err
 	}
 
 	if err := tm.validateTheme(&theme); err != nil {
-		return nil, err
+		// Themes with a fallback may leave slots out, they are filled in
+		// once all themes are loaded
+		if !validSystem(theme.System) || (theme.Fallback == "" && tm.fallback == "") {
+			return nil, err
+		}
+		theme.partial = true
 	}
 
 	return &theme, nil
@@ -97,7 +184,7 @@ func (tm *ThemeManager) validateTheme(theme *Theme) error {
 		return fmt.Errorf("theme missing system field")
 	}
 
-	if theme.System != "base16" && theme.System != "base24" {
+	if !validSystem(theme.System) {
 		return fmt.Errorf("unsupported theme system: %s", theme.System)
 	}
 
@@ -131,6 +218,36 @@ func (tm *ThemeManager) validateTheme(theme *Theme) error {
 	return nil
 }
 
+// PaletteKeys returns the palette slot names defined by the theme's system,
+// in base00..base0F (..base17 for base24) order
+func (t *Theme) PaletteKeys() []string {
+	count := 16
+	if t.System == "base24" {
+		count = 24
+	}
+
+	keys := make([]string, 0, count)
+	for i := 0; i < count; i++ {
+		keys = append(keys, fmt.Sprintf("base%02X", i))
+	}
+	return keys
+}
+
+// MapPalette returns a copy of the theme with fn applied to every palette
+// color, e.g. to warm or recolor it for one render
+func (t *Theme) MapPalette(fn func(color.RGBA) color.RGBA) (*Theme, error) {
+	mapped := *t
+	mapped.Palette = make(map[string]string, len(t.Palette))
+	for key, value := range t.Palette {
+		c, err := palette.ParseHex(value)
+		if err != nil {
+			return nil, fmt.Errorf("theme %s: %s: %w", t.Name, key, err)
+		}
+		mapped.Palette[key] = palette.ToHex(fn(c))
+	}
+	return &mapped, nil
+}
+
 func (tm *ThemeManager) GetTheme(name string) (*Theme, error) {
 	// First try the exact name
 	if theme, exists := tm.themes[name]; exists {
@@ -150,6 +267,14 @@ func (tm *ThemeManager) GetTheme(name string) (*Theme, error) {
 		}
 	}
 
+	if IsID(name) {
+		resolved, err := tm.findByID(name)
+		if err != nil {
+			return nil, err
+		}
+		return tm.themes[resolved], nil
+	}
+
 	return nil, fmt.Errorf("theme not found: %s", name)
 }
 
@@ -207,15 +332,19 @@ func (tm *ThemeManager) formatThemeYAML(theme *Theme) ([]byte, error) {
 	result.WriteString(fmt.Sprintf("name: \"%s\"\n", theme.Name))
 	result.WriteString(fmt.Sprintf("author: \"%s\"\n", theme.Author))
 	result.WriteString(fmt.Sprintf("variant: \"%s\"\n", theme.Variant))
+	if theme.License != "" {
+		result.WriteString(fmt.Sprintf("license: \"%s\"\n", theme.License))
+	}
+	if theme.SourceURL != "" {
+		result.WriteString(fmt.Sprintf("source_url: \"%s\"\n", theme.SourceURL))
+	}
+	if theme.Fallback != "" {
+		result.WriteString(fmt.Sprintf("fallback: \"%s\"\n", theme.Fallback))
+	}
 	result.WriteString("palette:\n")
 
 	// Write palette colors in correct order with proper indentation
-	baseOrder := []string{
-		"base00", "base01", "base02", "base03", "base04", "base05", "base06", "base07",
-		"base08", "base09", "base0A", "base0B", "base0C", "base0D", "base0E", "base0F",
-	}
-
-	for _, base := range baseOrder {
+	for _, base := range theme.PaletteKeys() {
 		if color, exists := theme.Palette[base]; exists {
 			result.WriteString(fmt.Sprintf("  %s: \"%s\"\n", base, color))
 		}
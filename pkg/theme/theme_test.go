@@ -0,0 +1,112 @@
+package theme
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// benchmarkThemeCount is about the size of a full base16 and base24 scheme
+// collection
+const benchmarkThemeCount = 1200
+
+// writeBenchmarkThemes writes benchmarkThemeCount distinct themes into the
+// base16 directory below dir
+func writeBenchmarkThemes(b *testing.B, dir string) {
+	b.Helper()
+	base16 := filepath.Join(dir, "base16")
+	if err := os.MkdirAll(base16, 0755); err != nil {
+		b.Fatal(err)
+	}
+	for i := 0; i < benchmarkThemeCount; i++ {
+		var yaml strings.Builder
+		fmt.Fprintf(&yaml, "system: \"base16\"\nname: \"Theme %d\"\nauthor: \"ppr\"\nvariant: \"dark\"\npalette:\n", i)
+		for slot := 0; slot < 16; slot++ {
+			fmt.Fprintf(&yaml, "  base%02X: \"#%06X\"\n", slot, (i*16+slot)*2654435%0xFFFFFF)
+		}
+		path := filepath.Join(base16, fmt.Sprintf("theme-%04d.yaml", i))
+		if err := os.WriteFile(path, []byte(yaml.String()), 0644); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkLoadThemes loads a large theme directory with LoadThemes and with
+// fixed worker counts around maxLoadWorkers, to check the bound: more
+// workers than maxLoadWorkers should no longer pay off.
+func BenchmarkLoadThemes(b *testing.B) {
+	dir := b.TempDir()
+	writeBenchmarkThemes(b, dir)
+
+	b.Run("LoadThemes", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			tm := NewThemeManager(dir)
+			if err := tm.LoadThemes(); err != nil {
+				b.Fatal(err)
+			}
+			if len(tm.themes) != benchmarkThemeCount {
+				b.Fatalf("loaded %d themes, want %d", len(tm.themes), benchmarkThemeCount)
+			}
+		}
+	})
+
+	counts := []int{1, 4, maxLoadWorkers, 4 * maxLoadWorkers}
+	if cpus := runtime.NumCPU(); !slices.Contains(counts, cpus) {
+		counts = append(counts, cpus)
+	}
+	for _, workers := range counts {
+		b.Run(fmt.Sprintf("workers=%d", workers), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				tm := NewThemeManager(dir)
+				if err := tm.loadThemesWithWorkers(filepath.Join(dir, "base16"), workers); err != nil {
+					b.Fatal(err)
+				}
+				if len(tm.themes) != benchmarkThemeCount {
+					b.Fatalf("loaded %d themes, want %d", len(tm.themes), benchmarkThemeCount)
+				}
+			}
+		})
+	}
+}
+
+func TestResolveName(t *testing.T) {
+	dir := t.TempDir()
+	base16 := filepath.Join(dir, "base16")
+	if err := os.MkdirAll(base16, 0755); err != nil {
+		t.Fatal(err)
+	}
+	for name, color := range map[string]string{"nord": "2E3440", "gruvbox": "282828"} {
+		content := "system: \"base16\"\nname: \"" + name + "\"\npalette:\n  base00: \"#" + color + "\"\n"
+		if err := os.WriteFile(filepath.Join(base16, name+".yaml"), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tm := NewThemeManager(dir)
+	if err := tm.LoadThemes(); err != nil {
+		t.Fatal(err)
+	}
+	nord, err := tm.GetTheme("nord")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		want string
+	}{
+		{name: "nord", want: "nord"},
+		{name: nord.ID(), want: "nord"},
+		{name: nord.ID()[:len(IDPrefix)+8], want: "nord"},
+		{name: IDPrefix + "00000000", want: IDPrefix + "00000000"},
+		{name: "missing", want: "missing"},
+	}
+	for _, tt := range tests {
+		if got := tm.ResolveName(tt.name); got != tt.want {
+			t.Errorf("ResolveName(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,327 @@
+package update
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Release channels
+const (
+	ChannelStable     = "stable"
+	ChannelPrerelease = "prerelease"
+)
+
+const repoAPI = "https://api.github.com/repos/byteowlz/ppr"
+
+// maxDownloadSize bounds release archive downloads
+const maxDownloadSize = 200 << 20
+
+// Release is a published GitHub release
+type Release struct {
+	Tag        string  `json:"tag_name"`
+	Prerelease bool    `json:"prerelease"`
+	Draft      bool    `json:"draft"`
+	Assets     []Asset `json:"assets"`
+}
+
+// Asset is a file attached to a release
+type Asset struct {
+	Name string `json:"name"`
+	URL  string `json:"browser_download_url"`
+}
+
+// Version returns the release tag without its leading v
+func (r *Release) Version() string {
+	return strings.TrimPrefix(r.Tag, "v")
+}
+
+var client = &http.Client{Timeout: 5 * time.Minute}
+
+// Latest returns the newest release of the channel. The prerelease channel
+// includes stable releases, whichever is newer.
+func Latest(channel string) (*Release, error) {
+	switch channel {
+	case ChannelStable:
+		var release Release
+		if err := getJSON(repoAPI+"/releases/latest", &release); err != nil {
+			return nil, err
+		}
+		return &release, nil
+	case ChannelPrerelease:
+		var releases []Release
+		if err := getJSON(repoAPI+"/releases?per_page=20", &releases); err != nil {
+			return nil, err
+		}
+		for i := range releases {
+			if !releases[i].Draft {
+				return &releases[i], nil
+			}
+		}
+		return nil, fmt.Errorf("no releases found")
+	default:
+		return nil, fmt.Errorf("unknown channel: %s (expected %s or %s)", channel, ChannelStable, ChannelPrerelease)
+	}
+}
+
+func getJSON(url string, v interface{}) error {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to query releases: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to query releases: %s", resp.Status)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("failed to parse release data: %w", err)
+	}
+	return nil
+}
+
+// ArchiveName is the release archive built for this platform
+func ArchiveName(version string) string {
+	ext := "tar.gz"
+	if runtime.GOOS == "windows" {
+		ext = "zip"
+	}
+	return fmt.Sprintf("ppr_%s_%s_%s.%s", version, runtime.GOOS, runtime.GOARCH, ext)
+}
+
+func (r *Release) asset(name string) (*Asset, error) {
+	for i := range r.Assets {
+		if r.Assets[i].Name == name {
+			return &r.Assets[i], nil
+		}
+	}
+	return nil, fmt.Errorf("release %s has no asset %s", r.Tag, name)
+}
+
+// Download fetches the archive for this platform, verifies it against the
+// release's checksums.txt and returns the contained ppr binary
+func (r *Release) Download() ([]byte, error) {
+	archiveName := ArchiveName(r.Version())
+	archiveAsset, err := r.asset(archiveName)
+	if err != nil {
+		return nil, err
+	}
+	checksumAsset, err := r.asset("checksums.txt")
+	if err != nil {
+		return nil, err
+	}
+
+	checksums, err := download(checksumAsset.URL)
+	if err != nil {
+		return nil, err
+	}
+	expected, err := findChecksum(checksums, archiveName)
+	if err != nil {
+		return nil, err
+	}
+
+	archive, err := download(archiveAsset.URL)
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(archive)
+	if actual := hex.EncodeToString(sum[:]); actual != expected {
+		return nil, fmt.Errorf("checksum mismatch for %s: expected %s, got %s", archiveName, expected, actual)
+	}
+
+	return extractBinary(archive, archiveName)
+}
+
+func download(url string) ([]byte, error) {
+	resp, err := client.Get(url)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to download %s: %s", url, resp.Status)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxDownloadSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to download %s: %w", url, err)
+	}
+	if len(data) > maxDownloadSize {
+		return nil, fmt.Errorf("download %s exceeds %d bytes", url, maxDownloadSize)
+	}
+	return data, nil
+}
+
+// findChecksum reads the SHA-256 of name from a "<hash>  <file>" list
+func findChecksum(checksums []byte, name string) (string, error) {
+	scanner := bufio.NewScanner(bytes.NewReader(checksums))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 2 && strings.TrimPrefix(fields[1], "*") == name {
+			return strings.ToLower(fields[0]), nil
+		}
+	}
+	return "", fmt.Errorf("no checksum listed for %s", name)
+}
+
+func extractBinary(archive []byte, archiveName string) ([]byte, error) {
+	binaryName := "ppr"
+	if runtime.GOOS == "windows" {
+		binaryName = "ppr.exe"
+	}
+
+	if strings.HasSuffix(archiveName, ".zip") {
+		reader, err := zip.NewReader(bytes.NewReader(archive), int64(len(archive)))
+		if err != nil {
+			return nil, fmt.Errorf("failed to open %s: %w", archiveName, err)
+		}
+		for _, file := range reader.File {
+			if filepath.Base(file.Name) != binaryName {
+				continue
+			}
+			rc, err := file.Open()
+			if err != nil {
+				return nil, fmt.Errorf("failed to extract %s: %w", binaryName, err)
+			}
+			defer rc.Close()
+			return io.ReadAll(io.LimitReader(rc, maxDownloadSize))
+		}
+		return nil, fmt.Errorf("%s not found in %s", binaryName, archiveName)
+	}
+
+	gz, err := gzip.NewReader(bytes.NewReader(archive))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", archiveName, err)
+	}
+	defer gz.Close()
+
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", archiveName, err)
+		}
+		if header.Typeflag == tar.TypeReg && filepath.Base(header.Name) == binaryName {
+			return io.ReadAll(io.LimitReader(tr, maxDownloadSize))
+		}
+	}
+	return nil, fmt.Errorf("%s not found in %s", binaryName, archiveName)
+}
+
+// ReplaceExecutable swaps the running binary for binary. The new file is
+// written next to the old one and renamed over it, so an interrupted
+// upgrade never leaves a partial binary behind. Windows cannot overwrite a
+// running executable, so there the old one is moved aside first.
+func ReplaceExecutable(binary []byte) (string, error) {
+	exe, err := os.Executable()
+	if err != nil {
+		return "", fmt.Errorf("failed to locate executable: %w", err)
+	}
+	if resolved, err := filepath.EvalSymlinks(exe); err == nil {
+		exe = resolved
+	}
+
+	info, err := os.Stat(exe)
+	if err != nil {
+		return "", fmt.Errorf("failed to stat executable: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(exe), ".ppr-upgrade-*")
+	if err != nil {
+		return "", fmt.Errorf("failed to write next to %s (try running with more permissions): %w", exe, err)
+	}
+	tmpPath := tmp.Name()
+	defer os.Remove(tmpPath)
+
+	if _, err := tmp.Write(binary); err != nil {
+		tmp.Close()
+		return "", fmt.Errorf("failed to write new binary: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return "", fmt.Errorf("failed to write new binary: %w", err)
+	}
+	if err := os.Chmod(tmpPath, info.Mode().Perm()|0111); err != nil {
+		return "", fmt.Errorf("failed to make new binary executable: %w", err)
+	}
+
+	if runtime.GOOS == "windows" {
+		old := exe + ".old"
+		os.Remove(old)
+		if err := os.Rename(exe, old); err != nil {
+			return "", fmt.Errorf("failed to move old binary aside: %w", err)
+		}
+		if err := os.Rename(tmpPath, exe); err != nil {
+			os.Rename(old, exe)
+			return "", fmt.Errorf("failed to install new binary: %w", err)
+		}
+		return exe, nil
+	}
+
+	if err := os.Rename(tmpPath, exe); err != nil {
+		return "", fmt.Errorf("failed to install new binary: %w", err)
+	}
+	return exe, nil
+}
+
+// CompareVersions compares two semantic versions, ignoring a leading v. A
+// prerelease sorts before the release it precedes.
+func CompareVersions(a, b string) int {
+	aCore, aPre, _ := strings.Cut(strings.TrimPrefix(a, "v"), "-")
+	bCore, bPre, _ := strings.Cut(strings.TrimPrefix(b, "v"), "-")
+
+	aParts := strings.Split(aCore, ".")
+	bParts := strings.Split(bCore, ".")
+	for i := 0; i < 3; i++ {
+		if d := versionPart(aParts, i) - versionPart(bParts, i); d != 0 {
+			if d < 0 {
+				return -1
+			}
+			return 1
+		}
+	}
+
+	switch {
+	case aPre == bPre:
+		return 0
+	case aPre == "":
+		return 1
+	case bPre == "":
+		return -1
+	case aPre < bPre:
+		return -1
+	default:
+		return 1
+	}
+}
+
+func versionPart(parts []string, i int) int {
+	if i >= len(parts) {
+		return 0
+	}
+	n, _ := strconv.Atoi(parts[i])
+	return n
+}
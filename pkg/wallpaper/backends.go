@@ -0,0 +1,159 @@
+package wallpaper
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"strings"
+
+	"github.com/byteowlz/ppr/pkg/platform"
+)
+
+// BackendAuto picks the backend of the detected desktop, the default chain
+const BackendAuto = "auto"
+
+// backend is one way of setting the wallpaper that Options.BackendPriority
+// can name
+type backend struct {
+	name string
+	// available reports whether the backend can run here at all
+	available func(s *Setter) bool
+	set       func(s *Setter, imagePath string) error
+}
+
+func unixDesktop(s *Setter) bool {
+	return runtime.GOOS != "darwin" && runtime.GOOS != "windows" && !platform.IsTermux()
+}
+
+// commandBackend is a backend available when tool is installed
+func commandBackend(name, tool string, set func(s *Setter, imagePath string) error) backend {
+	return backend{
+		name:      name,
+		available: func(s *Setter) bool { return unixDesktop(s) && s.commandExists(tool) },
+		set:       set,
+	}
+}
+
+// hyprlandDaemon is the hyprland backend forced to one daemon
+func hyprlandDaemon(daemon string) backend {
+	return backend{
+		name:      daemon,
+		available: func(s *Setter) bool { return isHyprland() && s.commandExists(daemon) },
+		set: func(s *Setter, imagePath string) error {
+			forced := *s
+			forced.options.HyprlandBackend = daemon
+			return forced.setHyprlandWallpaper(imagePath)
+		},
+	}
+}
+
+// backends lists every backend by the name used in backend_priority
+var backends = []backend{
+	{name: BackendAuto, available: func(s *Setter) bool { return true }, set: (*Setter).setDesktopWallpaper},
+	{name: "macos", available: func(s *Setter) bool { return runtime.GOOS == "darwin" }, set: (*Setter).setMacOSWallpaper},
+	{name: "windows", available: func(s *Setter) bool { return runtime.GOOS == "windows" }, set: (*Setter).setWindowsWallpaper},
+	{name: "termux", available: func(s *Setter) bool { return platform.IsTermux() }, set: (*Setter).setTermuxWallpaper},
+	{name: "hyprland", available: func(s *Setter) bool { return isHyprland() }, set: (*Setter).setHyprlandWallpaper},
+	hyprlandDaemon("swww"),
+	hyprlandDaemon("hyprpaper"),
+	{name: "portal", available: unixDesktop, set: (*Setter).setPortalWallpaper},
+	{name: "gnome", available: unixDesktop, set: (*Setter).setGnomeWallpaper},
+	commandBackend("gsettings", "gsettings", func(s *Setter, imagePath string) error {
+		values, err := s.gnomeValues(imagePath)
+		if err != nil {
+			return err
+		}
+		return s.setGnomeWithGsettings(values)
+	}),
+	commandBackend("kde", "qdbus", (*Setter).setKDEWallpaper),
+	commandBackend("xfce", "xfconf-query", (*Setter).setXfceWallpaper),
+	{
+		name:      "sway",
+		available: func(s *Setter) bool { return os.Getenv("SWAYSOCK") != "" && s.commandExists("swaymsg") },
+		set:       (*Setter).setSwayWallpaper,
+	},
+	commandBackend("feh", "feh", (*Setter).setFehWallpaper),
+	commandBackend("xwallpaper", "xwallpaper", (*Setter).setXwallpaper),
+	commandBackend("swaybg", "swaybg", (*Setter).setSwaybgWallpaper),
+	commandBackend("nitrogen", "nitrogen", func(s *Setter, imagePath string) error {
+		if err := s.command("nitrogen", "--set-scaled", imagePath).Run(); err != nil {
+			return fmt.Errorf("failed to set wallpaper with nitrogen: %w", err)
+		}
+		return nil
+	}),
+	commandBackend("pcmanfm", "pcmanfm", func(s *Setter, imagePath string) error {
+		if err := s.command("pcmanfm", "--set-wallpaper", imagePath).Run(); err != nil {
+			return fmt.Errorf("failed to set wallpaper with pcmanfm: %w", err)
+		}
+		return nil
+	}),
+}
+
+// BackendNames lists the names backend_priority accepts
+func BackendNames() []string {
+	names := make([]string, len(backends))
+	for i, b := range backends {
+		names[i] = b.name
+	}
+	return names
+}
+
+func findBackend(name string) (backend, bool) {
+	for _, b := range backends {
+		if b.name == name {
+			return b, true
+		}
+	}
+	return backend{}, false
+}
+
+// chain returns the backends to try in order
+func (s *Setter) chain() []backend {
+	names := s.options.BackendPriority
+	if len(names) == 0 {
+		names = []string{BackendAuto}
+	}
+	chain := make([]backend, 0, len(names))
+	for _, name := range names {
+		if b, ok := findBackend(name); ok {
+			chain = append(chain, b)
+		}
+	}
+	return chain
+}
+
+// Attempt is the outcome of one backend in the chain. Err is nil for
+// backends skipped because they cannot run here.
+type Attempt struct {
+	Backend string
+	Skipped bool
+	Err     error
+}
+
+// ChainError reports that no backend of the chain set the wallpaper
+type ChainError struct {
+	Attempts []Attempt
+}
+
+func (e *ChainError) Error() string {
+	parts := make([]string, len(e.Attempts))
+	for i, a := range e.Attempts {
+		if a.Skipped {
+			parts[i] = a.Backend + ": not available"
+		} else {
+			parts[i] = fmt.Sprintf("%s: %v", a.Backend, a.Err)
+		}
+	}
+	return "no wallpaper backend succeeded (" + strings.Join(parts, "; ") + ")"
+}
+
+// Unwrap returns the errors of the backends that ran
+func (e *ChainError) Unwrap() []error {
+	var errs []error
+	for _, a := range e.Attempts {
+		if a.Err != nil {
+			errs = append(errs, a.Err)
+		}
+	}
+	return errs
+}
@@ -0,0 +1,265 @@
+package wallpaper
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/godbus/dbus/v5"
+)
+
+const (
+	gnomeBackgroundSchema = "org.gnome.desktop.background"
+	gnomeBackgroundPath   = "/org/gnome/desktop/background/"
+
+	dconfService   = "ca.desrt.dconf"
+	dconfWriter    = "/ca/desrt/dconf/Writer/user"
+	dconfInterface = "ca.desrt.dconf.Writer"
+)
+
+func (s *Setter) setGnomeWallpaper(imagePath string) error {
+	values, err := s.gnomeValues(imagePath)
+	if err != nil {
+		return err
+	}
+
+	err = s.writeGnomeSettings(values)
+	if err == nil {
+		return nil
+	}
+
+	// Without a session bus or dconf service, gsettings may still work
+	// through its memory or keyfile backend
+	if errors.Is(err, errDconfUnavailable) && s.commandExists("gsettings") {
+		return s.setGnomeWithGsettings(values)
+	}
+	return fmt.Errorf("failed to set GNOME wallpaper: %w", err)
+}
+
+var errDconfUnavailable = errors.New("dconf service unavailable")
+
+// gnomeValues returns the org.gnome.desktop.background keys showing imagePath
+func (s *Setter) gnomeValues(imagePath string) (map[string]string, error) {
+	absPath, err := filepath.Abs(imagePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve wallpaper path: %w", err)
+	}
+	uri := "file://" + absPath
+
+	values := map[string]string{
+		"picture-uri":      uri,
+		"picture-uri-dark": uri,
+	}
+	if s.options.GnomePictureOptions != "" {
+		values["picture-options"] = s.options.GnomePictureOptions
+	}
+	return values, nil
+}
+
+// writeGnomeSettings writes org.gnome.desktop.background keys in one dconf
+// transaction over D-Bus, reporting missing schemas and locked keys
+func (s *Setter) writeGnomeSettings(values map[string]string) error {
+	if err := checkGnomeSchema(); err != nil {
+		return err
+	}
+
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	if locked := lockedDconfKeys(gnomeBackgroundPath, keys); len(locked) > 0 {
+		return fmt.Errorf("keys are locked by the system administrator: %s", strings.Join(locked, ", "))
+	}
+
+	conn, err := dbus.ConnectSessionBus()
+	if err != nil {
+		return fmt.Errorf("%w: %v", errDconfUnavailable, err)
+	}
+	defer conn.Close()
+
+	changes := make(map[string]string, len(values))
+	for key, value := range values {
+		changes[gnomeBackgroundPath+key] = value
+	}
+
+	var tag string
+	call := conn.Object(dconfService, dconfWriter).CallWithContext(s.context(), dconfInterface+".Change", 0, serializeChangeset(changes))
+	if err := call.Store(&tag); err != nil {
+		var dbusErr dbus.Error
+		if errors.As(err, &dbusErr) {
+			if dbusErr.Name == "org.freedesktop.DBus.Error.ServiceUnknown" || dbusErr.Name == "org.freedesktop.DBus.Error.NameHasNoOwner" {
+				return fmt.Errorf("%w: %s", errDconfUnavailable, dbusErr.Error())
+			}
+			return fmt.Errorf("dconf rejected change (%s): %s", dbusErr.Name, dbusErr.Error())
+		}
+		return fmt.Errorf("dconf write failed: %w", err)
+	}
+
+	return nil
+}
+
+func (s *Setter) setGnomeWithGsettings(values map[string]string) error {
+	for _, key := range []string{"picture-uri", "picture-uri-dark", "picture-options"} {
+		value, exists := values[key]
+		if !exists {
+			continue
+		}
+		output, err := s.command("gsettings", "set", gnomeBackgroundSchema, key, value).CombinedOutput()
+		if err != nil {
+			// picture-uri-dark only exists since GNOME 42
+			if key == "picture-uri-dark" {
+				continue
+			}
+			return fmt.Errorf("gsettings set %s failed: %w: %s", key, err, strings.TrimSpace(string(output)))
+		}
+	}
+	return nil
+}
+
+// schemaDirs returns the directories GLib searches for compiled schemas
+func schemaDirs() []string {
+	var dirs []string
+	if dir := os.Getenv("GSETTINGS_SCHEMA_DIR"); dir != "" {
+		dirs = append(dirs, filepath.SplitList(dir)...)
+	}
+
+	dataHome := os.Getenv("XDG_DATA_HOME")
+	if dataHome == "" {
+		homeDir, _ := os.UserHomeDir()
+		dataHome = filepath.Join(homeDir, ".local", "share")
+	}
+	dataDirs := os.Getenv("XDG_DATA_DIRS")
+	if dataDirs == "" {
+		dataDirs = "/usr/local/share:/usr/share"
+	}
+	for _, dir := range append([]string{dataHome}, filepath.SplitList(dataDirs)...) {
+		dirs = append(dirs, filepath.Join(dir, "glib-2.0", "schemas"))
+	}
+	return dirs
+}
+
+// checkGnomeSchema reports a missing background schema when schemas are
+// installed but GNOME's are not, e.g. on a non-GNOME system
+func checkGnomeSchema() error {
+	foundSchemas := false
+	for _, dir := range schemaDirs() {
+		if _, err := os.Stat(filepath.Join(dir, gnomeBackgroundSchema+".gschema.xml")); err == nil {
+			return nil
+		}
+		if _, err := os.Stat(filepath.Join(dir, "gschemas.compiled")); err == nil {
+			foundSchemas = true
+		}
+	}
+	if foundSchemas {
+		return fmt.Errorf("schema %s is not installed (is gsettings-desktop-schemas missing?)", gnomeBackgroundSchema)
+	}
+	return nil
+}
+
+// lockedDconfKeys returns the keys locked in the system dconf databases.
+// Locks are listed one per line in /etc/dconf/db/*.d/locks/*, either as
+// full keys or as directories ending in a slash.
+func lockedDconfKeys(dir string, keys []string) []string {
+	lockFiles, _ := filepath.Glob("/etc/dconf/db/*.d/locks/*")
+
+	locks := make(map[string]bool)
+	for _, path := range lockFiles {
+		file, err := os.Open(path)
+		if err != nil {
+			continue
+		}
+		scanner := bufio.NewScanner(file)
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			if line != "" && !strings.HasPrefix(line, "#") {
+				locks[line] = true
+			}
+		}
+		file.Close()
+	}
+
+	var locked []string
+	for _, key := range keys {
+		if locks[dir+key] || locks[dir] {
+			locked = append(locked, key)
+		}
+	}
+	return locked
+}
+
+// serializeChangeset encodes string writes as the GVariant a{smv} changeset
+// accepted by ca.desrt.dconf.Writer.Change
+func serializeChangeset(changes map[string]string) []byte {
+	keys := make([]string, 0, len(changes))
+	for key := range changes {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var entries [][]byte
+	for _, key := range keys {
+		entries = append(entries, serializeEntry(key, changes[key]))
+	}
+
+	// Array of variable-size elements: 8-aligned entries followed by the
+	// end offset of each entry
+	var body []byte
+	var ends []int
+	for _, entry := range entries {
+		body = pad(body, 8)
+		body = append(body, entry...)
+		ends = append(ends, len(body))
+	}
+	return appendOffsets(body, ends)
+}
+
+// serializeEntry encodes one {smv} dict entry holding Just(<string>)
+func serializeEntry(key, value string) []byte {
+	// Variant: child value, a zero byte, then the child's type string
+	variant := append([]byte(value), 0)
+	variant = append(variant, 0)
+	variant = append(variant, 's')
+
+	// Maybe of a variable-size type: the child followed by a zero byte
+	maybe := append(variant, 0)
+
+	entry := append([]byte(key), 0)
+	keyEnd := len(entry)
+	entry = pad(entry, 8)
+	entry = append(entry, maybe...)
+
+	// Only the key needs a framing offset, the value is the last member
+	return appendOffsets(entry, []int{keyEnd})
+}
+
+func pad(b []byte, alignment int) []byte {
+	for len(b)%alignment != 0 {
+		b = append(b, 0)
+	}
+	return b
+}
+
+// appendOffsets appends little-endian framing offsets sized by the total
+// container length
+func appendOffsets(body []byte, offsets []int) []byte {
+	size := 1
+	for _, candidate := range []int{1, 2, 4, 8} {
+		size = candidate
+		total := len(body) + len(offsets)*candidate
+		if candidate == 8 || total < 1<<(8*candidate) {
+			break
+		}
+	}
+
+	buf := make([]byte, 8)
+	for _, offset := range offsets {
+		binary.LittleEndian.PutUint64(buf, uint64(offset))
+		body = append(body, buf[:size]...)
+	}
+	return body
+}
@@ -0,0 +1,72 @@
+package wallpaper
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+)
+
+const backgroundKey = "/org/gnome/desktop/background/"
+
+// severalKeys is a changeset whose serialization grows by one byte with
+// each a in the URI
+func severalKeys(uriLength int) map[string]string {
+	return map[string]string{
+		"/a":                              "b",
+		backgroundKey + "picture-options": "'zoom'",
+		backgroundKey + "picture-uri":     "file:///" + strings.Repeat("a", uriLength),
+	}
+}
+
+// TestSerializeChangeset compares against GLib: the expected bytes are
+// g_variant_get_data of the a{smv} that g_variant_parse reads from the same
+// changes in GVariant text format (GLib 2.74). Past 255 bytes a container
+// switches from one to two byte framing offsets: the array first, as it
+// adds an offset to the entry, then the entry itself.
+func TestSerializeChangeset(t *testing.T) {
+	tests := []struct {
+		name    string
+		changes map[string]string
+		want    string
+	}{
+		{
+			name:    "one key",
+			changes: map[string]string{backgroundKey + "picture-uri": "file:///tmp/a.png"},
+			want:    "2f6f72672f676e6f6d652f6465736b746f702f6261636b67726f756e642f706963747572652d7572690000000000000066696c653a2f2f2f746d702f612e706e67000073002a46",
+		},
+		{
+			name:    "one-byte offsets",
+			changes: map[string]string{"/k": "file:///" + strings.Repeat("a", 233)},
+			want:    "2f6b00000000000066696c653a2f2f2f" + strings.Repeat("61", 233) + "0000730003fe",
+		},
+		{
+			name:    "array with two-byte offsets",
+			changes: map[string]string{"/k": "file:///" + strings.Repeat("a", 234)},
+			want:    "2f6b00000000000066696c653a2f2f2f" + strings.Repeat("61", 234) + "0000730003ff00",
+		},
+		{
+			name:    "entry with two-byte offsets",
+			changes: map[string]string{"/k": "file:///" + strings.Repeat("a", 235)},
+			want:    "2f6b00000000000066696c653a2f2f2f" + strings.Repeat("61", 235) + "0000730003000101",
+		},
+		{
+			name:    "several keys with one-byte offsets",
+			changes: severalKeys(111),
+			want:    "2f6100000000000062000073000300002f6f72672f676e6f6d652f6465736b746f702f6261636b67726f756e642f706963747572652d6f7074696f6e73000000277a6f6f6d27000073002e00000000002f6f72672f676e6f6d652f6465736b746f702f6261636b67726f756e642f706963747572652d7572690000000000000066696c653a2f2f2f" + strings.Repeat("61", 111) + "000073002a0e4bfc",
+		},
+		{
+			name:    "several keys with two-byte offsets",
+			changes: severalKeys(112),
+			want:    "2f6100000000000062000073000300002f6f72672f676e6f6d652f6465736b746f702f6261636b67726f756e642f706963747572652d6f7074696f6e73000000277a6f6f6d27000073002e00000000002f6f72672f676e6f6d652f6465736b746f702f6261636b67726f756e642f706963747572652d7572690000000000000066696c653a2f2f2f" + strings.Repeat("61", 112) + "000073002a0e004b00fd00",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := hex.EncodeToString(serializeChangeset(tt.changes))
+			if got != tt.want {
+				t.Errorf("serializeChangeset() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
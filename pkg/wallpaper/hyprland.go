@@ -0,0 +1,112 @@
+package wallpaper
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/byteowlz/ppr/pkg/headless"
+	"github.com/byteowlz/ppr/pkg/resolution"
+)
+
+func isHyprland() bool {
+	return os.Getenv("HYPRLAND_INSTANCE_SIGNATURE") != ""
+}
+
+// setHyprlandWallpaper sets imagePath on every monitor
+func (s *Setter) setHyprlandWallpaper(imagePath string) error {
+	return s.setHyprlandOutput("", imagePath)
+}
+
+// SetMonitorWallpaper sets imagePath on a single output. Only Hyprland
+// exposes per-output wallpapers so far.
+func (s *Setter) SetMonitorWallpaper(monitor, imagePath string) error {
+	if headless.Enabled() {
+		return headless.ErrNoDisplay
+	}
+	if !isHyprland() {
+		return fmt.Errorf("per-monitor wallpapers are only supported on Hyprland")
+	}
+	s, cancel := s.bounded()
+	defer cancel()
+
+	monitors, err := resolution.HyprlandMonitors()
+	if err != nil {
+		return err
+	}
+	var names []string
+	for _, m := range monitors {
+		if m.Name == monitor {
+			return s.timedOut(s.setHyprlandOutput(monitor, imagePath))
+		}
+		names = append(names, m.Name)
+	}
+	return fmt.Errorf("unknown monitor %q (available: %s)", monitor, strings.Join(names, ", "))
+}
+
+// setHyprlandOutput applies the wallpaper to monitor, or to all monitors
+// when it is empty, through swww or hyprpaper
+func (s *Setter) setHyprlandOutput(monitor, imagePath string) error {
+	absPath, err := filepath.Abs(imagePath)
+	if err != nil {
+		return fmt.Errorf("failed to resolve wallpaper path: %w", err)
+	}
+	if _, err := os.Stat(absPath); err != nil {
+		return fmt.Errorf("wallpaper file not accessible: %w", err)
+	}
+
+	switch s.hyprlandBackend() {
+	case "swww":
+		args := []string{"img", absPath}
+		if monitor != "" {
+			args = append(args, "--outputs", monitor)
+		}
+		if output, err := s.command("swww", args...).CombinedOutput(); err != nil {
+			return fmt.Errorf("failed to set wallpaper with swww: %w: %s", err, strings.TrimSpace(string(output)))
+		}
+		return nil
+	case "hyprpaper":
+		return s.setHyprpaper(monitor, absPath)
+	default:
+		return fmt.Errorf("no Hyprland wallpaper daemon found: start swww-daemon or hyprpaper")
+	}
+}
+
+// hyprlandBackend returns the configured backend, or picks swww when its
+// daemon answers and hyprpaper otherwise
+func (s *Setter) hyprlandBackend() string {
+	if s.options.HyprlandBackend != "" {
+		return s.options.HyprlandBackend
+	}
+	if s.commandExists("swww") && s.command("swww", "query").Run() == nil {
+		return "swww"
+	}
+	if s.commandExists("hyprpaper") {
+		return "hyprpaper"
+	}
+	return ""
+}
+
+// setHyprpaper drives a running hyprpaper over hyprctl. An empty monitor
+// applies to every output, and previously loaded images are unloaded to
+// free their memory.
+func (s *Setter) setHyprpaper(monitor, imagePath string) error {
+	commands := [][]string{
+		{"hyprpaper", "preload", imagePath},
+		{"hyprpaper", "wallpaper", monitor + "," + imagePath},
+		{"hyprpaper", "unload", "unused"},
+	}
+	for _, args := range commands {
+		output, err := s.command("hyprctl", args...).CombinedOutput()
+		msg := strings.TrimSpace(string(output))
+		// hyprctl exits 0 and reports failures like a missing hyprpaper socket in its output
+		if err == nil && msg != "ok" && msg != "" {
+			err = fmt.Errorf("%s", msg)
+		}
+		if err != nil {
+			return fmt.Errorf("hyprpaper %s failed: %w (is hyprpaper running?)", args[1], err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+package wallpaper
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/byteowlz/ppr/pkg/image"
+)
+
+// LockOptions mirrors each new wallpaper onto a screen locker
+type LockOptions struct {
+	// Tool is betterlockscreen, hyprlock, swaylock or i3lock. Empty
+	// disables the integration.
+	Tool string
+	// ImagePath is where the lock image is written for hyprlock, swaylock
+	// and i3lock. Empty uses the ppr cache directory.
+	ImagePath string
+	// Blur writes a blurred lock image, or asks betterlockscreen for its
+	// blur effect
+	Blur bool
+	// BlurRadius defaults to 20 pixels
+	BlurRadius int
+}
+
+var lockTools = []string{"betterlockscreen", "hyprlock", "swaylock", "i3lock"}
+
+// DefaultLockImagePath is the lock image location when none is configured
+func DefaultLockImagePath() string {
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		cacheDir = os.TempDir()
+	}
+	return filepath.Join(cacheDir, "ppr", "lock.png")
+}
+
+// updateLockScreen hands imagePath to the configured lock tool
+func (s *Setter) updateLockScreen(imagePath string) error {
+	lock := s.options.Lock
+	absPath, err := filepath.Abs(imagePath)
+	if err != nil {
+		return fmt.Errorf("failed to resolve wallpaper path: %w", err)
+	}
+
+	if lock.Tool == "betterlockscreen" {
+		args := []string{"-u", absPath}
+		if lock.Blur {
+			args = append(args, "--fx", "blur")
+		}
+		cmd := exec.Command("betterlockscreen", args...)
+		// Rebuilding the cache takes several seconds, let it finish after ppr exits
+		if err := cmd.Start(); err != nil {
+			return fmt.Errorf("failed to run betterlockscreen: %w", err)
+		}
+		cmd.Process.Release()
+		fmt.Println("Updating betterlockscreen cache in the background")
+		return nil
+	}
+
+	lockPath := lock.ImagePath
+	if lockPath == "" {
+		lockPath = DefaultLockImagePath()
+	}
+
+	img, err := image.LoadImage(absPath)
+	if err != nil {
+		return err
+	}
+	if lock.Blur {
+		radius := lock.BlurRadius
+		if radius == 0 {
+			radius = 20
+		}
+		img = image.Blur(img, radius)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(lockPath), 0755); err != nil {
+		return fmt.Errorf("failed to create lock image directory: %w", err)
+	}
+	// Write next to the target and rename, so a locker starting now never
+	// reads a half-written file
+	tmpPath := lockPath + ".tmp"
+	if err := image.WritePNG(img, tmpPath); err != nil {
+		return err
+	}
+	if err := os.Rename(tmpPath, lockPath); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to write lock image: %w", err)
+	}
+
+	fmt.Printf("Lock image for %s: %s\n", lock.Tool, lockPath)
+	return nil
+}
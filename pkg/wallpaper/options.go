@@ -0,0 +1,163 @@
+package wallpaper
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Options holds backend-specific display settings applied on every set.
+// Empty values keep each backend's default.
+type Options struct {
+	// GnomePictureOptions is org.gnome.desktop.background picture-options:
+	// none, wallpaper, centered, scaled, stretched, zoom or spanned
+	GnomePictureOptions string
+	// FehMode is the feh --bg-* mode: scale, fill, center, max or tile.
+	// xwallpaper uses the equivalent mode.
+	FehMode string
+	// SwaybgMode is the swaybg -m mode: stretch, fit, fill, center or tile
+	SwaybgMode string
+	// WindowsStyle is the desktop wallpaper style: fill, fit, stretch,
+	// tile, center or span
+	WindowsStyle string
+	// TermuxScreen is the Android screen termux-wallpaper sets: home, lock
+	// or both
+	TermuxScreen string
+	// HyprlandBackend is the wallpaper daemon used on Hyprland: swww or
+	// hyprpaper. Empty picks whichever is running.
+	HyprlandBackend string
+	// Verify is how a set is checked against what the desktop reports: warn
+	// (the default), strict to fail on a mismatch, or off
+	Verify string
+	// Lock updates a screen locker after every successful set
+	Lock LockOptions
+	// BackendPriority is the chain of backends tried in order until one
+	// sets the wallpaper, see BackendNames. Empty uses the detected desktop.
+	BackendPriority []string
+	// Timeout bounds each backend attempt, killing desktop tools that hang.
+	// Zero waits indefinitely.
+	Timeout time.Duration
+}
+
+var (
+	gnomePictureOptions = []string{"none", "wallpaper", "centered", "scaled", "stretched", "zoom", "spanned"}
+	fehModes            = []string{"scale", "fill", "center", "max", "tile"}
+	swaybgModes         = []string{"stretch", "fit", "fill", "center", "tile"}
+	termuxScreens       = []string{"home", "lock", "both"}
+	hyprlandBackends    = []string{"swww", "hyprpaper"}
+)
+
+// Windows WallpaperStyle and TileWallpaper registry values per style
+var windowsStyles = map[string][2]string{
+	"fill":    {"10", "0"},
+	"fit":     {"6", "0"},
+	"stretch": {"2", "0"},
+	"tile":    {"0", "1"},
+	"center":  {"0", "0"},
+	"span":    {"22", "0"},
+}
+
+// Validate reports options with values the backends do not accept
+func (o Options) Validate() error {
+	if err := checkOption("gnome_picture_options", o.GnomePictureOptions, gnomePictureOptions); err != nil {
+		return err
+	}
+	if err := checkOption("feh_mode", o.FehMode, fehModes); err != nil {
+		return err
+	}
+	if err := checkOption("swaybg_mode", o.SwaybgMode, swaybgModes); err != nil {
+		return err
+	}
+	if err := checkOption("termux_screen", o.TermuxScreen, termuxScreens); err != nil {
+		return err
+	}
+	if err := checkOption("hyprland_backend", o.HyprlandBackend, hyprlandBackends); err != nil {
+		return err
+	}
+	if _, exists := windowsStyles[o.WindowsStyle]; o.WindowsStyle != "" && !exists {
+		return fmt.Errorf("invalid windows_style: %s (expected fill, fit, stretch, tile, center or span)", o.WindowsStyle)
+	}
+	for _, name := range o.BackendPriority {
+		if err := checkOption("backend_priority entry", name, BackendNames()); err != nil {
+			return err
+		}
+	}
+	if err := checkOption("verify", o.Verify, verifyModes); err != nil {
+		return err
+	}
+	if err := checkOption("lock_integration tool", o.Lock.Tool, lockTools); err != nil {
+		return err
+	}
+	if o.Lock.BlurRadius < 0 {
+		return fmt.Errorf("invalid lock_integration blur_radius: %d", o.Lock.BlurRadius)
+	}
+	if o.Timeout < 0 {
+		return fmt.Errorf("invalid setter_timeout: %s", o.Timeout)
+	}
+	return nil
+}
+
+func checkOption(name, value string, allowed []string) error {
+	if value == "" {
+		return nil
+	}
+	for _, candidate := range allowed {
+		if value == candidate {
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid %s: %s (expected %s)", name, value, strings.Join(allowed, ", "))
+}
+
+// SetOptions sets the backend options used by SetWallpaper
+func (s *Setter) SetOptions(opts Options) error {
+	if err := opts.Validate(); err != nil {
+		return err
+	}
+	s.options = opts
+	return nil
+}
+
+func (s *Setter) fehArgs(imagePath string) []string {
+	mode := s.options.FehMode
+	if mode == "" {
+		mode = "scale"
+	}
+	return []string{"--bg-" + mode, imagePath}
+}
+
+// xwallpaperModes maps feh modes to the matching xwallpaper flags
+var xwallpaperModes = map[string]string{
+	"scale":  "--stretch",
+	"fill":   "--zoom",
+	"center": "--center",
+	"max":    "--maximize",
+	"tile":   "--tile",
+}
+
+func (s *Setter) xwallpaperArgs(imagePath string) []string {
+	mode := s.options.FehMode
+	if mode == "" {
+		mode = "scale"
+	}
+	return []string{xwallpaperModes[mode], imagePath}
+}
+
+func (s *Setter) swaybgMode() string {
+	if s.options.SwaybgMode == "" {
+		return "fill"
+	}
+	return s.options.SwaybgMode
+}
+
+// windowsStyleScript sets WallpaperStyle and TileWallpaper, which Windows
+// reads when the wallpaper is applied
+func (s *Setter) windowsStyleScript() string {
+	values, exists := windowsStyles[s.options.WindowsStyle]
+	if !exists {
+		return ""
+	}
+	return fmt.Sprintf(`Set-ItemProperty -Path "HKCU:\Control Panel\Desktop" -Name WallpaperStyle -Value "%s"
+Set-ItemProperty -Path "HKCU:\Control Panel\Desktop" -Name TileWallpaper -Value "%s"
+`, values[0], values[1])
+}
@@ -0,0 +1,67 @@
+package wallpaper
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// swaySnippetName is the sway config include ppr manages
+const swaySnippetName = "ppr-wallpaper"
+
+func wmConfigDir(wm string) string {
+	configHome := os.Getenv("XDG_CONFIG_HOME")
+	if configHome == "" {
+		homeDir, _ := os.UserHomeDir()
+		configHome = filepath.Join(homeDir, ".config")
+	}
+	return filepath.Join(configHome, wm)
+}
+
+// setSwayWallpaper hands the wallpaper to sway, which runs and restarts
+// swaybg itself, and writes the managed include snippet so the wallpaper is
+// restored when sway starts again
+func (s *Setter) setSwayWallpaper(imagePath string) error {
+	absPath, err := filepath.Abs(imagePath)
+	if err != nil {
+		return fmt.Errorf("failed to resolve wallpaper path: %w", err)
+	}
+
+	bg := fmt.Sprintf(`output * bg "%s" %s`, strings.ReplaceAll(absPath, `"`, `\"`), s.swaybgMode())
+	if output, err := s.command("swaymsg", bg).CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to set wallpaper with swaymsg: %w: %s", err, strings.TrimSpace(string(output)))
+	}
+
+	configDir := wmConfigDir("sway")
+	snippet := filepath.Join(configDir, swaySnippetName)
+	content := "# Managed by ppr, rewritten whenever ppr sets the wallpaper\n" + bg + "\n"
+	if err := os.MkdirAll(configDir, 0755); err != nil {
+		return fmt.Errorf("failed to create sway config directory: %w", err)
+	}
+	if err := os.WriteFile(snippet, []byte(content), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", snippet, err)
+	}
+
+	if !configMentions(filepath.Join(configDir, "config"), swaySnippetName) {
+		fmt.Printf("Note: add 'include %s' to your sway config to keep the wallpaper after restarts\n", snippet)
+	}
+	return nil
+}
+
+// checkFehbgAutostart hints at running ~/.fehbg, which feh rewrites on
+// every set, from the i3 config when it is not started there yet
+func checkFehbgAutostart() {
+	if !configMentions(filepath.Join(wmConfigDir("i3"), "config"), ".fehbg") {
+		fmt.Println("Note: add 'exec --no-startup-id ~/.fehbg' to your i3 config to keep the wallpaper after restarts")
+	}
+}
+
+// configMentions reports whether the config file exists and contains text
+func configMentions(path, text string) bool {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return false
+	}
+	return strings.Contains(string(content), text)
+}
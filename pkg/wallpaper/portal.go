@@ -0,0 +1,74 @@
+package wallpaper
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+)
+
+const (
+	portalService   = "org.freedesktop.portal.Desktop"
+	portalPath      = "/org/freedesktop/portal/desktop"
+	portalWallpaper = "org.freedesktop.portal.Wallpaper"
+	portalRequest   = "org.freedesktop.portal.Request"
+)
+
+// setPortalWallpaper asks the xdg-desktop-portal Wallpaper interface to
+// set the background, which works in sandboxes and on desktops ppr has no
+// backend for, and waits for the portal's answer
+func (s *Setter) setPortalWallpaper(imagePath string) error {
+	absPath, err := filepath.Abs(imagePath)
+	if err != nil {
+		return fmt.Errorf("failed to resolve wallpaper path: %w", err)
+	}
+
+	conn, err := dbus.ConnectSessionBus()
+	if err != nil {
+		return fmt.Errorf("wallpaper portal unavailable: %w", err)
+	}
+	defer conn.Close()
+
+	// The portal answers on a request object derived from our bus name and
+	// the token, subscribe before calling so the answer cannot be missed
+	token := fmt.Sprintf("ppr%d_%d", os.Getpid(), time.Now().UnixNano())
+	sender := strings.ReplaceAll(strings.TrimPrefix(conn.Names()[0], ":"), ".", "_")
+	request := dbus.ObjectPath(fmt.Sprintf("%s/request/%s/%s", portalPath, sender, token))
+	if err := conn.AddMatchSignalContext(s.context(), dbus.WithMatchObjectPath(request), dbus.WithMatchInterface(portalRequest), dbus.WithMatchMember("Response")); err != nil {
+		return fmt.Errorf("wallpaper portal unavailable: %w", err)
+	}
+	signals := make(chan *dbus.Signal, 1)
+	conn.Signal(signals)
+
+	options := map[string]dbus.Variant{
+		"handle_token": dbus.MakeVariant(token),
+		"show-preview": dbus.MakeVariant(false),
+		"set-on":       dbus.MakeVariant("background"),
+	}
+	call := conn.Object(portalService, portalPath).CallWithContext(s.context(), portalWallpaper+".SetWallpaperURI", 0, "", "file://"+absPath, options)
+	if call.Err != nil {
+		return fmt.Errorf("wallpaper portal unavailable: %w", call.Err)
+	}
+
+	for {
+		select {
+		case sig := <-signals:
+			if sig.Path != request || sig.Name != portalRequest+".Response" || len(sig.Body) == 0 {
+				continue
+			}
+			switch code, _ := sig.Body[0].(uint32); code {
+			case 0:
+				return nil
+			case 1:
+				return fmt.Errorf("wallpaper portal request was cancelled")
+			default:
+				return fmt.Errorf("wallpaper portal refused the request")
+			}
+		case <-s.context().Done():
+			return s.context().Err()
+		}
+	}
+}
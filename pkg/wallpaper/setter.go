@@ -1,24 +1,133 @@
 package wallpaper
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"runtime"
-	"strings"
+	"time"
+
+	"github.com/byteowlz/ppr/pkg/headless"
+	"github.com/byteowlz/ppr/pkg/platform"
 )
 
-type Setter struct{}
+type Setter struct {
+	options Options
+	ctx     context.Context
+}
 
 func NewSetter() *Setter {
 	return &Setter{}
 }
 
+// WithContext returns a copy of the setter whose commands are killed once
+// ctx is done
+func (s *Setter) WithContext(ctx context.Context) *Setter {
+	bound := *s
+	bound.ctx = ctx
+	return &bound
+}
+
+func (s *Setter) context() context.Context {
+	if s.ctx == nil {
+		return context.Background()
+	}
+	return s.ctx
+}
+
+// bounded applies the timeout option to the setter context for one call
+func (s *Setter) bounded() (*Setter, context.CancelFunc) {
+	if s.options.Timeout <= 0 {
+		return s, func() {}
+	}
+	ctx, cancel := context.WithTimeout(s.context(), s.options.Timeout)
+	return s.WithContext(ctx), cancel
+}
+
+// timedOut explains err when the call ran out of time
+func (s *Setter) timedOut(err error) error {
+	if err != nil && errors.Is(s.context().Err(), context.DeadlineExceeded) {
+		return fmt.Errorf("timed out after %s: %w", s.options.Timeout, err)
+	}
+	return err
+}
+
+// command is exec.Command bound to the setter context
+func (s *Setter) command(name string, args ...string) *exec.Cmd {
+	cmd := exec.CommandContext(s.context(), name, args...)
+	// Children of a killed shell script can hold the output pipes open
+	cmd.WaitDelay = time.Second
+	return cmd
+}
+
+// SetWallpaper sets imagePath on every desktop with the first backend of
+// the chain that succeeds and checks that the desktop reports it back,
+// then updates the lock screen when an integration is configured. Each
+// backend attempt is limited by the timeout option.
 func (s *Setter) SetWallpaper(imagePath string) error {
+	if headless.Enabled() {
+		return headless.ErrNoDisplay
+	}
+
+	chain := s.chain()
+	var attempts []Attempt
+	for i, b := range chain {
+		if !b.available(s) {
+			attempts = append(attempts, Attempt{Backend: b.name, Skipped: true})
+			continue
+		}
+		err := s.attempt(b, imagePath)
+		if err == nil {
+			s.afterSet(imagePath)
+			return nil
+		}
+		// A single backend keeps its own error
+		if len(chain) == 1 {
+			return err
+		}
+		attempts = append(attempts, Attempt{Backend: b.name, Err: err})
+		if i < len(chain)-1 {
+			fmt.Printf("Warning: %s backend failed: %v, trying the next one\n", b.name, err)
+		}
+	}
+	return &ChainError{Attempts: attempts}
+}
+
+// attempt sets and verifies the wallpaper with one backend
+func (s *Setter) attempt(b backend, imagePath string) error {
+	bound, cancel := s.bounded()
+	defer cancel()
+	if err := bound.timedOut(b.set(bound, imagePath)); err != nil {
+		return err
+	}
+	return bound.timedOut(bound.verifyAfterSet(imagePath))
+}
+
+// afterSet updates the lock screen, which never fails the set
+func (s *Setter) afterSet(imagePath string) {
+	if s.options.Lock.Tool == "" {
+		return
+	}
+	bound, cancel := s.bounded()
+	defer cancel()
+	if err := bound.timedOut(bound.updateLockScreen(imagePath)); err != nil {
+		fmt.Printf("Warning: failed to update lock screen: %v\n", err)
+	}
+}
+
+func (s *Setter) setDesktopWallpaper(imagePath string) error {
+	if platform.IsTermux() {
+		return s.setTermuxWallpaper(imagePath)
+	}
+
 	switch runtime.GOOS {
 	case "darwin":
 		return s.setMacOSWallpaper(imagePath)
-	case "linux":
+	case "linux", "freebsd", "openbsd", "netbsd":
+		// The BSDs run the same X11 and Wayland desktops as Linux
 		return s.setLinuxWallpaper(imagePath)
 	case "windows":
 		return s.setWindowsWallpaper(imagePath)
@@ -40,7 +149,7 @@ func (s *Setter) setMacOSWallpaper(imagePath string) error {
 		end tell
 	end tell`, imagePath)
 
-	cmd := exec.Command("osascript", "-e", script)
+	cmd := s.command("osascript", "-e", script)
 	output, err := cmd.CombinedOutput()
 
 	if err != nil {
@@ -48,7 +157,7 @@ func (s *Setter) setMacOSWallpaper(imagePath string) error {
 
 		// Method 2: Fallback to Finder method with POSIX file
 		script2 := fmt.Sprintf(`tell application "Finder" to set desktop picture to POSIX file "%s"`, imagePath)
-		cmd2 := exec.Command("osascript", "-e", script2)
+		cmd2 := s.command("osascript", "-e", script2)
 		output2, err2 := cmd2.CombinedOutput()
 
 		if err2 != nil {
@@ -58,36 +167,9 @@ func (s *Setter) setMacOSWallpaper(imagePath string) error {
 	}
 
 	// Force desktop refresh
-	refreshCmd := exec.Command("osascript", "-e", `tell application "Finder" to activate`)
+	refreshCmd := s.command("osascript", "-e", `tell application "Finder" to activate`)
 	refreshCmd.Run()
 
-	// Verify the wallpaper was set by checking current desktop picture
-	if err := s.verifyWallpaperSet(imagePath); err != nil {
-		fmt.Printf("Warning: wallpaper verification failed: %v\n", err)
-	}
-
-	return nil
-}
-
-func (s *Setter) verifyWallpaperSet(expectedPath string) error {
-	// Get current desktop picture path
-	cmd := exec.Command("osascript", "-e", `tell application "System Events" to get picture of first desktop`)
-	output, err := cmd.Output()
-	if err != nil {
-		return fmt.Errorf("failed to get current desktop picture: %w", err)
-	}
-
-	currentPath := string(output)
-	currentPath = strings.TrimSpace(currentPath)
-
-	// Remove any quotes that might be around the path
-	currentPath = strings.Trim(currentPath, "\"")
-
-	if currentPath != expectedPath {
-		return fmt.Errorf("wallpaper verification failed: expected %s, got %s", expectedPath, currentPath)
-	}
-
-	fmt.Printf("Wallpaper verification successful: %s\n", currentPath)
 	return nil
 }
 
@@ -95,6 +177,8 @@ func (s *Setter) setLinuxWallpaper(imagePath string) error {
 	desktopEnv := s.detectLinuxDesktopEnvironment()
 
 	switch desktopEnv {
+	case "hyprland":
+		return s.setHyprlandWallpaper(imagePath)
 	case "gnome":
 		return s.setGnomeWallpaper(imagePath)
 	case "kde":
@@ -108,7 +192,25 @@ func (s *Setter) setLinuxWallpaper(imagePath string) error {
 	}
 }
 
+// DetectDesktop names the platform and desktop the wallpaper will be set on,
+// such as "macOS" or "linux (gnome)"
+func DetectDesktop() string {
+	if platform.IsTermux() {
+		return "Android (Termux)"
+	}
+	switch runtime.GOOS {
+	case "darwin":
+		return "macOS"
+	case "windows":
+		return "Windows"
+	}
+	return fmt.Sprintf("%s (%s)", runtime.GOOS, NewSetter().detectLinuxDesktopEnvironment())
+}
+
 func (s *Setter) detectLinuxDesktopEnvironment() string {
+	if isHyprland() {
+		return "hyprland"
+	}
 	if s.commandExists("gnome-session") {
 		return "gnome"
 	}
@@ -132,18 +234,6 @@ func (s *Setter) commandExists(cmd string) bool {
 	return err == nil
 }
 
-func (s *Setter) setGnomeWallpaper(imagePath string) error {
-	cmd := exec.Command("gsettings", "set", "org.gnome.desktop.background", "picture-uri", fmt.Sprintf("file://%s", imagePath))
-	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("failed to set GNOME wallpaper: %w", err)
-	}
-
-	cmd = exec.Command("gsettings", "set", "org.gnome.desktop.background", "picture-uri-dark", fmt.Sprintf("file://%s", imagePath))
-	cmd.Run()
-
-	return nil
-}
-
 func (s *Setter) setKDEWallpaper(imagePath string) error {
 	script := fmt.Sprintf(`
 var allDesktops = desktops();
@@ -154,7 +244,7 @@ for (i=0;i<allDesktops.length;i++) {
 	d.writeConfig("Image", "%s");
 }`, imagePath)
 
-	cmd := exec.Command("qdbus", "org.kde.plasmashell", "/PlasmaShell", "org.kde.PlasmaShell.evaluateScript", script)
+	cmd := s.command("qdbus", "org.kde.plasmashell", "/PlasmaShell", "org.kde.PlasmaShell.evaluateScript", script)
 	if err := cmd.Run(); err != nil {
 		return fmt.Errorf("failed to set KDE wallpaper: %w", err)
 	}
@@ -163,7 +253,7 @@ for (i=0;i<allDesktops.length;i++) {
 }
 
 func (s *Setter) setXfceWallpaper(imagePath string) error {
-	cmd := exec.Command("xfconf-query", "-c", "xfce4-desktop", "-p", "/backdrop/screen0/monitor0/workspace0/last-image", "-s", imagePath)
+	cmd := s.command("xfconf-query", "-c", "xfce4-desktop", "-p", "/backdrop/screen0/monitor0/workspace0/last-image", "-s", imagePath)
 	if err := cmd.Run(); err != nil {
 		return fmt.Errorf("failed to set XFCE wallpaper: %w", err)
 	}
@@ -172,35 +262,47 @@ func (s *Setter) setXfceWallpaper(imagePath string) error {
 }
 
 func (s *Setter) setI3SwayWallpaper(imagePath string) error {
+	if os.Getenv("SWAYSOCK") != "" && s.commandExists("swaymsg") {
+		return s.setSwayWallpaper(imagePath)
+	}
+
 	if s.commandExists("feh") {
-		cmd := exec.Command("feh", "--bg-scale", imagePath)
-		if err := cmd.Run(); err != nil {
-			return fmt.Errorf("failed to set wallpaper with feh: %w", err)
+		if err := s.setFehWallpaper(imagePath); err != nil {
+			return err
+		}
+		if os.Getenv("I3SOCK") != "" {
+			checkFehbgAutostart()
 		}
 		return nil
 	}
 
+	if s.commandExists("xwallpaper") {
+		return s.setXwallpaper(imagePath)
+	}
+
 	if s.commandExists("swaybg") {
-		cmd := exec.Command("swaybg", "-i", imagePath, "-m", "fill")
-		if err := cmd.Start(); err != nil {
-			return fmt.Errorf("failed to set wallpaper with swaybg: %w", err)
-		}
-		return nil
+		return s.setSwaybgWallpaper(imagePath)
 	}
 
-	return fmt.Errorf("no suitable wallpaper setter found (tried feh, swaybg)")
+	return fmt.Errorf("no suitable wallpaper setter found (tried feh, xwallpaper, swaybg)")
 }
 
 func (s *Setter) setGenericLinuxWallpaper(imagePath string) error {
+	// X11 setters cannot reach a Wayland session without Xwayland
+	if os.Getenv("WAYLAND_DISPLAY") != "" && s.commandExists("swaybg") {
+		return s.setSwaybgWallpaper(imagePath)
+	}
+
 	commands := [][]string{
-		{"feh", "--bg-scale", imagePath},
+		append([]string{"feh"}, s.fehArgs(imagePath)...),
+		append([]string{"xwallpaper"}, s.xwallpaperArgs(imagePath)...),
 		{"nitrogen", "--set-scaled", imagePath},
 		{"pcmanfm", "--set-wallpaper", imagePath},
 	}
 
 	for _, cmd := range commands {
 		if s.commandExists(cmd[0]) {
-			if err := exec.Command(cmd[0], cmd[1:]...).Run(); err == nil {
+			if err := s.command(cmd[0], cmd[1:]...).Run(); err == nil {
 				return nil
 			}
 		}
@@ -209,8 +311,36 @@ func (s *Setter) setGenericLinuxWallpaper(imagePath string) error {
 	return fmt.Errorf("no suitable wallpaper setter found")
 }
 
+func (s *Setter) setFehWallpaper(imagePath string) error {
+	// feh records the path in ~/.fehbg, so give it one that stays around
+	absPath, err := filepath.Abs(imagePath)
+	if err != nil {
+		return fmt.Errorf("failed to resolve wallpaper path: %w", err)
+	}
+	if err := s.command("feh", s.fehArgs(absPath)...).Run(); err != nil {
+		return fmt.Errorf("failed to set wallpaper with feh: %w", err)
+	}
+	return nil
+}
+
+func (s *Setter) setXwallpaper(imagePath string) error {
+	if err := s.command("xwallpaper", s.xwallpaperArgs(imagePath)...).Run(); err != nil {
+		return fmt.Errorf("failed to set wallpaper with xwallpaper: %w", err)
+	}
+	return nil
+}
+
+func (s *Setter) setSwaybgWallpaper(imagePath string) error {
+	// swaybg keeps running, so it is not bound to the setter context
+	cmd := exec.Command("swaybg", "-i", imagePath, "-m", s.swaybgMode())
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to set wallpaper with swaybg: %w", err)
+	}
+	return nil
+}
+
 func (s *Setter) setWindowsWallpaper(imagePath string) error {
-	cmd := exec.Command("powershell", "-Command", fmt.Sprintf(`
+	cmd := s.command("powershell", "-Command", s.windowsStyleScript()+fmt.Sprintf(`
 Add-Type -TypeDefinition "
 using System;
 using System.Runtime.InteropServices;
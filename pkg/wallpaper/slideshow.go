@@ -0,0 +1,124 @@
+package wallpaper
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/byteowlz/ppr/pkg/headless"
+)
+
+// SetWindowsSlideshow points the built-in Windows desktop slideshow at
+// folder, advancing every interval and optionally shuffling. Pictures in
+// subfolders are included, so the ppr output folder cycles through every
+// generated theme.
+func (s *Setter) SetWindowsSlideshow(folder string, interval time.Duration, shuffle bool) error {
+	if runtime.GOOS != "windows" {
+		return fmt.Errorf("slideshow mode is only supported on Windows")
+	}
+	if headless.Enabled() {
+		return headless.ErrNoDisplay
+	}
+	s, cancel := s.bounded()
+	defer cancel()
+	if interval < time.Second {
+		return fmt.Errorf("slideshow interval must be at least 1s, got %s", interval)
+	}
+
+	absFolder, err := filepath.Abs(folder)
+	if err != nil {
+		return fmt.Errorf("failed to resolve slideshow folder: %w", err)
+	}
+	if info, err := os.Stat(absFolder); err != nil || !info.IsDir() {
+		return fmt.Errorf("slideshow folder not accessible: %s", absFolder)
+	}
+
+	// DSO_SHUFFLEIMAGES is the only slideshow option flag
+	options := 0
+	if shuffle {
+		options = 1
+	}
+
+	cmd := s.command("powershell", "-NoProfile", "-Command", s.windowsStyleScript()+slideshowTypes+fmt.Sprintf(`
+[Slideshow]::Set(%s, %d, %d)
+`, psQuote(absFolder), options, interval.Milliseconds()))
+
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to configure Windows slideshow: %w: %s", err, strings.TrimSpace(string(output)))
+	}
+
+	return nil
+}
+
+// slideshowTypes declares IDesktopWallpaper, whose methods must stay in
+// vtable order, and a helper building the folder's IShellItemArray
+const slideshowTypes = `
+Add-Type -TypeDefinition @"
+using System;
+using System.Runtime.InteropServices;
+
+[StructLayout(LayoutKind.Sequential)]
+public struct Rect { public int Left, Top, Right, Bottom; }
+
+[ComImport, Guid("B92B56A9-8B55-4E14-9A89-0199BBB6F93B"), InterfaceType(ComInterfaceType.InterfaceIsIUnknown)]
+public interface IDesktopWallpaper {
+    void SetWallpaper([MarshalAs(UnmanagedType.LPWStr)] string monitorID, [MarshalAs(UnmanagedType.LPWStr)] string wallpaper);
+    [return: MarshalAs(UnmanagedType.LPWStr)] string GetWallpaper([MarshalAs(UnmanagedType.LPWStr)] string monitorID);
+    [return: MarshalAs(UnmanagedType.LPWStr)] string GetMonitorDevicePathAt(uint monitorIndex);
+    uint GetMonitorDevicePathCount();
+    Rect GetMonitorRECT([MarshalAs(UnmanagedType.LPWStr)] string monitorID);
+    void SetBackgroundColor(uint color);
+    uint GetBackgroundColor();
+    void SetPosition(int position);
+    int GetPosition();
+    void SetSlideshow(IntPtr items);
+    IntPtr GetSlideshow();
+    void SetSlideshowOptions(int options, uint slideshowTick);
+    void GetSlideshowOptions(out int options, out uint slideshowTick);
+    void AdvanceSlideshow([MarshalAs(UnmanagedType.LPWStr)] string monitorID, int direction);
+    int GetStatus();
+    void Enable([MarshalAs(UnmanagedType.Bool)] bool enable);
+}
+
+[ComImport, Guid("C2CF3110-460E-4FC1-B9D0-8A1C0C9CC4BD")]
+public class DesktopWallpaperClass {}
+
+public static class Slideshow {
+    [DllImport("shell32.dll", CharSet = CharSet.Unicode, PreserveSig = false)]
+    static extern void SHCreateItemFromParsingName(string path, IntPtr bindCtx, ref Guid riid, out IntPtr item);
+
+    [DllImport("shell32.dll", PreserveSig = false)]
+    static extern void SHCreateShellItemArrayFromShellItem(IntPtr item, ref Guid riid, out IntPtr items);
+
+    public static void Set(string folder, int options, uint tick) {
+        Guid shellItem = new Guid("43826D1E-E718-42EE-BC55-A1E261C37BFE");
+        Guid shellItemArray = new Guid("B63EA76D-1F85-456F-A19C-48159EFA858B");
+
+        IntPtr item, items;
+        SHCreateItemFromParsingName(folder, IntPtr.Zero, ref shellItem, out item);
+        try {
+            SHCreateShellItemArrayFromShellItem(item, ref shellItemArray, out items);
+        } finally {
+            Marshal.Release(item);
+        }
+
+        IDesktopWallpaper wallpaper = (IDesktopWallpaper)new DesktopWallpaperClass();
+        try {
+            wallpaper.SetSlideshow(items);
+            wallpaper.SetSlideshowOptions(options, tick);
+        } finally {
+            Marshal.Release(items);
+            Marshal.ReleaseComObject(wallpaper);
+        }
+    }
+}
+"@
+`
+
+// psQuote quotes value as a single-quoted PowerShell string
+func psQuote(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", "''") + "'"
+}
@@ -0,0 +1,159 @@
+package wallpaper
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/byteowlz/ppr/pkg/headless"
+)
+
+// Space is a Mission Control desktop Space
+type Space struct {
+	// Index is the 1-based position in Mission Control, counted per display
+	Index int
+	// UUID identifies the Space, the first Space of the main display has an empty UUID
+	UUID string
+	// Display is the display identifier, "Main" for the main display
+	Display string
+	// Current reports whether this Space is active on its display
+	Current bool
+}
+
+// ListSpaces returns the desktop Spaces of every display, skipping
+// fullscreen app Spaces
+func ListSpaces() ([]Space, error) {
+	if runtime.GOOS != "darwin" {
+		return nil, fmt.Errorf("spaces are only supported on macOS")
+	}
+
+	output, err := exec.Command("defaults", "export", "com.apple.spaces", "-").Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read Spaces configuration: %w", err)
+	}
+
+	root, err := decodePlist(bytes.NewReader(output))
+	if err != nil {
+		return nil, err
+	}
+
+	monitors := plistPath(root, "SpacesDisplayConfiguration", "Management Data", "Monitors")
+	monitorList, ok := monitors.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("unexpected Spaces configuration format")
+	}
+
+	var spaces []Space
+	for _, m := range monitorList {
+		monitor, ok := m.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		display, _ := monitor["Display Identifier"].(string)
+		currentUUID, _ := plistPath(monitor, "Current Space", "uuid").(string)
+		spaceList, _ := monitor["Spaces"].([]interface{})
+
+		index := 0
+		for _, sp := range spaceList {
+			space, ok := sp.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			// Type 0 is a desktop, fullscreen apps use type 4
+			if kind, _ := space["type"].(int64); kind != 0 {
+				continue
+			}
+			index++
+			uuid, _ := space["uuid"].(string)
+			spaces = append(spaces, Space{Index: index, UUID: uuid, Display: display, Current: uuid == currentUUID})
+		}
+	}
+
+	return spaces, nil
+}
+
+func plistPath(value interface{}, keys ...string) interface{} {
+	for _, key := range keys {
+		dict, ok := value.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		value = dict[key]
+	}
+	return value
+}
+
+// desktopPictureDB is the Dock's per-Space wallpaper database, used up to
+// macOS 13. Later releases keep wallpapers in com.apple.wallpaper instead.
+func desktopPictureDB() string {
+	homeDir, _ := os.UserHomeDir()
+	return filepath.Join(homeDir, "Library", "Application Support", "Dock", "desktoppicture.db")
+}
+
+// SetSpaceWallpaper assigns imagePath to one Space on every display and
+// restarts the Dock to apply it. The current Space can always be set, other
+// Spaces need the desktoppicture.db used up to macOS 13.
+func (s *Setter) SetSpaceWallpaper(space Space, imagePath string) error {
+	if runtime.GOOS != "darwin" {
+		return fmt.Errorf("spaces are only supported on macOS")
+	}
+	if headless.Enabled() {
+		return headless.ErrNoDisplay
+	}
+	s, cancel := s.bounded()
+	defer cancel()
+
+	absPath, err := filepath.Abs(imagePath)
+	if err != nil {
+		return fmt.Errorf("failed to resolve wallpaper path: %w", err)
+	}
+	if _, err := os.Stat(absPath); err != nil {
+		return fmt.Errorf("wallpaper file not accessible: %w", err)
+	}
+
+	dbPath := desktopPictureDB()
+	if _, err := os.Stat(dbPath); err != nil {
+		if space.Current {
+			// System Events only reaches the desktops of the active Space
+			return s.setMacOSWallpaper(absPath)
+		}
+		return fmt.Errorf("per-Space wallpapers need %s, which this macOS version no longer uses; switch to the Space and run set-wallpaper instead", dbPath)
+	}
+
+	if !s.commandExists("sqlite3") {
+		return fmt.Errorf("sqlite3 is required to update %s", dbPath)
+	}
+
+	// Preference key 1 holds the image path of a (space, display) picture
+	uuid := sqlQuote(space.UUID)
+	script := strings.Join([]string{
+		"BEGIN;",
+		fmt.Sprintf("INSERT INTO spaces(space_uuid) SELECT %s WHERE NOT EXISTS (SELECT 1 FROM spaces WHERE space_uuid = %s);", uuid, uuid),
+		fmt.Sprintf("INSERT INTO pictures(space_id, display_id) SELECT s.rowid, d.rowid FROM spaces s, displays d WHERE s.space_uuid = %s AND NOT EXISTS (SELECT 1 FROM pictures p WHERE p.space_id = s.rowid AND p.display_id = d.rowid);", uuid),
+		fmt.Sprintf("INSERT INTO data(value) VALUES (%s);", sqlQuote(absPath)),
+		fmt.Sprintf("DELETE FROM preferences WHERE key = 1 AND picture_id IN (SELECT p.rowid FROM pictures p JOIN spaces s ON p.space_id = s.rowid WHERE s.space_uuid = %s);", uuid),
+		fmt.Sprintf("INSERT INTO preferences(key, data_id, picture_id) SELECT 1, (SELECT max(rowid) FROM data), p.rowid FROM pictures p JOIN spaces s ON p.space_id = s.rowid WHERE s.space_uuid = %s;", uuid),
+		"COMMIT;",
+	}, "\n")
+
+	cmd := s.command("sqlite3", dbPath)
+	cmd.Stdin = strings.NewReader(script)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to update %s: %w: %s", dbPath, err, strings.TrimSpace(string(output)))
+	}
+
+	// The Dock caches wallpapers and only rereads the database on restart
+	if err := s.command("killall", "Dock").Run(); err != nil {
+		return fmt.Errorf("failed to restart Dock: %w", err)
+	}
+
+	return nil
+}
+
+func sqlQuote(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", "''") + "'"
+}
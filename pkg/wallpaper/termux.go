@@ -0,0 +1,39 @@
+package wallpaper
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// setTermuxWallpaper sets the home and/or lock screen through termux-wallpaper
+// from the termux-api package, which needs the Termux:API app installed
+func (s *Setter) setTermuxWallpaper(imagePath string) error {
+	if !s.commandExists("termux-wallpaper") {
+		return fmt.Errorf("termux-wallpaper not found: install the termux-api package and the Termux:API app")
+	}
+
+	absPath, err := filepath.Abs(imagePath)
+	if err != nil {
+		return fmt.Errorf("failed to resolve wallpaper path: %w", err)
+	}
+
+	var runs [][]string
+	switch s.options.TermuxScreen {
+	case "lock":
+		runs = [][]string{{"-f", absPath, "-l"}}
+	case "both":
+		runs = [][]string{{"-f", absPath}, {"-f", absPath, "-l"}}
+	default:
+		runs = [][]string{{"-f", absPath}}
+	}
+
+	for _, args := range runs {
+		output, err := s.command("termux-wallpaper", args...).CombinedOutput()
+		if err != nil {
+			return fmt.Errorf("termux-wallpaper failed: %w: %s", err, strings.TrimSpace(string(output)))
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,181 @@
+package wallpaper
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/byteowlz/ppr/pkg/platform"
+)
+
+// Verification modes for Options.Verify
+const (
+	VerifyWarn   = "warn"
+	VerifyStrict = "strict"
+	VerifyOff    = "off"
+)
+
+var verifyModes = []string{VerifyWarn, VerifyStrict, VerifyOff}
+
+// ErrVerifyUnsupported is returned by Verify on desktops that cannot report
+// their current wallpaper
+var ErrVerifyUnsupported = errors.New("wallpaper verification is not supported on this desktop")
+
+// VerifyError reports that the desktop shows a different image than the one
+// that was set
+type VerifyError struct {
+	Desktop  string
+	Expected string
+	Actual   string
+}
+
+func (e *VerifyError) Error() string {
+	return fmt.Sprintf("wallpaper verification failed on %s: expected %s, desktop reports %s", e.Desktop, e.Expected, e.Actual)
+}
+
+// Verify reads the current wallpaper back from the desktop and returns a
+// *VerifyError when it is not expectedPath
+func (s *Setter) Verify(expectedPath string) error {
+	desktop, actual, err := s.currentWallpaper()
+	if err != nil {
+		return err
+	}
+
+	expected, err := filepath.Abs(expectedPath)
+	if err != nil {
+		return fmt.Errorf("failed to resolve wallpaper path: %w", err)
+	}
+	if !samePath(normalizeWallpaperPath(actual), expected) {
+		return &VerifyError{Desktop: desktop, Expected: expected, Actual: actual}
+	}
+
+	fmt.Printf("Wallpaper verification successful: %s\n", actual)
+	return nil
+}
+
+// verifyAfterSet checks a successful set according to the verify option.
+// Only a mismatch in strict mode is returned, everything else is a warning.
+func (s *Setter) verifyAfterSet(imagePath string) error {
+	if s.options.Verify == VerifyOff {
+		return nil
+	}
+
+	err := s.Verify(imagePath)
+	var mismatch *VerifyError
+	switch {
+	case err == nil, errors.Is(err, ErrVerifyUnsupported):
+		return nil
+	case errors.As(err, &mismatch) && s.options.Verify == VerifyStrict:
+		return err
+	case errors.As(err, &mismatch):
+		fmt.Printf("Warning: %v\n", err)
+	default:
+		fmt.Printf("Warning: could not verify wallpaper: %v\n", err)
+	}
+	return nil
+}
+
+// currentWallpaper asks the desktop which image it shows
+func (s *Setter) currentWallpaper() (desktop, path string, err error) {
+	if platform.IsTermux() {
+		return "", "", ErrVerifyUnsupported
+	}
+
+	switch runtime.GOOS {
+	case "darwin":
+		output, err := s.command("osascript", "-e", `tell application "System Events" to get picture of first desktop`).Output()
+		if err != nil {
+			return "", "", fmt.Errorf("failed to get current desktop picture: %w", err)
+		}
+		return "macOS", strings.Trim(strings.TrimSpace(string(output)), "\""), nil
+	case "windows":
+		output, err := s.command("powershell", "-NoProfile", "-Command", `(Get-ItemProperty -Path 'HKCU:\Control Panel\Desktop' -Name WallPaper).WallPaper`).Output()
+		if err != nil {
+			return "", "", fmt.Errorf("failed to read the Windows wallpaper: %w", err)
+		}
+		return "Windows", strings.TrimSpace(string(output)), nil
+	}
+
+	env := s.detectLinuxDesktopEnvironment()
+	switch env {
+	case "gnome":
+		path, err = s.gnomeCurrentWallpaper()
+	case "kde":
+		path, err = s.kdeCurrentWallpaper()
+	case "xfce":
+		var output []byte
+		output, err = s.command("xfconf-query", "-c", "xfce4-desktop", "-p", "/backdrop/screen0/monitor0/workspace0/last-image").Output()
+		if err != nil {
+			err = fmt.Errorf("failed to read the XFCE wallpaper: %w", err)
+		}
+		path = strings.TrimSpace(string(output))
+	default:
+		return "", "", ErrVerifyUnsupported
+	}
+	return env, path, err
+}
+
+// gnomeCurrentWallpaper reads picture-uri through gsettings or dconf
+func (s *Setter) gnomeCurrentWallpaper() (string, error) {
+	// The memory backend does not outlive the process that wrote it
+	if os.Getenv("GSETTINGS_BACKEND") == "memory" {
+		return "", ErrVerifyUnsupported
+	}
+
+	var cmd *exec.Cmd
+	if _, err := exec.LookPath("gsettings"); err == nil {
+		cmd = s.command("gsettings", "get", gnomeBackgroundSchema, "picture-uri")
+	} else if _, err := exec.LookPath("dconf"); err == nil {
+		cmd = s.command("dconf", "read", gnomeBackgroundPath+"picture-uri")
+	} else {
+		return "", ErrVerifyUnsupported
+	}
+
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to read the GNOME wallpaper: %w", err)
+	}
+	return strings.Trim(strings.TrimSpace(string(output)), "'"), nil
+}
+
+// kdeCurrentWallpaper prints the image of the first Plasma desktop
+func (s *Setter) kdeCurrentWallpaper() (string, error) {
+	script := `
+var d = desktops()[0];
+d.currentConfigGroup = Array("Wallpaper", "org.kde.image", "General");
+print(d.readConfig("Image"));`
+
+	output, err := s.command("qdbus", "org.kde.plasmashell", "/PlasmaShell", "org.kde.PlasmaShell.evaluateScript", script).Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to read the KDE wallpaper: %w", err)
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// normalizeWallpaperPath turns file:// URIs into absolute paths
+func normalizeWallpaperPath(value string) string {
+	if strings.HasPrefix(value, "file://") {
+		if u, err := url.Parse(value); err == nil {
+			value = u.Path
+		} else {
+			value = strings.TrimPrefix(value, "file://")
+		}
+	}
+	if abs, err := filepath.Abs(value); err == nil {
+		value = abs
+	}
+	return value
+}
+
+func samePath(a, b string) bool {
+	if runtime.GOOS == "windows" || runtime.GOOS == "darwin" {
+		// Both file systems are case-insensitive by default
+		return strings.EqualFold(filepath.Clean(a), filepath.Clean(b))
+	}
+	return filepath.Clean(a) == filepath.Clean(b)
+}
@@ -0,0 +1,142 @@
+package wallpaper
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"time"
+)
+
+// daemon is a long-running process a backend needs to keep the wallpaper
+// on screen
+type daemon struct {
+	name    string
+	running func(s *Setter) bool
+	// start launches the daemon, nil when setting the wallpaper does
+	start func() error
+}
+
+func processRunning(s *Setter, name string) bool {
+	return s.commandExists("pgrep") && s.command("pgrep", "-x", name).Run() == nil
+}
+
+// startDetached launches a daemon that outlives the setter
+func startDetached(name string, args ...string) error {
+	cmd := exec.Command(name, args...)
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	// Reap it when it exits while ppr is still running
+	go cmd.Wait()
+	return nil
+}
+
+var daemons = map[string]daemon{
+	"swww": {
+		name:    "swww-daemon",
+		running: func(s *Setter) bool { return s.command("swww", "query").Run() == nil },
+		start:   func() error { return startDetached("swww-daemon") },
+	},
+	"hyprpaper": {
+		name:    "hyprpaper",
+		running: func(s *Setter) bool { return processRunning(s, "hyprpaper") },
+		start:   func() error { return startDetached("hyprpaper") },
+	},
+	"swaybg": {
+		name:    "swaybg",
+		running: func(s *Setter) bool { return processRunning(s, "swaybg") },
+	},
+}
+
+// runningDaemon finds the daemon showing the wallpaper right now: the
+// configured Hyprland daemon, or whichever of swww, hyprpaper and swaybg
+// is running
+func (s *Setter) runningDaemon() (daemon, bool) {
+	if isHyprland() && s.options.HyprlandBackend != "" {
+		return daemons[s.options.HyprlandBackend], true
+	}
+	for _, name := range []string{"swww", "hyprpaper", "swaybg"} {
+		if d := daemons[name]; s.commandExists(name) && d.running(s) {
+			return d, true
+		}
+	}
+	return daemon{}, false
+}
+
+// Watchdog restarts the wallpaper daemon of swww, hyprpaper or swaybg when
+// it exits and sets the wallpaper again, so a crash does not leave an empty
+// desktop. Failed restarts are retried with a growing delay.
+type Watchdog struct {
+	setter *Setter
+	// current returns the wallpaper to restore
+	current func() string
+
+	daemon  *daemon
+	backoff time.Duration
+	retryAt time.Time
+}
+
+// Backoff bounds of failed restarts
+const (
+	minRestartBackoff = 5 * time.Second
+	maxRestartBackoff = 5 * time.Minute
+)
+
+// NewWatchdog supervises the daemon s sets wallpapers with. current is
+// asked for the wallpaper whenever it has to be restored.
+func NewWatchdog(s *Setter, current func() string) *Watchdog {
+	return &Watchdog{setter: s, current: current}
+}
+
+// Daemon names the supervised daemon, empty until one was found running
+func (w *Watchdog) Daemon() string {
+	if w.daemon == nil {
+		return ""
+	}
+	return w.daemon.name
+}
+
+// Check looks for the daemon once. Until one was seen running there is
+// nothing to supervise; afterwards a daemon that exited is restarted and
+// the wallpaper restored. It returns whether a restart happened.
+func (w *Watchdog) Check() (bool, error) {
+	if w.daemon == nil {
+		if d, ok := w.setter.runningDaemon(); ok {
+			w.daemon = &d
+		}
+		return false, nil
+	}
+	if w.daemon.running(w.setter) || time.Now().Before(w.retryAt) {
+		return false, nil
+	}
+
+	if err := w.restart(); err != nil {
+		w.backoff = min(max(2*w.backoff, minRestartBackoff), maxRestartBackoff)
+		w.retryAt = time.Now().Add(w.backoff)
+		return false, fmt.Errorf("failed to restart %s, retrying in %s: %w", w.daemon.name, w.backoff, err)
+	}
+	w.backoff = 0
+	return true, nil
+}
+
+func (w *Watchdog) restart() error {
+	imagePath := w.current()
+	if _, err := os.Stat(imagePath); err != nil {
+		return fmt.Errorf("no wallpaper to restore: %w", err)
+	}
+
+	if w.daemon.start != nil {
+		if err := w.daemon.start(); err != nil {
+			return err
+		}
+		// Daemons take a moment before they accept wallpapers
+		deadline := time.Now().Add(5 * time.Second)
+		for !w.daemon.running(w.setter) {
+			if time.Now().After(deadline) {
+				return fmt.Errorf("%s did not come up", w.daemon.name)
+			}
+			time.Sleep(200 * time.Millisecond)
+		}
+	}
+	return w.setter.SetWallpaper(imagePath)
+}
@@ -0,0 +1,176 @@
+package weather
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// Weather conditions reported by providers
+const (
+	Clear  = "clear"
+	Cloudy = "cloudy"
+	Fog    = "fog"
+	Rain   = "rain"
+	Snow   = "snow"
+	Storm  = "storm"
+)
+
+// Conditions lists every condition a provider may report
+func Conditions() []string {
+	return []string{Clear, Cloudy, Fog, Rain, Snow, Storm}
+}
+
+// Provider reports the current weather condition
+type Provider interface {
+	Name() string
+	Current() (string, error)
+}
+
+// OpenMeteoURL is the Open-Meteo forecast endpoint, which needs no API key
+const OpenMeteoURL = "https://api.open-meteo.com/v1/forecast"
+
+// OpenMeteo reads the current WMO weather code for a location
+type OpenMeteo struct {
+	Latitude  float64
+	Longitude float64
+	URL       string
+}
+
+// NewOpenMeteo creates a provider for the given coordinates
+func NewOpenMeteo(latitude, longitude float64) *OpenMeteo {
+	return &OpenMeteo{Latitude: latitude, Longitude: longitude, URL: OpenMeteoURL}
+}
+
+func (p *OpenMeteo) Name() string {
+	return "open-meteo"
+}
+
+func (p *OpenMeteo) Current() (string, error) {
+	url := fmt.Sprintf("%s?latitude=%g&longitude=%g&current=weather_code", p.URL, p.Latitude, p.Longitude)
+
+	client := &http.Client{Timeout: 15 * time.Second}
+	resp, err := client.Get(url)
+	if err != nil {
+		return "", fmt.Errorf("failed to query Open-Meteo: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to query Open-Meteo: %s", resp.Status)
+	}
+
+	var data struct {
+		Current struct {
+			WeatherCode *int `json:"weather_code"`
+		} `json:"current"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
+		return "", fmt.Errorf("failed to parse Open-Meteo response: %w", err)
+	}
+	if data.Current.WeatherCode == nil {
+		return "", fmt.Errorf("Open-Meteo response has no weather code")
+	}
+	return conditionFromWMO(*data.Current.WeatherCode), nil
+}
+
+// conditionFromWMO groups WMO 4677 weather codes as used by Open-Meteo
+func conditionFromWMO(code int) string {
+	switch {
+	case code <= 1:
+		return Clear
+	case code <= 3:
+		return Cloudy
+	case code == 45 || code == 48:
+		return Fog
+	case code >= 71 && code <= 77, code == 85 || code == 86:
+		return Snow
+	case code >= 95:
+		return Storm
+	case code >= 51:
+		return Rain
+	default:
+		return Cloudy
+	}
+}
+
+// Command runs a user command that prints one condition
+type Command struct {
+	Command string
+}
+
+func (p *Command) Name() string {
+	return "command"
+}
+
+func (p *Command) Current() (string, error) {
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.Command("cmd", "/C", p.Command)
+	} else {
+		cmd = exec.Command("sh", "-c", p.Command)
+	}
+
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("weather command failed: %w", err)
+	}
+
+	condition := strings.ToLower(strings.TrimSpace(string(output)))
+	for _, known := range Conditions() {
+		if condition == known {
+			return condition, nil
+		}
+	}
+	return "", fmt.Errorf("weather command printed %q (expected one of %s)", condition, strings.Join(Conditions(), ", "))
+}
+
+// cacheEntry is the last condition a provider reported
+type cacheEntry struct {
+	Provider  string    `json:"provider"`
+	Condition string    `json:"condition"`
+	FetchedAt time.Time `json:"fetched_at"`
+}
+
+// CachePath is where the last condition is kept between runs
+func CachePath() string {
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		cacheDir = os.TempDir()
+	}
+	return filepath.Join(cacheDir, "ppr", "weather.json")
+}
+
+// Cached returns the condition from cachePath while it is younger than
+// maxAge, and asks the provider otherwise. A stale condition is returned
+// with the error when the provider fails, so rules keep working offline.
+func Cached(provider Provider, cachePath string, maxAge time.Duration) (string, error) {
+	var entry cacheEntry
+	if data, err := os.ReadFile(cachePath); err == nil {
+		if json.Unmarshal(data, &entry) != nil || entry.Provider != provider.Name() {
+			entry = cacheEntry{}
+		}
+	}
+	if entry.Condition != "" && time.Since(entry.FetchedAt) < maxAge {
+		return entry.Condition, nil
+	}
+
+	condition, err := provider.Current()
+	if err != nil {
+		return entry.Condition, err
+	}
+
+	entry = cacheEntry{Provider: provider.Name(), Condition: condition, FetchedAt: time.Now()}
+	if data, err := json.Marshal(entry); err == nil {
+		if err := os.MkdirAll(filepath.Dir(cachePath), 0755); err == nil {
+			os.WriteFile(cachePath, data, 0644)
+		}
+	}
+	return condition, nil
+}